@@ -1,12 +1,32 @@
 package main
 
 import (
+	"1inch_testtask/internal/balancer"
 	"1inch_testtask/internal/config"
+	"1inch_testtask/internal/curve"
+	"1inch_testtask/internal/ens"
 	"1inch_testtask/internal/handlers"
+	"1inch_testtask/internal/pairindex"
+	"1inch_testtask/internal/pooldetect"
+	"1inch_testtask/internal/poolstate"
+	"1inch_testtask/internal/routing"
+	"1inch_testtask/internal/tokens"
+	"1inch_testtask/internal/transfertax"
 	"1inch_testtask/internal/uniswap_v2"
+	"1inch_testtask/internal/uniswap_v3"
 	"1inch_testtask/internal/usecase"
+	"context"
+	"errors"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
 	"github.com/joho/godotenv"
 	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	_ "1inch_testtask/docs" // Import generated docs
 
@@ -33,6 +53,10 @@ func main() {
 	log.Printf("Starting server on port %s", cfg.Port)
 	log.Printf("Using Infura URL: %s", cfg.InfuraURL)
 
+	// Background workers stop and the server shuts down on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Initialize Ethereum client
 	ethClient, err := uniswap_v2.NewClient(cfg.InfuraURL)
 	if err != nil {
@@ -40,11 +64,216 @@ func main() {
 	}
 	defer ethClient.Close()
 
+	var pairClient uniswap_v2.IUniswapV2 = ethClient
+	if cfg.MulticallEnabled {
+		pairClient, err = uniswap_v2.NewMulticallClient(ethClient.Backend(), common.HexToAddress(cfg.MulticallAddress))
+		if err != nil {
+			log.Fatalf("Failed to initialize Multicall3 client: %v", err)
+		}
+		log.Printf("Using Multicall3 at %s", cfg.MulticallAddress)
+	}
+
+	var poolCache *uniswap_v2.CachedClient
+	if cfg.PoolCacheEnabled {
+		poolCache, err = uniswap_v2.NewCachedClient(pairClient, cfg.PoolCacheSize, cfg.PoolCacheFile)
+		if err != nil {
+			log.Fatalf("Failed to initialize pool cache: %v", err)
+		}
+		pairClient = poolCache
+		go poolCache.Run(ctx)
+	}
+
+	routerFactory, err := uniswap_v2.ParseFactories([]string{cfg.RouterFactory})
+	if err != nil {
+		log.Fatalf("Failed to parse router factory: %v", err)
+	}
+	if routerFactory[0].InitCodeHash == (common.Hash{}) {
+		log.Fatalf("Router factory %s needs the init code hash of its pairs", cfg.RouterFactory)
+	}
+	swapRouter, err := uniswap_v2.NewRouter(common.HexToAddress(cfg.RouterAddress), common.HexToAddress(cfg.WETHAddress), routerFactory[0])
+	if err != nil {
+		log.Fatalf("Failed to initialize swap router: %v", err)
+	}
+
 	// Initialize services
-	uc := usecase.NewUsecase(ethClient)
+	opts := []usecase.Option{usecase.WithBlockResolver(ethClient), usecase.WithSwapRouter(swapRouter)}
+	if cfg.SnapshotEnabled {
+		opts = append(opts, usecase.WithSnapshots())
+	}
+	if cfg.DefaultFeeBps < 0 || cfg.DefaultFeeBps >= usecase.FeeDenominator {
+		log.Fatalf("Invalid default fee: %d bps", cfg.DefaultFeeBps)
+	}
+	poolFees, err := usecase.ParseFees(cfg.PoolFees)
+	if err != nil {
+		log.Fatalf("Failed to parse pool fees: %v", err)
+	}
+	factoryFees, err := usecase.ParseFees(cfg.FactoryFees)
+	if err != nil {
+		log.Fatalf("Failed to parse factory fees: %v", err)
+	}
+	opts = append(opts, usecase.WithFees(usecase.FeeConfig{
+		DefaultBps: uint32(cfg.DefaultFeeBps),
+		Pools:      poolFees,
+		Factories:  factoryFees,
+	}))
+	if cfg.UniswapV3Enabled {
+		v3Client, err := uniswap_v3.NewClient(ethClient.Backend(), common.HexToAddress(cfg.MulticallAddress), cfg.UniswapV3TickWords)
+		if err != nil {
+			log.Fatalf("Failed to initialize Uniswap V3 client: %v", err)
+		}
+		opts = append(opts, usecase.WithUniswapV3(v3Client))
+	}
+	if cfg.CurveEnabled {
+		curveClient, err := curve.NewClient(ethClient.Backend(), common.HexToAddress(cfg.MulticallAddress))
+		if err != nil {
+			log.Fatalf("Failed to initialize Curve client: %v", err)
+		}
+		opts = append(opts, usecase.WithCurve(curveClient))
+	}
+	if cfg.BalancerEnabled {
+		balancerClient, err := balancer.NewClient(ethClient.Backend(), common.HexToAddress(cfg.MulticallAddress))
+		if err != nil {
+			log.Fatalf("Failed to initialize Balancer client: %v", err)
+		}
+		opts = append(opts, usecase.WithBalancer(balancerClient))
+	}
+	if cfg.TokenMetadataEnabled {
+		tokenClient, err := tokens.NewClient(ethClient.Backend(), common.HexToAddress(cfg.MulticallAddress))
+		if err != nil {
+			log.Fatalf("Failed to initialize token metadata client: %v", err)
+		}
+		opts = append(opts, usecase.WithTokens(tokenClient))
+	}
+	if cfg.ENSEnabled {
+		resolver, err := ens.NewResolver(ethClient.Backend(), common.HexToAddress(cfg.ENSRegistryAddress), time.Duration(cfg.ENSCacheTTLSeconds)*time.Second)
+		if err != nil {
+			log.Fatalf("Failed to initialize ENS resolver: %v", err)
+		}
+		opts = append(opts, usecase.WithNameResolver(resolver))
+	}
+	tokenAliases, err := usecase.ParseTokenAliases(cfg.TokenAliases)
+	if err != nil {
+		log.Fatalf("Failed to parse token aliases: %v", err)
+	}
+	opts = append(opts, usecase.WithTokenAliases(tokenAliases))
+	if cfg.PoolDetectionEnabled {
+		opts = append(opts, usecase.WithPoolDetector(pooldetect.NewDetector(ethClient.Backend())))
+	}
+	if len(cfg.TransferTaxTokens) > 0 || cfg.TransferTaxSimulation {
+		registry, err := transfertax.ParseRegistry(cfg.TransferTaxTokens)
+		if err != nil {
+			log.Fatalf("Failed to parse transfer tax tokens: %v", err)
+		}
+
+		var simulator *transfertax.Simulator
+		if cfg.TransferTaxSimulation {
+			simulator = transfertax.NewSimulator(gethclient.New(ethClient.RPC()), 10000, time.Duration(cfg.TransferTaxCacheTTLSeconds)*time.Second)
+		}
+		opts = append(opts, usecase.WithTransferTaxes(transfertax.NewDetector(registry, simulator)))
+	}
+	if len(cfg.RoutingPairs) > 0 {
+		pools := make([]common.Address, len(cfg.RoutingPairs))
+		for i, pair := range cfg.RoutingPairs {
+			pools[i] = common.HexToAddress(pair)
+		}
+
+		graph, err := routing.LoadGraph(context.Background(), pairClient, pools)
+		if err != nil {
+			log.Fatalf("Failed to load routing pairs: %v", err)
+		}
+		log.Printf("Loaded %d routing pairs", graph.PairCount())
+
+		opts = append(opts, usecase.WithRouter(graph, usecase.RoutingConfig{
+			MaxHops:       cfg.RoutingMaxHops,
+			MaxCandidates: cfg.RoutingMaxCandidates,
+		}))
+	}
+	var pairIndex *pairindex.Indexer
+	if cfg.PairIndexEnabled {
+		if !common.IsHexAddress(cfg.PairIndexFactory) {
+			log.Fatalf("Invalid pair index factory: %s", cfg.PairIndexFactory)
+		}
+		if cfg.PairIndexFromBlock < 0 || cfg.PairIndexChunkSize <= 0 || cfg.PairIndexConfirmations < 0 {
+			log.Fatalf("Invalid pair index range: from block %d, chunk size %d, confirmations %d",
+				cfg.PairIndexFromBlock, cfg.PairIndexChunkSize, cfg.PairIndexConfirmations)
+		}
+
+		pairIndex, err = pairindex.NewIndexer(ethClient.Backend(), pairindex.NewFileStore(cfg.PairIndexFile), pairindex.Config{
+			Factory:       common.HexToAddress(cfg.PairIndexFactory),
+			Multicall:     common.HexToAddress(cfg.MulticallAddress),
+			FromBlock:     uint64(cfg.PairIndexFromBlock),
+			ChunkSize:     uint64(cfg.PairIndexChunkSize),
+			Confirmations: uint64(cfg.PairIndexConfirmations),
+			PollInterval:  12 * time.Second,
+		})
+		if err != nil {
+			log.Fatalf("Failed to initialize pair index: %v", err)
+		}
+		go pairIndex.Run(ctx)
+		log.Printf("Indexing pairs of factory %s into %s", cfg.PairIndexFactory, cfg.PairIndexFile)
+	}
+
+	if cfg.PairDiscoveryEnabled {
+		factories := []uniswap_v2.Factory{uniswap_v2.UniswapV2Factory}
+		if len(cfg.UniswapV2Factories) > 0 {
+			factories, err = uniswap_v2.ParseFactories(cfg.UniswapV2Factories)
+			if err != nil {
+				log.Fatalf("Failed to parse Uniswap V2 factories: %v", err)
+			}
+		}
+
+		finders := make([]uniswap_v2.IPairFinder, len(factories))
+		for i, factory := range factories {
+			finders[i], err = uniswap_v2.NewPairFinder(ethClient.Backend(), factory)
+			if err != nil {
+				log.Fatalf("Failed to initialize pair finder: %v", err)
+			}
+			// Pairs of the indexed factory are looked up locally
+			if pairIndex != nil && factory.Address == common.HexToAddress(cfg.PairIndexFactory) {
+				finders[i] = pairindex.NewFinder(pairIndex, finders[i])
+			}
+		}
+		opts = append(opts, usecase.WithPairFinders(finders))
+	}
+
+	var tracker *poolstate.Tracker
+	if cfg.PoolStateWSURL != "" {
+		wsClient, err := ethclient.Dial(cfg.PoolStateWSURL)
+		if err != nil {
+			log.Fatalf("Failed to connect to pool state WebSocket: %v", err)
+		}
+		defer wsClient.Close()
+
+		pools := make([]common.Address, len(cfg.PoolStatePairs))
+		for i, pair := range cfg.PoolStatePairs {
+			pools[i] = common.HexToAddress(pair)
+		}
+
+		tracker, err = poolstate.NewTracker(pairClient, ethClient, wsClient, poolstate.Config{
+			Pools:         pools,
+			MaxStaleness:  time.Duration(cfg.PoolStateMaxStaleness) * time.Second,
+			ReorgDepth:    uint64(cfg.PoolStateReorgDepth),
+			RetryInterval: 5 * time.Second,
+		})
+		if err != nil {
+			log.Fatalf("Failed to initialize pool state tracker: %v", err)
+		}
+		go tracker.Run(ctx)
+		log.Printf("Tracking %d pools from Sync events", len(pools))
+
+		opts = append(opts, usecase.WithPoolStateSource(tracker))
+	}
+	uc := usecase.NewUsecase(pairClient, opts...)
 
 	// Initialize handlers
-	handler := handlers.NewHandler(uc)
+	handlerOpts := []handlers.Option{
+		handlers.WithStreamInterval(time.Duration(cfg.StreamIntervalMs) * time.Millisecond),
+		handlers.WithStrictChecksums(cfg.StrictAddressChecksum),
+	}
+	if tracker != nil {
+		handlerOpts = append(handlerOpts, handlers.WithPoolUpdates(tracker))
+	}
+	handler := handlers.NewHandler(uc, handlerOpts...)
 
 	// Initialize Echo
 	e := echo.New()
@@ -56,7 +285,17 @@ func main() {
 
 	// Health check endpoint
 	e.GET("/health", func(c echo.Context) error {
-		return c.JSON(200, map[string]string{"status": "ok"})
+		resp := map[string]interface{}{"status": "ok"}
+		if poolCache != nil {
+			resp["pool_cache"] = poolCache.Stats()
+		}
+		if tracker != nil {
+			resp["pool_state"] = tracker.Status()
+		}
+		if pairIndex != nil {
+			resp["pair_index"] = pairIndex.Status()
+		}
+		return c.JSON(200, resp)
 	})
 
 	// Swagger endpoint
@@ -64,7 +303,30 @@ func main() {
 
 	// API routes
 	e.GET("/estimate", handler.Estimate)
+	e.GET("/estimate/in", handler.EstimateIn)
+	e.GET("/estimate/path", handler.EstimatePath)
+	e.GET("/estimate/split", handler.EstimateSplit)
+	e.GET("/estimate/stream", handler.EstimateStream)
+	e.GET("/swap/build", handler.BuildSwap)
 
 	// Start server
-	log.Fatal(e.Start(":" + cfg.Port))
+	go func() {
+		if err := e.Start(":" + cfg.Port); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Server failed: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Printf("Shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := e.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Failed to shut down server: %v", err)
+	}
+
+	// Flush pair cache entries that the background writer has not persisted yet, ethClient is closed by its defer
+	if poolCache != nil {
+		poolCache.Flush()
+	}
 }
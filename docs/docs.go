@@ -17,7 +17,7 @@ const docTemplate = `{
     "paths": {
         "/estimate": {
             "get": {
-                "description": "Estimates the output amount for a Uniswap V2 token swap based on current pool reserves",
+                "description": "Estimates the output amount for a token swap based on the current pool state. When pool is omitted, the best route over the configured pool graph and the pairs of src and dst in the configured Uniswap V2 factories is used. The pool type (Uniswap V2, Uniswap V3, Curve StableSwap or Balancer V2 weighted) is detected from the pool contract unless protocol is set; V3 pools are quoted crossing initialized ticks, Curve pools with the get_dy invariant math and Balancer pools with the weighted math of the pool contracts.",
                 "consumes": [
                     "application/json"
                 ],
@@ -32,7 +32,335 @@ const docTemplate = `{
                     {
                         "type": "string",
                         "example": "0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852",
-                        "description": "Uniswap V2 pool address",
+                        "description": "Uniswap V2, Uniswap V3, Curve or Balancer pool address or ENS name (omit to search for the best route over Uniswap V2 pairs, including the pairs of src and dst in the configured factories)",
+                        "name": "pool",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+                        "description": "Source token address, token alias such as USDT or ENS name",
+                        "name": "src",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+                        "description": "Destination token address, token alias such as WETH or ENS name",
+                        "name": "dst",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "10000000",
+                        "description": "Source amount to swap, an integer in base units or with amount_format=decimal a decimal in whole tokens",
+                        "name": "src_amount",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "base",
+                            "decimal"
+                        ],
+                        "type": "string",
+                        "description": "Format of src_amount: base (default) or decimal, converted with the on-chain decimals of src; decimal amounts more precise than the token are rejected",
+                        "name": "amount_format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "latest",
+                        "description": "Block number, block hash or tag (latest, safe, finalized) to pin the estimate to",
+                        "name": "block",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 50,
+                        "description": "Slippage tolerance in basis points, adds min_dst_amount to the response",
+                        "name": "slippage_bps",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "v2",
+                            "v3",
+                            "curve",
+                            "balancer"
+                        ],
+                        "type": "string",
+                        "description": "Pool protocol, v2, v3, curve or balancer; detected from the pool contract when omitted, v3, curve and balancer require a pool",
+                        "name": "protocol",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.EstimateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/estimate/in": {
+            "get": {
+                "description": "Estimates the source amount required to receive the given destination amount from a token swap based on the current pool state, detecting the pool type like /estimate",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "estimate"
+                ],
+                "summary": "Calculate reverse swap estimation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852",
+                        "description": "Uniswap V2, Uniswap V3, Curve or Balancer pool address or ENS name",
+                        "name": "pool",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+                        "description": "Source token address, token alias such as USDT or ENS name",
+                        "name": "src",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+                        "description": "Destination token address, token alias such as WETH or ENS name",
+                        "name": "dst",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "6241000000000000",
+                        "description": "Desired destination amount (integer with respect to decimals)",
+                        "name": "dst_amount",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "latest",
+                        "description": "Block number, block hash or tag (latest, safe, finalized) to pin the estimate to",
+                        "name": "block",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.EstimateInResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/estimate/path": {
+            "get": {
+                "description": "Estimates the output amounts for a swap routed through an ordered list of Uniswap V2 pools",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "estimate"
+                ],
+                "summary": "Calculate multi-hop swap estimation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852,0xa478c2975ab1ea89e8196811f51a7b7ade33eb11",
+                        "description": "Comma-separated Uniswap V2 pool addresses, one per hop",
+                        "name": "pools",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "0xdAC17F958D2ee523a2206206994597C13D831ec7,0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2,0x6B175474E89094C44Da98b954EedeAC495271d0F",
+                        "description": "Comma-separated token addresses from source to destination",
+                        "name": "path",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "10000000",
+                        "description": "Source amount to swap (integer with respect to decimals)",
+                        "name": "src_amount",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "latest",
+                        "description": "Block number, block hash or tag (latest, safe, finalized) to pin the estimate to",
+                        "name": "block",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.EstimatePathResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/estimate/split": {
+            "get": {
+                "description": "Splits the source amount across several Uniswap V2 pools trading the same pair to maximize the total output",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "estimate"
+                ],
+                "summary": "Calculate split swap estimation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852,0x06da0fd433c1a5d7a4faa01111c044910a184553",
+                        "description": "Comma-separated pool addresses trading the src/dst pair",
+                        "name": "pools",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+                        "description": "Source token address, token alias such as USDT or ENS name",
+                        "name": "src",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+                        "description": "Destination token address, token alias such as WETH or ENS name",
+                        "name": "dst",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "100000000000",
+                        "description": "Source amount to swap (integer with respect to decimals)",
+                        "name": "src_amount",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "example": 20,
+                        "description": "Number of chunks the amount is split into (granularity, default 10, max 100)",
+                        "name": "parts",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "latest",
+                        "description": "Block number, block hash or tag (latest, safe, finalized) to pin the estimate to",
+                        "name": "block",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.EstimateSplitResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/estimate/stream": {
+            "get": {
+                "description": "Streams an \"estimate\" Server-Sent Event with the current estimation, then a new one every time the output amount changes with the pool reserves. Pools tracked from Sync events are re-evaluated when their reserves change, other pools are polled. A failed re-evaluation is sent as an \"error\" event and the stream continues.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "estimate"
+                ],
+                "summary": "Stream swap estimation",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852",
+                        "description": "Uniswap V2 pool address or ENS name",
                         "name": "pool",
                         "in": "query",
                         "required": true
@@ -40,7 +368,7 @@ const docTemplate = `{
                     {
                         "type": "string",
                         "example": "0xdAC17F958D2ee523a2206206994597C13D831ec7",
-                        "description": "Source token address",
+                        "description": "Source token address, token alias such as USDT or ENS name",
                         "name": "src",
                         "in": "query",
                         "required": true
@@ -48,7 +376,7 @@ const docTemplate = `{
                     {
                         "type": "string",
                         "example": "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
-                        "description": "Destination token address",
+                        "description": "Destination token address, token alias such as WETH or ENS name",
                         "name": "dst",
                         "in": "query",
                         "required": true
@@ -83,6 +411,96 @@ const docTemplate = `{
                     }
                 }
             }
+        },
+        "/swap/build": {
+            "get": {
+                "description": "Estimates the swap and returns the router calldata of an exact input swap whose minimum output is the estimate minus slippage_bps. Use 0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE as src or dst for native ETH. When pool is omitted, the best route over the pairs of the router's factory in the configured pool graph is used. The router derives its pairs from the path and assumes the 0.3% fee, so a pool that is not the router factory's pair of src and dst, or has another fee, is rejected with unsupported_pool.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "swap"
+                ],
+                "summary": "Build swap transaction",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852",
+                        "description": "Uniswap V2 pool address or ENS name (omit to search for the best route)",
+                        "name": "pool",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+                        "description": "Source token address, token alias such as USDT or ENS name",
+                        "name": "src",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE",
+                        "description": "Destination token address, token alias such as WETH or ENS name",
+                        "name": "dst",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "10000000",
+                        "description": "Source amount to swap (integer with respect to decimals)",
+                        "name": "src_amount",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "0x1111111111111111111111111111111111111111",
+                        "description": "Address receiving the output tokens",
+                        "name": "recipient",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "example": 50,
+                        "description": "Slippage tolerance in basis points, default 50",
+                        "name": "slippage_bps",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 1200,
+                        "description": "Seconds the transaction stays valid for, default 1200",
+                        "name": "deadline_seconds",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.SwapBuildResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/models.ErrorResponse"
+                        }
+                    }
+                }
+            }
         }
     },
     "definitions": {
@@ -97,12 +515,439 @@ const docTemplate = `{
                 }
             }
         },
+        "models.EstimateInResponse": {
+            "type": "object",
+            "properties": {
+                "block_hash": {
+                    "type": "string",
+                    "example": "0x9e3d4a1b6c0f4b0b1c3e2f5d8a7b6c5d4e3f2a1b0c9d8e7f6a5b4c3d2e1f0a9b"
+                },
+                "block_number": {
+                    "type": "integer",
+                    "example": 18500000
+                },
+                "fee_bps": {
+                    "type": "integer",
+                    "example": 30
+                },
+                "resolved": {
+                    "description": "Resolved has the addresses of the parameters given as ENS names or token aliases",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ResolvedAddresses"
+                        }
+                    ]
+                },
+                "src_amount": {
+                    "type": "string",
+                    "example": "10000000"
+                },
+                "transfer_tax": {
+                    "description": "TransferTax is set when a fee-on-transfer tax was applied to the estimate",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.TransferTax"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.EstimatePathResponse": {
+            "type": "object",
+            "properties": {
+                "amounts": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "10000000",
+                        "3978866028279530",
+                        "9950000000000000000"
+                    ]
+                },
+                "block_hash": {
+                    "type": "string",
+                    "example": "0x9e3d4a1b6c0f4b0b1c3e2f5d8a7b6c5d4e3f2a1b0c9d8e7f6a5b4c3d2e1f0a9b"
+                },
+                "block_number": {
+                    "type": "integer",
+                    "example": 18500000
+                },
+                "dst_amount": {
+                    "type": "string",
+                    "example": "9950000000000000000"
+                },
+                "fee_bps": {
+                    "description": "FeeBps has the swap fee of every pool",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        30,
+                        30
+                    ]
+                },
+                "transfer_tax": {
+                    "description": "TransferTax is set when a fee-on-transfer tax was applied to the estimate",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.TransferTax"
+                        }
+                    ]
+                }
+            }
+        },
         "models.EstimateResponse": {
             "type": "object",
             "properties": {
+                "block_hash": {
+                    "type": "string",
+                    "example": "0x9e3d4a1b6c0f4b0b1c3e2f5d8a7b6c5d4e3f2a1b0c9d8e7f6a5b4c3d2e1f0a9b"
+                },
+                "block_number": {
+                    "type": "integer",
+                    "example": 18500000
+                },
+                "block_timestamp_last": {
+                    "description": "BlockTimestampLast is the pool's _blockTimestampLast from getReserves",
+                    "type": "integer",
+                    "example": 1699999991
+                },
                 "dst_amount": {
                     "type": "string",
                     "example": "6241000000000000"
+                },
+                "dst_amount_decimal": {
+                    "description": "DstAmountDecimal is dst_amount in whole tokens, set when the decimals of dst are known",
+                    "type": "string",
+                    "example": "0.006241"
+                },
+                "dst_token": {
+                    "$ref": "#/definitions/models.TokenInfo"
+                },
+                "execution_price": {
+                    "description": "ExecutionPrice is the effective price of the swap, dst_amount / src_amount",
+                    "type": "string",
+                    "example": "397886602.827953"
+                },
+                "fee_bps": {
+                    "description": "FeeBps is the swap fee of the pool, set when a pool is given",
+                    "type": "integer",
+                    "example": 30
+                },
+                "min_dst_amount": {
+                    "description": "MinDstAmount is dst_amount minus the requested slippage tolerance, rounded down",
+                    "type": "string",
+                    "example": "6209795000000000"
+                },
+                "price_impact_bps": {
+                    "description": "PriceImpactBps is how much worse the execution price is than the spot price in basis points, including the swap fee",
+                    "type": "string",
+                    "example": "30.12"
+                },
+                "resolved": {
+                    "description": "Resolved has the addresses of the parameters given as ENS names or token aliases",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ResolvedAddresses"
+                        }
+                    ]
+                },
+                "route": {
+                    "$ref": "#/definitions/models.Route"
+                },
+                "spot_price": {
+                    "description": "SpotPrice is the mid price given by the pool reserves before the swap",
+                    "type": "string",
+                    "example": "399088660.1"
+                },
+                "src_token": {
+                    "description": "SrcToken and DstToken are the metadata of the tokens, omitted when unavailable",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.TokenInfo"
+                        }
+                    ]
+                },
+                "transfer_tax": {
+                    "description": "TransferTax is set when a fee-on-transfer tax was applied to the estimate",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.TransferTax"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.EstimateSplitResponse": {
+            "type": "object",
+            "properties": {
+                "allocations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.SplitAllocation"
+                    }
+                },
+                "block_hash": {
+                    "type": "string",
+                    "example": "0x9e3d4a1b6c0f4b0b1c3e2f5d8a7b6c5d4e3f2a1b0c9d8e7f6a5b4c3d2e1f0a9b"
+                },
+                "block_number": {
+                    "type": "integer",
+                    "example": 18500000
+                },
+                "dst_amount": {
+                    "type": "string",
+                    "example": "39711870000000000000"
+                },
+                "resolved": {
+                    "description": "Resolved has the addresses of src and dst when they were given as ENS names or token aliases",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ResolvedAddresses"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.ResolvedAddresses": {
+            "type": "object",
+            "properties": {
+                "dst": {
+                    "type": "string",
+                    "example": "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"
+                },
+                "pool": {
+                    "type": "string",
+                    "example": "0x0d4a11d5EEaaC28EC3F61d100daF4d40471f1852"
+                },
+                "src": {
+                    "type": "string",
+                    "example": "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+                }
+            }
+        },
+        "models.Route": {
+            "type": "object",
+            "properties": {
+                "amounts": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "10000000",
+                        "6241000000000000"
+                    ]
+                },
+                "fee_bps": {
+                    "description": "FeeBps has the swap fee of every pool",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        30
+                    ]
+                },
+                "path": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+                        "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"
+                    ]
+                },
+                "pools": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "0x0d4a11d5EEaaC28EC3F61d100daF4d40471f1852"
+                    ]
+                }
+            }
+        },
+        "models.SplitAllocation": {
+            "type": "object",
+            "properties": {
+                "dst_amount": {
+                    "type": "string",
+                    "example": "23870000000000000000"
+                },
+                "fee_bps": {
+                    "type": "integer",
+                    "example": 30
+                },
+                "pool": {
+                    "type": "string",
+                    "example": "0x0d4a11d5EEaaC28EC3F61d100daF4d40471f1852"
+                },
+                "src_amount": {
+                    "type": "string",
+                    "example": "60000000000"
+                },
+                "transfer_tax": {
+                    "description": "TransferTax is set when a fee-on-transfer tax was applied to the allocation",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.TransferTax"
+                        }
+                    ]
+                }
+            }
+        },
+        "models.SwapBuildResponse": {
+            "type": "object",
+            "properties": {
+                "amount_out_min": {
+                    "type": "string",
+                    "example": "3958971698138132"
+                },
+                "block_hash": {
+                    "type": "string",
+                    "example": "0x9e3d4a1b6c0f4b0b1c3e2f5d8a7b6c5d4e3f2a1b0c9d8e7f6a5b4c3d2e1f0a9b"
+                },
+                "block_number": {
+                    "type": "integer",
+                    "example": 18500000
+                },
+                "calldata": {
+                    "type": "string",
+                    "example": "0x18cbafe5..."
+                },
+                "deadline": {
+                    "type": "integer",
+                    "example": 1700001200
+                },
+                "dst_amount": {
+                    "type": "string",
+                    "example": "3978866028279530"
+                },
+                "execution_price": {
+                    "description": "ExecutionPrice is the effective price of the swap, dst_amount / src_amount",
+                    "type": "string",
+                    "example": "397886602.827953"
+                },
+                "fee_bps": {
+                    "description": "FeeBps has the swap fee of every pool",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    },
+                    "example": [
+                        30
+                    ]
+                },
+                "method": {
+                    "type": "string",
+                    "example": "swapExactTokensForETH"
+                },
+                "path": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+                        "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"
+                    ]
+                },
+                "pools": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "0x0d4a11d5EEaaC28EC3F61d100daF4d40471f1852"
+                    ]
+                },
+                "price_impact_bps": {
+                    "description": "PriceImpactBps is how much worse the execution price is than the spot price in basis points, including the swap fee",
+                    "type": "string",
+                    "example": "30.12"
+                },
+                "resolved": {
+                    "description": "Resolved has the addresses of the parameters given as ENS names or token aliases",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ResolvedAddresses"
+                        }
+                    ]
+                },
+                "spot_price": {
+                    "description": "SpotPrice is the mid price given by the pool reserves before the swap",
+                    "type": "string",
+                    "example": "399088660.1"
+                },
+                "src_amount": {
+                    "type": "string",
+                    "example": "10000000"
+                },
+                "to": {
+                    "description": "To is the router the transaction must be sent to",
+                    "type": "string",
+                    "example": "0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D"
+                },
+                "transfer_tax": {
+                    "description": "TransferTax is set when a fee-on-transfer tax was applied, the swap then uses the router's fee-on-transfer method",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.TransferTax"
+                        }
+                    ]
+                },
+                "value": {
+                    "description": "Value is the ETH sent with the transaction in wei",
+                    "type": "string",
+                    "example": "0"
+                }
+            }
+        },
+        "models.TokenInfo": {
+            "type": "object",
+            "properties": {
+                "address": {
+                    "type": "string",
+                    "example": "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+                },
+                "decimals": {
+                    "type": "integer",
+                    "example": 6
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Tether USD"
+                },
+                "symbol": {
+                    "description": "Symbol and Name are empty when the token does not implement them",
+                    "type": "string",
+                    "example": "USDT"
+                }
+            }
+        },
+        "models.TransferTax": {
+            "type": "object",
+            "properties": {
+                "dst_tax_bps": {
+                    "description": "DstTaxBps is taken from the output when it is sent by the last pool",
+                    "type": "integer",
+                    "example": 0
+                },
+                "path_taxed": {
+                    "description": "PathTaxed is set when a token passed between two pools of a multi-hop swap is taxed",
+                    "type": "boolean",
+                    "example": false
+                },
+                "src_tax_bps": {
+                    "description": "SrcTaxBps is taken from src_amount when it is sent into the first pool",
+                    "type": "integer",
+                    "example": 500
                 }
             }
         }
@@ -0,0 +1,52 @@
+package balancer
+
+// WeightedPoolABI is the ABI for the state getters of Balancer V2 weighted pools, the Vault's getPoolTokens
+// and the decimals of the pool tokens
+const WeightedPoolABI = `[
+	{
+		"inputs": [],
+		"name": "getPoolId",
+		"outputs": [{"name": "", "type": "bytes32"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [],
+		"name": "getVault",
+		"outputs": [{"name": "", "type": "address"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [],
+		"name": "getNormalizedWeights",
+		"outputs": [{"name": "", "type": "uint256[]"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [],
+		"name": "getSwapFeePercentage",
+		"outputs": [{"name": "", "type": "uint256"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [{"name": "poolId", "type": "bytes32"}],
+		"name": "getPoolTokens",
+		"outputs": [
+			{"name": "tokens", "type": "address[]"},
+			{"name": "balances", "type": "uint256[]"},
+			{"name": "lastChangeBlock", "type": "uint256"}
+		],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [],
+		"name": "decimals",
+		"outputs": [{"name": "", "type": "uint8"}],
+		"stateMutability": "view",
+		"type": "function"
+	}
+]`
@@ -0,0 +1,163 @@
+package balancer
+
+import (
+	"1inch_testtask/internal/uniswap_v2"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ErrNotWeightedPool is returned for Balancer pools without normalized weights, such as stable and linear pools
+var ErrNotWeightedPool = errors.New("not a Balancer weighted pool")
+
+// IBalancer defines the interface for reading Balancer V2 weighted pools
+type IBalancer interface {
+	// GetPoolState loads the pool state. The block is taken from ctx, see uniswap_v2.WithBlockNumber.
+	GetPoolState(ctx context.Context, poolAddress common.Address) (*PoolState, error)
+}
+
+// contractCall is a pool, Vault or token method call batched through Multicall3
+type contractCall struct {
+	target common.Address
+	method string
+	args   []interface{}
+}
+
+// Client implements IBalancer by batching calls through Multicall3.
+// A pool state is loaded with three eth_calls: the pool parameters, its tokens and balances from the Vault,
+// then the decimals of its tokens.
+type Client struct {
+	multicall *uniswap_v2.Multicall3
+	poolABI   abi.ABI
+}
+
+// NewClient creates a client that batches calls through the Multicall3 contract at multicallAddress
+func NewClient(backend bind.ContractCaller, multicallAddress common.Address) (*Client, error) {
+	poolABI, err := abi.JSON(strings.NewReader(WeightedPoolABI))
+	if err != nil {
+		return nil, err
+	}
+
+	multicall, err := uniswap_v2.NewMulticall3(backend, multicallAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		multicall: multicall,
+		poolABI:   poolABI,
+	}, nil
+}
+
+// GetPoolState loads the weights and swap fee of the pool and its tokens and balances from the Vault
+func (c *Client) GetPoolState(ctx context.Context, poolAddress common.Address) (*PoolState, error) {
+	out, err := c.aggregate(ctx, []contractCall{
+		{target: poolAddress, method: "getPoolId"},
+		{target: poolAddress, method: "getVault"},
+		{target: poolAddress, method: "getNormalizedWeights"},
+		{target: poolAddress, method: "getSwapFeePercentage"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if out[0] == nil || out[1] == nil || out[3] == nil {
+		return nil, fmt.Errorf("%s is not a Balancer pool", poolAddress.Hex())
+	}
+	if out[2] == nil {
+		return nil, fmt.Errorf("%w: %s", ErrNotWeightedPool, poolAddress.Hex())
+	}
+
+	state := &PoolState{
+		Pool:    poolAddress,
+		PoolID:  common.Hash(out[0][0].([32]byte)),
+		Weights: out[2][0].([]*big.Int),
+		SwapFee: out[3][0].(*big.Int),
+	}
+	vault := out[1][0].(common.Address)
+
+	out, err = c.aggregate(ctx, []contractCall{{target: vault, method: "getPoolTokens", args: []interface{}{state.PoolID}}})
+	if err != nil {
+		return nil, err
+	}
+	if out[0] == nil {
+		return nil, fmt.Errorf("getPoolTokens of %s: execution reverted", poolAddress.Hex())
+	}
+	state.Tokens = out[0][0].([]common.Address)
+	state.Balances = out[0][1].([]*big.Int)
+	if len(state.Tokens) < 2 || len(state.Weights) != len(state.Tokens) {
+		return nil, fmt.Errorf("%s has %d tokens and %d weights", poolAddress.Hex(), len(state.Tokens), len(state.Weights))
+	}
+
+	if state.ScalingFactors, err = c.getScalingFactors(ctx, state.Tokens); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// getScalingFactors returns the factors upscaling token amounts to 18 decimals, 10^(18 - decimals)
+func (c *Client) getScalingFactors(ctx context.Context, tokens []common.Address) ([]*big.Int, error) {
+	calls := make([]contractCall, len(tokens))
+	for i, token := range tokens {
+		calls[i] = contractCall{target: token, method: "decimals"}
+	}
+
+	out, err := c.aggregate(ctx, calls)
+	if err != nil {
+		return nil, err
+	}
+
+	factors := make([]*big.Int, len(tokens))
+	for i, token := range tokens {
+		if out[i] == nil {
+			return nil, fmt.Errorf("decimals of %s: execution reverted", token.Hex())
+		}
+		decimals := out[i][0].(uint8)
+		if decimals > 18 {
+			return nil, fmt.Errorf("unsupported decimals %d of %s", decimals, token.Hex())
+		}
+		factors[i] = new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(18-decimals)), nil)
+	}
+
+	return factors, nil
+}
+
+// aggregate executes the calls in a single Multicall3.aggregate3 call and returns the unpacked outputs,
+// nil for calls that reverted
+func (c *Client) aggregate(ctx context.Context, contractCalls []contractCall) ([][]interface{}, error) {
+	calls := make([]uniswap_v2.Multicall3Call, len(contractCalls))
+	for i, call := range contractCalls {
+		callData, err := c.poolABI.Pack(call.method, call.args...)
+		if err != nil {
+			return nil, err
+		}
+		calls[i] = uniswap_v2.Multicall3Call{Target: call.target, AllowFailure: true, CallData: callData}
+	}
+
+	results, err := c.multicall.Aggregate3(ctx, calls)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([][]interface{}, len(results))
+	for i, result := range results {
+		if !result.Success {
+			continue
+		}
+
+		values, err := c.poolABI.Unpack(contractCalls[i].method, result.ReturnData)
+		if err != nil {
+			// An output that does not decode means the method is missing
+			continue
+		}
+		out[i] = values
+	}
+
+	return out, nil
+}
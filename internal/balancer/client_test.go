@@ -0,0 +1,272 @@
+package balancer
+
+import (
+	"1inch_testtask/internal/uniswap_v2"
+	"context"
+	"encoding/json"
+	"flag"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var vault = common.HexToAddress("0xBA12222222228d8Ba445958a75a0704d566BF2C8")
+
+// recordRPC is an archive node endpoint, when set TestPoolState_Recorded records its fixtures from mainnet first
+var recordRPC = flag.String("record-rpc", "", "archive node JSON-RPC URL to record "+recordedFile+" from")
+
+const (
+	// recordBlock is the mainnet block the fixtures are recorded at
+	recordBlock = 18500000
+	// recordTimeout bounds the recording
+	recordTimeout = time.Minute
+)
+
+// recordPools are the recorded pools: an 80/20 pool, a 50/50 pool of tokens with different decimals and a 3-token pool
+var recordPools = []struct {
+	name    string
+	address common.Address
+}{
+	{name: "BAL/WETH 80/20", address: balWethAddress},
+	{name: "WBTC/WETH 50/50", address: wbtcWethAddress},
+	{name: "WBTC/USDC/WETH", address: triPoolAddress},
+}
+
+// queryBatchSwapABI is the Vault's queryBatchSwap, which returns the asset deltas of swaps without executing them
+const queryBatchSwapABI = `[{
+	"inputs": [
+		{"name": "kind", "type": "uint8"},
+		{"name": "swaps", "type": "tuple[]", "components": [
+			{"name": "poolId", "type": "bytes32"},
+			{"name": "assetInIndex", "type": "uint256"},
+			{"name": "assetOutIndex", "type": "uint256"},
+			{"name": "amount", "type": "uint256"},
+			{"name": "userData", "type": "bytes"}
+		]},
+		{"name": "assets", "type": "address[]"},
+		{"name": "funds", "type": "tuple", "components": [
+			{"name": "sender", "type": "address"},
+			{"name": "fromInternalBalance", "type": "bool"},
+			{"name": "recipient", "type": "address"},
+			{"name": "toInternalBalance", "type": "bool"}
+		]}
+	],
+	"name": "queryBatchSwap",
+	"outputs": [{"name": "assetDeltas", "type": "int256[]"}],
+	"stateMutability": "nonpayable",
+	"type": "function"
+}]`
+
+// batchSwapStep and fundManagement are the tuples of queryBatchSwap
+type batchSwapStep struct {
+	PoolId        [32]byte
+	AssetInIndex  *big.Int
+	AssetOutIndex *big.Int
+	Amount        *big.Int
+	UserData      []byte
+}
+
+type fundManagement struct {
+	Sender              common.Address
+	FromInternalBalance bool
+	Recipient           common.Address
+	ToInternalBalance   bool
+}
+
+// recordQueryBatchSwap loads the recorded pools with the client at recordBlock and queries the Vault for swaps of
+// one whole token and of 1% of the balance in every direction, given in and given out, then writes the states and
+// outputs to recordedFile
+func recordQueryBatchSwap(t *testing.T, url string) {
+	ctx, cancel := context.WithTimeout(context.Background(), recordTimeout)
+	defer cancel()
+
+	backend, err := ethclient.DialContext(ctx, url)
+	require.NoError(t, err)
+	defer backend.Close()
+	client, err := NewClient(backend, uniswap_v2.Multicall3Address)
+	require.NoError(t, err)
+	vaultABI, err := abi.JSON(strings.NewReader(queryBatchSwapABI))
+	require.NoError(t, err)
+	contract := bind.NewBoundContract(vault, vaultABI, backend, nil, nil)
+
+	block := big.NewInt(recordBlock)
+	ctx = uniswap_v2.WithBlockNumber(ctx, block)
+	var pools []recordedPool
+	for _, pool := range recordPools {
+		state, err := client.GetPoolState(ctx, pool.address)
+		require.NoError(t, err, pool.name)
+
+		recorded := recordedPool{Name: pool.name, Block: recordBlock, State: state}
+		for i := range state.Tokens {
+			for j := range state.Tokens {
+				if i == j {
+					continue
+				}
+				for _, kind := range []uint8{givenIn, givenOut} {
+					// Amounts are of token i given in, of token j given out
+					token := i
+					if kind == givenOut {
+						token = j
+					}
+					unit := new(big.Int).Quo(One, state.ScalingFactors[token])
+					for _, amount := range []*big.Int{unit, new(big.Int).Quo(state.Balances[token], big.NewInt(100))} {
+						var out []interface{}
+						err := contract.Call(&bind.CallOpts{Context: ctx, BlockNumber: block}, &out, "queryBatchSwap", kind,
+							[]batchSwapStep{{PoolId: state.PoolID, AssetInIndex: big.NewInt(0), AssetOutIndex: big.NewInt(1), Amount: amount, UserData: []byte{}}},
+							[]common.Address{state.Tokens[i], state.Tokens[j]},
+							fundManagement{})
+						require.NoError(t, err, "%s queryBatchSwap(%d, %d to %d, %s)", pool.name, kind, i, j, amount)
+
+						// The deltas are positive for the amount the Vault receives, negative for the amount it sends
+						deltas := out[0].([]*big.Int)
+						swap := recordedSwap{Kind: kind, I: i, J: j, AmountIn: deltas[0], AmountOut: new(big.Int).Neg(deltas[1])}
+						recorded.Swaps = append(recorded.Swaps, swap)
+					}
+				}
+			}
+		}
+		pools = append(pools, recorded)
+	}
+
+	data, err := json.MarshalIndent(pools, "", "  ")
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(recordedFile), 0o755))
+	require.NoError(t, os.WriteFile(recordedFile, append(data, '\n'), 0o644))
+}
+
+// fakeChain is a bind.ContractCaller that executes Multicall3.aggregate3 against an in-memory pool, the Vault and tokens
+type fakeChain struct {
+	t       *testing.T
+	state   *PoolState
+	poolABI abi.ABI
+	callABI abi.ABI
+	// weighted is false for Balancer pools without getNormalizedWeights
+	weighted bool
+	// decimals are the decimals of the tokens, tokens without them revert
+	decimals map[common.Address]uint8
+	calls    int
+}
+
+func newFakeChain(t *testing.T, state *PoolState, decimals map[common.Address]uint8) *fakeChain {
+	poolABI, err := abi.JSON(strings.NewReader(WeightedPoolABI))
+	require.NoError(t, err)
+	callABI, err := abi.JSON(strings.NewReader(uniswap_v2.Multicall3ABI))
+	require.NoError(t, err)
+
+	return &fakeChain{t: t, state: state, poolABI: poolABI, callABI: callABI, weighted: true, decimals: decimals}
+}
+
+func (f *fakeChain) CodeAt(context.Context, common.Address, *big.Int) ([]byte, error) {
+	return []byte{0x1}, nil
+}
+
+func (f *fakeChain) CallContract(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	f.calls++
+	method, err := f.callABI.MethodById(msg.Data[:4])
+	require.NoError(f.t, err)
+	args, err := method.Inputs.Unpack(msg.Data[4:])
+	require.NoError(f.t, err)
+	calls := *abi.ConvertType(args[0], new([]uniswap_v2.Multicall3Call)).(*[]uniswap_v2.Multicall3Call)
+
+	results := make([]uniswap_v2.Multicall3Result, len(calls))
+	for i, call := range calls {
+		poolMethod, err := f.poolABI.MethodById(call.CallData[:4])
+		require.NoError(f.t, err)
+		inputs, err := poolMethod.Inputs.Unpack(call.CallData[4:])
+		require.NoError(f.t, err)
+
+		var returnData []byte
+		switch {
+		case call.Target == f.state.Pool && poolMethod.Name == "getPoolId":
+			returnData, err = poolMethod.Outputs.Pack([32]byte(f.state.PoolID))
+		case call.Target == f.state.Pool && poolMethod.Name == "getVault":
+			returnData, err = poolMethod.Outputs.Pack(vault)
+		case call.Target == f.state.Pool && poolMethod.Name == "getNormalizedWeights" && f.weighted:
+			returnData, err = poolMethod.Outputs.Pack(f.state.Weights)
+		case call.Target == f.state.Pool && poolMethod.Name == "getSwapFeePercentage":
+			returnData, err = poolMethod.Outputs.Pack(f.state.SwapFee)
+		case call.Target == vault && poolMethod.Name == "getPoolTokens":
+			if common.Hash(inputs[0].([32]byte)) != f.state.PoolID {
+				continue
+			}
+			returnData, err = poolMethod.Outputs.Pack(f.state.Tokens, f.state.Balances, big.NewInt(18500000))
+		case poolMethod.Name == "decimals":
+			decimals, ok := f.decimals[call.Target]
+			if !ok {
+				continue
+			}
+			returnData, err = poolMethod.Outputs.Pack(decimals)
+		default:
+			continue
+		}
+		require.NoError(f.t, err)
+		results[i] = uniswap_v2.Multicall3Result{Success: true, ReturnData: returnData}
+	}
+
+	return method.Outputs.Pack(results)
+}
+
+func TestClient_GetPoolState(t *testing.T) {
+	decimals := map[common.Address]uint8{bal: 18, weth: 18, wbtc: 8, usdc: 6}
+
+	tests := []struct {
+		name string
+		pool *PoolState
+	}{
+		{name: "80/20 pool", pool: balWeth()},
+		{name: "token with 8 decimals", pool: wbtcWeth()},
+		{name: "three tokens", pool: triPool()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newFakeChain(t, tt.pool, decimals)
+			client, err := NewClient(backend, uniswap_v2.Multicall3Address)
+			require.NoError(t, err)
+
+			state, err := client.GetPoolState(context.Background(), tt.pool.Pool)
+			require.NoError(t, err)
+			assert.Equal(t, 3, backend.calls, "pool parameters, Vault balances and token decimals should take one eth_call each")
+			assert.Equal(t, tt.pool, state)
+		})
+	}
+
+	t.Run("token without decimals", func(t *testing.T) {
+		backend := newFakeChain(t, triPool(), map[common.Address]uint8{wbtc: 8, weth: 18})
+		client, err := NewClient(backend, uniswap_v2.Multicall3Address)
+		require.NoError(t, err)
+
+		_, err = client.GetPoolState(context.Background(), triPoolAddress)
+		assert.ErrorContains(t, err, "decimals of "+usdc.Hex())
+	})
+
+	t.Run("pool without weights", func(t *testing.T) {
+		backend := newFakeChain(t, balWeth(), decimals)
+		backend.weighted = false
+		client, err := NewClient(backend, uniswap_v2.Multicall3Address)
+		require.NoError(t, err)
+
+		_, err = client.GetPoolState(context.Background(), balWethAddress)
+		assert.ErrorIs(t, err, ErrNotWeightedPool)
+	})
+
+	t.Run("not a pool", func(t *testing.T) {
+		backend := newFakeChain(t, balWeth(), decimals)
+		client, err := NewClient(backend, uniswap_v2.Multicall3Address)
+		require.NoError(t, err)
+
+		_, err = client.GetPoolState(context.Background(), weth)
+		assert.ErrorContains(t, err, "is not a Balancer pool")
+	})
+}
@@ -0,0 +1,291 @@
+package balancer
+
+import (
+	"errors"
+	"math/big"
+)
+
+// Ports of Balancer V2's FixedPoint and LogExpMath libraries. Values are 18 decimal fixed point numbers
+// and every operation rounds exactly like the Solidity code, so quotes match the pools to the wei.
+
+// ErrOutOfBounds is returned when LogExpMath is called outside of its domain, where the contracts revert
+var ErrOutOfBounds = errors.New("fixed point math out of bounds")
+
+var (
+	// One is 1.0 in 18 decimal fixed point
+	One = big.NewInt(1e18)
+
+	two  = big.NewInt(2e18)
+	four = big.NewInt(4e18)
+	// maxPowRelativeError bounds the error of pow, 10^(-14)
+	maxPowRelativeError = big.NewInt(10000)
+)
+
+// mulDown returns a * b rounded down
+func mulDown(a, b *big.Int) *big.Int {
+	product := new(big.Int).Mul(a, b)
+	return product.Quo(product, One)
+}
+
+// mulUp returns a * b rounded up
+func mulUp(a, b *big.Int) *big.Int {
+	product := new(big.Int).Mul(a, b)
+	if product.Sign() == 0 {
+		return product
+	}
+	product.Sub(product, big1)
+	product.Quo(product, One)
+	return product.Add(product, big1)
+}
+
+// divDown returns a / b rounded down
+func divDown(a, b *big.Int) *big.Int {
+	quotient := new(big.Int).Mul(a, One)
+	return quotient.Quo(quotient, b)
+}
+
+// divUp returns a / b rounded up
+func divUp(a, b *big.Int) *big.Int {
+	if a.Sign() == 0 {
+		return new(big.Int)
+	}
+	quotient := new(big.Int).Mul(a, One)
+	quotient.Sub(quotient, big1)
+	quotient.Quo(quotient, b)
+	return quotient.Add(quotient, big1)
+}
+
+// complement returns 1 - x, or 0 when x is over 1
+func complement(x *big.Int) *big.Int {
+	if x.Cmp(One) >= 0 {
+		return new(big.Int)
+	}
+	return new(big.Int).Sub(One, x)
+}
+
+// powUp returns x^y rounded up: exact for the exponents 1, 2 and 4, otherwise pow plus its maximum error
+func powUp(x, y *big.Int) (*big.Int, error) {
+	switch {
+	case y.Cmp(One) == 0:
+		return new(big.Int).Set(x), nil
+	case y.Cmp(two) == 0:
+		return mulUp(x, x), nil
+	case y.Cmp(four) == 0:
+		square := mulUp(x, x)
+		return mulUp(square, square), nil
+	}
+
+	raw, err := pow(x, y)
+	if err != nil {
+		return nil, err
+	}
+	maxError := mulUp(raw, maxPowRelativeError)
+	maxError.Add(maxError, big1)
+	return raw.Add(raw, maxError), nil
+}
+
+var (
+	big1       = big.NewInt(1)
+	one20      = exp10(20)
+	one36      = exp10(36)
+	oneSquared = new(big.Int).Mul(One, One)
+
+	maxNaturalExponent = new(big.Int).Mul(big.NewInt(130), One)
+	minNaturalExponent = new(big.Int).Mul(big.NewInt(-41), One)
+	ln36LowerBound     = new(big.Int).Sub(One, big.NewInt(1e17))
+	ln36UpperBound     = new(big.Int).Add(One, big.NewInt(1e17))
+	// mildExponentBound is 2^254 / ONE_20
+	mildExponentBound = new(big.Int).Quo(new(big.Int).Lsh(big1, 254), one20)
+
+	// x0 and x1 are 2^7 and 2^6 with 18 decimals, a0 and a1 are e^x0 and e^x1 without decimals
+	x0 = mustBigInt("128000000000000000000")
+	a0 = mustBigInt("38877084059945950922200000000000000000000000000000000000")
+	x1 = mustBigInt("64000000000000000000")
+	a1 = mustBigInt("6235149080811616882910000000")
+
+	// x2 to x11 are 2^5 down to 2^-4 with 20 decimals, a2 to a11 are e^x with 20 decimals
+	xs = []*big.Int{
+		mustBigInt("3200000000000000000000"),
+		mustBigInt("1600000000000000000000"),
+		mustBigInt("800000000000000000000"),
+		mustBigInt("400000000000000000000"),
+		mustBigInt("200000000000000000000"),
+		mustBigInt("100000000000000000000"),
+		mustBigInt("50000000000000000000"),
+		mustBigInt("25000000000000000000"),
+		mustBigInt("12500000000000000000"),
+		mustBigInt("6250000000000000000"),
+	}
+	as = []*big.Int{
+		mustBigInt("7896296018268069516100000000000000"),
+		mustBigInt("888611052050787263676000000"),
+		mustBigInt("298095798704172827474000"),
+		mustBigInt("5459815003314423907810"),
+		mustBigInt("738905609893065022723"),
+		mustBigInt("271828182845904523536"),
+		mustBigInt("164872127070012814685"),
+		mustBigInt("128402541668774148407"),
+		mustBigInt("113314845306682631683"),
+		mustBigInt("106449445891785942956"),
+	}
+)
+
+// pow returns x^y computed as exp(y * ln(x)) like LogExpMath.pow
+func pow(x, y *big.Int) (*big.Int, error) {
+	if y.Sign() == 0 {
+		return new(big.Int).Set(One), nil
+	}
+	if x.Sign() == 0 {
+		return new(big.Int), nil
+	}
+	if x.BitLen() > 255 || y.Cmp(mildExponentBound) >= 0 {
+		return nil, ErrOutOfBounds
+	}
+
+	var logxTimesY *big.Int
+	if ln36LowerBound.Cmp(x) < 0 && x.Cmp(ln36UpperBound) < 0 {
+		// (ln_36_x / ONE_18) * y + ((ln_36_x % ONE_18) * y) / ONE_18
+		ln36x := ln36(x)
+		logxTimesY = new(big.Int).Quo(ln36x, One)
+		logxTimesY.Mul(logxTimesY, y)
+		remainder := new(big.Int).Rem(ln36x, One)
+		remainder.Mul(remainder, y)
+		logxTimesY.Add(logxTimesY, remainder.Quo(remainder, One))
+	} else {
+		logxTimesY = ln(x)
+		logxTimesY.Mul(logxTimesY, y)
+	}
+	logxTimesY.Quo(logxTimesY, One)
+
+	return exp(logxTimesY)
+}
+
+// exp returns e^x for x with 18 decimals like LogExpMath.exp
+func exp(x *big.Int) (*big.Int, error) {
+	if x.Cmp(minNaturalExponent) < 0 || x.Cmp(maxNaturalExponent) > 0 {
+		return nil, ErrOutOfBounds
+	}
+	if x.Sign() < 0 {
+		// e^x = 1 / e^(-x)
+		inverse, err := exp(new(big.Int).Neg(x))
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).Quo(oneSquared, inverse), nil
+	}
+
+	x = new(big.Int).Set(x)
+	firstAN := big1
+	switch {
+	case x.Cmp(x0) >= 0:
+		x.Sub(x, x0)
+		firstAN = a0
+	case x.Cmp(x1) >= 0:
+		x.Sub(x, x1)
+		firstAN = a1
+	}
+
+	// Continue with 20 decimals, x10 and x11 are not needed at this precision
+	x.Mul(x, big.NewInt(100))
+	product := new(big.Int).Set(one20)
+	for k := 0; k < 8; k++ {
+		if x.Cmp(xs[k]) >= 0 {
+			x.Sub(x, xs[k])
+			product.Mul(product, as[k])
+			product.Quo(product, one20)
+		}
+	}
+
+	// Taylor series of the remainder up to the 12th term
+	seriesSum := new(big.Int).Add(one20, x)
+	term := new(big.Int).Set(x)
+	for k := int64(2); k <= 12; k++ {
+		term.Mul(term, x)
+		term.Quo(term, one20)
+		term.Quo(term, big.NewInt(k))
+		seriesSum.Add(seriesSum, term)
+	}
+
+	// ((product * seriesSum / ONE_20) * firstAN) / 100
+	result := product.Mul(product, seriesSum)
+	result.Quo(result, one20)
+	result.Mul(result, firstAN)
+	return result.Quo(result, big.NewInt(100)), nil
+}
+
+// ln returns the natural logarithm of a with 18 decimals like LogExpMath._ln
+func ln(a *big.Int) *big.Int {
+	if a.Cmp(One) < 0 {
+		// ln(a) = -ln(1 / a)
+		inverse := new(big.Int).Quo(oneSquared, a)
+		return inverse.Neg(ln(inverse))
+	}
+
+	a = new(big.Int).Set(a)
+	sum := new(big.Int)
+	if a.Cmp(new(big.Int).Mul(a0, One)) >= 0 {
+		a.Quo(a, a0)
+		sum.Add(sum, x0)
+	}
+	if a.Cmp(new(big.Int).Mul(a1, One)) >= 0 {
+		a.Quo(a, a1)
+		sum.Add(sum, x1)
+	}
+
+	// Continue with 20 decimals
+	sum.Mul(sum, big.NewInt(100))
+	a.Mul(a, big.NewInt(100))
+	for k := range as {
+		if a.Cmp(as[k]) >= 0 {
+			a.Mul(a, one20)
+			a.Quo(a, as[k])
+			sum.Add(sum, xs[k])
+		}
+	}
+
+	// ln(a) = 2 * atanh(z) with z = (a - 1) / (a + 1), summed up to the 11th power
+	z := new(big.Int).Sub(a, one20)
+	z.Mul(z, one20)
+	z.Quo(z, new(big.Int).Add(a, one20))
+	seriesSum := oddSeries(z, one20, 11)
+
+	sum.Add(sum, seriesSum)
+	return sum.Quo(sum, big.NewInt(100))
+}
+
+// ln36 returns the natural logarithm of x with 36 decimals for x close to 1 like LogExpMath._ln_36
+func ln36(x *big.Int) *big.Int {
+	x = new(big.Int).Mul(x, One)
+
+	z := new(big.Int).Sub(x, one36)
+	z.Mul(z, one36)
+	z.Quo(z, new(big.Int).Add(x, one36))
+	return oddSeries(z, one36, 15)
+}
+
+// oddSeries returns 2 * (z + z^3/3 + z^5/5 + ... + z^maxPower/maxPower) for z with the precision of one
+func oddSeries(z, one *big.Int, maxPower int64) *big.Int {
+	zSquared := new(big.Int).Mul(z, z)
+	zSquared.Quo(zSquared, one)
+
+	num := new(big.Int).Set(z)
+	seriesSum := new(big.Int).Set(z)
+	for k := int64(3); k <= maxPower; k += 2 {
+		num.Mul(num, zSquared)
+		num.Quo(num, one)
+		seriesSum.Add(seriesSum, new(big.Int).Quo(num, big.NewInt(k)))
+	}
+	return seriesSum.Lsh(seriesSum, 1)
+}
+
+func exp10(n int64) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(n), nil)
+}
+
+func mustBigInt(s string) *big.Int {
+	value, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic("invalid big int string: " + s)
+	}
+	return value
+}
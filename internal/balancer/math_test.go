@@ -0,0 +1,91 @@
+package balancer
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPow(t *testing.T) {
+	// Expected values were computed by evaluating LogExpMath.pow on these inputs
+	tests := []struct {
+		name string
+		x, y string
+		want string
+	}{
+		{name: "square root", x: "2000000000000000000", y: "500000000000000000", want: "1414213562373095047"},
+		{name: "integer exponent", x: "950000000000000000", y: "4000000000000000000", want: "814506250000000001"},
+		{name: "base close to one", x: "999000000000000000", y: "49000000000000000000", want: "952157785983014365"},
+		{name: "fourth root close to one", x: "1001000000000000000", y: "250000000000000000", want: "1000249906304649930"},
+		{name: "cube", x: "500000000000000000", y: "3000000000000000000", want: "125000000000000000"},
+		{name: "large base", x: "123456789000000000000", y: "2345000000000000000", want: "80279123373478352882285"},
+		{name: "zero exponent", x: "123456789000000000000", y: "0", want: "1000000000000000000"},
+		{name: "zero base", x: "0", y: "2345000000000000000", want: "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pow(mustBigInt(tt.x), mustBigInt(tt.y))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+
+			// Within the relative error the pools account for
+			if tt.x != "0" && tt.y != "0" {
+				x, _ := new(big.Rat).SetFrac(mustBigInt(tt.x), One).Float64()
+				y, _ := new(big.Rat).SetFrac(mustBigInt(tt.y), One).Float64()
+				actual, _ := new(big.Rat).SetFrac(got, One).Float64()
+				assert.InEpsilon(t, math.Pow(x, y), actual, 1e-14)
+			}
+		})
+	}
+}
+
+func TestPow_OutOfBounds(t *testing.T) {
+	// e^(ln(10^12) * 10) is far over e^130
+	_, err := pow(mustBigInt("1000000000000000000000000000000"), mustBigInt("10000000000000000000"))
+	assert.ErrorIs(t, err, ErrOutOfBounds)
+
+	_, err = pow(new(big.Int).Lsh(big1, 255), One)
+	assert.ErrorIs(t, err, ErrOutOfBounds)
+}
+
+func TestPowUp(t *testing.T) {
+	x := mustBigInt("1234567890123456789")
+
+	// Exponents of 1, 2 and 4 are computed exactly
+	got, err := powUp(x, One)
+	require.NoError(t, err)
+	assert.Equal(t, x, got)
+
+	got, err = powUp(x, two)
+	require.NoError(t, err)
+	assert.Equal(t, mulUp(x, x), got)
+
+	got, err = powUp(x, four)
+	require.NoError(t, err)
+	assert.Equal(t, mulUp(mulUp(x, x), mulUp(x, x)), got)
+
+	// Other exponents add the maximum error of pow
+	y := big.NewInt(3e17)
+	raw, err := pow(x, y)
+	require.NoError(t, err)
+	got, err = powUp(x, y)
+	require.NoError(t, err)
+	assert.Equal(t, 1, got.Cmp(raw))
+}
+
+func TestFixedPointRounding(t *testing.T) {
+	a, b := big.NewInt(3), big.NewInt(5e17)
+
+	assert.Equal(t, big.NewInt(1), mulDown(a, b))
+	assert.Equal(t, big.NewInt(2), mulUp(a, b))
+	assert.Equal(t, big.NewInt(0), mulUp(big.NewInt(0), b))
+	assert.Equal(t, big.NewInt(6), divDown(a, big.NewInt(5e17)))
+	assert.Equal(t, big.NewInt(2), divUp(big.NewInt(1), mustBigInt("700000000000000000")))
+	assert.Equal(t, big.NewInt(0), divUp(big.NewInt(0), b))
+	assert.Equal(t, big.NewInt(5e17), complement(b))
+	assert.Equal(t, big.NewInt(0), complement(two))
+}
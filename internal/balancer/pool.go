@@ -0,0 +1,130 @@
+package balancer
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// ErrInvalidToken is returned when a token index is out of range or both indexes are the same
+	ErrInvalidToken = errors.New("invalid token index")
+	// ErrInsufficientLiquidity is returned when the pool cannot provide the requested amount
+	ErrInsufficientLiquidity = errors.New("insufficient liquidity")
+)
+
+var (
+	// maxInRatio and maxOutRatio limit swaps to 30% of the balances, the pools revert with BAL#304 and BAL#305 above them
+	maxInRatio  = big.NewInt(3e17)
+	maxOutRatio = big.NewInt(3e17)
+)
+
+// PoolState is the state of a Balancer V2 weighted pool needed to quote swaps against it
+type PoolState struct {
+	Pool   common.Address
+	PoolID common.Hash
+	// Tokens and Balances are in the order of the Vault's getPoolTokens
+	Tokens   []common.Address
+	Balances []*big.Int
+	// Weights are the normalized weights of the tokens, scaled by One and summing to One
+	Weights []*big.Int
+	// ScalingFactors upscale the balances to 18 decimals, 10^(18 - decimals)
+	ScalingFactors []*big.Int
+	// SwapFee is the swap fee percentage scaled by One, 3e15 is 0.3%
+	SwapFee *big.Int
+}
+
+// FeeBps returns the swap fee in basis points, rounded down
+func (p *PoolState) FeeBps() uint32 {
+	return uint32(new(big.Int).Div(p.SwapFee, big.NewInt(1e14)).Uint64())
+}
+
+// TokenIndex returns the index of token in the pool
+func (p *PoolState) TokenIndex(token common.Address) (int, bool) {
+	for i, t := range p.Tokens {
+		if t == token {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// CalcOutGivenIn calculates the output of swapping amountIn of token i for token j exactly like the pool's onSwap
+// with GIVEN_IN: the fee is taken from the input, then WeightedMath._calcOutGivenIn runs on the upscaled amounts.
+func (p *PoolState) CalcOutGivenIn(i, j int, amountIn *big.Int) (*big.Int, error) {
+	if err := p.checkTokens(i, j); err != nil {
+		return nil, err
+	}
+
+	// amountIn - amountIn.mulUp(swapFee)
+	amount := new(big.Int).Sub(amountIn, mulUp(amountIn, p.SwapFee))
+	amount.Mul(amount, p.ScalingFactors[i])
+	balanceIn := new(big.Int).Mul(p.Balances[i], p.ScalingFactors[i])
+	balanceOut := new(big.Int).Mul(p.Balances[j], p.ScalingFactors[j])
+	if amount.Cmp(mulDown(balanceIn, maxInRatio)) > 0 {
+		return nil, fmt.Errorf("%w: input over 30%% of the balance %s", ErrInsufficientLiquidity, p.Balances[i])
+	}
+
+	// balanceOut * (1 - (balanceIn / (balanceIn + amountIn))^(weightIn / weightOut))
+	base := divUp(balanceIn, new(big.Int).Add(balanceIn, amount))
+	power, err := powUp(base, divDown(p.Weights[i], p.Weights[j]))
+	if err != nil {
+		return nil, err
+	}
+	amountOut := mulDown(balanceOut, complement(power))
+
+	return amountOut.Quo(amountOut, p.ScalingFactors[j]), nil
+}
+
+// CalcInGivenOut calculates the input of token i needed to receive amountOut of token j exactly like the pool's
+// onSwap with GIVEN_OUT: WeightedMath._calcInGivenOut runs on the upscaled amounts, then the fee is added to the input.
+func (p *PoolState) CalcInGivenOut(i, j int, amountOut *big.Int) (*big.Int, error) {
+	if err := p.checkTokens(i, j); err != nil {
+		return nil, err
+	}
+
+	amount := new(big.Int).Mul(amountOut, p.ScalingFactors[j])
+	balanceIn := new(big.Int).Mul(p.Balances[i], p.ScalingFactors[i])
+	balanceOut := new(big.Int).Mul(p.Balances[j], p.ScalingFactors[j])
+	if amount.Cmp(mulDown(balanceOut, maxOutRatio)) > 0 {
+		return nil, fmt.Errorf("%w: output over 30%% of the balance %s", ErrInsufficientLiquidity, p.Balances[j])
+	}
+
+	// balanceIn * ((balanceOut / (balanceOut - amountOut))^(weightOut / weightIn) - 1)
+	base := divUp(balanceOut, new(big.Int).Sub(balanceOut, amount))
+	power, err := powUp(base, divUp(p.Weights[j], p.Weights[i]))
+	if err != nil {
+		return nil, err
+	}
+	amountIn := mulUp(balanceIn, power.Sub(power, One))
+
+	// Downscale rounding up, then amountIn.divUp(swapFee.complement())
+	amountIn.Add(amountIn, new(big.Int).Sub(p.ScalingFactors[i], big1))
+	amountIn.Quo(amountIn, p.ScalingFactors[i])
+	return divUp(amountIn, complement(p.SwapFee)), nil
+}
+
+// SpotPrice returns the marginal price of token i in token j before fees,
+// (balance_j / weight_j) / (balance_i / weight_i). It is nil when the pool is empty.
+func (p *PoolState) SpotPrice(i, j int) *big.Rat {
+	if p.checkTokens(i, j) != nil {
+		return nil
+	}
+	numerator := new(big.Int).Mul(p.Balances[j], p.Weights[i])
+	denominator := new(big.Int).Mul(p.Balances[i], p.Weights[j])
+	return new(big.Rat).SetFrac(numerator, denominator)
+}
+
+// checkTokens returns an error unless i and j are different tokens of the pool with a balance
+func (p *PoolState) checkTokens(i, j int) error {
+	n := len(p.Tokens)
+	if i == j || i < 0 || j < 0 || i >= n || j >= n {
+		return fmt.Errorf("%w: i=%d, j=%d for %d tokens", ErrInvalidToken, i, j, n)
+	}
+	if p.Balances[i].Sign() == 0 || p.Balances[j].Sign() == 0 {
+		return fmt.Errorf("%w: empty balance", ErrInsufficientLiquidity)
+	}
+	return nil
+}
@@ -2,19 +2,174 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
 )
 
 // Config holds all configuration for the application
 type Config struct {
 	Port      string
 	InfuraURL string
+
+	// MulticallEnabled batches pool calls through the Multicall3 contract at MulticallAddress
+	MulticallEnabled bool
+	MulticallAddress string
+
+	// SnapshotEnabled pins every quote to a block resolved up front so all its calls see the same state
+	SnapshotEnabled bool
+
+	// PoolCacheEnabled memoizes immutable pair tokens, optionally persisted to PoolCacheFile
+	PoolCacheEnabled bool
+	PoolCacheSize    int
+	PoolCacheFile    string
+
+	// RoutingPairs is the list of Uniswap V2 pair addresses the best-route search may use
+	RoutingPairs         []string
+	RoutingMaxHops       int
+	RoutingMaxCandidates int
+
+	// PairDiscoveryEnabled finds the direct pairs of src and dst in UniswapV2Factories when /estimate has no pool.
+	// Factories are address or address:initCodeHash entries, the Uniswap V2 factory when empty.
+	PairDiscoveryEnabled bool
+	UniswapV2Factories   []string
+
+	// PairIndexEnabled indexes every pair of PairIndexFactory into PairIndexFile from allPairs and PairCreated logs.
+	// Logs are scanned from PairIndexFromBlock in ranges of at most PairIndexChunkSize blocks,
+	// PairIndexConfirmations blocks behind the head.
+	PairIndexEnabled       bool
+	PairIndexFile          string
+	PairIndexFactory       string
+	PairIndexFromBlock     int
+	PairIndexChunkSize     int
+	PairIndexConfirmations int
+
+	// PoolStateWSURL enables live reserve tracking of PoolStatePairs from Sync events over this WebSocket endpoint
+	PoolStateWSURL string
+	PoolStatePairs []string
+	// PoolStateMaxStaleness is how many seconds live state stays usable without a new block
+	PoolStateMaxStaleness int
+	PoolStateReorgDepth   int
+
+	// RouterAddress is the UniswapV2Router02 /swap/build encodes transactions for, WETHAddress is the token it wraps ETH as.
+	// RouterFactory is the address:initCodeHash of the factory the router swaps through.
+	RouterAddress string
+	RouterFactory string
+	WETHAddress   string
+
+	// TransferTaxTokens lists known fee-on-transfer tokens as token:bps or token:buyBps:sellBps entries
+	TransferTaxTokens []string
+	// TransferTaxSimulation detects taxes of other tokens with eth_call state overrides
+	TransferTaxSimulation bool
+	// TransferTaxCacheTTLSeconds is how long simulated taxes at the latest block are cached
+	TransferTaxCacheTTLSeconds int
+
+	// DefaultFeeBps is the swap fee of pools without an override, PoolFees and FactoryFees set the fee
+	// of individual pools and of every pair of a factory as address:bps entries
+	DefaultFeeBps int
+	PoolFees      []string
+	FactoryFees   []string
+
+	// UniswapV3Enabled enables protocol=v3 estimates, UniswapV3TickWords is how many tick bitmap words
+	// (256 tick spacings each) are loaded on each side of the current price
+	UniswapV3Enabled   bool
+	UniswapV3TickWords int
+
+	// CurveEnabled enables estimates against Curve StableSwap pools
+	CurveEnabled bool
+
+	// BalancerEnabled enables estimates against Balancer V2 weighted pools
+	BalancerEnabled bool
+
+	// TokenMetadataEnabled adds the decimals, symbol and name of src and dst to estimates
+	TokenMetadataEnabled bool
+
+	// PoolDetectionEnabled detects the type of pools passed to /estimate from their contract instead of assuming Uniswap V2
+	PoolDetectionEnabled bool
+
+	// ENSEnabled lets pool, src and dst be ENS names resolved through the registry at ENSRegistryAddress,
+	// resolutions are cached for ENSCacheTTLSeconds
+	ENSEnabled         bool
+	ENSRegistryAddress string
+	ENSCacheTTLSeconds int
+	// TokenAliases lets src and dst be token symbols, as symbol:address entries
+	TokenAliases []string
+
+	// StrictAddressChecksum rejects mixed-case addresses failing their EIP-55 checksum
+	StrictAddressChecksum bool
+
+	// StreamIntervalMs is how often /estimate/stream re-evaluates the quote of untracked pools, in milliseconds
+	StreamIntervalMs int
+}
+
+// defaultTokenAliases are the symbols of common mainnet tokens
+var defaultTokenAliases = []string{
+	"WETH:0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2",
+	"USDT:0xdAC17F958D2ee523a2206206994597C13D831ec7",
+	"USDC:0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48",
+	"DAI:0x6B175474E89094C44Da98b954EedeAC495271d0F",
+	"WBTC:0x2260FAC5E5542a773Aa44fBCfeDf7C193bc2C599",
 }
 
 // Load creates a new configuration instance with environment variables
 func Load() *Config {
 	return &Config{
-		Port:      getEnv("PORT", "8080"),
-		InfuraURL: getEnv("INFURA_URL", "https://mainnet.infura.io/v3/YOUR_API_KEY"),
+		Port:                 getEnv("PORT", "8080"),
+		InfuraURL:            getEnv("INFURA_URL", "https://mainnet.infura.io/v3/YOUR_API_KEY"),
+		MulticallEnabled:     getEnvBool("MULTICALL_ENABLED", true),
+		MulticallAddress:     getEnv("MULTICALL_ADDRESS", "0xcA11bde05977b3631167028862bE2a173976CA11"),
+		SnapshotEnabled:      getEnvBool("SNAPSHOT_ENABLED", false),
+		PoolCacheEnabled:     getEnvBool("POOL_CACHE_ENABLED", true),
+		PoolCacheSize:        getEnvInt("POOL_CACHE_SIZE", 10000),
+		PoolCacheFile:        getEnv("POOL_CACHE_FILE", ""),
+		RoutingPairs:         getEnvList("ROUTING_PAIRS"),
+		RoutingMaxHops:       getEnvInt("ROUTING_MAX_HOPS", 3),
+		RoutingMaxCandidates: getEnvInt("ROUTING_MAX_CANDIDATES", 20),
+		PairDiscoveryEnabled: getEnvBool("PAIR_DISCOVERY_ENABLED", true),
+		UniswapV2Factories:   getEnvList("UNISWAP_V2_FACTORIES"),
+
+		PairIndexEnabled:       getEnvBool("PAIR_INDEX_ENABLED", false),
+		PairIndexFile:          getEnv("PAIR_INDEX_FILE", "pair_index.json"),
+		PairIndexFactory:       getEnv("PAIR_INDEX_FACTORY", "0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f"),
+		PairIndexFromBlock:     getEnvInt("PAIR_INDEX_FROM_BLOCK", 10000835),
+		PairIndexChunkSize:     getEnvInt("PAIR_INDEX_CHUNK_SIZE", 2000),
+		PairIndexConfirmations: getEnvInt("PAIR_INDEX_CONFIRMATIONS", 12),
+
+		PoolStateWSURL:        getEnv("POOL_STATE_WS_URL", ""),
+		PoolStatePairs:        getEnvList("POOL_STATE_PAIRS"),
+		PoolStateMaxStaleness: getEnvInt("POOL_STATE_MAX_STALENESS", 30),
+		PoolStateReorgDepth:   getEnvInt("POOL_STATE_REORG_DEPTH", 64),
+
+		RouterAddress: getEnv("ROUTER_ADDRESS", "0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D"),
+		RouterFactory: getEnv("ROUTER_FACTORY", "0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f:0x96e8ac4277198ff8b6f785478aa9a39f403cb768dd02cbee326c3e7da348845f"),
+		WETHAddress:   getEnv("WETH_ADDRESS", "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"),
+
+		TransferTaxTokens:          getEnvList("TRANSFER_TAX_TOKENS"),
+		TransferTaxSimulation:      getEnvBool("TRANSFER_TAX_SIMULATION", false),
+		TransferTaxCacheTTLSeconds: getEnvInt("TRANSFER_TAX_CACHE_TTL_SECONDS", 600),
+
+		DefaultFeeBps: getEnvInt("DEFAULT_FEE_BPS", 30),
+		PoolFees:      getEnvList("POOL_FEES"),
+		FactoryFees:   getEnvList("FACTORY_FEES"),
+
+		UniswapV3Enabled:   getEnvBool("UNISWAP_V3_ENABLED", true),
+		UniswapV3TickWords: getEnvInt("UNISWAP_V3_TICK_WORDS", 4),
+
+		CurveEnabled: getEnvBool("CURVE_ENABLED", true),
+
+		BalancerEnabled: getEnvBool("BALANCER_ENABLED", true),
+
+		TokenMetadataEnabled: getEnvBool("TOKEN_METADATA_ENABLED", true),
+
+		PoolDetectionEnabled: getEnvBool("POOL_DETECTION_ENABLED", true),
+
+		ENSEnabled:         getEnvBool("ENS_ENABLED", true),
+		ENSRegistryAddress: getEnv("ENS_REGISTRY_ADDRESS", "0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e"),
+		ENSCacheTTLSeconds: getEnvInt("ENS_CACHE_TTL_SECONDS", 300),
+		TokenAliases:       getEnvListOr("TOKEN_ALIASES", defaultTokenAliases),
+
+		StrictAddressChecksum: getEnvBool("STRICT_ADDRESS_CHECKSUM", false),
+
+		StreamIntervalMs: getEnvInt("STREAM_INTERVAL_MS", 1000),
 	}
 }
 
@@ -25,3 +180,38 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvInt retrieves integer environment variable with fallback to default value
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvBool retrieves boolean environment variable with fallback to default value
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvList retrieves comma-separated environment variable as a list
+func getEnvList(key string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getEnvListOr retrieves comma-separated environment variable as a list with fallback to default value
+func getEnvListOr(key string, defaultValue []string) []string {
+	if items := getEnvList(key); len(items) > 0 {
+		return items
+	}
+	return defaultValue
+}
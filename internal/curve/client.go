@@ -0,0 +1,201 @@
+package curve
+
+import (
+	"1inch_testtask/internal/uniswap_v2"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// MaxCoins is the largest number of coins in a StableSwap pool
+	MaxCoins = 8
+	// aPrecision is A_PRECISION of the pools implementing A_precise()
+	aPrecision = 100
+)
+
+// NativeETH is the placeholder address Curve pools use for ether
+var NativeETH = common.HexToAddress("0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE")
+
+// ErrUnsupportedPool is returned for pools whose coin rates are not derived from decimals
+var ErrUnsupportedPool = errors.New("unsupported Curve pool")
+
+// ICurve defines the interface for reading Curve StableSwap pools
+type ICurve interface {
+	// GetPoolState loads the pool state. The block is taken from ctx, see uniswap_v2.WithBlockNumber.
+	GetPoolState(ctx context.Context, poolAddress common.Address) (*PoolState, error)
+}
+
+// contractCall is a pool or coin method call batched through Multicall3
+type contractCall struct {
+	target common.Address
+	method string
+	args   []interface{}
+}
+
+// Client implements ICurve by batching calls through Multicall3.
+// A pool state is loaded with two eth_calls: the pool parameters and balances, then the decimals of its coins.
+// Coin rates are derived from the decimals, so pools with dynamic rates are rejected with ErrUnsupportedPool:
+// lending pools, detected by underlying_coins, and metapools, detected by base_pool or base_virtual_price.
+type Client struct {
+	multicall *uniswap_v2.Multicall3
+	poolABI   abi.ABI
+}
+
+// NewClient creates a client that batches calls through the Multicall3 contract at multicallAddress
+func NewClient(backend bind.ContractCaller, multicallAddress common.Address) (*Client, error) {
+	poolABI, err := abi.JSON(strings.NewReader(StableSwapABI))
+	if err != nil {
+		return nil, err
+	}
+
+	multicall, err := uniswap_v2.NewMulticall3(backend, multicallAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		multicall: multicall,
+		poolABI:   poolABI,
+	}, nil
+}
+
+// GetPoolState loads the parameters, coins and balances of the pool
+func (c *Client) GetPoolState(ctx context.Context, poolAddress common.Address) (*PoolState, error) {
+	calls := []contractCall{
+		{target: poolAddress, method: "A"},
+		{target: poolAddress, method: "A_precise"},
+		{target: poolAddress, method: "fee"},
+	}
+	// The number of coins is not exposed, pools revert for indexes past their last coin
+	for i := 0; i < MaxCoins; i++ {
+		calls = append(calls, contractCall{target: poolAddress, method: "coins", args: []interface{}{big.NewInt(int64(i))}})
+	}
+	for i := 0; i < MaxCoins; i++ {
+		calls = append(calls, contractCall{target: poolAddress, method: "balances", args: []interface{}{big.NewInt(int64(i))}})
+	}
+	calls = append(calls,
+		contractCall{target: poolAddress, method: "underlying_coins", args: []interface{}{big.NewInt(0)}},
+		contractCall{target: poolAddress, method: "base_pool"},
+		contractCall{target: poolAddress, method: "base_virtual_price"},
+	)
+
+	out, err := c.aggregate(ctx, calls)
+	if err != nil {
+		return nil, err
+	}
+	if out[0] == nil || out[2] == nil {
+		return nil, fmt.Errorf("%s is not a StableSwap pool", poolAddress.Hex())
+	}
+	rateProbes := out[3+2*MaxCoins:]
+	if rateProbes[0] != nil {
+		return nil, fmt.Errorf("%w: %s is a lending pool, its coins accrue interest", ErrUnsupportedPool, poolAddress.Hex())
+	}
+	if rateProbes[1] != nil || rateProbes[2] != nil {
+		return nil, fmt.Errorf("%w: %s is a metapool, its base pool LP token is priced by the base pool", ErrUnsupportedPool, poolAddress.Hex())
+	}
+
+	state := &PoolState{
+		Pool:       poolAddress,
+		A:          out[0][0].(*big.Int),
+		APrecision: big.NewInt(1),
+		Fee:        out[2][0].(*big.Int),
+		Legacy:     true,
+	}
+	if out[1] != nil {
+		state.A = out[1][0].(*big.Int)
+		state.APrecision = big.NewInt(aPrecision)
+		state.Legacy = false
+	}
+
+	for i := 0; i < MaxCoins && out[3+i] != nil; i++ {
+		balance := out[3+MaxCoins+i]
+		if balance == nil {
+			return nil, fmt.Errorf("balances(%d) of %s: execution reverted", i, poolAddress.Hex())
+		}
+		state.Coins = append(state.Coins, out[3+i][0].(common.Address))
+		state.Balances = append(state.Balances, balance[0].(*big.Int))
+	}
+	if len(state.Coins) < 2 {
+		return nil, fmt.Errorf("%s has %d coins", poolAddress.Hex(), len(state.Coins))
+	}
+
+	if state.Rates, err = c.getRates(ctx, state.Coins); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// getRates returns the rates of plain pool coins, 10^(36 - decimals)
+func (c *Client) getRates(ctx context.Context, coins []common.Address) ([]*big.Int, error) {
+	var calls []contractCall
+	for _, coin := range coins {
+		if coin != NativeETH {
+			calls = append(calls, contractCall{target: coin, method: "decimals"})
+		}
+	}
+
+	out, err := c.aggregate(ctx, calls)
+	if err != nil {
+		return nil, err
+	}
+
+	rates := make([]*big.Int, len(coins))
+	for i, coin := range coins {
+		decimals := uint8(18)
+		if coin != NativeETH {
+			if out[0] == nil {
+				return nil, fmt.Errorf("decimals of %s: execution reverted", coin.Hex())
+			}
+			decimals = out[0][0].(uint8)
+			out = out[1:]
+		}
+		if decimals > 36 {
+			return nil, fmt.Errorf("unsupported decimals %d of %s", decimals, coin.Hex())
+		}
+		rates[i] = new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(36-decimals)), nil)
+	}
+
+	return rates, nil
+}
+
+// aggregate executes the calls in a single Multicall3.aggregate3 call and returns the unpacked outputs,
+// nil for calls that reverted
+func (c *Client) aggregate(ctx context.Context, contractCalls []contractCall) ([][]interface{}, error) {
+	calls := make([]uniswap_v2.Multicall3Call, len(contractCalls))
+	for i, call := range contractCalls {
+		callData, err := c.poolABI.Pack(call.method, call.args...)
+		if err != nil {
+			return nil, err
+		}
+		calls[i] = uniswap_v2.Multicall3Call{Target: call.target, AllowFailure: true, CallData: callData}
+	}
+
+	results, err := c.multicall.Aggregate3(ctx, calls)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([][]interface{}, len(results))
+	for i, result := range results {
+		if !result.Success {
+			continue
+		}
+
+		values, err := c.poolABI.Unpack(contractCalls[i].method, result.ReturnData)
+		if err != nil {
+			// An output that does not decode means the method is missing
+			continue
+		}
+		out[i] = values
+	}
+
+	return out, nil
+}
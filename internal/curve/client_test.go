@@ -0,0 +1,239 @@
+package curve
+
+import (
+	"1inch_testtask/internal/uniswap_v2"
+	"context"
+	"encoding/json"
+	"flag"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordRPC is an archive node endpoint, when set TestPoolState_GetDyRecorded records its fixtures from mainnet first
+var recordRPC = flag.String("record-rpc", "", "archive node JSON-RPC URL to record "+recordedFile+" from")
+
+const (
+	// recordBlock is the mainnet block the fixtures are recorded at
+	recordBlock = 18500000
+	// recordTimeout bounds the recording
+	recordTimeout = time.Minute
+)
+
+// recordPools are the recorded pools: 3pool, a legacy pool, and FRAX/USDC, a two-coin pool with A_precise
+var recordPools = []struct {
+	name    string
+	address common.Address
+}{
+	{name: "3pool", address: threePoolAddress},
+	{name: "FRAX/USDC", address: fraxUsdcAddress},
+}
+
+// recordGetDy loads the recorded pools with the client at recordBlock and calls get_dy of the pool contracts for
+// 1, 1000 and 1M whole coins of every direction, then writes the states and outputs to recordedFile
+func recordGetDy(t *testing.T, url string) {
+	ctx, cancel := context.WithTimeout(context.Background(), recordTimeout)
+	defer cancel()
+
+	backend, err := ethclient.DialContext(ctx, url)
+	require.NoError(t, err)
+	defer backend.Close()
+	client, err := NewClient(backend, uniswap_v2.Multicall3Address)
+	require.NoError(t, err)
+	poolABI, err := abi.JSON(strings.NewReader(StableSwapABI))
+	require.NoError(t, err)
+
+	block := big.NewInt(recordBlock)
+	ctx = uniswap_v2.WithBlockNumber(ctx, block)
+	var pools []recordedPool
+	for _, pool := range recordPools {
+		state, err := client.GetPoolState(ctx, pool.address)
+		require.NoError(t, err, pool.name)
+
+		recorded := recordedPool{Name: pool.name, Block: recordBlock, State: state}
+		contract := bind.NewBoundContract(pool.address, poolABI, backend, nil, nil)
+		for i := range state.Coins {
+			// One whole coin i is 10^36 / rate
+			unit := new(big.Int).Div(new(big.Int).Exp(big.NewInt(10), big.NewInt(36), nil), state.Rates[i])
+			for j := range state.Coins {
+				if i == j {
+					continue
+				}
+				for _, coins := range []int64{1, 1000, 1000000} {
+					dx := new(big.Int).Mul(unit, big.NewInt(coins))
+					var out []interface{}
+					err := contract.Call(&bind.CallOpts{Context: ctx, BlockNumber: block}, &out, "get_dy", big.NewInt(int64(i)), big.NewInt(int64(j)), dx)
+					require.NoError(t, err, "%s get_dy(%d, %d, %s)", pool.name, i, j, dx)
+					recorded.Swaps = append(recorded.Swaps, recordedSwap{I: i, J: j, Dx: dx, Dy: out[0].(*big.Int)})
+				}
+			}
+		}
+		pools = append(pools, recorded)
+	}
+
+	data, err := json.MarshalIndent(pools, "", "  ")
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(recordedFile), 0o755))
+	require.NoError(t, os.WriteFile(recordedFile, append(data, '\n'), 0o644))
+}
+
+// fakeChain is a bind.ContractCaller that executes Multicall3.aggregate3 against an in-memory pool and its coins
+type fakeChain struct {
+	t       *testing.T
+	state   *PoolState
+	poolABI abi.ABI
+	callABI abi.ABI
+	// decimals are the decimals of the coins, coins without them revert
+	decimals map[common.Address]uint8
+	// dynamic are the lending pool and metapool methods the pool implements
+	dynamic []string
+	calls   int
+}
+
+func newFakeChain(t *testing.T, state *PoolState, decimals map[common.Address]uint8) *fakeChain {
+	poolABI, err := abi.JSON(strings.NewReader(StableSwapABI))
+	require.NoError(t, err)
+	callABI, err := abi.JSON(strings.NewReader(uniswap_v2.Multicall3ABI))
+	require.NoError(t, err)
+
+	return &fakeChain{t: t, state: state, poolABI: poolABI, callABI: callABI, decimals: decimals}
+}
+
+func (f *fakeChain) CodeAt(context.Context, common.Address, *big.Int) ([]byte, error) {
+	return []byte{0x1}, nil
+}
+
+func (f *fakeChain) CallContract(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	f.calls++
+	method, err := f.callABI.MethodById(msg.Data[:4])
+	require.NoError(f.t, err)
+	args, err := method.Inputs.Unpack(msg.Data[4:])
+	require.NoError(f.t, err)
+	calls := *abi.ConvertType(args[0], new([]uniswap_v2.Multicall3Call)).(*[]uniswap_v2.Multicall3Call)
+
+	results := make([]uniswap_v2.Multicall3Result, len(calls))
+	for i, call := range calls {
+		poolMethod, err := f.poolABI.MethodById(call.CallData[:4])
+		require.NoError(f.t, err)
+		inputs, err := poolMethod.Inputs.Unpack(call.CallData[4:])
+		require.NoError(f.t, err)
+
+		var returnData []byte
+		if call.Target != f.state.Pool {
+			decimals, ok := f.decimals[call.Target]
+			if !ok || poolMethod.Name != "decimals" {
+				continue
+			}
+			returnData, err = poolMethod.Outputs.Pack(decimals)
+			require.NoError(f.t, err)
+			results[i] = uniswap_v2.Multicall3Result{Success: true, ReturnData: returnData}
+			continue
+		}
+
+		switch poolMethod.Name {
+		case "A":
+			returnData, err = poolMethod.Outputs.Pack(new(big.Int).Div(f.state.A, f.state.APrecision))
+		case "A_precise":
+			if f.state.Legacy {
+				continue
+			}
+			returnData, err = poolMethod.Outputs.Pack(f.state.A)
+		case "fee":
+			returnData, err = poolMethod.Outputs.Pack(f.state.Fee)
+		case "coins", "balances":
+			index := int(inputs[0].(*big.Int).Int64())
+			if index >= len(f.state.Coins) {
+				continue
+			}
+			if poolMethod.Name == "coins" {
+				returnData, err = poolMethod.Outputs.Pack(f.state.Coins[index])
+			} else {
+				returnData, err = poolMethod.Outputs.Pack(f.state.Balances[index])
+			}
+		case "underlying_coins", "base_pool", "base_virtual_price":
+			if !slices.Contains(f.dynamic, poolMethod.Name) {
+				continue
+			}
+			if poolMethod.Name == "base_virtual_price" {
+				returnData, err = poolMethod.Outputs.Pack(rate18)
+			} else {
+				returnData, err = poolMethod.Outputs.Pack(threePoolAddress)
+			}
+		default:
+			continue
+		}
+		require.NoError(f.t, err)
+		results[i] = uniswap_v2.Multicall3Result{Success: true, ReturnData: returnData}
+	}
+
+	return method.Outputs.Pack(results)
+}
+
+func TestClient_GetPoolState(t *testing.T) {
+	decimals := map[common.Address]uint8{dai: 18, usdc: 6, usdt: 6, frax: 18, steth: 18}
+
+	tests := []struct {
+		name string
+		pool *PoolState
+	}{
+		{name: "legacy pool with three coins", pool: threePool()},
+		{name: "pool with A_precise", pool: fraxUsdc()},
+		{name: "pool with native ether", pool: ethSteth()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newFakeChain(t, tt.pool, decimals)
+			client, err := NewClient(backend, uniswap_v2.Multicall3Address)
+			require.NoError(t, err)
+
+			state, err := client.GetPoolState(context.Background(), tt.pool.Pool)
+			require.NoError(t, err)
+			assert.Equal(t, 2, backend.calls, "pool state and coin decimals should take one eth_call each")
+			assert.Equal(t, tt.pool, state)
+		})
+	}
+
+	t.Run("coin without decimals", func(t *testing.T) {
+		backend := newFakeChain(t, threePool(), map[common.Address]uint8{dai: 18, usdc: 6})
+		client, err := NewClient(backend, uniswap_v2.Multicall3Address)
+		require.NoError(t, err)
+
+		_, err = client.GetPoolState(context.Background(), threePoolAddress)
+		assert.ErrorContains(t, err, "decimals of "+usdt.Hex())
+	})
+
+	for _, dynamic := range [][]string{{"underlying_coins"}, {"base_pool", "base_virtual_price"}, {"base_virtual_price"}} {
+		t.Run("pool with dynamic rates implementing "+strings.Join(dynamic, ", "), func(t *testing.T) {
+			backend := newFakeChain(t, fraxUsdc(), decimals)
+			backend.dynamic = dynamic
+			client, err := NewClient(backend, uniswap_v2.Multicall3Address)
+			require.NoError(t, err)
+
+			_, err = client.GetPoolState(context.Background(), fraxUsdcAddress)
+			assert.ErrorIs(t, err, ErrUnsupportedPool)
+		})
+	}
+
+	t.Run("not a pool", func(t *testing.T) {
+		pool := threePool()
+		backend := newFakeChain(t, pool, decimals)
+		client, err := NewClient(backend, uniswap_v2.Multicall3Address)
+		require.NoError(t, err)
+
+		_, err = client.GetPoolState(context.Background(), usdt)
+		assert.ErrorContains(t, err, "is not a StableSwap pool")
+	})
+}
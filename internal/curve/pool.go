@@ -0,0 +1,317 @@
+package curve
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// FeeDenominator is the denominator of Curve fees, a fee of 4000000 is 0.04%
+	FeeDenominator = 10000000000
+	// maxIterations bounds the Newton iterations like the pool contracts do
+	maxIterations = 255
+)
+
+var (
+	// Precision is the fixed-point precision balances are normalized to
+	Precision = big.NewInt(1000000000000000000)
+
+	feeDenominator = big.NewInt(FeeDenominator)
+)
+
+var (
+	// ErrInvalidCoin is returned when a coin index is out of range or both indexes are the same
+	ErrInvalidCoin = errors.New("invalid coin index")
+	// ErrInsufficientLiquidity is returned when the pool cannot provide the requested amount
+	ErrInsufficientLiquidity = errors.New("insufficient liquidity")
+)
+
+// PoolState is the state of a Curve StableSwap pool needed to quote swaps against it
+type PoolState struct {
+	Pool     common.Address
+	Coins    []common.Address
+	Balances []*big.Int
+	// Rates scale the balances to 18 decimals with Precision, 10^(36 - decimals) for plain pools
+	Rates []*big.Int
+	// A is the amplification coefficient multiplied by APrecision
+	A *big.Int
+	// APrecision is 1 for pools predating A_precise() and 100 for later ones
+	APrecision *big.Int
+	// Fee is the swap fee over FeeDenominator
+	Fee *big.Int
+	// Legacy pools such as 3pool take the fee after converting the output to coin units,
+	// later pools take it from the normalized output
+	Legacy bool
+}
+
+// FeeBps returns the swap fee in basis points, rounded down
+func (p *PoolState) FeeBps() uint32 {
+	return uint32(new(big.Int).Div(p.Fee, big.NewInt(FeeDenominator/10000)).Uint64())
+}
+
+// CoinIndex returns the index of coin in the pool
+func (p *PoolState) CoinIndex(coin common.Address) (int, bool) {
+	for i, c := range p.Coins {
+		if c == coin {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// GetDy calculates the output of swapping dx of coin i for coin j exactly like the pool's get_dy.
+// A dx too small to move the invariant yields zero where the contract would revert.
+func (p *PoolState) GetDy(i, j int, dx *big.Int) (*big.Int, error) {
+	if err := p.checkCoins(i, j); err != nil {
+		return nil, err
+	}
+
+	xp, err := p.xp()
+	if err != nil {
+		return nil, err
+	}
+	d := p.getD(xp)
+
+	return p.getDy(i, j, dx, xp, d), nil
+}
+
+// GetDx calculates the smallest input of coin i for which GetDy returns at least dy of coin j
+func (p *PoolState) GetDx(i, j int, dy *big.Int) (*big.Int, error) {
+	if err := p.checkCoins(i, j); err != nil {
+		return nil, err
+	}
+	if dy.Sign() <= 0 {
+		return big.NewInt(0), nil
+	}
+	if dy.Cmp(p.Balances[j]) >= 0 {
+		return nil, fmt.Errorf("%w: requested %s, balance %s", ErrInsufficientLiquidity, dy, p.Balances[j])
+	}
+
+	xp, err := p.xp()
+	if err != nil {
+		return nil, err
+	}
+	d := p.getD(xp)
+
+	// Estimate dx from the normalized output grossed up by the fee, as the pools' get_dx does
+	// y = xp[j] - (dy * rates[j] / PRECISION + 1) * FEE_DENOMINATOR / (FEE_DENOMINATOR - fee)
+	dyXP := new(big.Int).Mul(dy, p.Rates[j])
+	dyXP.Div(dyXP, Precision)
+	dyXP.Add(dyXP, big.NewInt(1))
+	dyXP.Mul(dyXP, feeDenominator)
+	dyXP.Div(dyXP, new(big.Int).Sub(feeDenominator, p.Fee))
+	if dyXP.Cmp(xp[j]) >= 0 {
+		return nil, fmt.Errorf("%w: requested %s, balance %s", ErrInsufficientLiquidity, dy, p.Balances[j])
+	}
+	x := p.getY(j, i, new(big.Int).Sub(xp[j], dyXP), xp, d)
+	hi := new(big.Int).Sub(x, xp[i])
+	hi.Mul(hi, Precision)
+	hi.Div(hi, p.Rates[i])
+	hi.Add(hi, big.NewInt(1))
+
+	// The estimate is off by rounding, search for the exact amount
+	for iteration := 0; p.getDy(i, j, hi, xp, d).Cmp(dy) < 0; iteration++ {
+		if iteration == maxIterations {
+			return nil, ErrInsufficientLiquidity
+		}
+		hi.Lsh(hi, 1)
+	}
+	lo := big.NewInt(0)
+	for new(big.Int).Sub(hi, lo).Cmp(big.NewInt(1)) > 0 {
+		mid := new(big.Int).Add(lo, hi)
+		mid.Rsh(mid, 1)
+		if p.getDy(i, j, mid, xp, d).Cmp(dy) >= 0 {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+
+	return hi, nil
+}
+
+// SpotPrice returns the marginal price of coin i in coin j before fees, derived from the invariant:
+// dy/dx = (Ann * x_i * x_j + D_P * x_j) / (Ann * x_i * x_j + D_P * x_i) in normalized units,
+// where D_P = D^(n+1) / (n^n * prod(x)). It is nil when the pool is empty.
+func (p *PoolState) SpotPrice(i, j int) *big.Rat {
+	if p.checkCoins(i, j) != nil {
+		return nil
+	}
+	xp, err := p.xp()
+	if err != nil {
+		return nil
+	}
+	d := new(big.Rat).SetInt(p.getD(xp))
+	n := int64(len(xp))
+
+	// dP = D^(n+1) / (n^n * prod(x))
+	dP := new(big.Rat).Set(d)
+	for _, x := range xp {
+		dP.Mul(dP, d)
+		dP.Quo(dP, new(big.Rat).SetInt(new(big.Int).Mul(x, big.NewInt(n))))
+	}
+
+	// The amplification in the invariant is Ann / A_PRECISION
+	ann := new(big.Rat).SetFrac(new(big.Int).Mul(p.A, big.NewInt(n)), p.APrecision)
+	xi, xj := new(big.Rat).SetInt(xp[i]), new(big.Rat).SetInt(xp[j])
+	annXiXj := new(big.Rat).Mul(ann, xi)
+	annXiXj.Mul(annXiXj, xj)
+
+	numerator := new(big.Rat).Add(annXiXj, new(big.Rat).Mul(dP, xj))
+	denominator := new(big.Rat).Add(annXiXj, new(big.Rat).Mul(dP, xi))
+	price := new(big.Rat).Quo(numerator, denominator)
+
+	// Back from normalized units to coin units
+	return price.Mul(price, new(big.Rat).SetFrac(p.Rates[i], p.Rates[j]))
+}
+
+// getDy is get_dy with the balances already normalized and the invariant computed
+func (p *PoolState) getDy(i, j int, dx *big.Int, xp []*big.Int, d *big.Int) *big.Int {
+	// x = xp[i] + dx * rates[i] / PRECISION
+	x := new(big.Int).Mul(dx, p.Rates[i])
+	x.Div(x, Precision)
+	x.Add(x, xp[i])
+	y := p.getY(i, j, x, xp, d)
+
+	// dy = xp[j] - y - 1
+	dy := new(big.Int).Sub(xp[j], y)
+	dy.Sub(dy, big.NewInt(1))
+	if dy.Sign() <= 0 {
+		return big.NewInt(0)
+	}
+
+	if p.Legacy {
+		// dy = dy * PRECISION / rates[j]; return dy - fee * dy / FEE_DENOMINATOR
+		dy.Mul(dy, Precision)
+		dy.Div(dy, p.Rates[j])
+		return dy.Sub(dy, p.feeOf(dy))
+	}
+
+	// return (dy - fee * dy / FEE_DENOMINATOR) * PRECISION / rates[j]
+	dy.Sub(dy, p.feeOf(dy))
+	dy.Mul(dy, Precision)
+	return dy.Div(dy, p.Rates[j])
+}
+
+// feeOf returns fee * amount / FEE_DENOMINATOR
+func (p *PoolState) feeOf(amount *big.Int) *big.Int {
+	fee := new(big.Int).Mul(p.Fee, amount)
+	return fee.Div(fee, feeDenominator)
+}
+
+// xp returns the balances normalized to 18 decimals
+func (p *PoolState) xp() ([]*big.Int, error) {
+	xp := make([]*big.Int, len(p.Balances))
+	for i, balance := range p.Balances {
+		xp[i] = new(big.Int).Mul(balance, p.Rates[i])
+		xp[i].Div(xp[i], Precision)
+		if xp[i].Sign() == 0 {
+			// The contracts divide by every balance
+			return nil, fmt.Errorf("%w: empty balance of coin %d", ErrInsufficientLiquidity, i)
+		}
+	}
+	return xp, nil
+}
+
+// getD solves the StableSwap invariant for D with Newton's method (get_D)
+func (p *PoolState) getD(xp []*big.Int) *big.Int {
+	n := big.NewInt(int64(len(xp)))
+	s := new(big.Int)
+	for _, x := range xp {
+		s.Add(s, x)
+	}
+
+	d := new(big.Int).Set(s)
+	ann := new(big.Int).Mul(p.A, n)
+	for iteration := 0; iteration < maxIterations; iteration++ {
+		// D_P = D^(n+1) / (n^n * prod(x)), rounded down at every step
+		dP := new(big.Int).Set(d)
+		for _, x := range xp {
+			dP.Mul(dP, d)
+			dP.Div(dP, new(big.Int).Mul(x, n))
+		}
+		prev := d
+
+		// D = (Ann * S / A_PRECISION + D_P * N) * D / ((Ann - A_PRECISION) * D / A_PRECISION + (N + 1) * D_P)
+		numerator := new(big.Int).Mul(ann, s)
+		numerator.Div(numerator, p.APrecision)
+		numerator.Add(numerator, new(big.Int).Mul(dP, n))
+		numerator.Mul(numerator, d)
+		denominator := new(big.Int).Sub(ann, p.APrecision)
+		denominator.Mul(denominator, d)
+		denominator.Div(denominator, p.APrecision)
+		denominator.Add(denominator, new(big.Int).Mul(new(big.Int).Add(n, big.NewInt(1)), dP))
+		d = numerator.Div(numerator, denominator)
+
+		if converged(d, prev) {
+			break
+		}
+	}
+	return d
+}
+
+// getY solves the invariant for the normalized balance of coin j when coin i has the normalized balance x (get_y)
+func (p *PoolState) getY(i, j int, x *big.Int, xp []*big.Int, d *big.Int) *big.Int {
+	n := big.NewInt(int64(len(xp)))
+	ann := new(big.Int).Mul(p.A, n)
+
+	c := new(big.Int).Set(d)
+	s := new(big.Int)
+	for k := range xp {
+		var xk *big.Int
+		switch k {
+		case i:
+			xk = x
+		case j:
+			continue
+		default:
+			xk = xp[k]
+		}
+		s.Add(s, xk)
+		c.Mul(c, d)
+		c.Div(c, new(big.Int).Mul(xk, n))
+	}
+	// c = c * D * A_PRECISION / (Ann * N)
+	c.Mul(c, d)
+	c.Mul(c, p.APrecision)
+	c.Div(c, new(big.Int).Mul(ann, n))
+	// b = S + D * A_PRECISION / Ann
+	b := new(big.Int).Mul(d, p.APrecision)
+	b.Div(b, ann)
+	b.Add(b, s)
+
+	y := new(big.Int).Set(d)
+	for iteration := 0; iteration < maxIterations; iteration++ {
+		prev := y
+		// y = (y^2 + c) / (2 * y + b - D)
+		numerator := new(big.Int).Mul(y, y)
+		numerator.Add(numerator, c)
+		denominator := new(big.Int).Lsh(y, 1)
+		denominator.Add(denominator, b)
+		denominator.Sub(denominator, d)
+		y = numerator.Div(numerator, denominator)
+
+		if converged(y, prev) {
+			break
+		}
+	}
+	return y
+}
+
+// converged reports whether two Newton iterations are within 1 of each other
+func converged(value, prev *big.Int) bool {
+	diff := new(big.Int).Sub(value, prev)
+	return diff.CmpAbs(big.NewInt(1)) <= 0
+}
+
+// checkCoins returns an error unless i and j are different coins of the pool
+func (p *PoolState) checkCoins(i, j int) error {
+	n := len(p.Coins)
+	if i == j || i < 0 || j < 0 || i >= n || j >= n {
+		return fmt.Errorf("%w: i=%d, j=%d for %d coins", ErrInvalidCoin, i, j, n)
+	}
+	return nil
+}
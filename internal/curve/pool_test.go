@@ -0,0 +1,239 @@
+package curve
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustBigInt(s string) *big.Int {
+	value, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic("invalid big int string: " + s)
+	}
+	return value
+}
+
+var (
+	dai   = common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+	usdc  = common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	usdt  = common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+	frax  = common.HexToAddress("0x853d955aCEF822Db058eb8587A1D2bE5e7Fef1D6")
+	eth   = common.HexToAddress("0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE")
+	steth = common.HexToAddress("0xae7ab96520DE3A18E5e111B5EaAb095312D7fE84")
+
+	threePoolAddress = common.HexToAddress("0xbEbc44782C7dB0a1A60Cb6fe97d0b483032FF1C7")
+	fraxUsdcAddress  = common.HexToAddress("0xDcEF968d416a41Cdac0ED8702fAC8128A64241A2")
+	ethStethAddress  = common.HexToAddress("0xDC24316b9AE028F1497c275EB9192a3Ea0f67022")
+
+	rate18 = mustBigInt("1000000000000000000")
+	rate6  = mustBigInt("1000000000000000000000000000000")
+)
+
+// Fixture states shaped like 3pool (a legacy pool without A_precise), FRAX/USDC and ETH/stETH (pools with A_PRECISION).
+// Expected outputs were computed by evaluating the get_dy, get_y and get_D code of the pool contracts on these states.
+// Mainnet states with the outputs of the pool contracts themselves are checked by TestPoolState_GetDyRecorded.
+
+func threePool() *PoolState {
+	return &PoolState{
+		Pool:       threePoolAddress,
+		Coins:      []common.Address{dai, usdc, usdt},
+		Balances:   []*big.Int{mustBigInt("165432109876543210987654321"), mustBigInt("171234567890123"), mustBigInt("62345678901234")},
+		Rates:      []*big.Int{rate18, rate6, rate6},
+		A:          big.NewInt(2000),
+		APrecision: big.NewInt(1),
+		Fee:        big.NewInt(1000000),
+		Legacy:     true,
+	}
+}
+
+func fraxUsdc() *PoolState {
+	return &PoolState{
+		Pool:       fraxUsdcAddress,
+		Coins:      []common.Address{frax, usdc},
+		Balances:   []*big.Int{mustBigInt("412345678901234567890123456"), mustBigInt("387654321098765")},
+		Rates:      []*big.Int{rate18, rate6},
+		A:          big.NewInt(150000),
+		APrecision: big.NewInt(100),
+		Fee:        big.NewInt(1000000),
+	}
+}
+
+func ethSteth() *PoolState {
+	return &PoolState{
+		Pool:       ethStethAddress,
+		Coins:      []common.Address{eth, steth},
+		Balances:   []*big.Int{mustBigInt("41234567890123456789012"), mustBigInt("58765432109876543210987")},
+		Rates:      []*big.Int{rate18, rate18},
+		A:          big.NewInt(5000),
+		APrecision: big.NewInt(100),
+		Fee:        big.NewInt(4000000),
+	}
+}
+
+func TestPoolState_GetDy(t *testing.T) {
+	tests := []struct {
+		name string
+		pool *PoolState
+		i, j int
+		dx   string
+		want string
+	}{
+		{name: "3pool 1000 DAI to USDC", pool: threePool(), i: 0, j: 1, dx: "1000000000000000000000", want: "999918129"},
+		{name: "3pool 1000 USDC to USDT", pool: threePool(), i: 1, j: 2, dx: "1000000000", want: "998997908"},
+		{name: "3pool 1M USDT to DAI", pool: threePool(), i: 2, j: 0, dx: "1000000000000", want: "1000767572959372300139919"},
+		{name: "3pool 50M USDC to DAI", pool: threePool(), i: 1, j: 0, dx: "50000000000000", want: "49985331759577029275069156"},
+		{name: "3pool 1 wei of DAI to USDT", pool: threePool(), i: 0, j: 2, dx: "1", want: "0"},
+		{name: "FRAX/USDC 250k FRAX to USDC", pool: fraxUsdc(), i: 0, j: 1, dx: "250000000000000000000000", want: "249964595753"},
+		{name: "FRAX/USDC 12345 USDC to FRAX", pool: fraxUsdc(), i: 1, j: 0, dx: "12345678901", want: "12344952719119270883382"},
+		{name: "ETH/stETH 1 ETH to stETH", pool: ethSteth(), i: 0, j: 1, dx: "1000000000000000000", want: "1006925185538923929"},
+		{name: "ETH/stETH 10k stETH to ETH", pool: ethSteth(), i: 1, j: 0, dx: "10000000000000000000000", want: "9868655393321434870067"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.pool.GetDy(tt.i, tt.j, mustBigInt(tt.dx))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+// recordedFile holds mainnet pool states with the outputs of get_dy at their block, see recordGetDy
+const recordedFile = "testdata/get_dy.json"
+
+// recordedPool is the state of a mainnet pool at a block and outputs of its get_dy at that block
+type recordedPool struct {
+	Name  string
+	Block uint64
+	State *PoolState
+	Swaps []recordedSwap
+}
+
+// recordedSwap is a get_dy call and its output
+type recordedSwap struct {
+	I, J   int
+	Dx, Dy *big.Int
+}
+
+func TestPoolState_GetDyRecorded(t *testing.T) {
+	if *recordRPC != "" {
+		recordGetDy(t, *recordRPC)
+	}
+
+	data, err := os.ReadFile(recordedFile)
+	require.NoError(t, err, "record the fixtures with -record-rpc=<archive node URL>")
+	var pools []recordedPool
+	require.NoError(t, json.Unmarshal(data, &pools))
+	require.NotEmpty(t, pools)
+
+	for _, pool := range pools {
+		for _, swap := range pool.Swaps {
+			t.Run(fmt.Sprintf("%s at %d %d to %d of %s", pool.Name, pool.Block, swap.I, swap.J, swap.Dx), func(t *testing.T) {
+				got, err := pool.State.GetDy(swap.I, swap.J, swap.Dx)
+				require.NoError(t, err)
+				assert.Equal(t, swap.Dy.String(), got.String())
+			})
+		}
+	}
+}
+
+func TestPoolState_GetDyInvalidCoins(t *testing.T) {
+	pool := threePool()
+
+	for _, coins := range [][2]int{{0, 0}, {-1, 1}, {0, 3}} {
+		_, err := pool.GetDy(coins[0], coins[1], big.NewInt(1000))
+		assert.ErrorIs(t, err, ErrInvalidCoin, "i=%d j=%d", coins[0], coins[1])
+	}
+
+	pool.Balances[2] = big.NewInt(0)
+	_, err := pool.GetDy(0, 1, big.NewInt(1000))
+	assert.ErrorIs(t, err, ErrInsufficientLiquidity)
+}
+
+func TestPoolState_GetDx(t *testing.T) {
+	tests := []struct {
+		name string
+		pool *PoolState
+		i, j int
+		dy   string
+	}{
+		{name: "3pool DAI for 1000 USDC", pool: threePool(), i: 0, j: 1, dy: "1000000000"},
+		{name: "3pool USDT for 1M DAI", pool: threePool(), i: 2, j: 0, dy: "1000000000000000000000000"},
+		{name: "3pool USDC for 1 wei of DAI", pool: threePool(), i: 1, j: 0, dy: "1"},
+		{name: "FRAX/USDC USDC for 250k FRAX", pool: fraxUsdc(), i: 1, j: 0, dy: "250000000000000000000000"},
+		{name: "ETH/stETH stETH for 20k ETH", pool: ethSteth(), i: 1, j: 0, dy: "20000000000000000000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dy := mustBigInt(tt.dy)
+			dx, err := tt.pool.GetDx(tt.i, tt.j, dy)
+			require.NoError(t, err)
+
+			// dx is the smallest input yielding dy
+			got, err := tt.pool.GetDy(tt.i, tt.j, dx)
+			require.NoError(t, err)
+			assert.GreaterOrEqual(t, got.Cmp(dy), 0, "get_dy(%s) = %s", dx, got)
+
+			got, err = tt.pool.GetDy(tt.i, tt.j, new(big.Int).Sub(dx, big.NewInt(1)))
+			require.NoError(t, err)
+			assert.Less(t, got.Cmp(dy), 0, "get_dy(%s - 1) = %s", dx, got)
+		})
+	}
+
+	t.Run("zero amount", func(t *testing.T) {
+		dx, err := threePool().GetDx(0, 1, big.NewInt(0))
+		require.NoError(t, err)
+		assert.Zero(t, dx.Sign())
+	})
+
+	t.Run("more than the balance", func(t *testing.T) {
+		_, err := threePool().GetDx(0, 2, mustBigInt("62345678901234"))
+		assert.ErrorIs(t, err, ErrInsufficientLiquidity)
+	})
+}
+
+func TestPoolState_SpotPrice(t *testing.T) {
+	tests := []struct {
+		name string
+		pool *PoolState
+		i, j int
+		dx   string
+	}{
+		{name: "3pool DAI to USDC", pool: threePool(), i: 0, j: 1, dx: "1000000000000000000"},
+		{name: "3pool USDT to DAI", pool: threePool(), i: 2, j: 0, dx: "1000000"},
+		{name: "FRAX/USDC USDC to FRAX", pool: fraxUsdc(), i: 1, j: 0, dx: "1000000"},
+		{name: "ETH/stETH stETH to ETH", pool: ethSteth(), i: 1, j: 0, dx: "1000000000000000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spot := tt.pool.SpotPrice(tt.i, tt.j)
+			require.NotNil(t, spot)
+
+			// A small swap executes at the spot price less the fee
+			dx := mustBigInt(tt.dx)
+			dy, err := tt.pool.GetDy(tt.i, tt.j, dx)
+			require.NoError(t, err)
+			execution, _ := new(big.Rat).SetFrac(dy, dx).Float64()
+
+			fee := float64(tt.pool.Fee.Int64()) / FeeDenominator
+			expected, _ := spot.Float64()
+			assert.InEpsilon(t, expected*(1-fee), execution, 1e-5)
+		})
+	}
+
+	assert.Nil(t, threePool().SpotPrice(1, 1))
+}
+
+func TestPoolState_FeeBps(t *testing.T) {
+	assert.Equal(t, uint32(1), threePool().FeeBps())
+	assert.Equal(t, uint32(4), ethSteth().FeeBps())
+}
@@ -0,0 +1,32 @@
+package ens
+
+import "github.com/ethereum/go-ethereum/common"
+
+// RegistryAddress is the address of the ENS registry on Ethereum mainnet
+var RegistryAddress = common.HexToAddress("0x00000000000C2E074eC69A0dFb2997BA6C7d2e1e")
+
+// RegistryABI is the ABI for the resolver lookup of the ENS registry
+const RegistryABI = `[
+	{
+		"constant": true,
+		"inputs": [{"name": "node", "type": "bytes32"}],
+		"name": "resolver",
+		"outputs": [{"name": "", "type": "address"}],
+		"payable": false,
+		"stateMutability": "view",
+		"type": "function"
+	}
+]`
+
+// ResolverABI is the ABI for the address record of ENS resolvers
+const ResolverABI = `[
+	{
+		"constant": true,
+		"inputs": [{"name": "node", "type": "bytes32"}],
+		"name": "addr",
+		"outputs": [{"name": "", "type": "address"}],
+		"payable": false,
+		"stateMutability": "view",
+		"type": "function"
+	}
+]`
@@ -0,0 +1,193 @@
+package ens
+
+import (
+	"1inch_testtask/internal/uniswap_v2"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+var (
+	// ErrInvalidName is returned for names that are not normalized ASCII ENS names
+	ErrInvalidName = errors.New("invalid ENS name")
+	// ErrNotFound is returned when a name has no resolver or no address record
+	ErrNotFound = errors.New("ENS name does not resolve to an address")
+)
+
+// maxCacheEntries bounds the cache, names come from requests
+const maxCacheEntries = 10000
+
+// IResolver defines the interface for resolving ENS names
+type IResolver interface {
+	// Resolve returns the address a name resolves to at the block pinned by uniswap_v2.WithBlockNumber, or the latest
+	Resolve(ctx context.Context, name string) (common.Address, error)
+}
+
+// cacheKey identifies the resolution of a name at a block, zero for the latest block
+type cacheKey struct {
+	name  string
+	block uint64
+}
+
+// cacheEntry is a resolution remembered until expires, forever when expires is zero as for resolutions at a pinned
+// block. The address is zero when the name does not resolve.
+type cacheEntry struct {
+	address common.Address
+	expires time.Time
+}
+
+// Resolver implements IResolver by looking up the resolver of a name in the ENS registry and reading the address
+// record from it. Resolutions at the latest block, including names that do not resolve, are cached for the TTL and
+// resolutions at a pinned block are cached per block.
+type Resolver struct {
+	backend     bind.ContractCaller
+	registry    *bind.BoundContract
+	resolverABI abi.ABI
+	ttl         time.Duration
+	// now is the clock of the cache, replaced in tests
+	now func() time.Time
+
+	mu    sync.Mutex
+	cache map[cacheKey]cacheEntry
+}
+
+// NewResolver creates a resolver using the ENS registry at registryAddress, caching resolutions for ttl
+func NewResolver(backend bind.ContractCaller, registryAddress common.Address, ttl time.Duration) (*Resolver, error) {
+	registryABI, err := abi.JSON(strings.NewReader(RegistryABI))
+	if err != nil {
+		return nil, err
+	}
+
+	resolverABI, err := abi.JSON(strings.NewReader(ResolverABI))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Resolver{
+		backend:     backend,
+		registry:    bind.NewBoundContract(registryAddress, registryABI, backend, nil, nil),
+		resolverABI: resolverABI,
+		ttl:         ttl,
+		now:         time.Now,
+		cache:       make(map[cacheKey]cacheEntry),
+	}, nil
+}
+
+// Resolve returns the address name resolves to at the block pinned in ctx, from the cache while the resolution is fresh
+func (r *Resolver) Resolve(ctx context.Context, name string) (common.Address, error) {
+	name, err := Normalize(name)
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	key := cacheKey{name: name}
+	block := uniswap_v2.BlockNumberFromContext(ctx)
+	if block != nil {
+		key.block = block.Uint64()
+	}
+
+	r.mu.Lock()
+	entry, ok := r.cache[key]
+	r.mu.Unlock()
+
+	if !ok || entry.expired(r.now()) {
+		address, err := r.lookup(ctx, NameHash(name), block)
+		if err != nil {
+			return common.Address{}, fmt.Errorf("resolve %s: %w", name, err)
+		}
+
+		entry = cacheEntry{address: address}
+		if block == nil {
+			entry.expires = r.now().Add(r.ttl)
+		}
+		r.store(key, entry)
+	}
+
+	if entry.address == (common.Address{}) {
+		return common.Address{}, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return entry.address, nil
+}
+
+// expired reports whether the resolution is stale at now
+func (e cacheEntry) expired(now time.Time) bool {
+	return !e.expires.IsZero() && !now.Before(e.expires)
+}
+
+// lookup reads the address record of node from its resolver at block, nil for the latest, zero when there is none
+func (r *Resolver) lookup(ctx context.Context, node [32]byte, block *big.Int) (common.Address, error) {
+	opts := &bind.CallOpts{Context: ctx, BlockNumber: block}
+
+	var out []interface{}
+	if err := r.registry.Call(opts, &out, "resolver", node); err != nil {
+		return common.Address{}, fmt.Errorf("registry: %w", err)
+	}
+	resolverAddress := *abi.ConvertType(out[0], new(common.Address)).(*common.Address)
+	if resolverAddress == (common.Address{}) {
+		return common.Address{}, nil
+	}
+
+	resolver := bind.NewBoundContract(resolverAddress, r.resolverABI, r.backend, nil, nil)
+	out = nil
+	if err := resolver.Call(opts, &out, "addr", node); err != nil {
+		return common.Address{}, fmt.Errorf("resolver %s: %w", resolverAddress.Hex(), err)
+	}
+	return *abi.ConvertType(out[0], new(common.Address)).(*common.Address), nil
+}
+
+// store caches a resolution, dropping expired entries when the cache is full and everything when that is not enough
+func (r *Resolver) store(key cacheKey, entry cacheEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.cache) >= maxCacheEntries {
+		now := r.now()
+		for cached, e := range r.cache {
+			if e.expired(now) {
+				delete(r.cache, cached)
+			}
+		}
+		if len(r.cache) >= maxCacheEntries {
+			r.cache = make(map[cacheKey]cacheEntry)
+		}
+	}
+	r.cache[key] = entry
+}
+
+// Normalize lowercases an ENS name and checks its labels. Only ASCII letters, digits, hyphens and underscores
+// are supported, names with other characters need the full ENSIP-15 normalization and are rejected.
+func Normalize(name string) (string, error) {
+	name = strings.ToLower(name)
+	for _, label := range strings.Split(name, ".") {
+		if label == "" {
+			return "", fmt.Errorf("%w: %q has an empty label", ErrInvalidName, name)
+		}
+		if strings.Trim(label, "abcdefghijklmnopqrstuvwxyz0123456789-_") != "" {
+			return "", fmt.Errorf("%w: %q may only contain ASCII letters, digits, hyphens and underscores", ErrInvalidName, name)
+		}
+	}
+	return name, nil
+}
+
+// NameHash computes the ENS namehash of a normalized name
+func NameHash(name string) [32]byte {
+	var node [32]byte
+	if name == "" {
+		return node
+	}
+
+	labels := strings.Split(name, ".")
+	for i := len(labels) - 1; i >= 0; i-- {
+		copy(node[:], crypto.Keccak256(node[:], crypto.Keccak256([]byte(labels[i]))))
+	}
+	return node
+}
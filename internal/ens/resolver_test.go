@@ -0,0 +1,181 @@
+package ens
+
+import (
+	"1inch_testtask/internal/uniswap_v2"
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	publicResolver = common.HexToAddress("0x231b0Ee14048e9dCcD1d247744d114a4EB5E8E63")
+	vitalik        = common.HexToAddress("0xd8dA6BF26964aF9D7eEd9e03E53415D37aA96045")
+)
+
+// fakeChain is a bind.ContractCaller serving an in-memory ENS registry and public resolver
+type fakeChain struct {
+	t           *testing.T
+	registryABI abi.ABI
+	resolverABI abi.ABI
+	// resolvers and addresses map namehashes to their resolver and address record
+	resolvers map[[32]byte]common.Address
+	addresses map[[32]byte]common.Address
+	err       error
+	calls     int
+	// blocks has the block of every call, nil for the latest
+	blocks []*big.Int
+}
+
+func newFakeChain(t *testing.T) *fakeChain {
+	registryABI, err := abi.JSON(strings.NewReader(RegistryABI))
+	require.NoError(t, err)
+	resolverABI, err := abi.JSON(strings.NewReader(ResolverABI))
+	require.NoError(t, err)
+
+	return &fakeChain{
+		t:           t,
+		registryABI: registryABI,
+		resolverABI: resolverABI,
+		resolvers: map[[32]byte]common.Address{
+			NameHash("vitalik.eth"): publicResolver,
+			// unset.eth has a resolver without an address record
+			NameHash("unset.eth"): publicResolver,
+		},
+		addresses: map[[32]byte]common.Address{NameHash("vitalik.eth"): vitalik},
+	}
+}
+
+func (f *fakeChain) CodeAt(context.Context, common.Address, *big.Int) ([]byte, error) {
+	return []byte{0x1}, nil
+}
+
+func (f *fakeChain) CallContract(_ context.Context, msg ethereum.CallMsg, block *big.Int) ([]byte, error) {
+	f.calls++
+	f.blocks = append(f.blocks, block)
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	var node [32]byte
+	copy(node[:], msg.Data[4:])
+	switch *msg.To {
+	case RegistryAddress:
+		return f.registryABI.Methods["resolver"].Outputs.Pack(f.resolvers[node])
+	case publicResolver:
+		return f.resolverABI.Methods["addr"].Outputs.Pack(f.addresses[node])
+	}
+	f.t.Fatalf("unexpected call to %s", msg.To.Hex())
+	return nil, nil
+}
+
+func TestNameHash(t *testing.T) {
+	// Test vectors of EIP-137
+	assert.Equal(t, common.Hash{}, common.Hash(NameHash("")))
+	assert.Equal(t, common.HexToHash("0x93cdeb708b7545dc668eb9280176169d1c33cfd8ed6f04690a0bcc88a93fc4ae"), common.Hash(NameHash("eth")))
+	assert.Equal(t, common.HexToHash("0xde9b09fd7c5f901e23a3f19fecc54828e9c848539801e86591bd9801b019f84f"), common.Hash(NameHash("foo.eth")))
+}
+
+func TestNormalize(t *testing.T) {
+	name, err := Normalize("Vitalik.ETH")
+	require.NoError(t, err)
+	assert.Equal(t, "vitalik.eth", name)
+
+	for _, name := range []string{"", "vitalik..eth", ".eth", "vitalik.eth.", "vit alik.eth", "💩.eth"} {
+		_, err := Normalize(name)
+		assert.ErrorIs(t, err, ErrInvalidName, name)
+	}
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	ctx := context.Background()
+	backend := newFakeChain(t)
+	resolver, err := NewResolver(backend, RegistryAddress, time.Minute)
+	require.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+	resolver.now = func() time.Time { return now }
+
+	address, err := resolver.Resolve(ctx, "Vitalik.eth")
+	require.NoError(t, err)
+	assert.Equal(t, vitalik, address)
+	assert.Equal(t, 2, backend.calls, "registry and resolver should be called once")
+
+	_, err = resolver.Resolve(ctx, "nobody.eth")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, 3, backend.calls, "a name without resolver should stop at the registry")
+
+	_, err = resolver.Resolve(ctx, "unset.eth")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, 5, backend.calls)
+
+	// Resolutions are served from the cache until the TTL passes, names that do not resolve too
+	backend.addresses[NameHash("vitalik.eth")] = common.HexToAddress("0x1")
+	backend.addresses[NameHash("unset.eth")] = common.HexToAddress("0x2")
+	address, err = resolver.Resolve(ctx, "vitalik.eth")
+	require.NoError(t, err)
+	assert.Equal(t, vitalik, address)
+	_, err = resolver.Resolve(ctx, "unset.eth")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, 5, backend.calls)
+
+	now = now.Add(time.Minute)
+	address, err = resolver.Resolve(ctx, "vitalik.eth")
+	require.NoError(t, err)
+	assert.Equal(t, common.HexToAddress("0x1"), address)
+	address, err = resolver.Resolve(ctx, "unset.eth")
+	require.NoError(t, err)
+	assert.Equal(t, common.HexToAddress("0x2"), address)
+	assert.Equal(t, 9, backend.calls)
+
+	// Failed lookups are not cached
+	now = now.Add(time.Minute)
+	backend.err = errors.New("429 Too Many Requests")
+	_, err = resolver.Resolve(ctx, "vitalik.eth")
+	assert.ErrorContains(t, err, "429 Too Many Requests")
+	backend.err = nil
+	address, err = resolver.Resolve(ctx, "vitalik.eth")
+	require.NoError(t, err)
+	assert.Equal(t, common.HexToAddress("0x1"), address)
+
+	_, err = resolver.Resolve(ctx, "vitalik..eth")
+	assert.ErrorIs(t, err, ErrInvalidName)
+}
+
+func TestResolver_ResolvePinned(t *testing.T) {
+	backend := newFakeChain(t)
+	resolver, err := NewResolver(backend, RegistryAddress, time.Minute)
+	require.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+	resolver.now = func() time.Time { return now }
+
+	pinned := uniswap_v2.WithBlockNumber(context.Background(), big.NewInt(18500000))
+	address, err := resolver.Resolve(pinned, "vitalik.eth")
+	require.NoError(t, err)
+	assert.Equal(t, vitalik, address)
+	assert.Equal(t, []*big.Int{big.NewInt(18500000), big.NewInt(18500000)}, backend.blocks, "registry and resolver should be read at the block")
+
+	// A later record change does not leak into the pinned resolution but is seen at the latest block
+	backend.addresses[NameHash("vitalik.eth")] = common.HexToAddress("0x1")
+	now = now.Add(time.Hour)
+	address, err = resolver.Resolve(pinned, "vitalik.eth")
+	require.NoError(t, err)
+	assert.Equal(t, vitalik, address)
+	assert.Equal(t, 2, backend.calls, "pinned resolutions are cached per block without expiring")
+
+	address, err = resolver.Resolve(context.Background(), "vitalik.eth")
+	require.NoError(t, err)
+	assert.Equal(t, common.HexToAddress("0x1"), address)
+	assert.Equal(t, []*big.Int{nil, nil}, backend.blocks[2:])
+
+	_, err = resolver.Resolve(uniswap_v2.WithBlockNumber(context.Background(), big.NewInt(18500001)), "vitalik.eth")
+	require.NoError(t, err)
+	assert.Equal(t, 6, backend.calls, "other blocks are resolved again")
+}
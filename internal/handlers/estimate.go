@@ -1,35 +1,95 @@
 package handlers
 
 import (
+	"1inch_testtask/internal/balancer"
+	"1inch_testtask/internal/curve"
+	"1inch_testtask/internal/ens"
 	"1inch_testtask/internal/models"
+	"1inch_testtask/internal/pooldetect"
+	"1inch_testtask/internal/tokens"
+	"1inch_testtask/internal/transfertax"
+	"1inch_testtask/internal/uniswap_v2"
 	"1inch_testtask/internal/usecase"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/labstack/echo/v4"
 )
 
-// Handler handles the /estimate endpoint
+// Handler handles the /estimate endpoints
 type Handler struct {
 	uniswapService *usecase.Usecase
+	// streamInterval is how often /estimate/stream re-evaluates the quote of pools without updates
+	streamInterval time.Duration
+	// poolUpdates drives /estimate/stream re-evaluations by reserve changes of tracked pools
+	poolUpdates PoolUpdates
+	// strictChecksums rejects requests with mixed-case addresses failing their EIP-55 checksum
+	strictChecksums bool
+}
+
+// PoolUpdates notifies about reserve changes of tracked pools, implemented by poolstate.Tracker
+type PoolUpdates interface {
+	// Subscribe returns a channel receiving a value whenever the reserves of the pool change, ok is false when the
+	// pool is not tracked
+	Subscribe(pool common.Address) (updates <-chan struct{}, unsubscribe func(), ok bool)
+}
+
+// Option configures optional Handler settings
+type Option func(*Handler)
+
+// WithStreamInterval sets how often streamed quotes of pools without updates are re-evaluated
+func WithStreamInterval(interval time.Duration) Option {
+	return func(h *Handler) {
+		h.streamInterval = interval
+	}
+}
+
+// WithPoolUpdates re-evaluates streamed quotes of tracked pools when their reserves change instead of polling
+func WithPoolUpdates(updates PoolUpdates) Option {
+	return func(h *Handler) {
+		h.poolUpdates = updates
+	}
+}
+
+// WithStrictChecksums sets whether mixed-case addresses failing their EIP-55 checksum are rejected
+func WithStrictChecksums(strict bool) Option {
+	return func(h *Handler) {
+		h.strictChecksums = strict
+	}
 }
 
 // NewHandler creates a new Handler
-func NewHandler(uniswapService *usecase.Usecase) *Handler {
-	return &Handler{
+func NewHandler(uniswapService *usecase.Usecase, opts ...Option) *Handler {
+	h := &Handler{
 		uniswapService: uniswapService,
+		streamInterval: DefaultStreamInterval,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
-// Estimate calculates the estimated output amount for a Uniswap V2 swap
+// Estimate calculates the estimated output amount for a single pool swap or the best route
 // @Summary Calculate swap estimation
-// @Description Estimates the output amount for a Uniswap V2 token swap based on current pool reserves
+// @Description Estimates the output amount for a token swap based on the current pool state. When pool is omitted, the best route over the configured pool graph and the pairs of src and dst in the configured Uniswap V2 factories is used. The pool type (Uniswap V2, Uniswap V3, Curve StableSwap or Balancer V2 weighted) is detected from the pool contract unless protocol is set; V3 pools are quoted crossing initialized ticks, Curve pools with the get_dy invariant math and Balancer pools with the weighted math of the pool contracts.
 // @Tags estimate
 // @Accept json
 // @Produce json
-// @Param pool query string true "Uniswap V2 pool address" example(0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852)
-// @Param src query string true "Source token address" example(0xdAC17F958D2ee523a2206206994597C13D831ec7)
-// @Param dst query string true "Destination token address" example(0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2)
-// @Param src_amount query string true "Source amount to swap (integer with respect to decimals)" example(10000000)
+// @Param pool query string false "Uniswap V2, Uniswap V3, Curve or Balancer pool address or ENS name (omit to search for the best route over Uniswap V2 pairs, including the pairs of src and dst in the configured factories)" example(0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852)
+// @Param src query string true "Source token address, token alias such as USDT or ENS name" example(0xdAC17F958D2ee523a2206206994597C13D831ec7)
+// @Param dst query string true "Destination token address, token alias such as WETH or ENS name" example(0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2)
+// @Param src_amount query string true "Source amount to swap, an integer in base units or with amount_format=decimal a decimal in whole tokens" example(10000000)
+// @Param amount_format query string false "Format of src_amount: base (default) or decimal, converted with the on-chain decimals of src; decimal amounts more precise than the token are rejected" Enums(base, decimal)
+// @Param block query string false "Block number, block hash or tag (latest, safe, finalized) to pin the estimate to" example(latest)
+// @Param slippage_bps query int false "Slippage tolerance in basis points, adds min_dst_amount to the response" example(50)
+// @Param protocol query string false "Pool protocol, v2, v3, curve or balancer; detected from the pool contract when omitted, v3, curve and balancer require a pool" Enums(v2, v3, curve, balancer)
 // @Success 200 {object} models.EstimateResponse
 // @Failure 400 {object} models.ErrorResponse
 // @Failure 500 {object} models.ErrorResponse
@@ -45,30 +105,537 @@ func (h *Handler) Estimate(c echo.Context) error {
 		})
 	}
 
+	// Resolve ENS names and token aliases
+	resolved, err := h.resolveAddresses(c.Request().Context(), req.Block, &req.Pool, &req.Src, &req.Dst)
+	if err != nil {
+		return resolveErrorOr(c, err)
+	}
+
 	// Validate request
+	if err := h.validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	if req.AmountFormat == models.AmountFormatDecimal {
+		srcAmount, err := h.uniswapService.ParseTokenAmount(c.Request().Context(), req.Src, req.SrcAmount)
+		if err != nil {
+			return amountErrorOr(c, err)
+		}
+		req.SrcAmount = srcAmount.String()
+		if err := models.ValidateAmount(req.SrcAmount); err != nil {
+			return validationError(c, err)
+		}
+	}
+
+	if req.Pool == "" {
+		return h.estimateRoute(c, req, resolved)
+	}
+
+	// Calculate estimation
+	estimate, err := h.uniswapService.EstimatePoolSwap(
+		c.Request().Context(),
+		poolTypes[req.Protocol],
+		req.Pool,
+		req.Src,
+		req.Dst,
+		req.SrcAmount,
+		req.Block,
+	)
+	if err != nil {
+		return poolErrorOr(c, err)
+	}
+
+	resp := swapResponse(estimate)
+	resp.Resolved = resolved
+	if req.SlippageBps > 0 {
+		resp.MinDstAmount = usecase.MinAmountOut(estimate.DstAmount, req.SlippageBps).String()
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// swapResponse converts a single pool estimate to an /estimate response
+func swapResponse(estimate *usecase.SwapEstimate) models.EstimateResponse {
+	return models.EstimateResponse{
+		DstAmount:          estimate.DstAmount.String(),
+		DstAmountDecimal:   decimalAmount(estimate.DstAmount, estimate.DstToken),
+		FeeBps:             &estimate.FeeBps,
+		BlockInfo:          blockInfo(estimate.Block),
+		BlockTimestampLast: estimate.BlockTimestampLast,
+		PriceInfo:          priceInfo(estimate.PriceInfo),
+		TransferTax:        transferTax(estimate.TransferTaxes),
+		SrcToken:           tokenInfo(estimate.SrcToken),
+		DstToken:           tokenInfo(estimate.DstToken),
+	}
+}
+
+// decimalAmount formats an amount of token in whole tokens, empty when its decimals are unknown
+func decimalAmount(amount *big.Int, token *tokens.Token) string {
+	if token == nil {
+		return ""
+	}
+	return tokens.FormatUnits(amount, token.Decimals)
+}
+
+// amountErrorOr responds with a 400 for decimal amounts that cannot be converted to base units, and like blockErrorOr otherwise
+func amountErrorOr(c echo.Context, err error) error {
+	if errors.Is(err, tokens.ErrTooPrecise) || errors.Is(err, usecase.ErrUnknownDecimals) || errors.Is(err, usecase.ErrTokenMetadataDisabled) {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_amount",
+			Message: err.Error(),
+		})
+	}
+
+	return blockErrorOr(c, err)
+}
+
+// tokenInfo describes the metadata of a token, nil when it is unknown
+func tokenInfo(token *tokens.Token) *models.TokenInfo {
+	if token == nil {
+		return nil
+	}
+	return &models.TokenInfo{
+		Address:  token.Address.Hex(),
+		Symbol:   token.Symbol,
+		Name:     token.Name,
+		Decimals: token.Decimals,
+	}
+}
+
+// transferTax describes the transfer taxes applied to an estimate, nil when none was
+func transferTax(taxes usecase.TransferTaxes) *models.TransferTax {
+	if !taxes.Taxed() {
+		return nil
+	}
+	return &models.TransferTax{
+		SrcTaxBps: taxes.SrcTax.SellBps,
+		DstTaxBps: taxes.DstTax.BuyBps,
+		PathTaxed: taxes.PathTaxed,
+	}
+}
+
+// priceInfo formats the prices of an estimate, empty when the pool has no liquidity
+func priceInfo(price usecase.PriceInfo) models.PriceInfo {
+	if price.SpotPrice == nil {
+		return models.PriceInfo{}
+	}
+	return models.PriceInfo{
+		SpotPrice:      formatPrice(price.SpotPrice),
+		ExecutionPrice: formatPrice(price.ExecutionPrice),
+		PriceImpactBps: price.PriceImpactBps.FloatString(2),
+	}
+}
+
+// priceDigits is the minimum number of significant digits prices are formatted with
+const priceDigits = 18
+
+// formatPrice formats a price as a decimal with at least priceDigits significant digits, dropping trailing zeros
+func formatPrice(price *big.Rat) string {
+	// Prices below 1 need extra decimals for their leading zeros
+	decimals := priceDigits
+	if leading := len(price.Denom().String()) - len(price.Num().String()); leading > 0 {
+		decimals += leading
+	}
+
+	value := price.FloatString(decimals)
+	if strings.Contains(value, ".") {
+		value = strings.TrimRight(strings.TrimRight(value, "0"), ".")
+	}
+	return value
+}
+
+// validate validates the request, checking the EIP-55 checksums of its addresses in strict mode
+func (h *Handler) validate(req models.Request) error {
 	if err := req.Validate(); err != nil {
+		return err
+	}
+	if h.strictChecksums {
+		return models.ValidateChecksums(req)
+	}
+	return nil
+}
+
+// resolveAddresses replaces ENS names in pool, src and dst and token aliases in src and dst by their checksummed
+// addresses. pool is nil for endpoints without one. ENS names are resolved at the requested block, empty for the latest.
+// The resolved addresses are returned, nil when no name was given.
+func (h *Handler) resolveAddresses(ctx context.Context, block string, pool, src, dst *string) (*models.ResolvedAddresses, error) {
+	// An invalid block is rejected by the validation following resolution
+	if models.ValidateBlock(block) != nil {
+		block = ""
+	}
+
+	var resolved models.ResolvedAddresses
+	params := []struct {
+		name     string
+		value    *string
+		resolved *string
+		token    bool
+	}{
+		{name: "pool", value: pool, resolved: &resolved.Pool},
+		{name: "src", value: src, resolved: &resolved.Src, token: true},
+		{name: "dst", value: dst, resolved: &resolved.Dst, token: true},
+	}
+
+	found := false
+	for _, p := range params {
+		if p.value == nil || *p.value == "" {
+			continue
+		}
+		address, ok, err := h.uniswapService.ResolveAddress(ctx, *p.value, p.token, block)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p.name, err)
+		}
+		if ok {
+			*p.value = address.Hex()
+			*p.resolved = address.Hex()
+			found = true
+		}
+	}
+
+	if !found {
+		return nil, nil
+	}
+	return &resolved, nil
+}
+
+// resolveErrorOr maps names that cannot be resolved to 400 and failed ENS lookups to 500, and block errors like blockErrorOr
+func resolveErrorOr(c echo.Context, err error) error {
+	if errors.Is(err, uniswap_v2.ErrBlockNotFound) || errors.Is(err, usecase.ErrBlockPinningDisabled) {
+		return blockErrorOr(c, err)
+	}
+	if errors.Is(err, ens.ErrNotFound) || errors.Is(err, ens.ErrInvalidName) ||
+		errors.Is(err, usecase.ErrUnknownAlias) || errors.Is(err, usecase.ErrNameResolutionDisabled) {
 		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
-			Error:   "validation_error",
+			Error:   "unresolved_name",
+			Message: "Failed to resolve " + err.Error(),
+		})
+	}
+	return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Error:   "resolution_error",
+		Message: "Failed to resolve " + err.Error(),
+	})
+}
+
+// validationError responds with a 400 for an invalid request, amount and checksum errors carry their own error code
+func validationError(c echo.Context, err error) error {
+	code := "validation_error"
+	var amountErr *models.AmountError
+	if errors.As(err, &amountErr) {
+		code = amountErr.Code
+	} else if errors.Is(err, models.ErrInvalidChecksum) {
+		code = "invalid_checksum"
+	}
+
+	return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+		Error:   code,
+		Message: err.Error(),
+	})
+}
+
+// blockErrorOr maps block resolution errors and amounts overflowing pool reserves to 400 and any other estimation error to 500
+func blockErrorOr(c echo.Context, err error) error {
+	if errors.Is(err, uniswap_v2.ErrBlockNotFound) || errors.Is(err, usecase.ErrBlockPinningDisabled) {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_block",
+			Message: err.Error(),
+		})
+	}
+	if errors.Is(err, usecase.ErrReserveOverflow) {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "reserve_overflow",
+			Message: err.Error(),
+		})
+	}
+
+	return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
+		Error:   "calculation_error",
+		Message: "Failed to calculate swap estimation: " + err.Error(),
+	})
+}
+
+// poolTypes maps the protocol of an /estimate request to the pool type, an empty protocol is detected
+var poolTypes = map[string]pooldetect.Type{
+	models.ProtocolV2:       pooldetect.UniswapV2,
+	models.ProtocolV3:       pooldetect.UniswapV3,
+	models.ProtocolCurve:    pooldetect.Curve,
+	models.ProtocolBalancer: pooldetect.Balancer,
+}
+
+// poolErrorOr responds with a 400 for pools that cannot be quoted, and like blockErrorOr otherwise
+func poolErrorOr(c echo.Context, err error) error {
+	if errors.Is(err, usecase.ErrUniswapV3Disabled) || errors.Is(err, usecase.ErrCurveDisabled) || errors.Is(err, usecase.ErrBalancerDisabled) ||
+		errors.Is(err, balancer.ErrNotWeightedPool) || errors.Is(err, curve.ErrUnsupportedPool) || errors.Is(err, pooldetect.ErrUnknownPool) {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "unsupported_protocol",
+			Message: err.Error(),
+		})
+	}
+	if errors.Is(err, pooldetect.ErrNoContract) {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_pool",
 			Message: err.Error(),
 		})
 	}
 
+	return blockErrorOr(c, err)
+}
+
+// blockInfo describes the block the estimate was pinned to, empty when it was not pinned
+func blockInfo(block *uniswap_v2.BlockRef) models.BlockInfo {
+	if block == nil {
+		return models.BlockInfo{}
+	}
+	return models.BlockInfo{
+		BlockNumber: block.Number.Uint64(),
+		BlockHash:   block.Hash.Hex(),
+	}
+}
+
+// estimateRoute answers an /estimate request without a pool using the best found route
+func (h *Handler) estimateRoute(c echo.Context, req models.EstimateRequest, resolved *models.ResolvedAddresses) error {
+	route, err := h.uniswapService.FindBestRoute(
+		c.Request().Context(),
+		req.Src,
+		req.Dst,
+		req.SrcAmount,
+		req.Block,
+	)
+	if errors.Is(err, usecase.ErrRoutingDisabled) || errors.Is(err, usecase.ErrNoRoute) {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "no_route",
+			Message: "Failed to find a route: " + err.Error(),
+		})
+	}
+	if err != nil {
+		return blockErrorOr(c, err)
+	}
+
+	resp := models.EstimateResponse{
+		DstAmount:        route.DstAmount().String(),
+		DstAmountDecimal: decimalAmount(route.DstAmount(), route.DstToken),
+		BlockInfo:        blockInfo(route.Block),
+		PriceInfo:        priceInfo(route.PriceInfo),
+		TransferTax:      transferTax(route.TransferTaxes),
+		SrcToken:         tokenInfo(route.SrcToken),
+		DstToken:         tokenInfo(route.DstToken),
+		Resolved:         resolved,
+		Route: &models.Route{
+			Pools:   make([]string, len(route.Pools)),
+			Path:    make([]string, len(route.Path)),
+			Amounts: make([]string, len(route.Amounts)),
+			FeeBps:  route.FeeBps,
+		},
+	}
+	for i, pool := range route.Pools {
+		resp.Route.Pools[i] = pool.Hex()
+	}
+	for i, token := range route.Path {
+		resp.Route.Path[i] = token.Hex()
+	}
+	for i, amount := range route.Amounts {
+		resp.Route.Amounts[i] = amount.String()
+	}
+	if req.SlippageBps > 0 {
+		resp.MinDstAmount = usecase.MinAmountOut(route.DstAmount(), req.SlippageBps).String()
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// EstimateIn calculates the input amount required to receive a desired output from a single pool swap
+// @Summary Calculate reverse swap estimation
+// @Description Estimates the source amount required to receive the given destination amount from a token swap based on the current pool state, detecting the pool type like /estimate
+// @Tags estimate
+// @Accept json
+// @Produce json
+// @Param pool query string true "Uniswap V2, Uniswap V3, Curve or Balancer pool address or ENS name" example(0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852)
+// @Param src query string true "Source token address, token alias such as USDT or ENS name" example(0xdAC17F958D2ee523a2206206994597C13D831ec7)
+// @Param dst query string true "Destination token address, token alias such as WETH or ENS name" example(0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2)
+// @Param dst_amount query string true "Desired destination amount (integer with respect to decimals)" example(6241000000000000)
+// @Param block query string false "Block number, block hash or tag (latest, safe, finalized) to pin the estimate to" example(latest)
+// @Success 200 {object} models.EstimateInResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /estimate/in [get]
+func (h *Handler) EstimateIn(c echo.Context) error {
+	var req models.EstimateInRequest
+
+	// Bind query parameters
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Failed to parse query parameters: " + err.Error(),
+		})
+	}
+
+	// Resolve ENS names and token aliases
+	resolved, err := h.resolveAddresses(c.Request().Context(), req.Block, &req.Pool, &req.Src, &req.Dst)
+	if err != nil {
+		return resolveErrorOr(c, err)
+	}
+
+	// Validate request
+	if err := h.validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
 	// Calculate estimation
-	outputAmount, err := h.uniswapService.EstimateSwap(
+	estimate, err := h.uniswapService.EstimateSwapIn(
 		c.Request().Context(),
 		req.Pool,
 		req.Src,
 		req.Dst,
+		req.DstAmount,
+		req.Block,
+	)
+	if errors.Is(err, usecase.ErrInsufficientLiquidity) {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "insufficient_liquidity",
+			Message: "Requested output exceeds pool liquidity: " + err.Error(),
+		})
+	}
+	if errors.Is(err, transfertax.ErrWholeAmount) {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "untradable_token",
+			Message: err.Error(),
+		})
+	}
+	if err != nil {
+		return poolErrorOr(c, err)
+	}
+
+	return c.JSON(http.StatusOK, models.EstimateInResponse{
+		SrcAmount:   estimate.SrcAmount.String(),
+		FeeBps:      estimate.FeeBps,
+		BlockInfo:   blockInfo(estimate.Block),
+		TransferTax: transferTax(estimate.TransferTaxes),
+		Resolved:    resolved,
+	})
+}
+
+// EstimatePath calculates every intermediate amount for a multi-hop Uniswap V2 swap
+// @Summary Calculate multi-hop swap estimation
+// @Description Estimates the output amounts for a swap routed through an ordered list of Uniswap V2 pools
+// @Tags estimate
+// @Accept json
+// @Produce json
+// @Param pools query string true "Comma-separated Uniswap V2 pool addresses, one per hop" example(0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852,0xa478c2975ab1ea89e8196811f51a7b7ade33eb11)
+// @Param path query string true "Comma-separated token addresses from source to destination" example(0xdAC17F958D2ee523a2206206994597C13D831ec7,0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2,0x6B175474E89094C44Da98b954EedeAC495271d0F)
+// @Param src_amount query string true "Source amount to swap (integer with respect to decimals)" example(10000000)
+// @Param block query string false "Block number, block hash or tag (latest, safe, finalized) to pin the estimate to" example(latest)
+// @Success 200 {object} models.EstimatePathResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /estimate/path [get]
+func (h *Handler) EstimatePath(c echo.Context) error {
+	var req models.EstimatePathRequest
+
+	// Bind query parameters
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Failed to parse query parameters: " + err.Error(),
+		})
+	}
+
+	// Validate request
+	if err := h.validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	// Calculate estimation
+	estimate, err := h.uniswapService.EstimatePath(
+		c.Request().Context(),
+		req.PoolList(),
+		req.TokenList(),
 		req.SrcAmount,
+		req.Block,
 	)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, models.ErrorResponse{
-			Error:   "calculation_error",
-			Message: "Failed to calculate swap estimation: " + err.Error(),
+		return blockErrorOr(c, err)
+	}
+
+	resp := models.EstimatePathResponse{
+		Amounts:     make([]string, len(estimate.Amounts)),
+		FeeBps:      estimate.FeeBps,
+		BlockInfo:   blockInfo(estimate.Block),
+		TransferTax: transferTax(estimate.TransferTaxes),
+	}
+	for i, amount := range estimate.Amounts {
+		resp.Amounts[i] = amount.String()
+	}
+	resp.DstAmount = resp.Amounts[len(resp.Amounts)-1]
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// EstimateSplit splits a swap across several pools of the same pair to maximize the output
+// @Summary Calculate split swap estimation
+// @Description Splits the source amount across several Uniswap V2 pools trading the same pair to maximize the total output
+// @Tags estimate
+// @Accept json
+// @Produce json
+// @Param pools query string true "Comma-separated pool addresses trading the src/dst pair" example(0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852,0x06da0fd433c1a5d7a4faa01111c044910a184553)
+// @Param src query string true "Source token address, token alias such as USDT or ENS name" example(0xdAC17F958D2ee523a2206206994597C13D831ec7)
+// @Param dst query string true "Destination token address, token alias such as WETH or ENS name" example(0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2)
+// @Param src_amount query string true "Source amount to swap (integer with respect to decimals)" example(100000000000)
+// @Param parts query int false "Number of chunks the amount is split into (granularity, default 10, max 100)" example(20)
+// @Param block query string false "Block number, block hash or tag (latest, safe, finalized) to pin the estimate to" example(latest)
+// @Success 200 {object} models.EstimateSplitResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /estimate/split [get]
+func (h *Handler) EstimateSplit(c echo.Context) error {
+	var req models.EstimateSplitRequest
+
+	// Bind query parameters
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Failed to parse query parameters: " + err.Error(),
 		})
 	}
 
-	return c.JSON(http.StatusOK, models.EstimateResponse{
-		DstAmount: outputAmount.String(),
-	})
+	// Resolve ENS names and token aliases
+	resolved, err := h.resolveAddresses(c.Request().Context(), req.Block, nil, &req.Src, &req.Dst)
+	if err != nil {
+		return resolveErrorOr(c, err)
+	}
+
+	// Validate request
+	if err := h.validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	// Calculate estimation
+	split, err := h.uniswapService.EstimateSplit(
+		c.Request().Context(),
+		req.PoolList(),
+		req.Src,
+		req.Dst,
+		req.SrcAmount,
+		req.Parts,
+		req.Block,
+	)
+	if err != nil {
+		return blockErrorOr(c, err)
+	}
+
+	resp := models.EstimateSplitResponse{
+		DstAmount:   split.DstAmount.String(),
+		Allocations: make([]models.SplitAllocation, len(split.Allocations)),
+		BlockInfo:   blockInfo(split.Block),
+		Resolved:    resolved,
+	}
+	for i, allocation := range split.Allocations {
+		resp.Allocations[i] = models.SplitAllocation{
+			Pool:        allocation.Pool.Hex(),
+			SrcAmount:   allocation.SrcAmount.String(),
+			DstAmount:   allocation.DstAmount.String(),
+			FeeBps:      allocation.FeeBps,
+			TransferTax: transferTax(allocation.TransferTaxes),
+		}
+	}
+
+	return c.JSON(http.StatusOK, resp)
 }
@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"1inch_testtask/internal/models"
+	"1inch_testtask/internal/usecase"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// DefaultStreamInterval is how often streamed quotes of pools without updates are re-evaluated by default
+	DefaultStreamInterval = time.Second
+	// streamKeepAlive is how often a comment is sent on an idle stream so proxies keep the connection open
+	streamKeepAlive = 15 * time.Second
+)
+
+// EstimateStream streams the estimated output amount of a Uniswap V2 swap as Server-Sent Events
+// @Summary Stream swap estimation
+// @Description Streams an "estimate" Server-Sent Event with the current estimation, then a new one every time the output amount changes with the pool reserves. Pools tracked from Sync events are re-evaluated when their reserves change, other pools are polled. A failed re-evaluation is sent as an "error" event and the stream continues.
+// @Tags estimate
+// @Produce text/event-stream
+// @Param pool query string true "Uniswap V2 pool address or ENS name" example(0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852)
+// @Param src query string true "Source token address, token alias such as USDT or ENS name" example(0xdAC17F958D2ee523a2206206994597C13D831ec7)
+// @Param dst query string true "Destination token address, token alias such as WETH or ENS name" example(0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2)
+// @Param src_amount query string true "Source amount to swap (integer with respect to decimals)" example(10000000)
+// @Success 200 {object} models.EstimateResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /estimate/stream [get]
+func (h *Handler) EstimateStream(c echo.Context) error {
+	var req models.EstimateStreamRequest
+
+	// Bind query parameters
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Failed to parse query parameters: " + err.Error(),
+		})
+	}
+
+	// Resolve ENS names and token aliases
+	resolved, err := h.resolveAddresses(c.Request().Context(), "", &req.Pool, &req.Src, &req.Dst)
+	if err != nil {
+		return resolveErrorOr(c, err)
+	}
+
+	// Validate request
+	if err := h.validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	// Subscribe before the first estimation so that no reserve change in between is missed
+	ctx := c.Request().Context()
+	var updates <-chan struct{}
+	if h.poolUpdates != nil {
+		if ch, unsubscribe, ok := h.poolUpdates.Subscribe(common.HexToAddress(req.Pool)); ok {
+			defer unsubscribe()
+			updates = ch
+		}
+	}
+
+	// The first estimation is made before streaming so that invalid requests get a regular error response
+	estimate, err := h.uniswapService.EstimateSwap(ctx, req.Pool, req.Src, req.Dst, req.SrcAmount, "")
+	if err != nil {
+		return blockErrorOr(c, err)
+	}
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.WriteHeader(http.StatusOK)
+
+	// Every event echoes the resolved addresses
+	event := func(estimate *usecase.SwapEstimate) models.EstimateResponse {
+		resp := swapResponse(estimate)
+		resp.Resolved = resolved
+		return resp
+	}
+
+	last := estimate.DstAmount.String()
+	if err := writeEvent(res, "estimate", event(estimate)); err != nil {
+		return nil
+	}
+
+	// requote re-evaluates the quote and returns the event to push, an empty name when there is nothing new
+	requote := func() (string, interface{}) {
+		estimate, err := h.uniswapService.EstimateSwap(ctx, req.Pool, req.Src, req.Dst, req.SrcAmount, "")
+		switch {
+		case ctx.Err() != nil:
+			return "", nil
+		case err != nil:
+			// Errors are pushed once and the next successful estimate is always pushed
+			if last == "" {
+				return "", nil
+			}
+			last = ""
+			return "error", models.ErrorResponse{
+				Error:   "calculation_error",
+				Message: "Failed to calculate swap estimation: " + err.Error(),
+			}
+		case estimate.DstAmount.String() != last:
+			last = estimate.DstAmount.String()
+			return "estimate", event(estimate)
+		}
+		return "", nil
+	}
+
+	// Tracked pools are re-evaluated on reserve changes only, the ticker then just keeps the stream alive
+	interval := h.streamInterval
+	if updates != nil {
+		interval = streamKeepAlive
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	lastWrite := time.Now()
+
+	for {
+		var name string
+		var payload interface{}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-updates:
+			name, payload = requote()
+		case <-ticker.C:
+			if updates == nil {
+				name, payload = requote()
+			}
+		}
+
+		var err error
+		switch {
+		case ctx.Err() != nil:
+			return nil
+		case name != "":
+			err = writeEvent(res, name, payload)
+		case time.Since(lastWrite) >= streamKeepAlive:
+			_, err = fmt.Fprint(res, ": keep-alive\n\n")
+			res.Flush()
+		default:
+			continue
+		}
+		if err != nil {
+			// The client has gone away
+			return nil
+		}
+		lastWrite = time.Now()
+		if updates != nil {
+			ticker.Reset(interval)
+		}
+	}
+}
+
+// writeEvent writes a Server-Sent Event with a JSON payload and flushes it to the client
+func writeEvent(res *echo.Response, event string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(res, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return err
+	}
+	res.Flush()
+	return nil
+}
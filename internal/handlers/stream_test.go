@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"1inch_testtask/internal/models"
+	"1inch_testtask/internal/uniswap_v2"
+	"1inch_testtask/internal/usecase"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	usdt     = common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+	weth     = common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+	usdtWeth = common.HexToAddress("0x0d4a11d5EEaaC28EC3F61d100daF4d40471f1852")
+)
+
+// fakePool is a tracked WETH/USDT pair serving as the pool state source, the pool updates and the RPC fallback.
+// While failing it is untracked and RPC reads fail.
+type fakePool struct {
+	uniswap_v2.IUniswapV2
+
+	mu       sync.Mutex
+	reserve1 *big.Int
+	failing  bool
+	updates  chan struct{}
+}
+
+func newFakePool() *fakePool {
+	return &fakePool{reserve1: big.NewInt(2000000000000), updates: make(chan struct{})}
+}
+
+func (f *fakePool) PoolStates(poolAddresses []common.Address) ([]uniswap_v2.PoolState, *uniswap_v2.BlockRef, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.failing || len(poolAddresses) != 1 || poolAddresses[0] != usdtWeth {
+		return nil, nil, false
+	}
+	state := uniswap_v2.PoolState{
+		Pool:     usdtWeth,
+		Token0:   weth,
+		Token1:   usdt,
+		Reserve0: new(big.Int).Mul(big.NewInt(1000), big.NewInt(1e18)),
+		Reserve1: f.reserve1,
+	}
+	return []uniswap_v2.PoolState{state}, &uniswap_v2.BlockRef{Number: big.NewInt(18500000)}, true
+}
+
+func (f *fakePool) GetPoolStates(context.Context, []common.Address) ([]uniswap_v2.PoolState, error) {
+	return nil, errors.New("429 Too Many Requests")
+}
+
+func (f *fakePool) Subscribe(pool common.Address) (<-chan struct{}, func(), bool) {
+	return f.updates, func() {}, pool == usdtWeth
+}
+
+// set changes the reserves and whether the pool fails, then notifies the stream
+func (f *fakePool) set(reserve1 int64, failing bool) {
+	f.mu.Lock()
+	f.reserve1 = big.NewInt(reserve1)
+	f.failing = failing
+	f.mu.Unlock()
+	f.updates <- struct{}{}
+}
+
+// sseEvent is a parsed Server-Sent Event
+type sseEvent struct {
+	name string
+	data string
+}
+
+// openStream starts a stream of WETH to USDT quotes and returns a function reading its next event
+func openStream(t *testing.T, h *Handler) func() sseEvent {
+	e := echo.New()
+	e.GET("/estimate/stream", h.EstimateStream)
+	server := httptest.NewServer(e)
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	url := server.URL + "/estimate/stream?pool=" + usdtWeth.Hex() + "&src=" + weth.Hex() + "&dst=" + usdt.Hex() + "&src_amount=1000000000000000000"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	require.NoError(t, err)
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { res.Body.Close() })
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get(echo.HeaderContentType))
+
+	reader := bufio.NewReader(res.Body)
+	return func() sseEvent {
+		var event sseEvent
+		for {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			line = strings.TrimRight(line, "\n")
+			switch {
+			case line == "" && event.name != "":
+				return event
+			case strings.HasPrefix(line, "event: "):
+				event.name = strings.TrimPrefix(line, "event: ")
+			case strings.HasPrefix(line, "data: "):
+				event.data = strings.TrimPrefix(line, "data: ")
+			}
+		}
+	}
+}
+
+// dstAmount returns the dst_amount of an estimate event
+func dstAmount(t *testing.T, event sseEvent) string {
+	require.Equal(t, "estimate", event.name, event.data)
+	var resp models.EstimateResponse
+	require.NoError(t, json.Unmarshal([]byte(event.data), &resp))
+	return resp.DstAmount
+}
+
+func TestEstimateStream(t *testing.T) {
+	t.Run("pushes only changed outputs on reserve updates", func(t *testing.T) {
+		pool := newFakePool()
+		service := usecase.NewUsecase(pool, usecase.WithPoolStateSource(pool))
+		next := openStream(t, NewHandler(service, WithPoolUpdates(pool)))
+
+		first := dstAmount(t, next())
+
+		// An update leaving the output unchanged pushes nothing, so the next event is the changed output
+		pool.set(2000000000000, false)
+		pool.set(1000000000000, false)
+		second := dstAmount(t, next())
+		assert.NotEqual(t, first, second)
+		assert.Equal(t, "996006981", second)
+	})
+
+	t.Run("reports an error once", func(t *testing.T) {
+		pool := newFakePool()
+		service := usecase.NewUsecase(pool, usecase.WithPoolStateSource(pool))
+		next := openStream(t, NewHandler(service, WithPoolUpdates(pool)))
+
+		first := dstAmount(t, next())
+
+		pool.set(2000000000000, true)
+		event := next()
+		assert.Equal(t, "error", event.name)
+		var resp models.ErrorResponse
+		require.NoError(t, json.Unmarshal([]byte(event.data), &resp))
+		assert.Equal(t, "calculation_error", resp.Error)
+		assert.Contains(t, resp.Message, "429 Too Many Requests")
+
+		// Further failures are not pushed, and the recovered estimate is pushed even though it is unchanged
+		pool.set(2000000000000, true)
+		pool.set(2000000000000, false)
+		assert.Equal(t, first, dstAmount(t, next()))
+	})
+
+	t.Run("polls without pool updates", func(t *testing.T) {
+		pool := newFakePool()
+		service := usecase.NewUsecase(pool, usecase.WithPoolStateSource(pool))
+		next := openStream(t, NewHandler(service, WithStreamInterval(10*time.Millisecond)))
+
+		first := dstAmount(t, next())
+
+		pool.mu.Lock()
+		pool.reserve1 = big.NewInt(1000000000000)
+		pool.mu.Unlock()
+		assert.NotEqual(t, first, dstAmount(t, next()))
+	})
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"1inch_testtask/internal/models"
+	"1inch_testtask/internal/uniswap_v2"
+	"1inch_testtask/internal/usecase"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/labstack/echo/v4"
+)
+
+// BuildSwap builds a UniswapV2Router02 swap transaction from an estimate
+// @Summary Build swap transaction
+// @Description Estimates the swap and returns the router calldata of an exact input swap whose minimum output is the estimate minus slippage_bps. Use 0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE as src or dst for native ETH. When pool is omitted, the best route over the pairs of the router's factory in the configured pool graph is used. The router derives its pairs from the path and assumes the 0.3% fee, so a pool that is not the router factory's pair of src and dst, or has another fee, is rejected with unsupported_pool.
+// @Tags swap
+// @Accept json
+// @Produce json
+// @Param pool query string false "Uniswap V2 pool address or ENS name (omit to search for the best route)" example(0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852)
+// @Param src query string true "Source token address, token alias such as USDT or ENS name" example(0xdAC17F958D2ee523a2206206994597C13D831ec7)
+// @Param dst query string true "Destination token address, token alias such as WETH or ENS name" example(0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE)
+// @Param src_amount query string true "Source amount to swap (integer with respect to decimals)" example(10000000)
+// @Param recipient query string true "Address receiving the output tokens" example(0x1111111111111111111111111111111111111111)
+// @Param slippage_bps query int false "Slippage tolerance in basis points, default 50" example(50)
+// @Param deadline_seconds query int false "Seconds the transaction stays valid for, default 1200" example(1200)
+// @Success 200 {object} models.SwapBuildResponse
+// @Failure 400 {object} models.ErrorResponse
+// @Failure 500 {object} models.ErrorResponse
+// @Router /swap/build [get]
+func (h *Handler) BuildSwap(c echo.Context) error {
+	var req models.SwapBuildRequest
+
+	// Bind query parameters
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "invalid_request",
+			Message: "Failed to parse query parameters: " + err.Error(),
+		})
+	}
+
+	// Resolve ENS names and token aliases
+	resolved, err := h.resolveAddresses(c.Request().Context(), "", &req.Pool, &req.Src, &req.Dst)
+	if err != nil {
+		return resolveErrorOr(c, err)
+	}
+
+	// Validate request
+	if err := h.validate(&req); err != nil {
+		return validationError(c, err)
+	}
+
+	deadline := time.Now().Add(time.Duration(req.DeadlineSeconds) * time.Second)
+	build, err := h.uniswapService.BuildSwap(
+		c.Request().Context(),
+		req.Pool,
+		req.Src,
+		req.Dst,
+		req.SrcAmount,
+		req.SlippageBps,
+		req.Recipient,
+		uint64(deadline.Unix()),
+	)
+	if errors.Is(err, usecase.ErrRoutingDisabled) || errors.Is(err, usecase.ErrNoRoute) {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "no_route",
+			Message: "Failed to find a route: " + err.Error(),
+		})
+	}
+	if errors.Is(err, usecase.ErrNotRouterPool) {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "unsupported_pool",
+			Message: err.Error(),
+		})
+	}
+	if errors.Is(err, uniswap_v2.ErrNativeToNative) {
+		return c.JSON(http.StatusBadRequest, models.ErrorResponse{
+			Error:   "validation_error",
+			Message: err.Error(),
+		})
+	}
+	if err != nil {
+		return blockErrorOr(c, err)
+	}
+
+	resp := models.SwapBuildResponse{
+		To:           build.Tx.To.Hex(),
+		Method:       build.Tx.Method,
+		Calldata:     hexutil.Encode(build.Tx.Data),
+		Value:        build.Tx.Value.String(),
+		SrcAmount:    build.SrcAmount.String(),
+		DstAmount:    build.DstAmount.String(),
+		AmountOutMin: build.AmountOutMin.String(),
+		Deadline:     build.Deadline,
+		Pools:        make([]string, len(build.Pools)),
+		Path:         make([]string, len(build.Path)),
+		FeeBps:       build.FeeBps,
+		BlockInfo:    blockInfo(build.Block),
+		PriceInfo:    priceInfo(build.PriceInfo),
+		TransferTax:  transferTax(build.TransferTaxes),
+		Resolved:     resolved,
+	}
+	for i, pool := range build.Pools {
+		resp.Pools[i] = pool.Hex()
+	}
+	for i, token := range build.Path {
+		resp.Path[i] = token.Hex()
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
@@ -3,22 +3,228 @@ package models
 import (
 	"errors"
 	"fmt"
+	"math/big"
 	"regexp"
-	"strconv"
 	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
 )
 
 // EstimateRequest represents the request parameters for the /estimate endpoint
 type EstimateRequest struct {
-	Pool      string `query:"pool" validate:"required" example:"0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852"`
+	Pool      string `query:"pool" example:"0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852"`
 	Src       string `query:"src" validate:"required" example:"0xdAC17F958D2ee523a2206206994597C13D831ec7"`
 	Dst       string `query:"dst" validate:"required" example:"0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"`
 	SrcAmount string `query:"src_amount" validate:"required" example:"10000000"`
+	Block     string `query:"block" example:"latest"`
+	// SlippageBps adds the minimum accepted output with this slippage tolerance to the response when set
+	SlippageBps int `query:"slippage_bps" example:"50"`
+	// Protocol is the protocol of the pool, detected from the pool contract when empty
+	Protocol string `query:"protocol" example:"v2"`
+	// AmountFormat is the format of SrcAmount, base units when empty
+	AmountFormat string `query:"amount_format" example:"decimal"`
 }
 
+// Supported values of EstimateRequest.Protocol
+const (
+	ProtocolV2       = "v2"
+	ProtocolV3       = "v3"
+	ProtocolCurve    = "curve"
+	ProtocolBalancer = "balancer"
+)
+
+// Supported values of EstimateRequest.AmountFormat
+const (
+	// AmountFormatBase is an integer amount in base units of the token
+	AmountFormatBase = "base"
+	// AmountFormatDecimal is a decimal amount in whole tokens, such as 1.5, converted with the token's decimals
+	AmountFormatDecimal = "decimal"
+)
+
 // EstimateResponse represents the response for the /estimate endpoint
 type EstimateResponse struct {
 	DstAmount string `json:"dst_amount" example:"6241000000000000"`
+	// DstAmountDecimal is dst_amount in whole tokens, set when the decimals of dst are known
+	DstAmountDecimal string `json:"dst_amount_decimal,omitempty" example:"0.006241"`
+	// MinDstAmount is dst_amount minus the requested slippage tolerance, rounded down
+	MinDstAmount string `json:"min_dst_amount,omitempty" example:"6209795000000000"`
+	Route        *Route `json:"route,omitempty"`
+	// FeeBps is the swap fee of the pool, set when a pool is given
+	FeeBps *uint32 `json:"fee_bps,omitempty" example:"30"`
+	BlockInfo
+	// BlockTimestampLast is the pool's _blockTimestampLast from getReserves
+	BlockTimestampLast uint32 `json:"block_timestamp_last,omitempty" example:"1699999991"`
+	PriceInfo
+	// TransferTax is set when a fee-on-transfer tax was applied to the estimate
+	TransferTax *TransferTax `json:"transfer_tax,omitempty"`
+	// SrcToken and DstToken are the metadata of the tokens, omitted when unavailable
+	SrcToken *TokenInfo `json:"src_token,omitempty"`
+	DstToken *TokenInfo `json:"dst_token,omitempty"`
+	// Resolved has the addresses of the parameters given as ENS names or token aliases
+	Resolved *ResolvedAddresses `json:"resolved,omitempty"`
+}
+
+// ResolvedAddresses are the addresses ENS names and token aliases of a request resolved to, empty for parameters
+// given as addresses
+type ResolvedAddresses struct {
+	Pool string `json:"pool,omitempty" example:"0x0d4a11d5EEaaC28EC3F61d100daF4d40471f1852"`
+	Src  string `json:"src,omitempty" example:"0xdAC17F958D2ee523a2206206994597C13D831ec7"`
+	Dst  string `json:"dst,omitempty" example:"0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"`
+}
+
+// TokenInfo is the ERC-20 metadata of a token
+type TokenInfo struct {
+	Address string `json:"address" example:"0xdAC17F958D2ee523a2206206994597C13D831ec7"`
+	// Symbol and Name are empty when the token does not implement them
+	Symbol   string `json:"symbol" example:"USDT"`
+	Name     string `json:"name" example:"Tether USD"`
+	Decimals uint8  `json:"decimals" example:"6"`
+}
+
+// TransferTax describes the taxes of fee-on-transfer tokens applied to an estimate
+type TransferTax struct {
+	// SrcTaxBps is taken from src_amount when it is sent into the first pool
+	SrcTaxBps uint32 `json:"src_tax_bps" example:"500"`
+	// DstTaxBps is taken from the output when it is sent by the last pool
+	DstTaxBps uint32 `json:"dst_tax_bps" example:"0"`
+	// PathTaxed is set when a token passed between two pools of a multi-hop swap is taxed
+	PathTaxed bool `json:"path_taxed,omitempty" example:"false"`
+}
+
+// PriceInfo describes the prices of a swap in dst base units per src base unit
+type PriceInfo struct {
+	// SpotPrice is the mid price given by the pool reserves before the swap
+	SpotPrice string `json:"spot_price,omitempty" example:"399088660.1"`
+	// ExecutionPrice is the effective price of the swap, dst_amount / src_amount
+	ExecutionPrice string `json:"execution_price,omitempty" example:"397886602.827953"`
+	// PriceImpactBps is how much worse the execution price is than the spot price in basis points, including the swap fee
+	PriceImpactBps string `json:"price_impact_bps,omitempty" example:"30.12"`
+}
+
+// BlockInfo identifies the block an estimate was calculated at
+type BlockInfo struct {
+	BlockNumber uint64 `json:"block_number,omitempty" example:"18500000"`
+	BlockHash   string `json:"block_hash,omitempty" example:"0x9e3d4a1b6c0f4b0b1c3e2f5d8a7b6c5d4e3f2a1b0c9d8e7f6a5b4c3d2e1f0a9b"`
+}
+
+// Route describes the pools a swap is routed through when no pool is given
+type Route struct {
+	Pools   []string `json:"pools" example:"0x0d4a11d5EEaaC28EC3F61d100daF4d40471f1852"`
+	Path    []string `json:"path" example:"0xdAC17F958D2ee523a2206206994597C13D831ec7,0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"`
+	Amounts []string `json:"amounts" example:"10000000,6241000000000000"`
+	// FeeBps has the swap fee of every pool
+	FeeBps []uint32 `json:"fee_bps" example:"30"`
+}
+
+// EstimateInRequest represents the request parameters for the /estimate/in endpoint
+type EstimateInRequest struct {
+	Pool      string `query:"pool" validate:"required" example:"0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852"`
+	Src       string `query:"src" validate:"required" example:"0xdAC17F958D2ee523a2206206994597C13D831ec7"`
+	Dst       string `query:"dst" validate:"required" example:"0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"`
+	DstAmount string `query:"dst_amount" validate:"required" example:"6241000000000000"`
+	Block     string `query:"block" example:"latest"`
+}
+
+// EstimateInResponse represents the response for the /estimate/in endpoint
+type EstimateInResponse struct {
+	SrcAmount string `json:"src_amount" example:"10000000"`
+	FeeBps    uint32 `json:"fee_bps" example:"30"`
+	BlockInfo
+	// TransferTax is set when a fee-on-transfer tax was applied to the estimate
+	TransferTax *TransferTax `json:"transfer_tax,omitempty"`
+	// Resolved has the addresses of the parameters given as ENS names or token aliases
+	Resolved *ResolvedAddresses `json:"resolved,omitempty"`
+}
+
+// EstimatePathRequest represents the request parameters for the /estimate/path endpoint
+type EstimatePathRequest struct {
+	Pools     string `query:"pools" validate:"required" example:"0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852,0xa478c2975ab1ea89e8196811f51a7b7ade33eb11"`
+	Path      string `query:"path" validate:"required" example:"0xdAC17F958D2ee523a2206206994597C13D831ec7,0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2,0x6B175474E89094C44Da98b954EedeAC495271d0F"`
+	SrcAmount string `query:"src_amount" validate:"required" example:"10000000"`
+	Block     string `query:"block" example:"latest"`
+}
+
+// EstimatePathResponse represents the response for the /estimate/path endpoint
+type EstimatePathResponse struct {
+	Amounts   []string `json:"amounts" example:"10000000,3978866028279530,9950000000000000000"`
+	DstAmount string   `json:"dst_amount" example:"9950000000000000000"`
+	// FeeBps has the swap fee of every pool
+	FeeBps []uint32 `json:"fee_bps" example:"30,30"`
+	BlockInfo
+	// TransferTax is set when a fee-on-transfer tax was applied to the estimate
+	TransferTax *TransferTax `json:"transfer_tax,omitempty"`
+}
+
+// EstimateSplitRequest represents the request parameters for the /estimate/split endpoint
+type EstimateSplitRequest struct {
+	Pools     string `query:"pools" validate:"required" example:"0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852,0x06da0fd433c1a5d7a4faa01111c044910a184553"`
+	Src       string `query:"src" validate:"required" example:"0xdAC17F958D2ee523a2206206994597C13D831ec7"`
+	Dst       string `query:"dst" validate:"required" example:"0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"`
+	SrcAmount string `query:"src_amount" validate:"required" example:"100000000000"`
+	Parts     int    `query:"parts" example:"20"`
+	Block     string `query:"block" example:"latest"`
+}
+
+// EstimateSplitResponse represents the response for the /estimate/split endpoint
+type EstimateSplitResponse struct {
+	DstAmount   string            `json:"dst_amount" example:"39711870000000000000"`
+	Allocations []SplitAllocation `json:"allocations"`
+	BlockInfo
+	// Resolved has the addresses of src and dst when they were given as ENS names or token aliases
+	Resolved *ResolvedAddresses `json:"resolved,omitempty"`
+}
+
+// SplitAllocation represents the part of a split order routed through a single pool
+type SplitAllocation struct {
+	Pool      string `json:"pool" example:"0x0d4a11d5EEaaC28EC3F61d100daF4d40471f1852"`
+	SrcAmount string `json:"src_amount" example:"60000000000"`
+	DstAmount string `json:"dst_amount" example:"23870000000000000000"`
+	FeeBps    uint32 `json:"fee_bps" example:"30"`
+	// TransferTax is set when a fee-on-transfer tax was applied to the allocation
+	TransferTax *TransferTax `json:"transfer_tax,omitempty"`
+}
+
+// EstimateStreamRequest represents the request parameters for the /estimate/stream endpoint
+type EstimateStreamRequest struct {
+	Pool      string `query:"pool" validate:"required" example:"0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852"`
+	Src       string `query:"src" validate:"required" example:"0xdAC17F958D2ee523a2206206994597C13D831ec7"`
+	Dst       string `query:"dst" validate:"required" example:"0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2"`
+	SrcAmount string `query:"src_amount" validate:"required" example:"10000000"`
+}
+
+// SwapBuildRequest represents the request parameters for the /swap/build endpoint
+type SwapBuildRequest struct {
+	Pool            string `query:"pool" example:"0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852"`
+	Src             string `query:"src" validate:"required" example:"0xdAC17F958D2ee523a2206206994597C13D831ec7"`
+	Dst             string `query:"dst" validate:"required" example:"0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE"`
+	SrcAmount       string `query:"src_amount" validate:"required" example:"10000000"`
+	Recipient       string `query:"recipient" validate:"required" example:"0x1111111111111111111111111111111111111111"`
+	SlippageBps     int    `query:"slippage_bps" example:"50"`
+	DeadlineSeconds int    `query:"deadline_seconds" example:"1200"`
+}
+
+// SwapBuildResponse represents the response for the /swap/build endpoint
+type SwapBuildResponse struct {
+	// To is the router the transaction must be sent to
+	To       string `json:"to" example:"0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D"`
+	Method   string `json:"method" example:"swapExactTokensForETH"`
+	Calldata string `json:"calldata" example:"0x18cbafe5..."`
+	// Value is the ETH sent with the transaction in wei
+	Value        string   `json:"value" example:"0"`
+	SrcAmount    string   `json:"src_amount" example:"10000000"`
+	DstAmount    string   `json:"dst_amount" example:"3978866028279530"`
+	AmountOutMin string   `json:"amount_out_min" example:"3958971698138132"`
+	Deadline     uint64   `json:"deadline" example:"1700001200"`
+	Pools        []string `json:"pools" example:"0x0d4a11d5EEaaC28EC3F61d100daF4d40471f1852"`
+	Path         []string `json:"path" example:"0xdAC17F958D2ee523a2206206994597C13D831ec7,0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"`
+	// FeeBps has the swap fee of every pool
+	FeeBps []uint32 `json:"fee_bps" example:"30"`
+	BlockInfo
+	PriceInfo
+	// TransferTax is set when a fee-on-transfer tax was applied, the swap then uses the router's fee-on-transfer method
+	TransferTax *TransferTax `json:"transfer_tax,omitempty"`
+	// Resolved has the addresses of the parameters given as ENS names or token aliases
+	Resolved *ResolvedAddresses `json:"resolved,omitempty"`
 }
 
 // ErrorResponse represents an error response
@@ -27,29 +233,379 @@ type ErrorResponse struct {
 	Message string `json:"message"`
 }
 
-// Validate validates the EstimateRequest
+// Validate validates the EstimateRequest. The pool is optional: without it the best route is searched.
 func (r *EstimateRequest) Validate() error {
+	if r.Pool != "" {
+		if err := validateAddress(r.Pool); err != nil {
+			return fmt.Errorf("invalid pool address: %w", err)
+		}
+	}
+	if err := validateAddress(r.Src); err != nil {
+		return fmt.Errorf("invalid src address: %w", err)
+	}
+	if err := validateAddress(r.Dst); err != nil {
+		return fmt.Errorf("invalid dst address: %w", err)
+	}
+	if err := ValidateBlock(r.Block); err != nil {
+		return errors.New("invalid block: " + err.Error())
+	}
+	if err := validateSlippage(r.SlippageBps); err != nil {
+		return err
+	}
+	switch r.Protocol {
+	case "", ProtocolV2:
+	case ProtocolV3, ProtocolCurve, ProtocolBalancer:
+		if r.Pool == "" {
+			return fmt.Errorf("pool is required for protocol %s", r.Protocol)
+		}
+	default:
+		return fmt.Errorf("invalid protocol %q, expected %s, %s, %s or %s", r.Protocol, ProtocolV2, ProtocolV3, ProtocolCurve, ProtocolBalancer)
+	}
+
+	switch r.AmountFormat {
+	case "", AmountFormatBase:
+		return ValidateAmount(r.SrcAmount)
+	case AmountFormatDecimal:
+		return validateDecimalAmount(r.SrcAmount)
+	default:
+		return fmt.Errorf("invalid amount_format %q, expected %s or %s", r.AmountFormat, AmountFormatBase, AmountFormatDecimal)
+	}
+}
+
+// addresses returns the addresses of the EstimateRequest
+func (r *EstimateRequest) addresses() []namedAddress {
+	return []namedAddress{
+		{"pool address", r.Pool},
+		{"src address", r.Src},
+		{"dst address", r.Dst},
+	}
+}
+
+// Validate validates the EstimateInRequest
+func (r *EstimateInRequest) Validate() error {
 	if err := validateAddress(r.Pool); err != nil {
-		return errors.New("invalid pool address: " + err.Error())
+		return fmt.Errorf("invalid pool address: %w", err)
 	}
 	if err := validateAddress(r.Src); err != nil {
-		return errors.New("invalid src address: " + err.Error())
+		return fmt.Errorf("invalid src address: %w", err)
 	}
 	if err := validateAddress(r.Dst); err != nil {
-		return errors.New("invalid dst address: " + err.Error())
+		return fmt.Errorf("invalid dst address: %w", err)
+	}
+
+	if err := ValidateBlock(r.Block); err != nil {
+		return errors.New("invalid block: " + err.Error())
+	}
+
+	return ValidateAmount(r.DstAmount)
+}
+
+// addresses returns the addresses of the EstimateInRequest
+func (r *EstimateInRequest) addresses() []namedAddress {
+	return []namedAddress{
+		{"pool address", r.Pool},
+		{"src address", r.Src},
+		{"dst address", r.Dst},
 	}
+}
 
-	amount, err := strconv.ParseUint(r.SrcAmount, 10, 64)
-	if err != nil {
-		return fmt.Errorf("invalid amount format: %s", r.SrcAmount)
+// Validate validates the EstimateStreamRequest
+func (r *EstimateStreamRequest) Validate() error {
+	if err := validateAddress(r.Pool); err != nil {
+		return fmt.Errorf("invalid pool address: %w", err)
 	}
-	if amount == 0 {
-		return fmt.Errorf("amount must be greater than 0")
+	if err := validateAddress(r.Src); err != nil {
+		return fmt.Errorf("invalid src address: %w", err)
+	}
+	if err := validateAddress(r.Dst); err != nil {
+		return fmt.Errorf("invalid dst address: %w", err)
+	}
+
+	return ValidateAmount(r.SrcAmount)
+}
+
+// addresses returns the addresses of the EstimateStreamRequest
+func (r *EstimateStreamRequest) addresses() []namedAddress {
+	return []namedAddress{
+		{"pool address", r.Pool},
+		{"src address", r.Src},
+		{"dst address", r.Dst},
+	}
+}
+
+const (
+	// DefaultSlippageBps is the slippage tolerance used by /swap/build when slippage_bps is not given
+	DefaultSlippageBps = 50
+	// MaxSlippageBps is the maximum slippage tolerance
+	MaxSlippageBps = 5000
+	// DefaultDeadlineSeconds is how long a built swap stays valid when deadline_seconds is not given
+	DefaultDeadlineSeconds = 1200
+	// MaxDeadlineSeconds is the maximum validity of a built swap
+	MaxDeadlineSeconds = 86400
+)
+
+// Validate validates the SwapBuildRequest and applies the default slippage and deadline
+func (r *SwapBuildRequest) Validate() error {
+	if r.Pool != "" {
+		if err := validateAddress(r.Pool); err != nil {
+			return fmt.Errorf("invalid pool address: %w", err)
+		}
+	}
+	if err := validateAddress(r.Src); err != nil {
+		return fmt.Errorf("invalid src address: %w", err)
+	}
+	if err := validateAddress(r.Dst); err != nil {
+		return fmt.Errorf("invalid dst address: %w", err)
+	}
+	if err := validateAddress(r.Recipient); err != nil {
+		return fmt.Errorf("invalid recipient address: %w", err)
+	}
+
+	if r.SlippageBps == 0 {
+		r.SlippageBps = DefaultSlippageBps
+	}
+	if err := validateSlippage(r.SlippageBps); err != nil {
+		return err
+	}
+	if r.DeadlineSeconds == 0 {
+		r.DeadlineSeconds = DefaultDeadlineSeconds
+	}
+	if r.DeadlineSeconds < 1 || r.DeadlineSeconds > MaxDeadlineSeconds {
+		return fmt.Errorf("deadline_seconds must be between 1 and %d", MaxDeadlineSeconds)
+	}
+
+	return ValidateAmount(r.SrcAmount)
+}
+
+// addresses returns the addresses of the SwapBuildRequest
+func (r *SwapBuildRequest) addresses() []namedAddress {
+	return []namedAddress{
+		{"pool address", r.Pool},
+		{"src address", r.Src},
+		{"dst address", r.Dst},
+		{"recipient address", r.Recipient},
+	}
+}
+
+// MaxPathHops is the maximum number of pools allowed in a multi-hop path
+const MaxPathHops = 4
+
+// PoolList returns the comma-separated pools as a slice
+func (r *EstimatePathRequest) PoolList() []string {
+	return splitList(r.Pools)
+}
+
+// TokenList returns the comma-separated path tokens as a slice
+func (r *EstimatePathRequest) TokenList() []string {
+	return splitList(r.Path)
+}
+
+// Validate validates the EstimatePathRequest
+func (r *EstimatePathRequest) Validate() error {
+	pools := r.PoolList()
+	tokens := r.TokenList()
+
+	if len(pools) == 0 {
+		return errors.New("pools cannot be empty")
+	}
+	if len(pools) > MaxPathHops {
+		return fmt.Errorf("too many hops: %d, maximum is %d", len(pools), MaxPathHops)
+	}
+	if len(tokens) != len(pools)+1 {
+		return fmt.Errorf("path must contain exactly %d tokens for %d pools, got %d", len(pools)+1, len(pools), len(tokens))
+	}
+
+	for i, pool := range pools {
+		if err := validateAddress(pool); err != nil {
+			return fmt.Errorf("invalid pool address at hop %d: %w", i, err)
+		}
+	}
+	for i, token := range tokens {
+		if err := validateAddress(token); err != nil {
+			return fmt.Errorf("invalid path token at position %d: %w", i, err)
+		}
+	}
+	if err := ValidateBlock(r.Block); err != nil {
+		return errors.New("invalid block: " + err.Error())
+	}
+
+	return ValidateAmount(r.SrcAmount)
+}
+
+// addresses returns the pools and path tokens of the EstimatePathRequest
+func (r *EstimatePathRequest) addresses() []namedAddress {
+	var addresses []namedAddress
+	for i, pool := range r.PoolList() {
+		addresses = append(addresses, namedAddress{fmt.Sprintf("pool address at hop %d", i), pool})
+	}
+	for i, token := range r.TokenList() {
+		addresses = append(addresses, namedAddress{fmt.Sprintf("path token at position %d", i), token})
+	}
+	return addresses
+}
+
+const (
+	// DefaultSplitParts is the split granularity used when parts is not given
+	DefaultSplitParts = 10
+	// MaxSplitParts is the maximum split granularity
+	MaxSplitParts = 100
+	// MaxSplitPools is the maximum number of pools an order can be split across
+	MaxSplitPools = 8
+)
+
+// PoolList returns the comma-separated pools as a slice
+func (r *EstimateSplitRequest) PoolList() []string {
+	return splitList(r.Pools)
+}
+
+// Validate validates the EstimateSplitRequest and applies the default granularity
+func (r *EstimateSplitRequest) Validate() error {
+	pools := r.PoolList()
+	if len(pools) == 0 {
+		return errors.New("pools cannot be empty")
+	}
+	if len(pools) > MaxSplitPools {
+		return fmt.Errorf("too many pools: %d, maximum is %d", len(pools), MaxSplitPools)
+	}
+	for i, pool := range pools {
+		if err := validateAddress(pool); err != nil {
+			return fmt.Errorf("invalid pool address at position %d: %w", i, err)
+		}
+	}
+	if err := validateAddress(r.Src); err != nil {
+		return fmt.Errorf("invalid src address: %w", err)
+	}
+	if err := validateAddress(r.Dst); err != nil {
+		return fmt.Errorf("invalid dst address: %w", err)
+	}
+
+	if r.Parts == 0 {
+		r.Parts = DefaultSplitParts
+	}
+	if r.Parts < 1 || r.Parts > MaxSplitParts {
+		return fmt.Errorf("parts must be between 1 and %d", MaxSplitParts)
+	}
+	if err := ValidateBlock(r.Block); err != nil {
+		return errors.New("invalid block: " + err.Error())
+	}
+
+	return ValidateAmount(r.SrcAmount)
+}
+
+// addresses returns the pools and tokens of the EstimateSplitRequest
+func (r *EstimateSplitRequest) addresses() []namedAddress {
+	var addresses []namedAddress
+	for i, pool := range r.PoolList() {
+		addresses = append(addresses, namedAddress{fmt.Sprintf("pool address at position %d", i), pool})
+	}
+	return append(addresses, namedAddress{"src address", r.Src}, namedAddress{"dst address", r.Dst})
+}
+
+// splitList splits a comma-separated list, trimming whitespace and dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Error codes of AmountError
+const (
+	AmountErrorNotDecimal  = "amount_not_decimal"
+	AmountErrorNegative    = "amount_negative"
+	AmountErrorLeadingZero = "amount_leading_zero"
+	AmountErrorZero        = "amount_zero"
+	AmountErrorOverflow    = "amount_overflow"
+)
+
+// AmountError is a validation error of a token amount, Code tells the client what is wrong with it
+type AmountError struct {
+	Code    string
+	Message string
+}
+
+func (e *AmountError) Error() string {
+	return e.Message
+}
+
+// maxUint256 is the largest amount a token can represent
+var maxUint256 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+
+// ValidateAmount validates a token amount given in base units: a decimal integer between 1 and 2^256-1 without sign
+// or leading zeros. Errors are *AmountError.
+func ValidateAmount(value string) error {
+	digits := strings.TrimPrefix(value, "-")
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return &AmountError{Code: AmountErrorNotDecimal, Message: fmt.Sprintf("invalid amount format: %s, expected a decimal integer", value)}
+	}
+	if digits != value {
+		return &AmountError{Code: AmountErrorNegative, Message: fmt.Sprintf("amount must not be negative: %s", value)}
+	}
+	if strings.Trim(value, "0") == "" {
+		return &AmountError{Code: AmountErrorZero, Message: "amount must be greater than 0"}
+	}
+	if value[0] == '0' {
+		return &AmountError{Code: AmountErrorLeadingZero, Message: fmt.Sprintf("amount must not have leading zeros: %s", value)}
+	}
+
+	amount, _ := new(big.Int).SetString(value, 10)
+	if amount.Cmp(maxUint256) > 0 {
+		return &AmountError{Code: AmountErrorOverflow, Message: fmt.Sprintf("amount %s overflows uint256", value)}
+	}
+
+	return nil
+}
+
+// validateDecimalAmount validates a token amount given in whole tokens like ValidateAmount, allowing a fractional part
+// such as 1.5. Its precision and range are checked against the token decimals once it is converted to base units.
+func validateDecimalAmount(value string) error {
+	unsigned := strings.TrimPrefix(value, "-")
+	whole, fraction, hasFraction := strings.Cut(unsigned, ".")
+	if whole == "" || strings.Trim(whole, "0123456789") != "" || hasFraction && (fraction == "" || strings.Trim(fraction, "0123456789") != "") {
+		return &AmountError{Code: AmountErrorNotDecimal, Message: fmt.Sprintf("invalid decimal amount format: %s", value)}
+	}
+	if unsigned != value {
+		return &AmountError{Code: AmountErrorNegative, Message: fmt.Sprintf("amount must not be negative: %s", value)}
+	}
+	if strings.Trim(whole+fraction, "0") == "" {
+		return &AmountError{Code: AmountErrorZero, Message: "amount must be greater than 0"}
+	}
+	if len(whole) > 1 && whole[0] == '0' {
+		return &AmountError{Code: AmountErrorLeadingZero, Message: fmt.Sprintf("amount must not have leading zeros: %s", value)}
 	}
 
 	return nil
 }
 
+// validateSlippage validates a slippage tolerance in basis points
+func validateSlippage(slippageBps int) error {
+	if slippageBps < 0 || slippageBps > MaxSlippageBps {
+		return fmt.Errorf("slippage_bps must be between 0 and %d", MaxSlippageBps)
+	}
+	return nil
+}
+
+// blockHashRegexp matches a 0x-prefixed 32-byte block hash
+var blockHashRegexp = regexp.MustCompile("^0x[0-9a-fA-F]{64}$")
+
+// blockNumberRegexp matches a decimal or 0x-prefixed hex block number
+var blockNumberRegexp = regexp.MustCompile("^([0-9]{1,19}|0x[0-9a-fA-F]{1,15})$")
+
+// ValidateBlock validates an optional block number, hash or tag
+func ValidateBlock(block string) error {
+	switch block {
+	case "", "latest", "safe", "finalized":
+		return nil
+	}
+	if blockHashRegexp.MatchString(block) || blockNumberRegexp.MatchString(block) {
+		return nil
+	}
+	return errors.New("block must be a number, a block hash or one of latest, safe, finalized")
+}
+
 // validateAddress validates Ethereum address format
 func validateAddress(address string) error {
 	if address == "" {
@@ -72,3 +628,44 @@ func validateAddress(address string) error {
 
 	return nil
 }
+
+// ErrInvalidChecksum is returned by ValidateChecksum for a mixed-case address that fails its EIP-55 checksum
+var ErrInvalidChecksum = errors.New("mixed-case address does not match its EIP-55 checksum")
+
+// ValidateChecksum validates the EIP-55 checksum of a well-formed address. All-lowercase and all-uppercase addresses
+// carry no checksum and are accepted, a mixed-case address must be checksummed, which catches most typos.
+func ValidateChecksum(address string) error {
+	addr := strings.TrimPrefix(address, "0x")
+	if strings.ToLower(addr) == addr || strings.ToUpper(addr) == addr {
+		return nil
+	}
+	if common.HexToAddress(addr).Hex()[2:] != addr {
+		return fmt.Errorf("%w: %s", ErrInvalidChecksum, address)
+	}
+	return nil
+}
+
+// namedAddress is an address of a request with the name it is reported under
+type namedAddress struct {
+	name    string
+	address string
+}
+
+// Request is a bound request with addresses
+type Request interface {
+	// Validate validates the request
+	Validate() error
+	// addresses returns the addresses of the request, empty when optional and not given
+	addresses() []namedAddress
+}
+
+// ValidateChecksums validates the EIP-55 checksums of the addresses of a valid request, empty optional addresses
+// are skipped
+func ValidateChecksums(req Request) error {
+	for _, a := range req.addresses() {
+		if err := ValidateChecksum(a.address); err != nil {
+			return fmt.Errorf("invalid %s: %w", a.name, err)
+		}
+	}
+	return nil
+}
@@ -34,15 +34,124 @@ func TestEstimateRequest_Validate(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name: "empty pool address",
+			name: "empty pool address searches for a route",
 			request: EstimateRequest{
 				Pool:      "",
 				Src:       "0xdAC17F958D2ee523a2206206994597C13D831ec7",
 				Dst:       "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
 				SrcAmount: "10000000",
 			},
+			wantErr: false,
+		},
+		{
+			name: "v3 pool",
+			request: EstimateRequest{
+				Pool:      "0x4e68Ccd3E89f51C3074ca5072bbAC773960dFa36",
+				Src:       "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+				Dst:       "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+				SrcAmount: "10000000",
+				Protocol:  "v3",
+			},
+			wantErr: false,
+		},
+		{
+			name: "v3 without pool",
+			request: EstimateRequest{
+				Src:       "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+				Dst:       "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+				SrcAmount: "10000000",
+				Protocol:  "v3",
+			},
 			wantErr: true,
-			errMsg:  "invalid pool address",
+			errMsg:  "pool is required for protocol v3",
+		},
+		{
+			name: "curve without pool",
+			request: EstimateRequest{
+				Src:       "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+				Dst:       "0x6B175474E89094C44Da98b954EedeAC495271d0F",
+				SrcAmount: "10000000",
+				Protocol:  "curve",
+			},
+			wantErr: true,
+			errMsg:  "pool is required for protocol curve",
+		},
+		{
+			name: "decimal amount",
+			request: EstimateRequest{
+				Src:          "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+				Dst:          "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+				SrcAmount:    "1.5",
+				AmountFormat: "decimal",
+			},
+			wantErr: false,
+		},
+		{
+			name: "decimal amount in base format",
+			request: EstimateRequest{
+				Src:          "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+				Dst:          "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+				SrcAmount:    "1.5",
+				AmountFormat: "base",
+			},
+			wantErr: true,
+			errMsg:  "invalid amount format: 1.5",
+		},
+		{
+			name: "decimal amount with exponent",
+			request: EstimateRequest{
+				Src:          "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+				Dst:          "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+				SrcAmount:    "1.5e6",
+				AmountFormat: "decimal",
+			},
+			wantErr: true,
+			errMsg:  "invalid decimal amount format: 1.5e6",
+		},
+		{
+			name: "zero decimal amount",
+			request: EstimateRequest{
+				Src:          "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+				Dst:          "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+				SrcAmount:    "0.000",
+				AmountFormat: "decimal",
+			},
+			wantErr: true,
+			errMsg:  "amount must be greater than 0",
+		},
+		{
+			name: "unknown amount format",
+			request: EstimateRequest{
+				Src:          "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+				Dst:          "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+				SrcAmount:    "1.5",
+				AmountFormat: "ether",
+			},
+			wantErr: true,
+			errMsg:  `invalid amount_format "ether", expected base or decimal`,
+		},
+		{
+			name: "balancer without pool",
+			request: EstimateRequest{
+				Src:       "0xba100000625a3754423978a60c9317c58a424e3D",
+				Dst:       "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+				SrcAmount: "1000000000000000000",
+				Protocol:  "balancer",
+			},
+			wantErr: true,
+			errMsg:  "pool is required for protocol balancer",
+		},
+		{
+			name: "unknown protocol",
+			request: EstimateRequest{
+				Pool:      "0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852",
+				Src:       "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+				Dst:       "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+				SrcAmount: "10000000",
+				Protocol:  "v4",
+			},
+			wantErr: true,
+			errMsg:  "invalid protocol",
 		},
 		{
 			name: "invalid pool address length",
@@ -66,6 +175,51 @@ func TestEstimateRequest_Validate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "invalid pool address",
 		},
+		{
+			name: "valid request at block tag",
+			request: EstimateRequest{
+				Pool:      "0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852",
+				Src:       "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+				Dst:       "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+				SrcAmount: "10000000",
+				Block:     "finalized",
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid request at block number",
+			request: EstimateRequest{
+				Pool:      "0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852",
+				Src:       "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+				Dst:       "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+				SrcAmount: "10000000",
+				Block:     "18500000",
+			},
+			wantErr: false,
+		},
+		{
+			name: "valid request at block hash",
+			request: EstimateRequest{
+				Pool:      "0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852",
+				Src:       "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+				Dst:       "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+				SrcAmount: "10000000",
+				Block:     "0x9e3d4a1b6c0f4b0b1c3e2f5d8a7b6c5d4e3f2a1b0c9d8e7f6a5b4c3d2e1f0a9b",
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid block",
+			request: EstimateRequest{
+				Pool:      "0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852",
+				Src:       "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+				Dst:       "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+				SrcAmount: "10000000",
+				Block:     "pending",
+			},
+			wantErr: true,
+			errMsg:  "invalid block",
+		},
 		{
 			name: "empty src amount",
 			request: EstimateRequest{
@@ -77,6 +231,29 @@ func TestEstimateRequest_Validate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "invalid amount format",
 		},
+		{
+			name: "valid slippage",
+			request: EstimateRequest{
+				Pool:        "0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852",
+				Src:         "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+				Dst:         "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+				SrcAmount:   "10000000",
+				SlippageBps: 50,
+			},
+			wantErr: false,
+		},
+		{
+			name: "slippage too high",
+			request: EstimateRequest{
+				Pool:        "0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852",
+				Src:         "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+				Dst:         "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+				SrcAmount:   "10000000",
+				SlippageBps: 5001,
+			},
+			wantErr: true,
+			errMsg:  "slippage_bps must be between 0 and 5000",
+		},
 	}
 
 	for _, tt := range tests {
@@ -92,6 +269,344 @@ func TestEstimateRequest_Validate(t *testing.T) {
 	}
 }
 
+func TestSwapBuildRequest_Validate(t *testing.T) {
+	valid := SwapBuildRequest{
+		Pool:      "0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852",
+		Src:       "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+		Dst:       "0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE",
+		SrcAmount: "10000000",
+		Recipient: "0x1111111111111111111111111111111111111111",
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		request := valid
+		assert.NoError(t, request.Validate())
+		assert.Equal(t, DefaultSlippageBps, request.SlippageBps)
+		assert.Equal(t, DefaultDeadlineSeconds, request.DeadlineSeconds)
+	})
+
+	tests := []struct {
+		name    string
+		modify  func(r *SwapBuildRequest)
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name:    "without pool uses the best route",
+			modify:  func(r *SwapBuildRequest) { r.Pool = "" },
+			wantErr: false,
+		},
+		{
+			name:    "missing recipient",
+			modify:  func(r *SwapBuildRequest) { r.Recipient = "" },
+			wantErr: true,
+			errMsg:  "invalid recipient address",
+		},
+		{
+			name:    "negative slippage",
+			modify:  func(r *SwapBuildRequest) { r.SlippageBps = -1 },
+			wantErr: true,
+			errMsg:  "slippage_bps must be between 0 and 5000",
+		},
+		{
+			name:    "deadline too far",
+			modify:  func(r *SwapBuildRequest) { r.DeadlineSeconds = 86401 },
+			wantErr: true,
+			errMsg:  "deadline_seconds must be between 1 and 86400",
+		},
+		{
+			name:    "zero src amount",
+			modify:  func(r *SwapBuildRequest) { r.SrcAmount = "0" },
+			wantErr: true,
+			errMsg:  "amount must be greater than 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request := valid
+			tt.modify(&request)
+			err := request.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestEstimateInRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		request EstimateInRequest
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name: "valid request",
+			request: EstimateInRequest{
+				Pool:      "0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852",
+				Src:       "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+				Dst:       "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+				DstAmount: "6241000000000000",
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid dst address",
+			request: EstimateInRequest{
+				Pool:      "0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852",
+				Src:       "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+				Dst:       "0x123",
+				DstAmount: "6241000000000000",
+			},
+			wantErr: true,
+			errMsg:  "invalid dst address",
+		},
+		{
+			name: "empty dst amount",
+			request: EstimateInRequest{
+				Pool:      "0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852",
+				Src:       "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+				Dst:       "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+				DstAmount: "",
+			},
+			wantErr: true,
+			errMsg:  "invalid amount format",
+		},
+		{
+			name: "invalid block",
+			request: EstimateInRequest{
+				Pool:      "0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852",
+				Src:       "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+				Dst:       "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+				DstAmount: "6241000000000000",
+				Block:     "0x",
+			},
+			wantErr: true,
+			errMsg:  "invalid block",
+		},
+		{
+			name: "zero dst amount",
+			request: EstimateInRequest{
+				Pool:      "0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852",
+				Src:       "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+				Dst:       "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+				DstAmount: "0",
+			},
+			wantErr: true,
+			errMsg:  "amount must be greater than 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.request.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestEstimateStreamRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		request EstimateStreamRequest
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name: "valid request",
+			request: EstimateStreamRequest{
+				Pool:      "0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852",
+				Src:       "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+				Dst:       "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+				SrcAmount: "10000000",
+			},
+			wantErr: false,
+		},
+		{
+			name: "pool is required",
+			request: EstimateStreamRequest{
+				Src:       "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+				Dst:       "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+				SrcAmount: "10000000",
+			},
+			wantErr: true,
+			errMsg:  "invalid pool address",
+		},
+		{
+			name: "zero src amount",
+			request: EstimateStreamRequest{
+				Pool:      "0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852",
+				Src:       "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+				Dst:       "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+				SrcAmount: "0",
+			},
+			wantErr: true,
+			errMsg:  "amount must be greater than 0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.request.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestEstimatePathRequest_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		request EstimatePathRequest
+		wantErr bool
+		errMsg  string
+	}{
+		{
+			name: "valid two hop request",
+			request: EstimatePathRequest{
+				Pools:     "0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852,0xa478c2975ab1ea89e8196811f51a7b7ade33eb11",
+				Path:      "0xdAC17F958D2ee523a2206206994597C13D831ec7, 0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2, 0x6B175474E89094C44Da98b954EedeAC495271d0F",
+				SrcAmount: "10000000",
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty pools",
+			request: EstimatePathRequest{
+				Pools:     "",
+				Path:      "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+				SrcAmount: "10000000",
+			},
+			wantErr: true,
+			errMsg:  "pools cannot be empty",
+		},
+		{
+			name: "path length mismatch",
+			request: EstimatePathRequest{
+				Pools:     "0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852",
+				Path:      "0xdAC17F958D2ee523a2206206994597C13D831ec7,0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2,0x6B175474E89094C44Da98b954EedeAC495271d0F",
+				SrcAmount: "10000000",
+			},
+			wantErr: true,
+			errMsg:  "path must contain exactly 2 tokens",
+		},
+		{
+			name: "too many hops",
+			request: EstimatePathRequest{
+				Pools:     "0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852,0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852,0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852,0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852,0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852",
+				Path:      "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+				SrcAmount: "10000000",
+			},
+			wantErr: true,
+			errMsg:  "too many hops",
+		},
+		{
+			name: "invalid token in path",
+			request: EstimatePathRequest{
+				Pools:     "0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852",
+				Path:      "0xdAC17F958D2ee523a2206206994597C13D831ec7,0x123",
+				SrcAmount: "10000000",
+			},
+			wantErr: true,
+			errMsg:  "invalid path token at position 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.request.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestEstimateSplitRequest_Validate(t *testing.T) {
+	t.Run("default parts", func(t *testing.T) {
+		req := EstimateSplitRequest{
+			Pools:     "0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852,0x06da0fd433c1a5d7a4faa01111c044910a184553",
+			Src:       "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+			Dst:       "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+			SrcAmount: "100000000000",
+		}
+		assert.NoError(t, req.Validate())
+		assert.Equal(t, DefaultSplitParts, req.Parts)
+	})
+
+	tests := []struct {
+		name    string
+		request EstimateSplitRequest
+		errMsg  string
+	}{
+		{
+			name: "empty pools",
+			request: EstimateSplitRequest{
+				Src:       "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+				Dst:       "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+				SrcAmount: "100000000000",
+			},
+			errMsg: "pools cannot be empty",
+		},
+		{
+			name: "too many parts",
+			request: EstimateSplitRequest{
+				Pools:     "0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852",
+				Src:       "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+				Dst:       "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+				SrcAmount: "100000000000",
+				Parts:     MaxSplitParts + 1,
+			},
+			errMsg: "parts must be between 1 and 100",
+		},
+		{
+			name: "negative parts",
+			request: EstimateSplitRequest{
+				Pools:     "0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852",
+				Src:       "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+				Dst:       "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+				SrcAmount: "100000000000",
+				Parts:     -1,
+			},
+			errMsg: "parts must be between 1 and 100",
+		},
+		{
+			name: "invalid pool",
+			request: EstimateSplitRequest{
+				Pools:     "0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852,0x12",
+				Src:       "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+				Dst:       "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+				SrcAmount: "100000000000",
+			},
+			errMsg: "invalid pool address at position 1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.request.Validate()
+			assert.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
 func TestValidateAddress(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -152,3 +667,113 @@ func TestValidateAddress(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateChecksum(t *testing.T) {
+	tests := []struct {
+		name    string
+		address string
+		wantErr bool
+	}{
+		{name: "checksummed", address: "0xdAC17F958D2ee523a2206206994597C13D831ec7"},
+		{name: "checksummed without 0x prefix", address: "dAC17F958D2ee523a2206206994597C13D831ec7"},
+		{name: "lowercase", address: "0xdac17f958d2ee523a2206206994597c13d831ec7"},
+		{name: "uppercase", address: "0xDAC17F958D2EE523A2206206994597C13D831EC7"},
+		{name: "digits only", address: "0x1111111111111111111111111111111111111111"},
+		{name: "wrong case", address: "0xDAC17F958D2ee523a2206206994597C13D831ec7", wantErr: true},
+		{name: "typo in checksummed address", address: "0xdAC17F958D2ee523a2206206994597C13D831ec8", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateChecksum(tt.address)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidChecksum)
+				assert.Contains(t, err.Error(), tt.address)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateChecksums(t *testing.T) {
+	typo := "0xdAC17F958D2ee523a2206206994597C13D831ec8"
+
+	estimate := EstimateRequest{Src: "0xdAC17F958D2ee523a2206206994597C13D831ec7", Dst: "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2", SrcAmount: "1"}
+	assert.NoError(t, ValidateChecksums(&estimate), "an empty pool is not checked")
+	estimate.Dst = typo
+	assert.ErrorIs(t, ValidateChecksums(&estimate), ErrInvalidChecksum)
+	assert.ErrorContains(t, ValidateChecksums(&estimate), "invalid dst address")
+
+	swap := SwapBuildRequest{Src: "0xdAC17F958D2ee523a2206206994597C13D831ec7", Dst: "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2", Recipient: typo}
+	assert.ErrorContains(t, ValidateChecksums(&swap), "invalid recipient address")
+
+	path := EstimatePathRequest{
+		Pools: "0x0d4a11d5EEaaC28EC3F61d100daF4d40471f1852",
+		Path:  "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2," + typo,
+	}
+	assert.ErrorContains(t, ValidateChecksums(&path), "invalid path token at position 1")
+
+	split := EstimateSplitRequest{
+		Pools: "0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852," + typo,
+		Src:   "0xdAC17F958D2ee523a2206206994597C13D831ec7",
+		Dst:   "0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2",
+	}
+	assert.ErrorContains(t, ValidateChecksums(&split), "invalid pool address at position 1")
+
+	// Validate accepts addresses with a wrong checksum, they are only rejected in strict mode
+	assert.NoError(t, (&EstimateStreamRequest{Pool: typo, Src: typo, Dst: typo, SrcAmount: "1"}).Validate())
+}
+
+func TestValidateAmount(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		decimal  bool
+		wantCode string
+	}{
+		{name: "above uint64", amount: "18446744073709551616"},
+		{name: "1M ETH in wei", amount: "1000000000000000000000000"},
+		{name: "max uint256", amount: "115792089237316195423570985008687907853269984665640564039457584007913129639935"},
+		{name: "above uint256", amount: "115792089237316195423570985008687907853269984665640564039457584007913129639936", wantCode: AmountErrorOverflow},
+		{name: "negative", amount: "-1000", wantCode: AmountErrorNegative},
+		{name: "negative zero", amount: "-0", wantCode: AmountErrorNegative},
+		{name: "leading zero", amount: "0100", wantCode: AmountErrorLeadingZero},
+		{name: "zero", amount: "0", wantCode: AmountErrorZero},
+		{name: "zeros", amount: "000", wantCode: AmountErrorZero},
+		{name: "empty", amount: "", wantCode: AmountErrorNotDecimal},
+		{name: "plus sign", amount: "+1000", wantCode: AmountErrorNotDecimal},
+		{name: "hex", amount: "0x3e8", wantCode: AmountErrorNotDecimal},
+		{name: "exponent", amount: "1e18", wantCode: AmountErrorNotDecimal},
+		{name: "fraction", amount: "1.5", wantCode: AmountErrorNotDecimal},
+		{name: "whitespace", amount: " 1000", wantCode: AmountErrorNotDecimal},
+		{name: "decimal", amount: "1.5", decimal: true},
+		{name: "decimal below one", amount: "0.000001", decimal: true},
+		{name: "decimal trailing zeros", amount: "1.500", decimal: true},
+		{name: "negative decimal", amount: "-1.5", decimal: true, wantCode: AmountErrorNegative},
+		{name: "decimal leading zero", amount: "01.5", decimal: true, wantCode: AmountErrorLeadingZero},
+		{name: "zero decimal", amount: "0.0", decimal: true, wantCode: AmountErrorZero},
+		{name: "decimal without whole part", amount: ".5", decimal: true, wantCode: AmountErrorNotDecimal},
+		{name: "decimal without fraction digits", amount: "1.", decimal: true, wantCode: AmountErrorNotDecimal},
+		{name: "decimal with exponent", amount: "1.5e6", decimal: true, wantCode: AmountErrorNotDecimal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validate := ValidateAmount
+			if tt.decimal {
+				validate = validateDecimalAmount
+			}
+
+			err := validate(tt.amount)
+			if tt.wantCode == "" {
+				assert.NoError(t, err)
+				return
+			}
+			var amountErr *AmountError
+			if assert.ErrorAs(t, err, &amountErr) {
+				assert.Equal(t, tt.wantCode, amountErr.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package pairindex
+
+import (
+	"1inch_testtask/internal/uniswap_v2"
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Finder is a uniswap_v2.IPairFinder answering from the index. Lookups the index cannot answer, such as
+// before the first sync or at blocks it has not reached, fall back to the factory.
+type Finder struct {
+	index    *Indexer
+	fallback uniswap_v2.IPairFinder
+}
+
+// NewFinder creates a finder reading index, fallback must query the factory of the index
+func NewFinder(index *Indexer, fallback uniswap_v2.IPairFinder) *Finder {
+	return &Finder{index: index, fallback: fallback}
+}
+
+// GetPair returns the pair of tokenA and tokenB at the block pinned in ctx, ErrPairNotFound when it is not deployed
+func (f *Finder) GetPair(ctx context.Context, tokenA, tokenB common.Address) (common.Address, error) {
+	pair, found, known := f.index.lookup(tokenA, tokenB, uniswap_v2.BlockNumberFromContext(ctx))
+	if !known {
+		return f.fallback.GetPair(ctx, tokenA, tokenB)
+	}
+	if !found {
+		return common.Address{}, uniswap_v2.ErrPairNotFound
+	}
+	return pair, nil
+}
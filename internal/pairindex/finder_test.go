@@ -0,0 +1,93 @@
+package pairindex
+
+import (
+	"1inch_testtask/internal/uniswap_v2"
+	"context"
+	"math/big"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingFinder is a fallback uniswap_v2.IPairFinder returning a fixed pair
+type countingFinder struct {
+	pair  common.Address
+	calls int
+}
+
+func (f *countingFinder) GetPair(context.Context, common.Address, common.Address) (common.Address, error) {
+	f.calls++
+	return f.pair, nil
+}
+
+func TestFinder_GetPair(t *testing.T) {
+	ctx := context.Background()
+	atBlock := func(block int64) context.Context {
+		return uniswap_v2.WithBlockNumber(ctx, big.NewInt(block))
+	}
+
+	chain := newFakeChain(t, 1000)
+	usdcWeth := chain.create(usdc, weth, 100)
+	wethUsdt := chain.create(weth, usdt, 600)
+
+	t.Run("before the first sync", func(t *testing.T) {
+		ix := newTestIndexer(t, chain, NewFileStore(filepath.Join(t.TempDir(), "pairs.json")), Config{FromBlock: 1, ChunkSize: 1000})
+		fallback := &countingFinder{pair: usdcWeth.Address}
+		finder := NewFinder(ix, fallback)
+
+		pair, err := finder.GetPair(ctx, weth, usdc)
+		require.NoError(t, err)
+		assert.Equal(t, usdcWeth.Address, pair)
+		assert.Equal(t, 1, fallback.calls)
+	})
+
+	t.Run("synced index", func(t *testing.T) {
+		ix := newTestIndexer(t, chain, NewFileStore(filepath.Join(t.TempDir(), "pairs.json")), Config{FromBlock: 1, ChunkSize: 1000, Confirmations: 10})
+		require.NoError(t, ix.Sync(ctx))
+		fallback := &countingFinder{}
+		finder := NewFinder(ix, fallback)
+
+		pair, err := finder.GetPair(ctx, weth, usdc)
+		require.NoError(t, err)
+		assert.Equal(t, usdcWeth.Address, pair)
+
+		pair, err = finder.GetPair(atBlock(700), usdt, weth)
+		require.NoError(t, err)
+		assert.Equal(t, wethUsdt.Address, pair)
+
+		// Created after the pinned block
+		_, err = finder.GetPair(atBlock(500), usdt, weth)
+		assert.ErrorIs(t, err, uniswap_v2.ErrPairNotFound)
+
+		_, err = finder.GetPair(atBlock(900), dai, weth)
+		assert.ErrorIs(t, err, uniswap_v2.ErrPairNotFound)
+		assert.Zero(t, fallback.calls)
+
+		// Unknown pairs at the latest block or past the indexed blocks may have been created since
+		_, err = finder.GetPair(ctx, dai, weth)
+		require.NoError(t, err)
+		_, err = finder.GetPair(atBlock(995), dai, weth)
+		require.NoError(t, err)
+		assert.Equal(t, 2, fallback.calls)
+	})
+
+	t.Run("enumerated pair without creation block", func(t *testing.T) {
+		ix := newTestIndexer(t, chain, NewFileStore(filepath.Join(t.TempDir(), "pairs.json")), Config{ChunkSize: 1000})
+		require.NoError(t, ix.Sync(ctx))
+		fallback := &countingFinder{pair: usdcWeth.Address}
+		finder := NewFinder(ix, fallback)
+
+		pair, err := finder.GetPair(atBlock(1000), usdc, weth)
+		require.NoError(t, err)
+		assert.Equal(t, usdcWeth.Address, pair)
+		assert.Zero(t, fallback.calls)
+
+		// Whether it existed before the enumeration block is unknown
+		_, err = finder.GetPair(atBlock(50), usdc, weth)
+		require.NoError(t, err)
+		assert.Equal(t, 1, fallback.calls)
+	})
+}
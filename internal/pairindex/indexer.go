@@ -0,0 +1,487 @@
+package pairindex
+
+import (
+	"1inch_testtask/internal/uniswap_v2"
+	"context"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	// batchSize is how many pairs are enumerated per Multicall3 call
+	batchSize = 500
+	// saveInterval is how often progress is persisted while syncing, the state is also saved when a sync ends
+	saveInterval = 30 * time.Second
+)
+
+// Backend is the part of an Ethereum node API the indexer uses, implemented by ethclient.Client
+type Backend interface {
+	bind.ContractCaller
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error)
+}
+
+// Config configures the indexer
+type Config struct {
+	// Factory is the Uniswap V2 factory whose pairs are indexed
+	Factory common.Address
+	// Multicall is the Multicall3 contract enumeration is batched through
+	Multicall common.Address
+	// FromBlock is the first block scanned for PairCreated logs, usually the factory deployment block.
+	// Zero skips the history: pairs enumerated on the first sync keep an unknown creation block.
+	FromBlock uint64
+	// ChunkSize is the maximum number of blocks per eth_getLogs request
+	ChunkSize uint64
+	// Confirmations is how many blocks behind the head the indexer stays, so reorged logs are never indexed
+	Confirmations uint64
+	// PollInterval is the delay between syncs in Run
+	PollInterval time.Duration
+}
+
+// Status describes the progress of the indexer
+type Status struct {
+	Pairs     int    `json:"pairs"`
+	LastBlock uint64 `json:"last_block"`
+	// TargetBlock is the confirmed block the last sync indexed up to
+	TargetBlock uint64 `json:"target_block"`
+	Synced      bool   `json:"synced"`
+}
+
+// contractCall is a factory or pair method call batched through Multicall3
+type contractCall struct {
+	target common.Address
+	method string
+	args   []interface{}
+}
+
+// Indexer keeps a local index of every pair of a Uniswap V2 factory.
+// Existing pairs are enumerated once with allPairsLength/allPairs, then PairCreated logs are scanned in chunks
+// to record creation blocks and pick up new pairs. Progress is persisted so a restarted indexer resumes where it stopped.
+type Indexer struct {
+	backend   Backend
+	store     Store
+	cfg       Config
+	multicall *uniswap_v2.Multicall3
+	// contractABI holds the factory methods, the PairCreated event and token0/token1 of pairs
+	contractABI abi.ABI
+	now         func() time.Time
+
+	// chunk is the current eth_getLogs range, halved on errors and grown back after successes
+	chunk    uint64
+	lastSave time.Time
+
+	mu        sync.RWMutex
+	state     State
+	target    uint64
+	byAddress map[common.Address]int
+	byToken   map[common.Address][]int
+}
+
+// NewIndexer creates an indexer resuming from the state in store. Call Run to start indexing.
+func NewIndexer(backend Backend, store Store, cfg Config) (*Indexer, error) {
+	if cfg.ChunkSize == 0 {
+		return nil, fmt.Errorf("pair index chunk size must be positive")
+	}
+
+	contractABI, err := abi.JSON(strings.NewReader(uniswap_v2.UniswapV2FactoryABI))
+	if err != nil {
+		return nil, err
+	}
+	pairABI, err := abi.JSON(strings.NewReader(uniswap_v2.UniswapV2PairABI))
+	if err != nil {
+		return nil, err
+	}
+	contractABI.Methods["token0"] = pairABI.Methods["token0"]
+	contractABI.Methods["token1"] = pairABI.Methods["token1"]
+
+	multicall, err := uniswap_v2.NewMulticall3(backend, cfg.Multicall)
+	if err != nil {
+		return nil, err
+	}
+
+	ix := &Indexer{
+		backend:     backend,
+		store:       store,
+		cfg:         cfg,
+		multicall:   multicall,
+		contractABI: contractABI,
+		now:         time.Now,
+		chunk:       cfg.ChunkSize,
+		state:       State{Factory: cfg.Factory},
+		byAddress:   make(map[common.Address]int),
+		byToken:     make(map[common.Address][]int),
+	}
+
+	state, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	if state != nil {
+		if state.Factory != cfg.Factory {
+			return nil, fmt.Errorf("pair index belongs to factory %s, not %s", state.Factory.Hex(), cfg.Factory.Hex())
+		}
+		pairs := state.Pairs
+		state.Pairs = nil
+		ix.state = *state
+		for _, pair := range pairs {
+			ix.addPair(pair)
+		}
+	}
+
+	return ix, nil
+}
+
+// Run syncs the index every PollInterval until ctx is cancelled
+func (ix *Indexer) Run(ctx context.Context) {
+	for {
+		if err := ix.Sync(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("Pair index sync failed, retrying in %s: %v", ix.cfg.PollInterval, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(ix.cfg.PollInterval):
+		}
+	}
+}
+
+// Sync indexes the pairs up to the latest confirmed block
+func (ix *Indexer) Sync(ctx context.Context) (err error) {
+	header, err := ix.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to get head block: %w", err)
+	}
+	head := header.Number.Uint64()
+	if head < ix.cfg.Confirmations {
+		return nil
+	}
+	target := head - ix.cfg.Confirmations
+
+	// Keep whatever was indexed before a failure
+	defer func() {
+		if saveErr := ix.save(); err == nil {
+			err = saveErr
+		}
+	}()
+
+	if ix.state.EnumeratedAt == 0 {
+		if err := ix.start(ctx, target); err != nil {
+			return err
+		}
+	}
+	if err := ix.enumerate(ctx); err != nil {
+		return err
+	}
+
+	ix.mu.Lock()
+	ix.target = target
+	ix.mu.Unlock()
+
+	return ix.scan(ctx, target)
+}
+
+// Status returns the progress of the indexer
+func (ix *Indexer) Status() Status {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	return Status{
+		Pairs:       len(ix.state.Pairs),
+		LastBlock:   ix.state.LastBlock,
+		TargetBlock: ix.target,
+		Synced:      ix.enumerated() && ix.target > 0 && ix.state.LastBlock >= ix.target,
+	}
+}
+
+// Pairs returns every indexed pair ordered by factory index
+func (ix *Indexer) Pairs() []Pair {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	return append([]Pair(nil), ix.state.Pairs...)
+}
+
+// Pair returns the indexed pair at address
+func (ix *Indexer) Pair(address common.Address) (Pair, bool) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	i, ok := ix.byAddress[address]
+	if !ok {
+		return Pair{}, false
+	}
+	return ix.state.Pairs[i], true
+}
+
+// PairsOf returns the indexed pairs trading token
+func (ix *Indexer) PairsOf(token common.Address) []Pair {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	positions := ix.byToken[token]
+	pairs := make([]Pair, len(positions))
+	for i, position := range positions {
+		pairs[i] = ix.state.Pairs[position]
+	}
+	return pairs
+}
+
+// lookup returns the pair of tokenA and tokenB deployed at block, nil for the latest block.
+// known is false when the index does not tell whether the pair existed at block.
+func (ix *Indexer) lookup(tokenA, tokenB common.Address, block *big.Int) (pair common.Address, found, known bool) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	if !ix.enumerated() {
+		return common.Address{}, false, false
+	}
+
+	token0, token1 := uniswap_v2.SortTokens(tokenA, tokenB)
+	for _, position := range ix.byToken[token0] {
+		indexed := ix.state.Pairs[position]
+		if indexed.Token0 != token0 || indexed.Token1 != token1 {
+			continue
+		}
+		switch {
+		case block == nil:
+			// Pairs are never removed
+			return indexed.Address, true, true
+		case indexed.Block != 0:
+			if indexed.Block <= block.Uint64() {
+				return indexed.Address, true, true
+			}
+			return common.Address{}, false, true
+		case block.Uint64() >= ix.state.EnumeratedAt:
+			return indexed.Address, true, true
+		default:
+			// Enumerated pair of unknown creation block
+			return common.Address{}, false, false
+		}
+	}
+
+	// Every pair created up to the enumeration block and the last scanned block is indexed
+	if block != nil && block.Uint64() <= max(ix.state.EnumeratedAt, ix.state.LastBlock) {
+		return common.Address{}, false, true
+	}
+	return common.Address{}, false, false
+}
+
+// start fixes the block existing pairs are enumerated at and where the log scan begins
+func (ix *Indexer) start(ctx context.Context, target uint64) error {
+	out, err := ix.aggregate(uniswap_v2.WithBlockNumber(ctx, new(big.Int).SetUint64(target)), []contractCall{
+		{target: ix.cfg.Factory, method: "allPairsLength"},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get pair count: %w", err)
+	}
+
+	lastBlock := target
+	if ix.cfg.FromBlock > 0 && ix.cfg.FromBlock-1 < target {
+		lastBlock = ix.cfg.FromBlock - 1
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.state.EnumeratedAt = target
+	ix.state.EnumeratedPairs = out[0][0].(*big.Int).Uint64()
+	ix.state.LastBlock = lastBlock
+	return nil
+}
+
+// enumerate loads the pairs registered in allPairs at the enumeration block that are not indexed yet
+func (ix *Indexer) enumerate(ctx context.Context) error {
+	ctx = uniswap_v2.WithBlockNumber(ctx, new(big.Int).SetUint64(ix.state.EnumeratedAt))
+
+	for !ix.enumerated() {
+		start := uint64(len(ix.state.Pairs))
+		end := min(start+batchSize, ix.state.EnumeratedPairs)
+
+		calls := make([]contractCall, 0, end-start)
+		for i := start; i < end; i++ {
+			calls = append(calls, contractCall{target: ix.cfg.Factory, method: "allPairs", args: []interface{}{new(big.Int).SetUint64(i)}})
+		}
+		out, err := ix.aggregate(ctx, calls)
+		if err != nil {
+			return fmt.Errorf("failed to enumerate pairs %d-%d: %w", start, end-1, err)
+		}
+
+		calls = calls[:0]
+		for _, values := range out {
+			pair := values[0].(common.Address)
+			calls = append(calls, contractCall{target: pair, method: "token0"}, contractCall{target: pair, method: "token1"})
+		}
+		tokens, err := ix.aggregate(ctx, calls)
+		if err != nil {
+			return fmt.Errorf("failed to get tokens of pairs %d-%d: %w", start, end-1, err)
+		}
+
+		ix.mu.Lock()
+		for i, values := range out {
+			ix.addPair(Pair{
+				Address: values[0].(common.Address),
+				Token0:  tokens[2*i][0].(common.Address),
+				Token1:  tokens[2*i+1][0].(common.Address),
+				Index:   start + uint64(i),
+			})
+		}
+		ix.mu.Unlock()
+
+		ix.saveEvery()
+	}
+	return nil
+}
+
+// enumerated reports whether every pair existing at the enumeration block is indexed
+func (ix *Indexer) enumerated() bool {
+	return ix.state.EnumeratedAt > 0 && uint64(len(ix.state.Pairs)) >= ix.state.EnumeratedPairs
+}
+
+// scan processes PairCreated logs up to target. Any eth_getLogs error, such as a provider range or result limit,
+// halves the block range until a single block fails.
+func (ix *Indexer) scan(ctx context.Context, target uint64) error {
+	event := ix.contractABI.Events["PairCreated"]
+
+	for ix.state.LastBlock < target {
+		from := ix.state.LastBlock + 1
+		to := min(from+ix.chunk-1, target)
+
+		logs, err := ix.backend.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(from),
+			ToBlock:   new(big.Int).SetUint64(to),
+			Addresses: []common.Address{ix.cfg.Factory},
+			Topics:    [][]common.Hash{{event.ID}},
+		})
+		if err != nil {
+			if ctx.Err() != nil || ix.chunk == 1 {
+				return fmt.Errorf("failed to get PairCreated logs of blocks %d-%d: %w", from, to, err)
+			}
+			ix.chunk /= 2
+			log.Printf("Failed to get PairCreated logs of blocks %d-%d, retrying %d blocks: %v", from, to, ix.chunk, err)
+			continue
+		}
+
+		ix.mu.Lock()
+		for _, entry := range logs {
+			if err = ix.applyLog(entry); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			ix.state.LastBlock = to
+		}
+		ix.mu.Unlock()
+		if err != nil {
+			return err
+		}
+
+		ix.chunk = min(ix.chunk*2, ix.cfg.ChunkSize)
+		ix.saveEvery()
+	}
+	return nil
+}
+
+// applyLog indexes the pair of a PairCreated log or records the creation block of an enumerated pair
+func (ix *Indexer) applyLog(entry types.Log) error {
+	if entry.Address != ix.cfg.Factory || len(entry.Topics) != 3 {
+		return nil
+	}
+	values, err := ix.contractABI.Unpack("PairCreated", entry.Data)
+	if err != nil {
+		return fmt.Errorf("failed to decode PairCreated log in block %d: %w", entry.BlockNumber, err)
+	}
+
+	pair := Pair{
+		Address: values[0].(common.Address),
+		Token0:  common.BytesToAddress(entry.Topics[1].Bytes()),
+		Token1:  common.BytesToAddress(entry.Topics[2].Bytes()),
+		// The event carries allPairs.length after the pair was pushed
+		Index: values[1].(*big.Int).Uint64() - 1,
+		Block: entry.BlockNumber,
+	}
+
+	count := uint64(len(ix.state.Pairs))
+	switch {
+	case pair.Index < count:
+		existing := &ix.state.Pairs[pair.Index]
+		if existing.Address != pair.Address {
+			return fmt.Errorf("PairCreated log in block %d has pair %d at %s, indexed at %s", entry.BlockNumber, pair.Index, pair.Address.Hex(), existing.Address.Hex())
+		}
+		existing.Block = pair.Block
+	case pair.Index == count:
+		ix.addPair(pair)
+	default:
+		return fmt.Errorf("PairCreated log in block %d has pair %d, only %d pairs are indexed", entry.BlockNumber, pair.Index, count)
+	}
+	return nil
+}
+
+// addPair appends pair to the index, the caller must hold the lock
+func (ix *Indexer) addPair(pair Pair) {
+	position := len(ix.state.Pairs)
+	ix.state.Pairs = append(ix.state.Pairs, pair)
+	ix.byAddress[pair.Address] = position
+	ix.byToken[pair.Token0] = append(ix.byToken[pair.Token0], position)
+	if pair.Token1 != pair.Token0 {
+		ix.byToken[pair.Token1] = append(ix.byToken[pair.Token1], position)
+	}
+}
+
+// saveEvery persists the state when saveInterval elapsed since the last save, logging failures since the sync
+// can redo the work
+func (ix *Indexer) saveEvery() {
+	if ix.now().Sub(ix.lastSave) < saveInterval {
+		return
+	}
+	if err := ix.save(); err != nil {
+		log.Printf("Failed to persist pair index: %v", err)
+	}
+}
+
+// save persists the state
+func (ix *Indexer) save() error {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	ix.lastSave = ix.now()
+	return ix.store.Save(&ix.state)
+}
+
+// aggregate executes the calls in a single Multicall3.aggregate3 call and returns the unpacked outputs.
+// The block is taken from ctx, see uniswap_v2.WithBlockNumber.
+func (ix *Indexer) aggregate(ctx context.Context, contractCalls []contractCall) ([][]interface{}, error) {
+	calls := make([]uniswap_v2.Multicall3Call, len(contractCalls))
+	for i, call := range contractCalls {
+		callData, err := ix.contractABI.Pack(call.method, call.args...)
+		if err != nil {
+			return nil, err
+		}
+		calls[i] = uniswap_v2.Multicall3Call{Target: call.target, CallData: callData}
+	}
+
+	results, err := ix.multicall.Aggregate3(ctx, calls)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([][]interface{}, len(results))
+	for i, result := range results {
+		values, err := ix.contractABI.Unpack(contractCalls[i].method, result.ReturnData)
+		if err != nil {
+			return nil, fmt.Errorf("%s of %s: %w", contractCalls[i].method, contractCalls[i].target.Hex(), err)
+		}
+		out[i] = values
+	}
+
+	return out, nil
+}
@@ -0,0 +1,310 @@
+package pairindex
+
+import (
+	"1inch_testtask/internal/uniswap_v2"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	factory = uniswap_v2.UniswapV2Factory.Address
+	weth    = common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+	usdc    = common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	usdt    = common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+	dai     = common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+)
+
+// fakeChain is a Backend serving a factory whose pairs are created at given blocks
+type fakeChain struct {
+	t           *testing.T
+	contractABI abi.ABI
+	callABI     abi.ABI
+	head        uint64
+	pairs       []Pair
+	// maxRange is the widest eth_getLogs block range accepted, like the limits of RPC providers
+	maxRange uint64
+	// failFrom fails eth_getLogs requests reaching this block, zero disables failures
+	failFrom uint64
+	// multicalls counts eth_calls, queries records the ranges of successful eth_getLogs requests
+	multicalls int
+	queries    [][2]uint64
+}
+
+func newFakeChain(t *testing.T, head uint64) *fakeChain {
+	contractABI, err := abi.JSON(strings.NewReader(uniswap_v2.UniswapV2FactoryABI))
+	require.NoError(t, err)
+	pairABI, err := abi.JSON(strings.NewReader(uniswap_v2.UniswapV2PairABI))
+	require.NoError(t, err)
+	contractABI.Methods["token0"] = pairABI.Methods["token0"]
+	contractABI.Methods["token1"] = pairABI.Methods["token1"]
+	callABI, err := abi.JSON(strings.NewReader(uniswap_v2.Multicall3ABI))
+	require.NoError(t, err)
+
+	return &fakeChain{t: t, contractABI: contractABI, callABI: callABI, head: head, maxRange: 1000}
+}
+
+// create deploys a pair of tokenA and tokenB at block
+func (f *fakeChain) create(tokenA, tokenB common.Address, block uint64) Pair {
+	token0, token1 := uniswap_v2.SortTokens(tokenA, tokenB)
+	pair := Pair{Token0: token0, Token1: token1, Index: uint64(len(f.pairs)), Block: block}
+	pair.Address = uniswap_v2.UniswapV2Factory.PairFor(token0, token1)
+	f.pairs = append(f.pairs, pair)
+	return pair
+}
+
+// pairsAt returns the number of pairs created up to block
+func (f *fakeChain) pairsAt(block uint64) int {
+	count := 0
+	for _, pair := range f.pairs {
+		if pair.Block <= block {
+			count++
+		}
+	}
+	return count
+}
+
+func (f *fakeChain) CodeAt(context.Context, common.Address, *big.Int) ([]byte, error) {
+	return []byte{0x1}, nil
+}
+
+func (f *fakeChain) HeaderByNumber(context.Context, *big.Int) (*types.Header, error) {
+	return &types.Header{Number: new(big.Int).SetUint64(f.head)}, nil
+}
+
+func (f *fakeChain) CallContract(_ context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	f.multicalls++
+	require.NotNil(f.t, blockNumber, "enumeration should be pinned to a block")
+	count := f.pairsAt(blockNumber.Uint64())
+
+	method, err := f.callABI.MethodById(msg.Data[:4])
+	require.NoError(f.t, err)
+	args, err := method.Inputs.Unpack(msg.Data[4:])
+	require.NoError(f.t, err)
+	calls := *abi.ConvertType(args[0], new([]uniswap_v2.Multicall3Call)).(*[]uniswap_v2.Multicall3Call)
+
+	results := make([]uniswap_v2.Multicall3Result, len(calls))
+	for i, call := range calls {
+		contractMethod, err := f.contractABI.MethodById(call.CallData[:4])
+		require.NoError(f.t, err)
+		inputs, err := contractMethod.Inputs.Unpack(call.CallData[4:])
+		require.NoError(f.t, err)
+
+		var returnData []byte
+		switch contractMethod.Name {
+		case "allPairsLength":
+			returnData, err = contractMethod.Outputs.Pack(big.NewInt(int64(count)))
+		case "allPairs":
+			i := inputs[0].(*big.Int).Uint64()
+			if i >= uint64(count) {
+				return nil, errors.New("execution reverted")
+			}
+			returnData, err = contractMethod.Outputs.Pack(f.pairs[i].Address)
+		case "token0", "token1":
+			for _, pair := range f.pairs[:count] {
+				if pair.Address == call.Target && contractMethod.Name == "token0" {
+					returnData, err = contractMethod.Outputs.Pack(pair.Token0)
+				} else if pair.Address == call.Target {
+					returnData, err = contractMethod.Outputs.Pack(pair.Token1)
+				}
+			}
+		}
+		require.NoError(f.t, err)
+		if returnData == nil {
+			return nil, errors.New("execution reverted")
+		}
+		results[i] = uniswap_v2.Multicall3Result{Success: true, ReturnData: returnData}
+	}
+
+	return method.Outputs.Pack(results)
+}
+
+func (f *fakeChain) FilterLogs(_ context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	from, to := q.FromBlock.Uint64(), q.ToBlock.Uint64()
+	if f.failFrom > 0 && to >= f.failFrom {
+		return nil, errors.New("429 Too Many Requests")
+	}
+	require.LessOrEqual(f.t, to, f.head)
+	if to-from+1 > f.maxRange {
+		return nil, fmt.Errorf("query exceeds max block range %d", f.maxRange)
+	}
+	f.queries = append(f.queries, [2]uint64{from, to})
+
+	event := f.contractABI.Events["PairCreated"]
+	var logs []types.Log
+	for _, pair := range f.pairs {
+		if pair.Block < from || pair.Block > to {
+			continue
+		}
+		data, err := event.Inputs.NonIndexed().Pack(pair.Address, new(big.Int).SetUint64(pair.Index+1))
+		require.NoError(f.t, err)
+		logs = append(logs, types.Log{
+			Address:     factory,
+			Topics:      []common.Hash{event.ID, common.BytesToHash(pair.Token0.Bytes()), common.BytesToHash(pair.Token1.Bytes())},
+			Data:        data,
+			BlockNumber: pair.Block,
+		})
+	}
+	return logs, nil
+}
+
+func newTestIndexer(t *testing.T, backend Backend, store Store, cfg Config) *Indexer {
+	cfg.Factory = factory
+	cfg.Multicall = uniswap_v2.Multicall3Address
+	ix, err := NewIndexer(backend, store, cfg)
+	require.NoError(t, err)
+	return ix
+}
+
+func TestIndexer_Sync(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("enumerates pairs and scans their creation blocks", func(t *testing.T) {
+		chain := newFakeChain(t, 1000)
+		usdcWeth := chain.create(usdc, weth, 100)
+		wethUsdt := chain.create(weth, usdt, 150)
+		daiWeth := chain.create(dai, weth, 150)
+		// Not confirmed yet
+		chain.create(dai, usdc, 995)
+
+		store := NewFileStore(filepath.Join(t.TempDir(), "pairs.json"))
+		ix := newTestIndexer(t, chain, store, Config{FromBlock: 50, ChunkSize: 400, Confirmations: 10})
+		require.NoError(t, ix.Sync(ctx))
+
+		assert.Equal(t, []Pair{usdcWeth, wethUsdt, daiWeth}, ix.Pairs())
+		assert.Equal(t, Status{Pairs: 3, LastBlock: 990, TargetBlock: 990, Synced: true}, ix.Status())
+		assert.Equal(t, [][2]uint64{{50, 449}, {450, 849}, {850, 990}}, chain.queries)
+
+		state, err := store.Load()
+		require.NoError(t, err)
+		assert.Equal(t, ix.Pairs(), state.Pairs)
+		assert.Equal(t, uint64(990), state.LastBlock)
+	})
+
+	t.Run("without history pairs existing before the first sync have no creation block", func(t *testing.T) {
+		chain := newFakeChain(t, 1000)
+		usdcWeth := chain.create(usdc, weth, 100)
+
+		ix := newTestIndexer(t, chain, NewFileStore(filepath.Join(t.TempDir(), "pairs.json")), Config{ChunkSize: 400})
+		require.NoError(t, ix.Sync(ctx))
+		assert.Empty(t, chain.queries)
+
+		chain.head = 1200
+		daiWeth := chain.create(dai, weth, 1100)
+		require.NoError(t, ix.Sync(ctx))
+
+		usdcWeth.Block = 0
+		assert.Equal(t, []Pair{usdcWeth, daiWeth}, ix.Pairs())
+		assert.Equal(t, [][2]uint64{{1001, 1200}}, chain.queries)
+	})
+
+	t.Run("log ranges shrink to the provider limit", func(t *testing.T) {
+		chain := newFakeChain(t, 1000)
+		chain.maxRange = 100
+		usdcWeth := chain.create(usdc, weth, 420)
+
+		ix := newTestIndexer(t, chain, NewFileStore(filepath.Join(t.TempDir(), "pairs.json")), Config{FromBlock: 1, ChunkSize: 400})
+		require.NoError(t, ix.Sync(ctx))
+
+		assert.Equal(t, []Pair{usdcWeth}, ix.Pairs())
+		for _, query := range chain.queries {
+			assert.LessOrEqual(t, query[1]-query[0]+1, uint64(100))
+		}
+		assert.Equal(t, [2]uint64{1, 100}, chain.queries[0])
+		assert.Equal(t, uint64(1000), chain.queries[len(chain.queries)-1][1])
+	})
+
+	t.Run("resumes from the store", func(t *testing.T) {
+		chain := newFakeChain(t, 1000)
+		usdcWeth := chain.create(usdc, weth, 100)
+		store := NewFileStore(filepath.Join(t.TempDir(), "pairs.json"))
+
+		// The scan fails halfway, progress made so far is kept
+		chain.failFrom = 500
+		ix := newTestIndexer(t, chain, store, Config{FromBlock: 1, ChunkSize: 400})
+		assert.ErrorContains(t, ix.Sync(ctx), "429 Too Many Requests")
+		assert.False(t, ix.Status().Synced)
+
+		state, err := store.Load()
+		require.NoError(t, err)
+		assert.Equal(t, uint64(499), state.LastBlock)
+		assert.Equal(t, []Pair{usdcWeth}, state.Pairs)
+
+		chain.failFrom = 0
+		chain.queries = nil
+		ix = newTestIndexer(t, chain, store, Config{FromBlock: 1, ChunkSize: 400})
+		require.NoError(t, ix.Sync(ctx))
+		assert.Equal(t, []Pair{usdcWeth}, ix.Pairs())
+		assert.Equal(t, [][2]uint64{{500, 899}, {900, 1000}}, chain.queries)
+
+		// A restarted indexer only scans new blocks and does not enumerate again
+		chain.head = 1100
+		chain.multicalls = 0
+		chain.queries = nil
+		daiWeth := chain.create(dai, weth, 1050)
+		ix = newTestIndexer(t, chain, store, Config{FromBlock: 1, ChunkSize: 400})
+		require.NoError(t, ix.Sync(ctx))
+
+		assert.Equal(t, []Pair{usdcWeth, daiWeth}, ix.Pairs())
+		assert.Equal(t, [][2]uint64{{1001, 1100}}, chain.queries)
+		assert.Zero(t, chain.multicalls)
+	})
+
+	t.Run("enumeration in batches", func(t *testing.T) {
+		chain := newFakeChain(t, 1000)
+		tokens := make([]common.Address, 0, 40)
+		for i := 1; i <= cap(tokens); i++ {
+			tokens = append(tokens, common.BigToAddress(big.NewInt(int64(i))))
+		}
+		for i := range tokens {
+			for j := i + 1; j < len(tokens) && len(chain.pairs) < batchSize+20; j++ {
+				chain.create(tokens[i], tokens[j], uint64(len(chain.pairs)+1))
+			}
+		}
+
+		ix := newTestIndexer(t, chain, NewFileStore(filepath.Join(t.TempDir(), "pairs.json")), Config{FromBlock: 1, ChunkSize: 1000})
+		require.NoError(t, ix.Sync(ctx))
+
+		assert.Equal(t, chain.pairs, ix.Pairs())
+		assert.Equal(t, 5, chain.multicalls, "pair count, then pairs and tokens of two batches")
+	})
+}
+
+func TestIndexer_Lookups(t *testing.T) {
+	chain := newFakeChain(t, 1000)
+	usdcWeth := chain.create(usdc, weth, 100)
+	wethUsdt := chain.create(weth, usdt, 150)
+	daiUsdc := chain.create(dai, usdc, 200)
+
+	ix := newTestIndexer(t, chain, NewFileStore(filepath.Join(t.TempDir(), "pairs.json")), Config{FromBlock: 1, ChunkSize: 1000})
+	require.NoError(t, ix.Sync(context.Background()))
+
+	assert.Equal(t, []Pair{usdcWeth, wethUsdt}, ix.PairsOf(weth))
+	assert.Equal(t, []Pair{usdcWeth, daiUsdc}, ix.PairsOf(usdc))
+	assert.Empty(t, ix.PairsOf(common.HexToAddress("0x01")))
+
+	pair, ok := ix.Pair(daiUsdc.Address)
+	assert.True(t, ok)
+	assert.Equal(t, daiUsdc, pair)
+	_, ok = ix.Pair(common.HexToAddress("0x01"))
+	assert.False(t, ok)
+}
+
+func TestNewIndexer_OtherFactory(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "pairs.json"))
+	require.NoError(t, store.Save(&State{Factory: common.HexToAddress("0xC0AEe478e3658e2610c5F7A4A2E1777cE9e4f2Ac")}))
+
+	_, err := NewIndexer(nil, store, Config{Factory: factory, ChunkSize: 1000})
+	assert.ErrorContains(t, err, "belongs to factory")
+}
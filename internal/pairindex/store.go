@@ -0,0 +1,97 @@
+package pairindex
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Pair is a pair deployed by the indexed factory
+type Pair struct {
+	Address common.Address `json:"address"`
+	Token0  common.Address `json:"token0"`
+	Token1  common.Address `json:"token1"`
+	// Index is the position of the pair in the factory's allPairs
+	Index uint64 `json:"index"`
+	// Block is the block the pair was created at, zero when it was enumerated before the scanned block range
+	Block uint64 `json:"block"`
+}
+
+// State is the persisted progress of an indexer
+type State struct {
+	Factory common.Address `json:"factory"`
+	// EnumeratedAt is the block allPairs is enumerated at, zero before the first sync
+	EnumeratedAt uint64 `json:"enumerated_at"`
+	// EnumeratedPairs is allPairsLength at EnumeratedAt, enumeration is complete once Pairs holds that many pairs
+	EnumeratedPairs uint64 `json:"enumerated_pairs"`
+	// LastBlock is the last block whose PairCreated logs were processed
+	LastBlock uint64 `json:"last_block"`
+	// Pairs are ordered by Index
+	Pairs []Pair `json:"pairs"`
+}
+
+// Store persists the indexer state
+type Store interface {
+	// Load returns the saved state, nil when nothing was saved yet
+	Load() (*State, error)
+	Save(state *State) error
+}
+
+// FileStore is a Store keeping the state in a JSON file
+type FileStore struct {
+	path string
+}
+
+// NewFileStore creates a store persisting to the file at path
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Load reads the state from the file, a missing file is not an error
+func (s *FileStore) Load() (*State, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read pair index: %w", err)
+	}
+
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("parse pair index %s: %w", s.path, err)
+	}
+	return &state, nil
+}
+
+// Save writes the state to the file atomically
+func (s *FileStore) Save(state *State) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("save pair index: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("save pair index: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("save pair index: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("save pair index: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,136 @@
+package pooldetect
+
+import (
+	"1inch_testtask/internal/uniswap_v2"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Type is the protocol of a pool
+type Type string
+
+// Supported pool types
+const (
+	UniswapV2 Type = "uniswap_v2"
+	UniswapV3 Type = "uniswap_v3"
+	Curve     Type = "curve"
+	Balancer  Type = "balancer"
+)
+
+var (
+	// ErrNoContract is returned when there is no contract code at the pool address
+	ErrNoContract = errors.New("no contract code at address")
+	// ErrUnknownPool is returned when the contract does not implement any supported pool interface
+	ErrUnknownPool = errors.New("unknown pool type")
+)
+
+// probe recognizes a pool type by a view method without arguments that only its pools implement
+type probe struct {
+	poolType Type
+	selector []byte
+	// outputSize is the size of the ABI encoded outputs of the method
+	outputSize int
+}
+
+// probes are tried in order, the first one whose call succeeds with the expected output size wins
+var probes = []probe{
+	// getReserves() returns (uint112, uint112, uint32)
+	{poolType: UniswapV2, selector: crypto.Keccak256([]byte("getReserves()"))[:4], outputSize: 3 * 32},
+	// slot0() returns (uint160, int24, uint16, uint16, uint16, uint8, bool)
+	{poolType: UniswapV3, selector: crypto.Keccak256([]byte("slot0()"))[:4], outputSize: 7 * 32},
+	// A() returns the uint256 amplification coefficient of StableSwap pools
+	{poolType: Curve, selector: crypto.Keccak256([]byte("A()"))[:4], outputSize: 32},
+	// getPoolId() returns the bytes32 id of Balancer V2 pools in the Vault
+	{poolType: Balancer, selector: crypto.Keccak256([]byte("getPoolId()"))[:4], outputSize: 32},
+}
+
+// Detector detects the type of pools by checking their bytecode exists and probing their interface with eth_call.
+// Pool types never change, so detected types are remembered for the lifetime of the detector.
+type Detector struct {
+	backend bind.ContractCaller
+
+	mu    sync.RWMutex
+	types map[common.Address]Type
+}
+
+// NewDetector creates a detector probing pools through backend
+func NewDetector(backend bind.ContractCaller) *Detector {
+	return &Detector{
+		backend: backend,
+		types:   make(map[common.Address]Type),
+	}
+}
+
+// Detect returns the type of the pool at poolAddress. The probes run at the block pinned in ctx.
+func (d *Detector) Detect(ctx context.Context, poolAddress common.Address) (Type, error) {
+	d.mu.RLock()
+	poolType, ok := d.types[poolAddress]
+	d.mu.RUnlock()
+	if ok {
+		return poolType, nil
+	}
+
+	poolType, err := d.detect(ctx, poolAddress)
+	if err != nil {
+		return "", err
+	}
+
+	d.mu.Lock()
+	d.types[poolAddress] = poolType
+	d.mu.Unlock()
+	return poolType, nil
+}
+
+// detect probes the pool at poolAddress
+func (d *Detector) detect(ctx context.Context, poolAddress common.Address) (Type, error) {
+	blockNumber := uniswap_v2.BlockNumberFromContext(ctx)
+
+	code, err := d.backend.CodeAt(ctx, poolAddress, blockNumber)
+	if err != nil {
+		return "", fmt.Errorf("get code of %s: %w", poolAddress.Hex(), err)
+	}
+	if len(code) == 0 {
+		return "", fmt.Errorf("%w %s", ErrNoContract, poolAddress.Hex())
+	}
+
+	for _, p := range probes {
+		output, err := d.backend.CallContract(ctx, ethereum.CallMsg{To: &poolAddress, Data: p.selector}, blockNumber)
+		if err != nil {
+			// A missing method reverts, anything else is a failure to reach the node
+			if isRevert(err) {
+				continue
+			}
+			return "", fmt.Errorf("probe %s of %s: %w", p.poolType, poolAddress.Hex(), err)
+		}
+		if len(output) == p.outputSize {
+			return p.poolType, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %s", ErrUnknownPool, poolAddress.Hex())
+}
+
+// isRevert reports whether err is the JSON-RPC error of a reverted call. Reverts with data have code 3, while geth
+// reports reverts without data, such as calls to a missing method, as a server error with the plain revert message.
+func isRevert(err error) bool {
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return false
+	}
+	switch rpcErr.ErrorCode() {
+	case 3:
+		return true
+	case -32000:
+		return rpcErr.Error() == vm.ErrExecutionReverted.Error()
+	}
+	return false
+}
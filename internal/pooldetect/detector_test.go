@@ -0,0 +1,204 @@
+package pooldetect
+
+import (
+	"1inch_testtask/internal/uniswap_v2"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeContract answers the view methods a contract implements with outputs of the given size
+type fakeContract struct {
+	methods map[string]int
+}
+
+// rpcError is a JSON-RPC error response of the node
+type rpcError struct {
+	code    int
+	message string
+}
+
+func (e rpcError) Error() string  { return e.message }
+func (e rpcError) ErrorCode() int { return e.code }
+
+// emptyRevert is how geth reports a call reverting without data
+var emptyRevert = rpcError{code: -32000, message: "execution reverted"}
+
+// fakeBackend is a bind.ContractCaller serving in-memory contracts
+type fakeBackend struct {
+	contracts map[common.Address]fakeContract
+	// err is returned by every call when set
+	err error
+	// revert is returned by calls to missing methods
+	revert error
+	calls  int
+	// blocks records the block number of every call, nil for latest
+	blocks []*big.Int
+}
+
+func (f *fakeBackend) CodeAt(_ context.Context, contract common.Address, blockNumber *big.Int) ([]byte, error) {
+	f.calls++
+	f.blocks = append(f.blocks, blockNumber)
+	if _, ok := f.contracts[contract]; !ok {
+		return nil, nil
+	}
+	return []byte{0x60, 0x80}, nil
+}
+
+func (f *fakeBackend) CallContract(_ context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	f.calls++
+	f.blocks = append(f.blocks, blockNumber)
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	for _, p := range probes {
+		if !bytes.Equal(p.selector, msg.Data) {
+			continue
+		}
+		if size, ok := f.contracts[*msg.To].methods[string(p.poolType)]; ok {
+			return make([]byte, size), nil
+		}
+	}
+	return nil, f.revert
+}
+
+var (
+	usdtWethV2 = common.HexToAddress("0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852")
+	usdtWethV3 = common.HexToAddress("0x4e68Ccd3E89f51C3074ca5072bbAC773960dFa36")
+	threePool  = common.HexToAddress("0xbEbc44782C7dB0a1A60Cb6fe97d0b483032FF1C7")
+	balWeth    = common.HexToAddress("0x5c6Ee304399DBdB9C8Ef030aB642B10820DB8F56")
+	usdt       = common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+	// fallbackContract returns empty data for any call, like a contract with a fallback function
+	fallbackContract = common.HexToAddress("0x00000000000000000000000000000000000fa11b")
+)
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{revert: emptyRevert, contracts: map[common.Address]fakeContract{
+		usdtWethV2:       {methods: map[string]int{string(UniswapV2): 96}},
+		usdtWethV3:       {methods: map[string]int{string(UniswapV3): 224}},
+		threePool:        {methods: map[string]int{string(Curve): 32}},
+		balWeth:          {methods: map[string]int{string(Balancer): 32}},
+		usdt:             {},
+		fallbackContract: {methods: map[string]int{string(UniswapV2): 0, string(UniswapV3): 0, string(Curve): 0, string(Balancer): 0}},
+	}}
+}
+
+func TestDetector_Detect(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		pool    common.Address
+		want    Type
+		wantErr error
+	}{
+		{name: "uniswap v2 pair", pool: usdtWethV2, want: UniswapV2},
+		{name: "uniswap v3 pool", pool: usdtWethV3, want: UniswapV3},
+		{name: "curve pool", pool: threePool, want: Curve},
+		{name: "balancer pool", pool: balWeth, want: Balancer},
+		{name: "token is not a pool", pool: usdt, wantErr: ErrUnknownPool},
+		{name: "empty outputs are not a pool", pool: fallbackContract, wantErr: ErrUnknownPool},
+		{name: "no contract", pool: common.HexToAddress("0x000000000000000000000000000000000000dEaD"), wantErr: ErrNoContract},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detector := NewDetector(newFakeBackend())
+
+			got, err := detector.Detect(ctx, tt.pool)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDetector_Cache(t *testing.T) {
+	ctx := context.Background()
+	backend := newFakeBackend()
+	detector := NewDetector(backend)
+
+	_, err := detector.Detect(ctx, usdtWethV3)
+	require.NoError(t, err)
+	assert.Equal(t, 3, backend.calls, "code check, failed V2 probe and V3 probe")
+
+	got, err := detector.Detect(ctx, usdtWethV3)
+	require.NoError(t, err)
+	assert.Equal(t, UniswapV3, got)
+	assert.Equal(t, 3, backend.calls, "detected types should be cached")
+
+	// Failures are not cached
+	_, err = detector.Detect(ctx, usdt)
+	assert.ErrorIs(t, err, ErrUnknownPool)
+	_, err = detector.Detect(ctx, usdt)
+	assert.ErrorIs(t, err, ErrUnknownPool)
+	assert.Equal(t, 13, backend.calls)
+}
+
+func TestDetector_RPCError(t *testing.T) {
+	backend := newFakeBackend()
+	backend.err = errors.New("429 Too Many Requests")
+	detector := NewDetector(backend)
+
+	_, err := detector.Detect(context.Background(), usdtWethV2)
+	assert.ErrorContains(t, err, "429 Too Many Requests")
+	assert.NotErrorIs(t, err, ErrUnknownPool)
+
+	// The pool is probed again once the node recovers
+	backend.err = nil
+	got, err := detector.Detect(context.Background(), usdtWethV2)
+	require.NoError(t, err)
+	assert.Equal(t, UniswapV2, got)
+}
+
+func TestDetector_Reverts(t *testing.T) {
+	tests := []struct {
+		name    string
+		revert  error
+		wantErr bool
+	}{
+		{name: "revert without data", revert: emptyRevert},
+		{name: "revert with data", revert: rpcError{code: 3, message: "execution reverted: not implemented"}},
+		{name: "other server error", revert: rpcError{code: -32000, message: "header not found"}, wantErr: true},
+		{name: "revert message outside of an RPC error", revert: fmt.Errorf("proxy: %w", errors.New("execution reverted")), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := newFakeBackend()
+			backend.revert = tt.revert
+
+			// The V3 pool reverts the V2 probe
+			got, err := NewDetector(backend).Detect(context.Background(), usdtWethV3)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, tt.revert)
+				assert.NotErrorIs(t, err, ErrUnknownPool)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, UniswapV3, got)
+		})
+	}
+}
+
+func TestDetector_PinnedBlock(t *testing.T) {
+	backend := newFakeBackend()
+	detector := NewDetector(backend)
+
+	ctx := uniswap_v2.WithBlockNumber(context.Background(), big.NewInt(18500000))
+	_, err := detector.Detect(ctx, usdtWethV2)
+	require.NoError(t, err)
+	assert.Equal(t, []*big.Int{big.NewInt(18500000), big.NewInt(18500000)}, backend.blocks)
+}
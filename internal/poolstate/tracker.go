@@ -0,0 +1,418 @@
+package poolstate
+
+import (
+	"1inch_testtask/internal/uniswap_v2"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ErrNoPools is returned when the tracker is configured without pools, whose Sync log filter would match every contract
+var ErrNoPools = errors.New("no pools to track")
+
+// Backend is the subscription API of an Ethereum node, implemented by ethclient.Client over WebSocket
+type Backend interface {
+	SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error)
+	SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error)
+}
+
+// Config configures the tracker
+type Config struct {
+	// Pools is the list of watched Uniswap V2 pairs
+	Pools []common.Address
+	// MaxStaleness is how long the tracker stays usable without receiving a new head
+	MaxStaleness time.Duration
+	// ReorgDepth is how many blocks of reserve history are kept to roll back reorged Sync events
+	ReorgDepth uint64
+	// RetryInterval is the delay before resubscribing after the subscription fails
+	RetryInterval time.Duration
+}
+
+// Status describes the health of the tracker
+type Status struct {
+	Pools     int    `json:"pools"`
+	HeadBlock uint64 `json:"head_block"`
+	// HeadAgeSeconds is the time since the last head was received
+	HeadAgeSeconds float64 `json:"head_age_seconds"`
+	Stale          bool    `json:"stale"`
+}
+
+// version is the reserves of a pool after the Sync event at (block, logIndex)
+type version struct {
+	block    uint64
+	logIndex uint
+	// hash and timestamp identify the block, the timestamp is zero when its head was not received
+	hash      common.Hash
+	timestamp uint64
+	reserves  uniswap_v2.Reserves
+}
+
+// trackedPool is the reserve history of a pool, oldest version first
+type trackedPool struct {
+	tokens   uniswap_v2.PairTokens
+	versions []version
+}
+
+// head is the latest block header seen by the tracker
+type head struct {
+	ref    uniswap_v2.BlockRef
+	seenAt time.Time
+}
+
+// Tracker keeps reserves of watched pools in memory by following their Sync events.
+// Reserves are backfilled with getReserves on (re)subscription and Sync events removed by reorgs are rolled back.
+type Tracker struct {
+	client   uniswap_v2.IUniswapV2
+	resolver uniswap_v2.IBlockResolver
+	backend  Backend
+	cfg      Config
+	syncABI  abi.ABI
+	now      func() time.Time
+
+	mu    sync.RWMutex
+	pools map[common.Address]*trackedPool
+	head  head
+	// timestamps maps recent block numbers to their timestamps
+	timestamps map[uint64]uint64
+	// subscribers maps pools to the channels notified when their reserves change
+	subscribers map[common.Address]map[chan struct{}]struct{}
+}
+
+// NewTracker creates a tracker for the configured pools. Call Run to start tracking.
+func NewTracker(client uniswap_v2.IUniswapV2, resolver uniswap_v2.IBlockResolver, backend Backend, cfg Config) (*Tracker, error) {
+	if len(cfg.Pools) == 0 {
+		return nil, ErrNoPools
+	}
+
+	syncABI, err := abi.JSON(strings.NewReader(uniswap_v2.UniswapV2PairABI))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Tracker{
+		client:      client,
+		resolver:    resolver,
+		backend:     backend,
+		cfg:         cfg,
+		syncABI:     syncABI,
+		now:         time.Now,
+		pools:       make(map[common.Address]*trackedPool),
+		timestamps:  make(map[uint64]uint64),
+		subscribers: make(map[common.Address]map[chan struct{}]struct{}),
+	}, nil
+}
+
+// Run tracks the pools until ctx is cancelled, resubscribing after failures
+func (t *Tracker) Run(ctx context.Context) {
+	for {
+		err := t.follow(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("Pool state subscription failed, retrying in %s: %v", t.cfg.RetryInterval, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(t.cfg.RetryInterval):
+		}
+	}
+}
+
+// PoolStates returns the in-memory state of the pools and the oldest block among their last applied Sync events,
+// the backfill block for pools without events since. Sync events may arrive after the head of their block, so the
+// head is not reported. ok is false when any pool is not tracked or the tracker is stale, callers should then fall
+// back to RPC.
+func (t *Tracker) PoolStates(poolAddresses []common.Address) (states []uniswap_v2.PoolState, block *uniswap_v2.BlockRef, ok bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	if t.stale() || len(poolAddresses) == 0 {
+		return nil, nil, false
+	}
+
+	states = make([]uniswap_v2.PoolState, len(poolAddresses))
+	for i, poolAddress := range poolAddresses {
+		pool, tracked := t.pools[poolAddress]
+		if !tracked || len(pool.versions) == 0 {
+			return nil, nil, false
+		}
+
+		latest := pool.versions[len(pool.versions)-1]
+		states[i] = uniswap_v2.PoolState{
+			Pool:               poolAddress,
+			Token0:             pool.tokens.Token0,
+			Token1:             pool.tokens.Token1,
+			Factory:            pool.tokens.Factory,
+			Reserve0:           latest.reserves.Reserve0,
+			Reserve1:           latest.reserves.Reserve1,
+			BlockTimestampLast: latest.reserves.BlockTimestampLast,
+		}
+		if block == nil || latest.block < block.Number.Uint64() {
+			block = &uniswap_v2.BlockRef{
+				Number:    new(big.Int).SetUint64(latest.block),
+				Hash:      latest.hash,
+				Timestamp: latest.timestamp,
+			}
+		}
+	}
+
+	return states, block, true
+}
+
+// Subscribe returns a channel receiving a value whenever the reserves of the pool change and a function ending the
+// subscription. Notifications are coalesced, a receiver that falls behind gets a single one.
+// ok is false when the pool is not watched.
+func (t *Tracker) Subscribe(poolAddress common.Address) (updates <-chan struct{}, unsubscribe func(), ok bool) {
+	if !slices.Contains(t.cfg.Pools, poolAddress) {
+		return nil, nil, false
+	}
+
+	ch := make(chan struct{}, 1)
+	t.mu.Lock()
+	if t.subscribers[poolAddress] == nil {
+		t.subscribers[poolAddress] = make(map[chan struct{}]struct{})
+	}
+	t.subscribers[poolAddress][ch] = struct{}{}
+	t.mu.Unlock()
+
+	unsubscribe = func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		delete(t.subscribers[poolAddress], ch)
+		if len(t.subscribers[poolAddress]) == 0 {
+			delete(t.subscribers, poolAddress)
+		}
+	}
+	return ch, unsubscribe, true
+}
+
+// notify signals the subscribers of a pool that its reserves changed, must be called with mu held
+func (t *Tracker) notify(poolAddress common.Address) {
+	for ch := range t.subscribers[poolAddress] {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Status returns the tracker health
+func (t *Tracker) Status() Status {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	status := Status{Pools: len(t.pools), Stale: t.stale()}
+	if t.head.ref.Number != nil {
+		status.HeadBlock = t.head.ref.Number.Uint64()
+		status.HeadAgeSeconds = t.now().Sub(t.head.seenAt).Seconds()
+	}
+	return status
+}
+
+// stale reports whether no head was received within MaxStaleness, must be called with mu held
+func (t *Tracker) stale() bool {
+	return t.head.ref.Number == nil || t.now().Sub(t.head.seenAt) > t.cfg.MaxStaleness
+}
+
+// follow subscribes to Sync events and heads, backfills the pools and applies events until the subscription fails
+func (t *Tracker) follow(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Subscribe before backfilling so that no event between the two is missed
+	logs := make(chan types.Log, 256)
+	logSub, err := t.backend.SubscribeFilterLogs(ctx, ethereum.FilterQuery{
+		Addresses: t.cfg.Pools,
+		Topics:    [][]common.Hash{{t.syncABI.Events["Sync"].ID}},
+	}, logs)
+	if err != nil {
+		return fmt.Errorf("subscribe to Sync logs: %w", err)
+	}
+	defer logSub.Unsubscribe()
+
+	heads := make(chan *types.Header, 16)
+	headSub, err := t.backend.SubscribeNewHead(ctx, heads)
+	if err != nil {
+		return fmt.Errorf("subscribe to heads: %w", err)
+	}
+	defer headSub.Unsubscribe()
+
+	if err := t.backfill(ctx, t.cfg.Pools); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case err := <-logSub.Err():
+			return fmt.Errorf("logs subscription: %w", err)
+		case err := <-headSub.Err():
+			return fmt.Errorf("heads subscription: %w", err)
+		case header := <-heads:
+			t.applyHead(header)
+		case entry := <-logs:
+			if resync := t.applyLog(entry); resync {
+				if err := t.backfill(ctx, []common.Address{entry.Address}); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// backfill loads tokens and reserves of the pools at the latest block, replacing their history
+func (t *Tracker) backfill(ctx context.Context, poolAddresses []common.Address) error {
+	block, err := t.resolver.ResolveBlock(ctx, "latest")
+	if err != nil {
+		return fmt.Errorf("resolve backfill block: %w", err)
+	}
+
+	states, err := t.client.GetPoolStates(uniswap_v2.WithBlockNumber(ctx, block.Number), poolAddresses)
+	if err != nil {
+		return fmt.Errorf("backfill reserves: %w", err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, state := range states {
+		t.pools[state.Pool] = &trackedPool{
+			tokens: uniswap_v2.PairTokens{Token0: state.Token0, Token1: state.Token1, Factory: state.Factory},
+			versions: []version{{
+				block: block.Number.Uint64(),
+				// The backfilled state includes every event of its block
+				logIndex:  math.MaxUint,
+				hash:      block.Hash,
+				timestamp: block.Timestamp,
+				reserves: uniswap_v2.Reserves{
+					Reserve0:           state.Reserve0,
+					Reserve1:           state.Reserve1,
+					BlockTimestampLast: state.BlockTimestampLast,
+				},
+			}},
+		}
+		t.notify(state.Pool)
+	}
+	t.setHead(block)
+
+	return nil
+}
+
+// applyHead records a new head block
+func (t *Tracker) applyHead(header *types.Header) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.setHead(&uniswap_v2.BlockRef{
+		Number:    header.Number,
+		Hash:      header.Hash(),
+		Timestamp: header.Time,
+	})
+}
+
+// setHead records the head and prunes history older than ReorgDepth, must be called with mu held
+func (t *Tracker) setHead(block *uniswap_v2.BlockRef) {
+	number := block.Number.Uint64()
+	t.timestamps[number] = block.Timestamp
+
+	// After a reorg the new head may be lower than the previous one, it is still the canonical head
+	t.head = head{ref: *block, seenAt: t.now()}
+
+	if number <= t.cfg.ReorgDepth {
+		return
+	}
+	finalized := number - t.cfg.ReorgDepth
+	for n := range t.timestamps {
+		if n < finalized {
+			delete(t.timestamps, n)
+		}
+	}
+	for _, pool := range t.pools {
+		// Keep the newest version at or below the finalized block as the base of the history
+		base := 0
+		for i, v := range pool.versions {
+			if v.block <= finalized {
+				base = i
+			}
+		}
+		pool.versions = pool.versions[base:]
+	}
+}
+
+// applyLog applies a Sync event or rolls back a removed one.
+// It returns true when the pool history cannot be rolled back far enough and must be backfilled again.
+func (t *Tracker) applyLog(entry types.Log) (resync bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	pool, ok := t.pools[entry.Address]
+	if !ok {
+		return false
+	}
+
+	if entry.Removed {
+		// The block was reorged out, drop every version from it onwards
+		kept := pool.versions[:0]
+		for _, v := range pool.versions {
+			if v.block < entry.BlockNumber {
+				kept = append(kept, v)
+			}
+		}
+		pool.versions = kept
+		if len(pool.versions) == 0 {
+			return true
+		}
+		t.notify(entry.Address)
+		return false
+	}
+
+	if len(pool.versions) > 0 {
+		latest := pool.versions[len(pool.versions)-1]
+		if entry.BlockNumber < latest.block || (entry.BlockNumber == latest.block && entry.Index <= latest.logIndex) {
+			// Already included in the current state
+			return false
+		}
+	}
+
+	values, err := t.syncABI.Unpack("Sync", entry.Data)
+	if err != nil {
+		log.Printf("Failed to decode Sync event of %s: %v", entry.Address.Hex(), err)
+		return true
+	}
+
+	reserves := uniswap_v2.Reserves{
+		Reserve0: values[0].(*big.Int),
+		Reserve1: values[1].(*big.Int),
+	}
+	timestamp, ok := t.timestamps[entry.BlockNumber]
+	if ok {
+		reserves.BlockTimestampLast = uint32(timestamp)
+	} else if len(pool.versions) > 0 {
+		reserves.BlockTimestampLast = pool.versions[len(pool.versions)-1].reserves.BlockTimestampLast
+	}
+
+	pool.versions = append(pool.versions, version{
+		block:     entry.BlockNumber,
+		logIndex:  entry.Index,
+		hash:      entry.BlockHash,
+		timestamp: timestamp,
+		reserves:  reserves,
+	})
+	t.notify(entry.Address)
+
+	return false
+}
@@ -0,0 +1,425 @@
+package poolstate
+
+import (
+	"1inch_testtask/internal/uniswap_v2"
+	"1inch_testtask/internal/usecase"
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	usdt     = common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+	weth     = common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+	usdtWeth = common.HexToAddress("0x0d4a11d5EEaaC28EC3F61d100daF4d40471f1852")
+	other    = common.HexToAddress("0xA478c2975Ab1Ea89e8196811F51A7B7Ade33eB11")
+	factory  = common.HexToAddress("0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f")
+)
+
+// fakePairs is a uniswap_v2.IUniswapV2 serving fixed pool states, only GetPoolStates is implemented
+type fakePairs struct {
+	uniswap_v2.IUniswapV2
+
+	mu       sync.Mutex
+	reserves map[common.Address][2]int64
+	// blocks records the block number each GetPoolStates call was pinned to
+	blocks []*big.Int
+}
+
+func (f *fakePairs) GetPoolStates(ctx context.Context, poolAddresses []common.Address) ([]uniswap_v2.PoolState, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.blocks = append(f.blocks, uniswap_v2.BlockNumberFromContext(ctx))
+	states := make([]uniswap_v2.PoolState, len(poolAddresses))
+	for i, poolAddress := range poolAddresses {
+		reserves := f.reserves[poolAddress]
+		states[i] = uniswap_v2.PoolState{
+			Pool:               poolAddress,
+			Token0:             usdt,
+			Token1:             weth,
+			Factory:            factory,
+			Reserve0:           big.NewInt(reserves[0]),
+			Reserve1:           big.NewInt(reserves[1]),
+			BlockTimestampLast: 1700000000,
+		}
+	}
+	return states, nil
+}
+
+// fakeResolver resolves "latest" to a settable block
+type fakeResolver struct {
+	mu     sync.Mutex
+	latest uniswap_v2.BlockRef
+}
+
+func (f *fakeResolver) ResolveBlock(_ context.Context, _ string) (*uniswap_v2.BlockRef, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ref := f.latest
+	return &ref, nil
+}
+
+// fakeSubscription is an ethereum.Subscription whose failure is triggered by the test
+type fakeSubscription struct {
+	errc chan error
+}
+
+func (s *fakeSubscription) Unsubscribe()      {}
+func (s *fakeSubscription) Err() <-chan error { return s.errc }
+
+// fakeBackend is a simulated subscription backend that forwards logs and heads emitted by the test
+type fakeBackend struct {
+	logs  chan types.Log
+	heads chan *types.Header
+	// query is the filter of the last log subscription
+	query chan ethereum.FilterQuery
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{
+		logs:  make(chan types.Log),
+		heads: make(chan *types.Header),
+		query: make(chan ethereum.FilterQuery, 1),
+	}
+}
+
+func (b *fakeBackend) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	b.query <- q
+	go forward(ctx, b.logs, ch)
+	return &fakeSubscription{errc: make(chan error)}, nil
+}
+
+func (b *fakeBackend) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	go forward(ctx, b.heads, ch)
+	return &fakeSubscription{errc: make(chan error)}, nil
+}
+
+func forward[T any](ctx context.Context, in <-chan T, out chan<- T) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case v := <-in:
+			out <- v
+		}
+	}
+}
+
+func newTestTracker(t *testing.T, backend Backend) (*Tracker, *fakePairs, *fakeResolver) {
+	t.Helper()
+
+	pairs := &fakePairs{reserves: map[common.Address][2]int64{
+		usdtWeth: {1000, 2000},
+	}}
+	resolver := &fakeResolver{latest: blockRef(100, 1700000100)}
+
+	tracker, err := NewTracker(pairs, resolver, backend, Config{
+		Pools:         []common.Address{usdtWeth},
+		MaxStaleness:  30 * time.Second,
+		ReorgDepth:    10,
+		RetryInterval: time.Millisecond,
+	})
+	require.NoError(t, err)
+	return tracker, pairs, resolver
+}
+
+func blockRef(number, timestamp uint64) uniswap_v2.BlockRef {
+	return uniswap_v2.BlockRef{
+		Number:    new(big.Int).SetUint64(number),
+		Hash:      common.BigToHash(new(big.Int).SetUint64(number)),
+		Timestamp: timestamp,
+	}
+}
+
+func syncLog(t *testing.T, tracker *Tracker, pool common.Address, block uint64, index uint, reserve0, reserve1 int64) types.Log {
+	t.Helper()
+
+	data, err := tracker.syncABI.Events["Sync"].Inputs.NonIndexed().Pack(big.NewInt(reserve0), big.NewInt(reserve1))
+	require.NoError(t, err)
+	return types.Log{
+		Address:     pool,
+		Topics:      []common.Hash{tracker.syncABI.Events["Sync"].ID},
+		Data:        data,
+		BlockNumber: block,
+		BlockHash:   common.BigToHash(new(big.Int).SetUint64(block)),
+		Index:       index,
+	}
+}
+
+// reserves returns the tracked reserves of usdtWeth, failing when they are unavailable
+func reserves(t *testing.T, tracker *Tracker) (int64, int64) {
+	t.Helper()
+
+	states, _, ok := tracker.PoolStates([]common.Address{usdtWeth})
+	require.True(t, ok)
+	return states[0].Reserve0.Int64(), states[0].Reserve1.Int64()
+}
+
+func TestTrackerBackfill(t *testing.T) {
+	tracker, pairs, _ := newTestTracker(t, nil)
+
+	_, _, ok := tracker.PoolStates([]common.Address{usdtWeth})
+	assert.False(t, ok, "nothing is served before the backfill")
+
+	require.NoError(t, tracker.backfill(context.Background(), []common.Address{usdtWeth}))
+
+	states, block, ok := tracker.PoolStates([]common.Address{usdtWeth})
+	require.True(t, ok)
+	assert.Equal(t, []uniswap_v2.PoolState{{
+		Pool:               usdtWeth,
+		Token0:             usdt,
+		Token1:             weth,
+		Factory:            factory,
+		Reserve0:           big.NewInt(1000),
+		Reserve1:           big.NewInt(2000),
+		BlockTimestampLast: 1700000000,
+	}}, states)
+	assert.Equal(t, uint64(100), block.Number.Uint64())
+	assert.Equal(t, []*big.Int{big.NewInt(100)}, pairs.blocks, "backfill reads the pools at the resolved block")
+
+	_, _, ok = tracker.PoolStates([]common.Address{usdtWeth, other})
+	assert.False(t, ok, "untracked pools are not served")
+}
+
+func TestTrackerPoolStatesBlock(t *testing.T) {
+	tracker, pairs, _ := newTestTracker(t, nil)
+	pairs.reserves[other] = [2]int64{3000, 4000}
+	require.NoError(t, tracker.backfill(context.Background(), []common.Address{usdtWeth, other}))
+
+	tracker.applyHead(&types.Header{Number: big.NewInt(101), Time: 1700000112})
+	require.False(t, tracker.applyLog(syncLog(t, tracker, usdtWeth, 101, 0, 1100, 1900)))
+	tracker.applyHead(&types.Header{Number: big.NewInt(102), Time: 1700000124})
+
+	// A Sync of the head block may not have arrived yet, so pools report their last Sync block and not the head
+	_, block, ok := tracker.PoolStates([]common.Address{usdtWeth})
+	require.True(t, ok)
+	assert.Equal(t, blockRef(101, 1700000112), *block)
+
+	_, block, ok = tracker.PoolStates([]common.Address{usdtWeth, other})
+	require.True(t, ok)
+	assert.Equal(t, blockRef(100, 1700000100), *block, "the oldest block among the pools is reported")
+}
+
+func TestNewTrackerWithoutPools(t *testing.T) {
+	_, err := NewTracker(&fakePairs{}, &fakeResolver{}, nil, Config{})
+	assert.ErrorIs(t, err, ErrNoPools)
+}
+
+func TestTrackerFactoryFees(t *testing.T) {
+	tracker, pairs, _ := newTestTracker(t, nil)
+	pairs.reserves[usdtWeth] = [2]int64{1000000000, 2000000000}
+	require.NoError(t, tracker.backfill(context.Background(), []common.Address{usdtWeth}))
+
+	// Quotes served from the tracker use the fee of the pair's factory like quotes read over RPC
+	service := usecase.NewUsecase(pairs,
+		usecase.WithPoolStateSource(tracker),
+		usecase.WithFees(usecase.FeeConfig{DefaultBps: usecase.DefaultFeeBps, Factories: map[common.Address]uint32{factory: 25}}),
+	)
+	estimate, err := service.EstimateSwap(context.Background(), usdtWeth.Hex(), usdt.Hex(), weth.Hex(), "1000000", "")
+	require.NoError(t, err)
+	assert.Equal(t, uint32(25), estimate.FeeBps)
+	// 1000000 * 9975 * 2000000000 / (1000000000 * 10000 + 1000000 * 9975), 1992013 with the default fee
+	assert.Equal(t, big.NewInt(1993011), estimate.DstAmount)
+	assert.Equal(t, uint64(100), estimate.Block.Number.Uint64())
+}
+
+func TestTrackerApplyLog(t *testing.T) {
+	tracker, _, _ := newTestTracker(t, nil)
+	require.NoError(t, tracker.backfill(context.Background(), []common.Address{usdtWeth}))
+
+	tests := []struct {
+		name       string
+		entry      types.Log
+		wantResync bool
+		want0      int64
+		want1      int64
+	}{
+		{
+			name:  "event of the backfilled block is already included",
+			entry: syncLog(t, tracker, usdtWeth, 100, 5, 1, 1),
+			want0: 1000,
+			want1: 2000,
+		},
+		{
+			name:  "new event is applied",
+			entry: syncLog(t, tracker, usdtWeth, 101, 3, 1100, 1900),
+			want0: 1100,
+			want1: 1900,
+		},
+		{
+			name:  "duplicate event is ignored",
+			entry: syncLog(t, tracker, usdtWeth, 101, 3, 1, 1),
+			want0: 1100,
+			want1: 1900,
+		},
+		{
+			name:  "later event in the same block is applied",
+			entry: syncLog(t, tracker, usdtWeth, 101, 7, 1200, 1800),
+			want0: 1200,
+			want1: 1800,
+		},
+		{
+			name:  "event of an untracked pool is ignored",
+			entry: syncLog(t, tracker, other, 102, 0, 1, 1),
+			want0: 1200,
+			want1: 1800,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantResync, tracker.applyLog(tt.entry))
+			reserve0, reserve1 := reserves(t, tracker)
+			assert.Equal(t, tt.want0, reserve0)
+			assert.Equal(t, tt.want1, reserve1)
+		})
+	}
+}
+
+func TestTrackerReorg(t *testing.T) {
+	tracker, _, _ := newTestTracker(t, nil)
+	require.NoError(t, tracker.backfill(context.Background(), []common.Address{usdtWeth}))
+
+	tracker.applyHead(&types.Header{Number: big.NewInt(101), Time: 1700000112})
+	require.False(t, tracker.applyLog(syncLog(t, tracker, usdtWeth, 101, 0, 1100, 1900)))
+	tracker.applyHead(&types.Header{Number: big.NewInt(102), Time: 1700000124})
+	require.False(t, tracker.applyLog(syncLog(t, tracker, usdtWeth, 102, 0, 1200, 1800)))
+
+	states, _, ok := tracker.PoolStates([]common.Address{usdtWeth})
+	require.True(t, ok)
+	assert.Equal(t, uint32(1700000124), states[0].BlockTimestampLast, "timestamp comes from the head of the event block")
+
+	// Block 102 is reorged out and replaced by a block with a different event
+	removed := syncLog(t, tracker, usdtWeth, 102, 0, 1200, 1800)
+	removed.Removed = true
+	require.False(t, tracker.applyLog(removed))
+	reserve0, reserve1 := reserves(t, tracker)
+	assert.Equal(t, int64(1100), reserve0)
+	assert.Equal(t, int64(1900), reserve1)
+
+	require.False(t, tracker.applyLog(syncLog(t, tracker, usdtWeth, 102, 1, 1300, 1700)))
+	reserve0, reserve1 = reserves(t, tracker)
+	assert.Equal(t, int64(1300), reserve0)
+	assert.Equal(t, int64(1700), reserve1)
+
+	// Rolling back past the backfilled block leaves no known state
+	removed = syncLog(t, tracker, usdtWeth, 100, 0, 1000, 2000)
+	removed.Removed = true
+	assert.True(t, tracker.applyLog(removed), "pool must be backfilled again")
+	_, _, ok = tracker.PoolStates([]common.Address{usdtWeth})
+	assert.False(t, ok)
+}
+
+func TestTrackerSubscribe(t *testing.T) {
+	tracker, _, _ := newTestTracker(t, nil)
+
+	_, _, ok := tracker.Subscribe(other)
+	assert.False(t, ok, "unwatched pools cannot be subscribed to")
+
+	updates, unsubscribe, ok := tracker.Subscribe(usdtWeth)
+	require.True(t, ok)
+	notified := func() bool {
+		select {
+		case <-updates:
+			return true
+		default:
+			return false
+		}
+	}
+
+	require.NoError(t, tracker.backfill(context.Background(), []common.Address{usdtWeth}))
+	assert.True(t, notified(), "backfill sets the reserves")
+
+	tracker.applyHead(&types.Header{Number: big.NewInt(101), Time: 1700000112})
+	assert.False(t, notified(), "heads do not change reserves")
+
+	require.False(t, tracker.applyLog(syncLog(t, tracker, usdtWeth, 101, 0, 1100, 1900)))
+	require.False(t, tracker.applyLog(syncLog(t, tracker, usdtWeth, 101, 1, 1200, 1800)))
+	assert.True(t, notified())
+	assert.False(t, notified(), "notifications are coalesced")
+
+	require.False(t, tracker.applyLog(syncLog(t, tracker, usdtWeth, 101, 1, 1200, 1800)))
+	assert.False(t, notified(), "events already applied are not notified")
+
+	removed := syncLog(t, tracker, usdtWeth, 101, 1, 1200, 1800)
+	removed.Removed = true
+	require.False(t, tracker.applyLog(removed))
+	assert.True(t, notified(), "rollbacks change reserves")
+
+	unsubscribe()
+	require.False(t, tracker.applyLog(syncLog(t, tracker, usdtWeth, 102, 0, 1300, 1700)))
+	assert.False(t, notified())
+}
+
+func TestTrackerPrunesHistory(t *testing.T) {
+	tracker, _, _ := newTestTracker(t, nil)
+	require.NoError(t, tracker.backfill(context.Background(), []common.Address{usdtWeth}))
+
+	for block := uint64(101); block <= 120; block++ {
+		tracker.applyHead(&types.Header{Number: new(big.Int).SetUint64(block), Time: 1700000100 + block})
+		require.False(t, tracker.applyLog(syncLog(t, tracker, usdtWeth, block, 0, int64(block), int64(block))))
+	}
+
+	pool := tracker.pools[usdtWeth]
+	assert.Equal(t, uint64(110), pool.versions[0].block, "history older than ReorgDepth is pruned")
+	assert.Len(t, tracker.timestamps, 11)
+}
+
+func TestTrackerStaleness(t *testing.T) {
+	tracker, _, _ := newTestTracker(t, nil)
+	now := time.Unix(1700000000, 0)
+	tracker.now = func() time.Time { return now }
+
+	assert.True(t, tracker.Status().Stale, "tracker is stale before the first head")
+	require.NoError(t, tracker.backfill(context.Background(), []common.Address{usdtWeth}))
+
+	now = now.Add(20 * time.Second)
+	assert.Equal(t, Status{Pools: 1, HeadBlock: 100, HeadAgeSeconds: 20}, tracker.Status())
+	_, _, ok := tracker.PoolStates([]common.Address{usdtWeth})
+	assert.True(t, ok)
+
+	now = now.Add(20 * time.Second)
+	assert.Equal(t, Status{Pools: 1, HeadBlock: 100, HeadAgeSeconds: 40, Stale: true}, tracker.Status())
+	_, _, ok = tracker.PoolStates([]common.Address{usdtWeth})
+	assert.False(t, ok, "stale state is not served")
+
+	tracker.applyHead(&types.Header{Number: big.NewInt(101), Time: 1700000112})
+	assert.False(t, tracker.Status().Stale)
+}
+
+func TestTrackerRun(t *testing.T) {
+	backend := newFakeBackend()
+	tracker, _, _ := newTestTracker(t, backend)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		tracker.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	query := <-backend.query
+	assert.Equal(t, []common.Address{usdtWeth}, query.Addresses)
+	assert.Equal(t, [][]common.Hash{{tracker.syncABI.Events["Sync"].ID}}, query.Topics)
+
+	backend.heads <- &types.Header{Number: big.NewInt(101), Time: 1700000112}
+	backend.logs <- syncLog(t, tracker, usdtWeth, 101, 0, 1100, 1900)
+
+	require.Eventually(t, func() bool {
+		states, block, ok := tracker.PoolStates([]common.Address{usdtWeth})
+		return ok && states[0].Reserve0.Int64() == 1100 && block.Number.Uint64() == 101
+	}, time.Second, time.Millisecond)
+}
@@ -0,0 +1,161 @@
+package routing
+
+import (
+	"1inch_testtask/internal/uniswap_v2"
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Pair describes a Uniswap V2 pair known to the router
+type Pair struct {
+	Pool   common.Address
+	Token0 common.Address
+	Token1 common.Address
+}
+
+// Edge is a directed swap through a pool from one token into another
+type Edge struct {
+	Pool     common.Address
+	TokenIn  common.Address
+	TokenOut common.Address
+	// ZeroForOne is true when TokenIn is token0 of the pool
+	ZeroForOne bool
+}
+
+// Route is an ordered list of edges leading from a source token to a destination token
+type Route []Edge
+
+// Pools returns the pool addresses of the route, one per hop
+func (r Route) Pools() []common.Address {
+	pools := make([]common.Address, len(r))
+	for i, edge := range r {
+		pools[i] = edge.Pool
+	}
+	return pools
+}
+
+// Tokens returns the token path of the route, starting with the source token
+func (r Route) Tokens() []common.Address {
+	if len(r) == 0 {
+		return nil
+	}
+	tokens := make([]common.Address, 0, len(r)+1)
+	tokens = append(tokens, r[0].TokenIn)
+	for _, edge := range r {
+		tokens = append(tokens, edge.TokenOut)
+	}
+	return tokens
+}
+
+// Graph is a token graph where every known pair contributes an edge in both directions
+type Graph struct {
+	edges map[common.Address][]Edge
+	pairs int
+}
+
+// NewGraph creates a graph from a list of pairs
+func NewGraph(pairs []Pair) *Graph {
+	g := &Graph{edges: make(map[common.Address][]Edge)}
+	for _, pair := range pairs {
+		g.AddPair(pair)
+	}
+	return g
+}
+
+// AddPair adds both swap directions of a pair to the graph
+func (g *Graph) AddPair(pair Pair) {
+	g.edges[pair.Token0] = append(g.edges[pair.Token0], Edge{
+		Pool:       pair.Pool,
+		TokenIn:    pair.Token0,
+		TokenOut:   pair.Token1,
+		ZeroForOne: true,
+	})
+	g.edges[pair.Token1] = append(g.edges[pair.Token1], Edge{
+		Pool:       pair.Pool,
+		TokenIn:    pair.Token1,
+		TokenOut:   pair.Token0,
+		ZeroForOne: false,
+	})
+	g.pairs++
+}
+
+// PairCount returns the number of pairs in the graph
+func (g *Graph) PairCount() int {
+	return g.pairs
+}
+
+// FindRoutes enumerates simple routes from src to dst with at most maxHops hops.
+// Routes are returned shortest first and the search stops after maxCandidates routes.
+func (g *Graph) FindRoutes(src, dst common.Address, maxHops, maxCandidates int) []Route {
+	if src == dst || maxHops <= 0 || maxCandidates <= 0 {
+		return nil
+	}
+
+	var routes []Route
+	// Breadth-first search over partial routes so that shorter routes are found first
+	queue := []Route{{}}
+	for len(queue) > 0 && len(routes) < maxCandidates {
+		route := queue[0]
+		queue = queue[1:]
+
+		current := src
+		if len(route) > 0 {
+			current = route[len(route)-1].TokenOut
+		}
+
+		for _, edge := range g.edges[current] {
+			if route.visits(edge) {
+				continue
+			}
+
+			next := make(Route, len(route), len(route)+1)
+			copy(next, route)
+			next = append(next, edge)
+
+			if edge.TokenOut == dst {
+				routes = append(routes, next)
+				if len(routes) >= maxCandidates {
+					break
+				}
+				continue
+			}
+			if len(next) < maxHops {
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	return routes
+}
+
+// visits reports whether taking edge would revisit a pool or token already on the route
+func (r Route) visits(edge Edge) bool {
+	for _, prev := range r {
+		if prev.Pool == edge.Pool || prev.TokenIn == edge.TokenOut {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadGraph builds a graph from a list of pool addresses by fetching their tokens on-chain
+func LoadGraph(ctx context.Context, client uniswap_v2.IUniswapV2, pools []common.Address) (*Graph, error) {
+	pairs := make([]Pair, 0, len(pools))
+	for _, pool := range pools {
+		token0, err := client.GetToken0(ctx, pool)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get token0 of %s: %w", pool.Hex(), err)
+		}
+
+		token1, err := client.GetToken1(ctx, pool)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get token1 of %s: %w", pool.Hex(), err)
+		}
+
+		pairs = append(pairs, Pair{Pool: pool, Token0: token0, Token1: token1})
+	}
+
+	return NewGraph(pairs), nil
+}
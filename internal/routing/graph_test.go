@@ -0,0 +1,80 @@
+package routing
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	usdt = common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+	weth = common.HexToAddress("0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2")
+	dai  = common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+	usdc = common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+
+	usdtWeth = common.HexToAddress("0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852")
+	daiWeth  = common.HexToAddress("0xa478c2975ab1ea89e8196811f51a7b7ade33eb11")
+	usdcWeth = common.HexToAddress("0xb4e16d0168e52d35cacd2c6185b44281ec28c9dc")
+	usdtDai  = common.HexToAddress("0xb20bd5d04be54f870d5c0d3ca85d82b34b836405")
+	usdcDai  = common.HexToAddress("0xae461ca67b15dc8dc81ce7615e0320da1a9ab8d5")
+)
+
+func newTestGraph() *Graph {
+	return NewGraph([]Pair{
+		{Pool: usdtWeth, Token0: weth, Token1: usdt},
+		{Pool: daiWeth, Token0: dai, Token1: weth},
+		{Pool: usdcWeth, Token0: usdc, Token1: weth},
+		{Pool: usdtDai, Token0: dai, Token1: usdt},
+		{Pool: usdcDai, Token0: dai, Token1: usdc},
+	})
+}
+
+func TestGraph_FindRoutes(t *testing.T) {
+	g := newTestGraph()
+
+	t.Run("direct route comes first", func(t *testing.T) {
+		routes := g.FindRoutes(usdt, dai, 3, 10)
+		if assert.NotEmpty(t, routes) {
+			assert.Equal(t, []common.Address{usdtDai}, routes[0].Pools())
+			assert.Equal(t, []common.Address{usdt, dai}, routes[0].Tokens())
+			assert.False(t, routes[0][0].ZeroForOne)
+		}
+	})
+
+	t.Run("routes respect hop limit", func(t *testing.T) {
+		for _, maxHops := range []int{1, 2, 3} {
+			routes := g.FindRoutes(usdt, usdc, maxHops, 100)
+			for _, route := range routes {
+				assert.LessOrEqual(t, len(route), maxHops)
+			}
+		}
+		assert.Empty(t, g.FindRoutes(usdt, usdc, 1, 100))
+		assert.Len(t, g.FindRoutes(usdt, usdc, 2, 100), 2) // via WETH and via DAI
+	})
+
+	t.Run("routes are simple", func(t *testing.T) {
+		for _, route := range g.FindRoutes(usdt, usdc, 4, 100) {
+			seen := make(map[common.Address]bool)
+			for _, token := range route.Tokens() {
+				assert.False(t, seen[token], "token %s visited twice", token.Hex())
+				seen[token] = true
+			}
+			tokens := route.Tokens()
+			assert.Equal(t, usdt, tokens[0])
+			assert.Equal(t, usdc, tokens[len(tokens)-1])
+		}
+	})
+
+	t.Run("candidate count is bounded", func(t *testing.T) {
+		assert.Len(t, g.FindRoutes(usdt, usdc, 4, 1), 1)
+	})
+
+	t.Run("unknown token has no routes", func(t *testing.T) {
+		assert.Empty(t, g.FindRoutes(usdt, common.HexToAddress("0x01"), 3, 10))
+	})
+
+	t.Run("same token has no routes", func(t *testing.T) {
+		assert.Empty(t, g.FindRoutes(usdt, usdt, 3, 10))
+	})
+}
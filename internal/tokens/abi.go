@@ -0,0 +1,32 @@
+package tokens
+
+// ERC20MetadataABI is the ABI for the optional metadata methods of ERC-20 tokens
+const ERC20MetadataABI = `[
+	{
+		"constant": true,
+		"inputs": [],
+		"name": "decimals",
+		"outputs": [{"name": "", "type": "uint8"}],
+		"payable": false,
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"constant": true,
+		"inputs": [],
+		"name": "symbol",
+		"outputs": [{"name": "", "type": "string"}],
+		"payable": false,
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"constant": true,
+		"inputs": [],
+		"name": "name",
+		"outputs": [{"name": "", "type": "string"}],
+		"payable": false,
+		"stateMutability": "view",
+		"type": "function"
+	}
+]`
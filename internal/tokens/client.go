@@ -0,0 +1,154 @@
+package tokens
+
+import (
+	"1inch_testtask/internal/uniswap_v2"
+	"bytes"
+	"context"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Token is the ERC-20 metadata of a token
+type Token struct {
+	Address common.Address
+	// Symbol and Name are empty when the token does not implement them
+	Symbol   string
+	Name     string
+	Decimals uint8
+}
+
+// ITokens defines the interface for reading ERC-20 token metadata
+type ITokens interface {
+	// GetTokens returns the metadata of the tokens, nil for addresses without decimals, which are not ERC-20 tokens
+	GetTokens(ctx context.Context, addresses []common.Address) ([]*Token, error)
+}
+
+// metadataMethods are the methods called on every token, in the order of their results
+var metadataMethods = []string{"decimals", "symbol", "name"}
+
+// Client implements ITokens by batching the metadata calls of all uncached tokens into one Multicall3 call.
+// Metadata never changes, so tokens are cached for the lifetime of the client, addresses without decimals included.
+// Calls are not pinned to a block, token metadata is the same at any block after deployment.
+type Client struct {
+	multicall *uniswap_v2.Multicall3
+	tokenABI  abi.ABI
+
+	mu sync.RWMutex
+	// tokens holds nil for addresses without decimals
+	tokens map[common.Address]*Token
+}
+
+// NewClient creates a client that batches calls through the Multicall3 contract at multicallAddress
+func NewClient(backend bind.ContractCaller, multicallAddress common.Address) (*Client, error) {
+	tokenABI, err := abi.JSON(strings.NewReader(ERC20MetadataABI))
+	if err != nil {
+		return nil, err
+	}
+
+	multicall, err := uniswap_v2.NewMulticall3(backend, multicallAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		multicall: multicall,
+		tokenABI:  tokenABI,
+		tokens:    make(map[common.Address]*Token),
+	}, nil
+}
+
+// GetTokens returns the metadata of the tokens, fetching uncached ones
+func (c *Client) GetTokens(ctx context.Context, addresses []common.Address) ([]*Token, error) {
+	tokens := make([]*Token, len(addresses))
+	var missing []common.Address
+	c.mu.RLock()
+	for i, address := range addresses {
+		if token, ok := c.tokens[address]; ok {
+			tokens[i] = token
+		} else {
+			missing = append(missing, address)
+		}
+	}
+	c.mu.RUnlock()
+
+	if len(missing) == 0 {
+		return tokens, nil
+	}
+
+	fetched, err := c.fetch(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	for _, address := range missing {
+		c.tokens[address] = fetched[address]
+	}
+	c.mu.Unlock()
+
+	for i, address := range addresses {
+		if tokens[i] == nil {
+			tokens[i] = fetched[address]
+		}
+	}
+	return tokens, nil
+}
+
+// fetch loads the metadata of the tokens in a single Multicall3.aggregate3 call, skipping addresses without decimals
+func (c *Client) fetch(ctx context.Context, addresses []common.Address) (map[common.Address]*Token, error) {
+	calls := make([]uniswap_v2.Multicall3Call, 0, len(addresses)*len(metadataMethods))
+	for _, address := range addresses {
+		for _, method := range metadataMethods {
+			calls = append(calls, uniswap_v2.Multicall3Call{Target: address, AllowFailure: true, CallData: c.tokenABI.Methods[method].ID})
+		}
+	}
+
+	// Metadata is read at the latest block whatever block ctx is pinned to
+	results, err := c.multicall.Aggregate3(uniswap_v2.WithBlockNumber(ctx, nil), calls)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make(map[common.Address]*Token, len(addresses))
+	for i, address := range addresses {
+		decimals, symbol, name := results[3*i], results[3*i+1], results[3*i+2]
+		if !decimals.Success || len(decimals.ReturnData) < 32 {
+			continue
+		}
+		value := new(big.Int).SetBytes(decimals.ReturnData[:32])
+		if !value.IsUint64() || value.Uint64() > 255 {
+			continue
+		}
+
+		tokens[address] = &Token{
+			Address:  address,
+			Symbol:   c.decodeString("symbol", symbol),
+			Name:     c.decodeString("name", name),
+			Decimals: uint8(value.Uint64()),
+		}
+	}
+	return tokens, nil
+}
+
+// decodeString decodes the result of a string metadata method, accepting the bytes32 returned by legacy tokens
+// such as MKR. It is empty when the call reverted or returned something else.
+func (c *Client) decodeString(method string, result uniswap_v2.Multicall3Result) string {
+	if !result.Success {
+		return ""
+	}
+
+	var value string
+	if len(result.ReturnData) == 32 {
+		value = string(bytes.TrimRight(result.ReturnData, "\x00"))
+	} else if out, err := c.tokenABI.Unpack(method, result.ReturnData); err == nil {
+		value = out[0].(string)
+	}
+
+	// Some tokens pad their strings with zero bytes or return arbitrary bytes
+	return strings.ToValidUTF8(strings.TrimRight(value, "\x00"), "")
+}
@@ -0,0 +1,127 @@
+package tokens
+
+import (
+	"1inch_testtask/internal/uniswap_v2"
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	usdc = common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	mkr  = common.HexToAddress("0x9f8F72aA9304c8B593d555F12eF6589cC3A579A2")
+	weth = common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+	// bare has decimals but neither symbol nor name
+	bare = common.HexToAddress("0x0000000000000000000000000000000000000b0b")
+	// eoa has no code, calls to it succeed with empty return data
+	eoa = common.HexToAddress("0x000000000000000000000000000000000000dEaD")
+)
+
+// fakeChain is a bind.ContractCaller that executes Multicall3.aggregate3 against in-memory token contracts
+type fakeChain struct {
+	t       *testing.T
+	callABI abi.ABI
+	// methods maps selectors to token method names
+	methods map[string]string
+	// returns maps a token and method name to its raw return data, missing methods revert
+	returns map[common.Address]map[string][]byte
+	err     error
+	calls   int
+}
+
+func newFakeChain(t *testing.T) *fakeChain {
+	tokenABI, err := abi.JSON(strings.NewReader(ERC20MetadataABI))
+	require.NoError(t, err)
+	callABI, err := abi.JSON(strings.NewReader(uniswap_v2.Multicall3ABI))
+	require.NoError(t, err)
+
+	pack := func(method string, value interface{}) []byte {
+		data, err := tokenABI.Methods[method].Outputs.Pack(value)
+		require.NoError(t, err)
+		return data
+	}
+	bytes32 := func(value string) []byte {
+		return common.RightPadBytes([]byte(value), 32)
+	}
+
+	methods := make(map[string]string)
+	for name, method := range tokenABI.Methods {
+		methods[string(method.ID)] = name
+	}
+
+	return &fakeChain{t: t, callABI: callABI, methods: methods, returns: map[common.Address]map[string][]byte{
+		usdc: {"decimals": pack("decimals", uint8(6)), "symbol": pack("symbol", "USDC"), "name": pack("name", "USD Coin")},
+		// MKR returns bytes32 symbol and name
+		mkr: {"decimals": common.LeftPadBytes(big.NewInt(18).Bytes(), 32), "symbol": bytes32("MKR"), "name": bytes32("Maker")},
+		// WETH pads its name with zero bytes
+		weth: {"decimals": pack("decimals", uint8(18)), "symbol": pack("symbol", "WETH"), "name": pack("name", "Wrapped Ether\x00\x00")},
+		bare: {"decimals": pack("decimals", uint8(9))},
+		eoa:  {"decimals": nil, "symbol": nil, "name": nil},
+	}}
+}
+
+func (f *fakeChain) CodeAt(context.Context, common.Address, *big.Int) ([]byte, error) {
+	return []byte{0x1}, nil
+}
+
+func (f *fakeChain) CallContract(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	method, err := f.callABI.MethodById(msg.Data[:4])
+	require.NoError(f.t, err)
+	args, err := method.Inputs.Unpack(msg.Data[4:])
+	require.NoError(f.t, err)
+	calls := *abi.ConvertType(args[0], new([]uniswap_v2.Multicall3Call)).(*[]uniswap_v2.Multicall3Call)
+
+	results := make([]uniswap_v2.Multicall3Result, len(calls))
+	for i, call := range calls {
+		returnData, ok := f.returns[call.Target][f.methods[string(call.CallData)]]
+		results[i] = uniswap_v2.Multicall3Result{Success: ok, ReturnData: returnData}
+	}
+	return method.Outputs.Pack(results)
+}
+
+func TestClient_GetTokens(t *testing.T) {
+	ctx := context.Background()
+	backend := newFakeChain(t)
+	client, err := NewClient(backend, uniswap_v2.Multicall3Address)
+	require.NoError(t, err)
+
+	tokens, err := client.GetTokens(ctx, []common.Address{usdc, mkr, weth, bare, eoa})
+	require.NoError(t, err)
+	assert.Equal(t, []*Token{
+		{Address: usdc, Symbol: "USDC", Name: "USD Coin", Decimals: 6},
+		{Address: mkr, Symbol: "MKR", Name: "Maker", Decimals: 18},
+		{Address: weth, Symbol: "WETH", Name: "Wrapped Ether", Decimals: 18},
+		{Address: bare, Decimals: 9},
+		nil,
+	}, tokens)
+	assert.Equal(t, 1, backend.calls, "all tokens should be fetched in one eth_call")
+
+	// Found tokens and addresses without decimals are both cached
+	tokens, err = client.GetTokens(ctx, []common.Address{mkr, usdc})
+	require.NoError(t, err)
+	assert.Equal(t, "MKR", tokens[0].Symbol)
+	assert.Equal(t, "USDC", tokens[1].Symbol)
+	assert.Equal(t, 1, backend.calls)
+
+	tokens, err = client.GetTokens(ctx, []common.Address{usdc, eoa})
+	require.NoError(t, err)
+	assert.Equal(t, "USDC", tokens[0].Symbol)
+	assert.Nil(t, tokens[1])
+	assert.Equal(t, 1, backend.calls)
+
+	backend.err = errors.New("429 Too Many Requests")
+	_, err = client.GetTokens(ctx, []common.Address{common.HexToAddress("0x01")})
+	assert.ErrorContains(t, err, "429 Too Many Requests")
+}
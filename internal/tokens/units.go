@@ -0,0 +1,49 @@
+package tokens
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// ErrTooPrecise is returned for decimal amounts that cannot be expressed in whole base units of the token
+var ErrTooPrecise = errors.New("amount is more precise than the token decimals")
+
+// ParseUnits converts a decimal amount such as 1.5 to base units of a token with the given decimals.
+// Amounts are never rounded: fractional digits beyond decimals must be zeros, otherwise ErrTooPrecise is returned.
+func ParseUnits(amount string, decimals uint8) (*big.Int, error) {
+	whole, fraction, _ := strings.Cut(amount, ".")
+	if whole == "" || strings.Trim(whole+fraction, "0123456789") != "" {
+		return nil, fmt.Errorf("invalid decimal amount: %s", amount)
+	}
+
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > int(decimals) {
+		return nil, fmt.Errorf("%w: %s has %d fractional digits, at most %d are allowed", ErrTooPrecise, amount, len(fraction), decimals)
+	}
+
+	units, ok := new(big.Int).SetString(whole+fraction+strings.Repeat("0", int(decimals)-len(fraction)), 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal amount: %s", amount)
+	}
+	return units, nil
+}
+
+// FormatUnits formats an amount of base units as an exact decimal amount of a token with the given decimals,
+// without trailing zeros
+func FormatUnits(units *big.Int, decimals uint8) string {
+	digits := new(big.Int).Abs(units).String()
+	if len(digits) <= int(decimals) {
+		digits = strings.Repeat("0", int(decimals)-len(digits)+1) + digits
+	}
+
+	whole, fraction := digits[:len(digits)-int(decimals)], strings.TrimRight(digits[len(digits)-int(decimals):], "0")
+	if units.Sign() < 0 {
+		whole = "-" + whole
+	}
+	if fraction == "" {
+		return whole
+	}
+	return whole + "." + fraction
+}
@@ -0,0 +1,74 @@
+package tokens
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUnits(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		decimals uint8
+		want     string
+		wantErr  string
+	}{
+		{name: "whole", amount: "2", decimals: 6, want: "2000000"},
+		{name: "fraction", amount: "1.5", decimals: 6, want: "1500000"},
+		{name: "full precision", amount: "0.000001", decimals: 6, want: "1"},
+		{name: "trailing zeros beyond decimals", amount: "1.50000000", decimals: 6, want: "1500000"},
+		{name: "18 decimals", amount: "123456789.123456789123456789", decimals: 18, want: "123456789123456789123456789"},
+		{name: "no decimals", amount: "42.0", decimals: 0, want: "42"},
+		{name: "above uint64", amount: "100000000000", decimals: 18, want: "100000000000000000000000000000"},
+		{name: "over-precise", amount: "1.0000001", decimals: 6, wantErr: "more precise than the token decimals"},
+		{name: "fraction of an indivisible token", amount: "0.5", decimals: 0, wantErr: "more precise than the token decimals"},
+		{name: "missing whole part", amount: ".5", decimals: 6, wantErr: "invalid decimal amount"},
+		{name: "exponent", amount: "1e6", decimals: 6, wantErr: "invalid decimal amount"},
+		{name: "two points", amount: "1.2.3", decimals: 6, wantErr: "invalid decimal amount"},
+		{name: "negative", amount: "-1", decimals: 6, wantErr: "invalid decimal amount"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			units, err := ParseUnits(tt.amount, tt.decimals)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, units.String())
+		})
+	}
+}
+
+func TestFormatUnits(t *testing.T) {
+	tests := []struct {
+		units    string
+		decimals uint8
+		want     string
+	}{
+		{units: "1500000", decimals: 6, want: "1.5"},
+		{units: "2000000", decimals: 6, want: "2"},
+		{units: "1", decimals: 6, want: "0.000001"},
+		{units: "0", decimals: 18, want: "0"},
+		{units: "3978866028279530", decimals: 18, want: "0.00397886602827953"},
+		{units: "42", decimals: 0, want: "42"},
+		{units: "-1500000", decimals: 6, want: "-1.5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.units, func(t *testing.T) {
+			units, _ := new(big.Int).SetString(tt.units, 10)
+			assert.Equal(t, tt.want, FormatUnits(units, tt.decimals))
+
+			if units.Sign() >= 0 {
+				parsed, err := ParseUnits(tt.want, tt.decimals)
+				require.NoError(t, err)
+				assert.Equal(t, units, parsed, "formatting should be exact")
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package transfertax
+
+import (
+	"1inch_testtask/internal/uniswap_v2"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+)
+
+// OverrideCaller executes eth_call with state overrides, implemented by gethclient.Client
+type OverrideCaller interface {
+	CallContract(ctx context.Context, msg ethereum.CallMsg, blockNumber *big.Int, overrides *map[common.Address]gethclient.OverrideAccount) ([]byte, error)
+}
+
+// probeCode replaces the code of the pool and of probeHelper during a simulation.
+// Called by eth_call on the pool with (token, helper) it sends 0.1% of the pool balance to the helper, has the
+// helper send everything it received back and returns (amount, received by helper, received back by pool).
+// Called by the pool with (token, amount, 0) it transfers amount of token back to the caller.
+//
+//	    PUSH1 0x60 CALLDATASIZE EQ @helper JUMPI
+//	    @a1 ADDRESS @balance JUMP
+//	a1: PUSH2 1000 SWAP1 DIV                          // amount = balanceOf(pool) / 1000
+//	    DUP1 ISZERO @fail JUMPI
+//	    @a2 PUSH1 0x20 CALLDATALOAD @balance JUMP     // b0 = balanceOf(helper)
+//	a2: @a3 DUP3 PUSH1 0x20 CALLDATALOAD @transfer JUMP
+//	a3: @a4 PUSH1 0x20 CALLDATALOAD @balance JUMP
+//	a4: SUB                                           // received = balanceOf(helper) - b0
+//	    @a5 ADDRESS @balance JUMP                     // p0 = balanceOf(pool)
+//	a5: mstore(0, token) mstore(0x20, received) mstore(0x40, 0)
+//	    call(gas, helper, 0, 0, 0x60, 0, 0) ISZERO @fail JUMPI
+//	    @a6 ADDRESS @balance JUMP
+//	a6: SUB                                           // returned = balanceOf(pool) - p0
+//	    return(amount, received, returned)
+//	helper:   @b1 PUSH1 0x20 CALLDATALOAD CALLER @transfer JUMP
+//	b1:       STOP
+//	balance:  [ret, account] -> [token.balanceOf(account)]
+//	transfer: [ret, amount, to] -> [], token.transfer(to, amount) must not revert or return false
+//	fail:     REVERT
+var probeCode = hexutil.MustDecode("0x606036146100835761001030610091565b6103e8900480156100ed57610026602035610091565b610032826020356100b7565b61003d602035610091565b0361004730610091565b600035600052816020526000604052600060006060600060006020355af1156100ed5761007330610091565b0360405260205260005260606000f35b61008f602035336100b7565b005b6370a0823160e01b60005260045260206000602460006000355afa156100ed5760005190565b63a9059cbb60e01b600052600452602452602060006044600060006000355af1156100ed573d156100eb57600051156100ed575b565b60006000fd")
+
+// probeHelper is the address the simulated transfers go through, it has no state on any chain
+var probeHelper = common.HexToAddress("0x00000000000000000000000000000000fee0fee0")
+
+// ErrNotSimulated is wrapped by simulation errors caused by the token, such as reverting transfers from the pool
+var ErrNotSimulated = errors.New("transfer tax simulation failed")
+
+// simulationKey identifies a simulated token and pool pair at a block, zero for the latest block
+type simulationKey struct {
+	token, pool common.Address
+	block       uint64
+}
+
+// simulation is a cached tax, expires is zero for taxes at a pinned block, which never change
+type simulation struct {
+	tax     Tax
+	expires time.Time
+}
+
+// Simulator detects transfer taxes by simulating a transfer out of and back into the pool with eth_call state overrides.
+// The pool code is replaced by a probe for the duration of the call, so the token sees real transfers from and to the pool.
+type Simulator struct {
+	caller OverrideCaller
+	cache  *lru.Cache[simulationKey, simulation]
+	ttl    time.Duration
+	// now is the clock of the cache, replaced in tests
+	now func() time.Time
+}
+
+// NewSimulator creates a simulator remembering the taxes of up to cacheSize token and pool pairs.
+// Taxes at the latest block are simulated again after ttl since tokens may change them.
+func NewSimulator(caller OverrideCaller, cacheSize int, ttl time.Duration) *Simulator {
+	return &Simulator{
+		caller: caller,
+		cache:  lru.NewCache[simulationKey, simulation](cacheSize),
+		ttl:    ttl,
+		now:    time.Now,
+	}
+}
+
+// Simulate returns the tax of token when traded through pool. Tokens whose transfers cannot be simulated are
+// treated as untaxed. The simulation runs at the block pinned in ctx.
+func (s *Simulator) Simulate(ctx context.Context, token, pool common.Address) (Tax, error) {
+	key := simulationKey{token: token, pool: pool}
+	var expires time.Time
+	if block := uniswap_v2.BlockNumberFromContext(ctx); block != nil {
+		key.block = block.Uint64()
+	} else {
+		expires = s.now().Add(s.ttl)
+	}
+	if cached, ok := s.cache.Get(key); ok && (cached.expires.IsZero() || s.now().Before(cached.expires)) {
+		return cached.tax, nil
+	}
+
+	tax, err := s.simulate(ctx, token, pool)
+	if errors.Is(err, ErrNotSimulated) {
+		log.Printf("Treating %s as untaxed in %s: %v", token.Hex(), pool.Hex(), err)
+		tax, err = Tax{}, nil
+	}
+	if err != nil {
+		return Tax{}, err
+	}
+
+	s.cache.Add(key, simulation{tax: tax, expires: expires})
+	return tax, nil
+}
+
+// simulate runs the probe against the pool
+func (s *Simulator) simulate(ctx context.Context, token, pool common.Address) (Tax, error) {
+	input := append(common.LeftPadBytes(token.Bytes(), 32), common.LeftPadBytes(probeHelper.Bytes(), 32)...)
+	overrides := map[common.Address]gethclient.OverrideAccount{
+		pool:        {Code: probeCode},
+		probeHelper: {Code: probeCode},
+	}
+
+	output, err := s.caller.CallContract(ctx, ethereum.CallMsg{To: &pool, Data: input}, uniswap_v2.BlockNumberFromContext(ctx), &overrides)
+	if err != nil {
+		if strings.Contains(err.Error(), "execution reverted") {
+			return Tax{}, fmt.Errorf("%w: %v", ErrNotSimulated, err)
+		}
+		return Tax{}, fmt.Errorf("simulate transfer tax of %s: %w", token.Hex(), err)
+	}
+	if len(output) != 96 {
+		return Tax{}, fmt.Errorf("%w: unexpected probe output of %d bytes", ErrNotSimulated, len(output))
+	}
+
+	amount := new(big.Int).SetBytes(output[:32])
+	received := new(big.Int).SetBytes(output[32:64])
+	returned := new(big.Int).SetBytes(output[64:])
+	if received.Sign() == 0 || received.Cmp(amount) > 0 || returned.Cmp(received) > 0 {
+		return Tax{}, fmt.Errorf("%w: sent %s, received %s, returned %s", ErrNotSimulated, amount, received, returned)
+	}
+
+	return Tax{
+		BuyBps:  lossBps(amount, received),
+		SellBps: lossBps(received, returned),
+	}, nil
+}
+
+// lossBps returns the share of sent lost in transfer in basis points, rounded up so that estimates never
+// overstate what arrives
+func lossBps(sent, received *big.Int) uint32 {
+	// bps = ceil((sent - received) * 10000 / sent)
+	loss := new(big.Int).Sub(sent, received)
+	loss.Mul(loss, big.NewInt(MaxBps))
+	loss.Add(loss, new(big.Int).Sub(sent, big.NewInt(1)))
+	loss.Div(loss, sent)
+	return uint32(loss.Uint64())
+}
@@ -0,0 +1,200 @@
+package transfertax
+
+import (
+	"1inch_testtask/internal/uniswap_v2"
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	taxedToken = common.HexToAddress("0x1111111111111111111111111111111111111111")
+	plainToken = common.HexToAddress("0x2222222222222222222222222222222222222222")
+	// taxedPool is the pool taxedTokenCode taxes transfers from and to
+	taxedPool = common.HexToAddress("0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852")
+)
+
+// taxedTokenCode is a minimal token with balances stored at the holder's slot that takes 3% of transfers from
+// taxedPool and 5% of transfers to it. transfer reverts on insufficient balance.
+//
+//	selector == balanceOf: return sload(account)
+//	selector == transfer:  require(sload(caller) >= amount)
+//	                       sstore(caller, sload(caller) - amount)
+//	                       tax = (caller == pool) * 300 + (to == pool) * 500
+//	                       sstore(to, sload(to) + amount - amount * tax / 10000)
+//	                       return true
+var taxedTokenCode = hexutil.MustDecode("0x60003560e01c806370a0823114610021578063a9059cbb1461002e5760006000fd5b6004355460005260206000f35b3354602435808210610096578181900333558033730d4a11d5eeaac28ec3f61d100daf4d40471f18521461012c02600435730d4a11d5eeaac28ec3f61d100daf4d40471f1852146101f402010261271090049003600435540160043555600160005260206000f35b60006000fd")
+
+// revertingCode reverts every call
+var revertingCode = hexutil.MustDecode("0x60006000fd")
+
+// evmCaller is an OverrideCaller executing calls in an in-memory EVM
+type evmCaller struct {
+	t     *testing.T
+	code  map[common.Address][]byte
+	slots map[common.Address]map[common.Hash]common.Hash
+	calls int
+}
+
+func (c *evmCaller) CallContract(_ context.Context, msg ethereum.CallMsg, _ *big.Int, overrides *map[common.Address]gethclient.OverrideAccount) ([]byte, error) {
+	c.calls++
+	statedb, err := state.New(types.EmptyRootHash, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	require.NoError(c.t, err)
+
+	for address, code := range c.code {
+		statedb.SetCode(address, code)
+	}
+	for address, slots := range c.slots {
+		for key, value := range slots {
+			statedb.SetState(address, key, value)
+		}
+	}
+	for address, override := range *overrides {
+		statedb.SetCode(address, override.Code)
+	}
+
+	output, _, err := runtime.Call(*msg.To, msg.Data, &runtime.Config{State: statedb, GasLimit: 1000000})
+	return output, err
+}
+
+// newEVMCaller creates a chain where taxedPool holds 1e12 units of a taxed and an untaxed token
+func newEVMCaller(t *testing.T) *evmCaller {
+	poolBalance := map[common.Hash]common.Hash{
+		common.BytesToHash(taxedPool.Bytes()): common.BigToHash(big.NewInt(1000000000000)),
+	}
+	return &evmCaller{
+		t: t,
+		code: map[common.Address][]byte{
+			taxedToken: taxedTokenCode,
+			plainToken: taxedTokenCode,
+		},
+		slots: map[common.Address]map[common.Hash]common.Hash{
+			taxedToken: poolBalance,
+			plainToken: poolBalance,
+		},
+	}
+}
+
+func TestSimulator_Simulate(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("taxed token", func(t *testing.T) {
+		caller := newEVMCaller(t)
+		simulator := NewSimulator(caller, 16, time.Minute)
+
+		tax, err := simulator.Simulate(ctx, taxedToken, taxedPool)
+		require.NoError(t, err)
+		assert.Equal(t, Tax{BuyBps: 300, SellBps: 500}, tax)
+
+		_, err = simulator.Simulate(ctx, taxedToken, taxedPool)
+		require.NoError(t, err)
+		assert.Equal(t, 1, caller.calls, "the result is cached")
+	})
+
+	t.Run("latest taxes expire", func(t *testing.T) {
+		caller := newEVMCaller(t)
+		simulator := NewSimulator(caller, 16, time.Minute)
+		now := time.Unix(1700000000, 0)
+		simulator.now = func() time.Time { return now }
+
+		_, err := simulator.Simulate(ctx, taxedToken, taxedPool)
+		require.NoError(t, err)
+		now = now.Add(time.Minute)
+		_, err = simulator.Simulate(ctx, taxedToken, taxedPool)
+		require.NoError(t, err)
+		assert.Equal(t, 2, caller.calls)
+	})
+
+	t.Run("pinned taxes are cached per block", func(t *testing.T) {
+		caller := newEVMCaller(t)
+		simulator := NewSimulator(caller, 16, time.Minute)
+		now := time.Unix(1700000000, 0)
+		simulator.now = func() time.Time { return now }
+
+		for _, block := range []int64{18500000, 18500001, 18500000} {
+			_, err := simulator.Simulate(uniswap_v2.WithBlockNumber(ctx, big.NewInt(block)), taxedToken, taxedPool)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 2, caller.calls)
+
+		// Past blocks never change
+		now = now.Add(time.Hour)
+		_, err := simulator.Simulate(uniswap_v2.WithBlockNumber(ctx, big.NewInt(18500000)), taxedToken, taxedPool)
+		require.NoError(t, err)
+		assert.Equal(t, 2, caller.calls)
+	})
+
+	t.Run("token not taxed in another pool", func(t *testing.T) {
+		caller := newEVMCaller(t)
+		otherPool := common.HexToAddress("0x3333333333333333333333333333333333333333")
+		caller.slots[plainToken] = map[common.Hash]common.Hash{
+			common.BytesToHash(otherPool.Bytes()): common.BigToHash(big.NewInt(1000000000000)),
+		}
+
+		tax, err := NewSimulator(caller, 16, time.Minute).Simulate(ctx, plainToken, otherPool)
+		require.NoError(t, err)
+		assert.True(t, tax.IsZero())
+	})
+
+	t.Run("reverting token is treated as untaxed", func(t *testing.T) {
+		caller := newEVMCaller(t)
+		caller.code[taxedToken] = revertingCode
+
+		tax, err := NewSimulator(caller, 16, time.Minute).Simulate(ctx, taxedToken, taxedPool)
+		require.NoError(t, err)
+		assert.True(t, tax.IsZero())
+	})
+
+	t.Run("empty pool is treated as untaxed", func(t *testing.T) {
+		caller := newEVMCaller(t)
+		delete(caller.slots, taxedToken)
+
+		tax, err := NewSimulator(caller, 16, time.Minute).Simulate(ctx, taxedToken, taxedPool)
+		require.NoError(t, err)
+		assert.True(t, tax.IsZero())
+	})
+}
+
+func TestDetector_Simulation(t *testing.T) {
+	registry := map[common.Address]Tax{plainToken: {BuyBps: 100, SellBps: 100}}
+	detector := NewDetector(registry, NewSimulator(newEVMCaller(t), 16, time.Minute))
+
+	tax, err := detector.TransferTax(context.Background(), taxedToken, taxedPool)
+	require.NoError(t, err)
+	assert.Equal(t, Tax{BuyBps: 300, SellBps: 500}, tax)
+
+	tax, err = detector.TransferTax(context.Background(), plainToken, taxedPool)
+	require.NoError(t, err)
+	assert.Equal(t, Tax{BuyBps: 100, SellBps: 100}, tax, "the registry takes precedence over simulation")
+}
+
+func TestLossBps(t *testing.T) {
+	tests := []struct {
+		sent, received int64
+		want           uint32
+	}{
+		{sent: 1000000, received: 1000000, want: 0},
+		{sent: 1000000, received: 970000, want: 300},
+		// 0.01 bps is reported as 1 bps
+		{sent: 1000000, received: 999999, want: 1},
+		// 300.4 bps is reported as 301 bps
+		{sent: 10000000, received: 9699600, want: 301},
+		{sent: 1000000, received: 0, want: 10000},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, lossBps(big.NewInt(tt.sent), big.NewInt(tt.received)), "%d of %d", tt.received, tt.sent)
+	}
+}
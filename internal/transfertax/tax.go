@@ -0,0 +1,126 @@
+package transfertax
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// MaxBps is a tax that takes the whole transferred amount
+const MaxBps = 10000
+
+// ErrWholeAmount is returned when an amount cannot be received because the tax takes the whole transfer
+var ErrWholeAmount = errors.New("transfer tax takes the whole amount")
+
+// Tax is the fee a token takes on transfers to and from a pool, in basis points
+type Tax struct {
+	// BuyBps is taken from the tokens a pool sends out
+	BuyBps uint32
+	// SellBps is taken from the tokens sent into a pool
+	SellBps uint32
+}
+
+// IsZero reports whether the token is not taxed
+func (t Tax) IsZero() bool {
+	return t.BuyBps == 0 && t.SellBps == 0
+}
+
+// AfterBuy returns the amount received when a pool sends amount, rounded down
+func (t Tax) AfterBuy(amount *big.Int) *big.Int {
+	return afterTax(amount, t.BuyBps)
+}
+
+// AfterSell returns the amount a pool receives when amount is sent to it, rounded down
+func (t Tax) AfterSell(amount *big.Int) *big.Int {
+	return afterTax(amount, t.SellBps)
+}
+
+// BeforeBuy returns the smallest amount a pool must send for amount to be received
+func (t Tax) BeforeBuy(amount *big.Int) (*big.Int, error) {
+	return beforeTax(amount, t.BuyBps)
+}
+
+// BeforeSell returns the smallest amount to send for a pool to receive amount
+func (t Tax) BeforeSell(amount *big.Int) (*big.Int, error) {
+	return beforeTax(amount, t.SellBps)
+}
+
+// beforeTax inverts afterTax, returning ceil(amount * 10000 / (10000 - bps))
+func beforeTax(amount *big.Int, bps uint32) (*big.Int, error) {
+	if bps == 0 {
+		return amount, nil
+	}
+	if bps >= MaxBps {
+		return nil, ErrWholeAmount
+	}
+	result := new(big.Int).Mul(amount, big.NewInt(MaxBps))
+	denominator := big.NewInt(int64(MaxBps - bps))
+	result.Add(result, new(big.Int).Sub(denominator, big.NewInt(1)))
+	return result.Div(result, denominator), nil
+}
+
+// afterTax returns amount * (10000 - bps) / 10000
+func afterTax(amount *big.Int, bps uint32) *big.Int {
+	if bps == 0 {
+		return amount
+	}
+	result := new(big.Int).Mul(amount, big.NewInt(int64(MaxBps-bps)))
+	return result.Div(result, big.NewInt(MaxBps))
+}
+
+// Detector reports the transfer tax of tokens, first from a registry of known tokens and then by simulation
+type Detector struct {
+	registry  map[common.Address]Tax
+	simulator *Simulator
+}
+
+// NewDetector creates a detector for the registered taxes. Unregistered tokens are simulated when simulator
+// is set and treated as untaxed otherwise.
+func NewDetector(registry map[common.Address]Tax, simulator *Simulator) *Detector {
+	return &Detector{
+		registry:  registry,
+		simulator: simulator,
+	}
+}
+
+// TransferTax returns the tax of token when traded through pool
+func (d *Detector) TransferTax(ctx context.Context, token, pool common.Address) (Tax, error) {
+	if tax, ok := d.registry[token]; ok {
+		return tax, nil
+	}
+	if d.simulator == nil {
+		return Tax{}, nil
+	}
+	return d.simulator.Simulate(ctx, token, pool)
+}
+
+// ParseRegistry parses registry entries of the form token:bps, taxing buys and sells alike, or token:buyBps:sellBps
+func ParseRegistry(entries []string) (map[common.Address]Tax, error) {
+	registry := make(map[common.Address]Tax, len(entries))
+	for _, entry := range entries {
+		parts := strings.Split(entry, ":")
+		if len(parts) < 2 || len(parts) > 3 || !common.IsHexAddress(parts[0]) {
+			return nil, fmt.Errorf("invalid transfer tax entry %q, expected token:bps or token:buyBps:sellBps", entry)
+		}
+
+		var bps [2]uint32
+		for i, part := range parts[1:] {
+			value, err := strconv.ParseUint(part, 10, 32)
+			if err != nil || value >= MaxBps {
+				return nil, fmt.Errorf("invalid transfer tax entry %q: tax must be between 0 and %d bps", entry, MaxBps-1)
+			}
+			bps[i] = uint32(value)
+		}
+		if len(parts) == 2 {
+			bps[1] = bps[0]
+		}
+
+		registry[common.HexToAddress(parts[0])] = Tax{BuyBps: bps[0], SellBps: bps[1]}
+	}
+	return registry, nil
+}
@@ -0,0 +1,104 @@
+package transfertax
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRegistry(t *testing.T) {
+	token := common.HexToAddress("0x1111111111111111111111111111111111111111")
+
+	tests := []struct {
+		name    string
+		entries []string
+		want    map[common.Address]Tax
+		wantErr bool
+	}{
+		{
+			name:    "same tax both ways",
+			entries: []string{"0x1111111111111111111111111111111111111111:200"},
+			want:    map[common.Address]Tax{token: {BuyBps: 200, SellBps: 200}},
+		},
+		{
+			name:    "separate buy and sell tax",
+			entries: []string{"0x1111111111111111111111111111111111111111:300:500"},
+			want:    map[common.Address]Tax{token: {BuyBps: 300, SellBps: 500}},
+		},
+		{
+			name:    "missing tax",
+			entries: []string{"0x1111111111111111111111111111111111111111"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid token",
+			entries: []string{"0x1111:200"},
+			wantErr: true,
+		},
+		{
+			name:    "tax of the whole amount",
+			entries: []string{"0x1111111111111111111111111111111111111111:10000"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry, err := ParseRegistry(tt.entries)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, registry)
+		})
+	}
+}
+
+func TestTax_Apply(t *testing.T) {
+	tax := Tax{BuyBps: 300, SellBps: 500}
+
+	assert.Equal(t, big.NewInt(970), tax.AfterBuy(big.NewInt(1000)))
+	assert.Equal(t, big.NewInt(950), tax.AfterSell(big.NewInt(1000)))
+	assert.Equal(t, big.NewInt(949), tax.AfterSell(big.NewInt(999)), "rounded down")
+	assert.Equal(t, big.NewInt(1000), Tax{}.AfterBuy(big.NewInt(1000)))
+}
+
+func TestTax_Invert(t *testing.T) {
+	tax := Tax{BuyBps: 300, SellBps: 500}
+
+	amount, err := tax.BeforeBuy(big.NewInt(970))
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(1000), amount)
+
+	amount, err = tax.BeforeSell(big.NewInt(949))
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(999), amount, "rounded up to the smallest amount that is enough")
+	assert.Equal(t, big.NewInt(949), tax.AfterSell(amount))
+	assert.Equal(t, big.NewInt(948), tax.AfterSell(new(big.Int).Sub(amount, big.NewInt(1))))
+
+	_, err = Tax{SellBps: MaxBps}.BeforeSell(big.NewInt(1))
+	assert.ErrorIs(t, err, ErrWholeAmount)
+}
+
+func TestDetector_TransferTax(t *testing.T) {
+	ctx := context.Background()
+	registered := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	other := common.HexToAddress("0x2222222222222222222222222222222222222222")
+	pool := common.HexToAddress("0x3333333333333333333333333333333333333333")
+	registry := map[common.Address]Tax{registered: {BuyBps: 100, SellBps: 100}}
+
+	detector := NewDetector(registry, nil)
+
+	tax, err := detector.TransferTax(ctx, registered, pool)
+	require.NoError(t, err)
+	assert.Equal(t, Tax{BuyBps: 100, SellBps: 100}, tax)
+
+	tax, err = detector.TransferTax(ctx, other, pool)
+	require.NoError(t, err)
+	assert.True(t, tax.IsZero(), "unregistered tokens are untaxed without a simulator")
+}
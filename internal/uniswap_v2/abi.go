@@ -32,5 +32,179 @@ const UniswapV2PairABI = `[
 		"payable": false,
 		"stateMutability": "view",
 		"type": "function"
+	},
+	{
+		"constant": true,
+		"inputs": [],
+		"name": "factory",
+		"outputs": [{"name": "", "type": "address"}],
+		"payable": false,
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"anonymous": false,
+		"inputs": [
+			{"indexed": false, "name": "reserve0", "type": "uint112"},
+			{"indexed": false, "name": "reserve1", "type": "uint112"}
+		],
+		"name": "Sync",
+		"type": "event"
+	}
+]`
+
+// Multicall3ABI is the ABI for the aggregate3 method of the Multicall3 contract
+const Multicall3ABI = `[
+	{
+		"inputs": [
+			{
+				"components": [
+					{"name": "target", "type": "address"},
+					{"name": "allowFailure", "type": "bool"},
+					{"name": "callData", "type": "bytes"}
+				],
+				"name": "calls",
+				"type": "tuple[]"
+			}
+		],
+		"name": "aggregate3",
+		"outputs": [
+			{
+				"components": [
+					{"name": "success", "type": "bool"},
+					{"name": "returnData", "type": "bytes"}
+				],
+				"name": "returnData",
+				"type": "tuple[]"
+			}
+		],
+		"stateMutability": "payable",
+		"type": "function"
+	}
+]`
+
+// UniswapV2Router02ABI is the ABI for the exact input swap methods of the UniswapV2Router02 contract,
+// including their variants for fee-on-transfer tokens
+const UniswapV2Router02ABI = `[
+	{
+		"inputs": [
+			{"name": "amountIn", "type": "uint256"},
+			{"name": "amountOutMin", "type": "uint256"},
+			{"name": "path", "type": "address[]"},
+			{"name": "to", "type": "address"},
+			{"name": "deadline", "type": "uint256"}
+		],
+		"name": "swapExactTokensForTokens",
+		"outputs": [{"name": "amounts", "type": "uint256[]"}],
+		"stateMutability": "nonpayable",
+		"type": "function"
+	},
+	{
+		"inputs": [
+			{"name": "amountOutMin", "type": "uint256"},
+			{"name": "path", "type": "address[]"},
+			{"name": "to", "type": "address"},
+			{"name": "deadline", "type": "uint256"}
+		],
+		"name": "swapExactETHForTokens",
+		"outputs": [{"name": "amounts", "type": "uint256[]"}],
+		"stateMutability": "payable",
+		"type": "function"
+	},
+	{
+		"inputs": [
+			{"name": "amountIn", "type": "uint256"},
+			{"name": "amountOutMin", "type": "uint256"},
+			{"name": "path", "type": "address[]"},
+			{"name": "to", "type": "address"},
+			{"name": "deadline", "type": "uint256"}
+		],
+		"name": "swapExactTokensForETH",
+		"outputs": [{"name": "amounts", "type": "uint256[]"}],
+		"stateMutability": "nonpayable",
+		"type": "function"
+	},
+	{
+		"inputs": [
+			{"name": "amountIn", "type": "uint256"},
+			{"name": "amountOutMin", "type": "uint256"},
+			{"name": "path", "type": "address[]"},
+			{"name": "to", "type": "address"},
+			{"name": "deadline", "type": "uint256"}
+		],
+		"name": "swapExactTokensForTokensSupportingFeeOnTransferTokens",
+		"outputs": [],
+		"stateMutability": "nonpayable",
+		"type": "function"
+	},
+	{
+		"inputs": [
+			{"name": "amountOutMin", "type": "uint256"},
+			{"name": "path", "type": "address[]"},
+			{"name": "to", "type": "address"},
+			{"name": "deadline", "type": "uint256"}
+		],
+		"name": "swapExactETHForTokensSupportingFeeOnTransferTokens",
+		"outputs": [],
+		"stateMutability": "payable",
+		"type": "function"
+	},
+	{
+		"inputs": [
+			{"name": "amountIn", "type": "uint256"},
+			{"name": "amountOutMin", "type": "uint256"},
+			{"name": "path", "type": "address[]"},
+			{"name": "to", "type": "address"},
+			{"name": "deadline", "type": "uint256"}
+		],
+		"name": "swapExactTokensForETHSupportingFeeOnTransferTokens",
+		"outputs": [],
+		"stateMutability": "nonpayable",
+		"type": "function"
+	}
+]`
+
+// UniswapV2FactoryABI is the ABI for the pair registry and the PairCreated event of the Uniswap V2 Factory contract
+const UniswapV2FactoryABI = `[
+	{
+		"constant": true,
+		"inputs": [
+			{"name": "tokenA", "type": "address"},
+			{"name": "tokenB", "type": "address"}
+		],
+		"name": "getPair",
+		"outputs": [{"name": "pair", "type": "address"}],
+		"payable": false,
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"constant": true,
+		"inputs": [],
+		"name": "allPairsLength",
+		"outputs": [{"name": "", "type": "uint256"}],
+		"payable": false,
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"constant": true,
+		"inputs": [{"name": "", "type": "uint256"}],
+		"name": "allPairs",
+		"outputs": [{"name": "", "type": "address"}],
+		"payable": false,
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"anonymous": false,
+		"inputs": [
+			{"indexed": true, "name": "token0", "type": "address"},
+			{"indexed": true, "name": "token1", "type": "address"},
+			{"indexed": false, "name": "pair", "type": "address"},
+			{"indexed": false, "name": "", "type": "uint256"}
+		],
+		"name": "PairCreated",
+		"type": "event"
 	}
 ]`
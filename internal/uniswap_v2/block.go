@@ -0,0 +1,117 @@
+package uniswap_v2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// ErrBlockNotFound is returned when the requested block does not exist
+var ErrBlockNotFound = errors.New("block not found")
+
+// IBlockResolver resolves block numbers, hashes and tags into concrete blocks
+type IBlockResolver interface {
+	ResolveBlock(ctx context.Context, block string) (*BlockRef, error)
+}
+
+// BlockRef identifies a concrete block
+type BlockRef struct {
+	Number    *big.Int
+	Hash      common.Hash
+	Timestamp uint64
+}
+
+// ParseBlock parses a block number (decimal or 0x-hex), a 32-byte block hash
+// or one of the latest, safe and finalized tags
+func ParseBlock(block string) (rpc.BlockNumberOrHash, error) {
+	switch block {
+	case "", "latest":
+		return rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber), nil
+	case "safe":
+		return rpc.BlockNumberOrHashWithNumber(rpc.SafeBlockNumber), nil
+	case "finalized":
+		return rpc.BlockNumberOrHashWithNumber(rpc.FinalizedBlockNumber), nil
+	}
+
+	if strings.HasPrefix(block, "0x") && len(block) == 66 {
+		hash := common.HexToHash(block)
+		if hash.Hex() != strings.ToLower(block) {
+			return rpc.BlockNumberOrHash{}, fmt.Errorf("invalid block hash: %s", block)
+		}
+		return rpc.BlockNumberOrHashWithHash(hash, false), nil
+	}
+
+	var (
+		number uint64
+		err    error
+	)
+	if strings.HasPrefix(block, "0x") {
+		number, err = strconv.ParseUint(block[2:], 16, 63)
+	} else {
+		number, err = strconv.ParseUint(block, 10, 63)
+	}
+	if err != nil {
+		return rpc.BlockNumberOrHash{}, fmt.Errorf("invalid block: %s", block)
+	}
+
+	return rpc.BlockNumberOrHashWithNumber(rpc.BlockNumber(number)), nil
+}
+
+// ResolveBlock resolves a block number, hash or tag into a concrete block
+func (c *Client) ResolveBlock(ctx context.Context, block string) (*BlockRef, error) {
+	ref, err := ParseBlock(block)
+	if err != nil {
+		return nil, err
+	}
+
+	var header *types.Header
+	if hash, ok := ref.Hash(); ok {
+		header, err = c.client.HeaderByHash(ctx, hash)
+	} else {
+		number, _ := ref.Number()
+		header, err = c.client.HeaderByNumber(ctx, big.NewInt(number.Int64()))
+	}
+	if errors.Is(err, ethereum.NotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrBlockNotFound, block)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &BlockRef{
+		Number:    header.Number,
+		Hash:      header.Hash(),
+		Timestamp: header.Time,
+	}, nil
+}
+
+// blockNumberKey is the context key of the block number calls are pinned to
+type blockNumberKey struct{}
+
+// WithBlockNumber returns a context that pins pool calls made with it to the given block
+func WithBlockNumber(ctx context.Context, number *big.Int) context.Context {
+	return context.WithValue(ctx, blockNumberKey{}, number)
+}
+
+// BlockNumberFromContext returns the block number pinned by WithBlockNumber, nil means latest
+func BlockNumberFromContext(ctx context.Context) *big.Int {
+	number, _ := ctx.Value(blockNumberKey{}).(*big.Int)
+	return number
+}
+
+// callOpts returns call options for ctx, honouring the block pinned by WithBlockNumber
+func callOpts(ctx context.Context) *bind.CallOpts {
+	return &bind.CallOpts{
+		Context:     ctx,
+		BlockNumber: BlockNumberFromContext(ctx),
+	}
+}
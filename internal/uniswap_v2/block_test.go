@@ -0,0 +1,44 @@
+package uniswap_v2
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBlock(t *testing.T) {
+	hash := "0x9e3d4a1b6c0f4b0b1c3e2f5d8a7b6c5d4e3f2a1b0c9d8e7f6a5b4c3d2e1f0a9b"
+
+	tests := []struct {
+		name     string
+		block    string
+		expected rpc.BlockNumberOrHash
+		wantErr  bool
+	}{
+		{name: "empty is latest", block: "", expected: rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)},
+		{name: "latest", block: "latest", expected: rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)},
+		{name: "safe", block: "safe", expected: rpc.BlockNumberOrHashWithNumber(rpc.SafeBlockNumber)},
+		{name: "finalized", block: "finalized", expected: rpc.BlockNumberOrHashWithNumber(rpc.FinalizedBlockNumber)},
+		{name: "decimal number", block: "18500000", expected: rpc.BlockNumberOrHashWithNumber(18500000)},
+		{name: "hex number", block: "0x11a49a0", expected: rpc.BlockNumberOrHashWithNumber(18500000)},
+		{name: "hash", block: hash, expected: rpc.BlockNumberOrHashWithHash(common.HexToHash(hash), false)},
+		{name: "pending is rejected", block: "pending", wantErr: true},
+		{name: "negative number", block: "-1", wantErr: true},
+		{name: "invalid hex", block: "0xzz", wantErr: true},
+		{name: "invalid hash", block: "0x9e3d4a1b6c0f4b0b1c3e2f5d8a7b6c5d4e3f2a1b0c9d8e7f6a5b4c3d2e1f0azz", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ParseBlock(tt.block)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
@@ -0,0 +1,294 @@
+package uniswap_v2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+)
+
+// PairTokens holds the immutable tokens and factory of a Uniswap V2 pair
+type PairTokens struct {
+	Token0 common.Address `json:"token0"`
+	Token1 common.Address `json:"token1"`
+	// Factory is zero when only the tokens were fetched, such entries are not used for full pool states
+	Factory common.Address `json:"factory,omitempty"`
+}
+
+// cachePersistInterval is how often Run writes new cache entries to the persistence file
+const cachePersistInterval = 10 * time.Second
+
+// CacheStats holds pair metadata cache counters
+type CacheStats struct {
+	Hits   uint64 `json:"hits"`
+	Misses uint64 `json:"misses"`
+	Size   int    `json:"size"`
+}
+
+// CachedClient is an IUniswapV2 decorator that memoizes token0/token1/factory of pairs, which never change.
+// Reserves are always fetched fresh from the wrapped client.
+type CachedClient struct {
+	next  IUniswapV2
+	pairs *lru.Cache[common.Address, PairTokens]
+	// path is the file the cache is persisted to, empty disables persistence
+	path   string
+	saveMu sync.Mutex
+	// dirty is set when entries were added since the last save
+	dirty           atomic.Bool
+	persistInterval time.Duration
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+// NewCachedClient wraps next with a pair metadata cache of the given capacity.
+// If path is not empty, the cache is loaded from and persisted to that file.
+func NewCachedClient(next IUniswapV2, capacity int, path string) (*CachedClient, error) {
+	c := &CachedClient{
+		next:            next,
+		pairs:           lru.NewCache[common.Address, PairTokens](capacity),
+		path:            path,
+		persistInterval: cachePersistInterval,
+	}
+
+	if path != "" {
+		if err := c.load(); err != nil {
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// Stats returns the cache counters
+func (c *CachedClient) Stats() CacheStats {
+	return CacheStats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+		Size:   c.pairs.Len(),
+	}
+}
+
+// Run persists new cache entries in the background until ctx is done, then persists once more.
+// Misses only mark the cache dirty so that requests never wait on the file.
+func (c *CachedClient) Run(ctx context.Context) {
+	if c.path == "" {
+		return
+	}
+
+	ticker := time.NewTicker(c.persistInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			c.persist()
+			return
+		case <-ticker.C:
+			c.persist()
+		}
+	}
+}
+
+// Flush persists cache entries the background writer has not saved yet, leaving the wrapped client open
+func (c *CachedClient) Flush() {
+	c.persist()
+}
+
+// Close persists the cache and closes the wrapped client
+func (c *CachedClient) Close() {
+	c.Flush()
+	c.next.Close()
+}
+
+// GetReserves gets the reserves from a Uniswap V2 pair, bypassing the cache
+func (c *CachedClient) GetReserves(ctx context.Context, poolAddress common.Address) (*big.Int, *big.Int, error) {
+	return c.next.GetReserves(ctx, poolAddress)
+}
+
+// GetReservesBatch gets reserves of the pairs, bypassing the cache
+func (c *CachedClient) GetReservesBatch(ctx context.Context, poolAddresses []common.Address) ([]Reserves, error) {
+	return c.next.GetReservesBatch(ctx, poolAddresses)
+}
+
+// GetToken0 gets token0 address from the pair
+func (c *CachedClient) GetToken0(ctx context.Context, poolAddress common.Address) (common.Address, error) {
+	tokens, err := c.getTokens(ctx, poolAddress)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return tokens.Token0, nil
+}
+
+// GetToken1 gets token1 address from the pair
+func (c *CachedClient) GetToken1(ctx context.Context, poolAddress common.Address) (common.Address, error) {
+	tokens, err := c.getTokens(ctx, poolAddress)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return tokens.Token1, nil
+}
+
+// GetPoolStates gets tokens and reserves of the pairs. Only reserves are fetched for cached pairs,
+// full state is fetched for the rest.
+func (c *CachedClient) GetPoolStates(ctx context.Context, poolAddresses []common.Address) ([]PoolState, error) {
+	states := make([]PoolState, len(poolAddresses))
+
+	var cached, missing []int
+	for i, poolAddress := range poolAddresses {
+		if tokens, ok := c.pairs.Get(poolAddress); ok && tokens.Factory != (common.Address{}) {
+			c.hits.Add(1)
+			states[i] = PoolState{Pool: poolAddress, Token0: tokens.Token0, Token1: tokens.Token1, Factory: tokens.Factory}
+			cached = append(cached, i)
+		} else {
+			c.misses.Add(1)
+			missing = append(missing, i)
+		}
+	}
+
+	if len(cached) > 0 {
+		addresses := make([]common.Address, len(cached))
+		for j, i := range cached {
+			addresses[j] = poolAddresses[i]
+		}
+
+		reserves, err := c.next.GetReservesBatch(ctx, addresses)
+		if err != nil {
+			return nil, err
+		}
+		for j, i := range cached {
+			states[i].Reserve0 = reserves[j].Reserve0
+			states[i].Reserve1 = reserves[j].Reserve1
+			states[i].BlockTimestampLast = reserves[j].BlockTimestampLast
+		}
+	}
+
+	if len(missing) > 0 {
+		addresses := make([]common.Address, len(missing))
+		for j, i := range missing {
+			addresses[j] = poolAddresses[i]
+		}
+
+		fetched, err := c.next.GetPoolStates(ctx, addresses)
+		if err != nil {
+			return nil, err
+		}
+		for j, i := range missing {
+			states[i] = fetched[j]
+			c.pairs.Add(fetched[j].Pool, PairTokens{Token0: fetched[j].Token0, Token1: fetched[j].Token1, Factory: fetched[j].Factory})
+		}
+		c.dirty.Store(true)
+	}
+
+	return states, nil
+}
+
+// getTokens returns the tokens of a pair from the cache, fetching them on a miss
+func (c *CachedClient) getTokens(ctx context.Context, poolAddress common.Address) (PairTokens, error) {
+	if tokens, ok := c.pairs.Get(poolAddress); ok {
+		c.hits.Add(1)
+		return tokens, nil
+	}
+	c.misses.Add(1)
+
+	token0, err := c.next.GetToken0(ctx, poolAddress)
+	if err != nil {
+		return PairTokens{}, err
+	}
+
+	token1, err := c.next.GetToken1(ctx, poolAddress)
+	if err != nil {
+		return PairTokens{}, err
+	}
+
+	tokens := PairTokens{Token0: token0, Token1: token1}
+	c.pairs.Add(poolAddress, tokens)
+	c.dirty.Store(true)
+
+	return tokens, nil
+}
+
+// load fills the cache from the persistence file, a missing file is not an error
+func (c *CachedClient) load() error {
+	data, err := os.ReadFile(c.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read pool cache: %w", err)
+	}
+
+	var pairs map[common.Address]PairTokens
+	if err := json.Unmarshal(data, &pairs); err != nil {
+		return fmt.Errorf("parse pool cache %s: %w", c.path, err)
+	}
+	for pool, tokens := range pairs {
+		c.pairs.Add(pool, tokens)
+	}
+
+	return nil
+}
+
+// persist saves the cache if it changed, logging failures since the cache can always be rebuilt from chain
+func (c *CachedClient) persist() {
+	if !c.dirty.Swap(false) {
+		return
+	}
+	if err := c.save(); err != nil {
+		// Retried on the next persist
+		c.dirty.Store(true)
+		log.Printf("Failed to persist pool cache: %v", err)
+	}
+}
+
+// save writes the cache to the persistence file atomically
+func (c *CachedClient) save() error {
+	if c.path == "" {
+		return nil
+	}
+
+	c.saveMu.Lock()
+	defer c.saveMu.Unlock()
+
+	pairs := make(map[common.Address]PairTokens, c.pairs.Len())
+	for _, pool := range c.pairs.Keys() {
+		if tokens, ok := c.pairs.Peek(pool); ok {
+			pairs[pool] = tokens
+		}
+	}
+
+	data, err := json.MarshalIndent(pairs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.path), filepath.Base(c.path)+".*")
+	if err != nil {
+		return fmt.Errorf("save pool cache: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("save pool cache: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("save pool cache: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), c.path); err != nil {
+		return fmt.Errorf("save pool cache: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,206 @@
+package uniswap_v2
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingClient wraps an IUniswapV2 and counts calls per method
+type countingClient struct {
+	IUniswapV2
+	calls map[string]int
+}
+
+func newCountingClient(t *testing.T) *countingClient {
+	client, _ := newTestMulticallClient(t)
+	return &countingClient{IUniswapV2: client, calls: make(map[string]int)}
+}
+
+func (c *countingClient) GetReserves(ctx context.Context, poolAddress common.Address) (*big.Int, *big.Int, error) {
+	c.calls["getReserves"]++
+	return c.IUniswapV2.GetReserves(ctx, poolAddress)
+}
+
+func (c *countingClient) GetToken0(ctx context.Context, poolAddress common.Address) (common.Address, error) {
+	c.calls["token0"]++
+	return c.IUniswapV2.GetToken0(ctx, poolAddress)
+}
+
+func (c *countingClient) GetToken1(ctx context.Context, poolAddress common.Address) (common.Address, error) {
+	c.calls["token1"]++
+	return c.IUniswapV2.GetToken1(ctx, poolAddress)
+}
+
+func (c *countingClient) Close() {
+	c.calls["close"]++
+}
+
+func (c *countingClient) GetPoolStates(ctx context.Context, poolAddresses []common.Address) ([]PoolState, error) {
+	c.calls["poolStates"]++
+	return c.IUniswapV2.GetPoolStates(ctx, poolAddresses)
+}
+
+func (c *countingClient) GetReservesBatch(ctx context.Context, poolAddresses []common.Address) ([]Reserves, error) {
+	c.calls["reservesBatch"]++
+	return c.IUniswapV2.GetReservesBatch(ctx, poolAddresses)
+}
+
+func TestCachedClient_GetPoolStates(t *testing.T) {
+	next := newCountingClient(t)
+	client, err := NewCachedClient(next, 16, "")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	first, err := client.GetPoolStates(ctx, []common.Address{usdtWeth})
+	require.NoError(t, err)
+	assert.Equal(t, 1, next.calls["poolStates"])
+	assert.Equal(t, CacheStats{Hits: 0, Misses: 1, Size: 1}, client.Stats())
+
+	// Cached pair only needs fresh reserves, new pair is fetched in full
+	second, err := client.GetPoolStates(ctx, []common.Address{daiWeth, usdtWeth})
+	require.NoError(t, err)
+	assert.Equal(t, 2, next.calls["poolStates"])
+	assert.Equal(t, 1, next.calls["reservesBatch"])
+	assert.Equal(t, CacheStats{Hits: 1, Misses: 2, Size: 2}, client.Stats())
+
+	assert.Equal(t, first[0], second[1])
+	assert.Equal(t, dai, second[0].Token0)
+	assert.Equal(t, big.NewInt(2000000), second[0].Reserve0)
+
+	// Fully cached request is a single reserves call
+	_, err = client.GetPoolStates(ctx, []common.Address{daiWeth, usdtWeth})
+	require.NoError(t, err)
+	assert.Equal(t, 2, next.calls["poolStates"])
+	assert.Equal(t, 2, next.calls["reservesBatch"])
+	assert.Equal(t, CacheStats{Hits: 3, Misses: 2, Size: 2}, client.Stats())
+}
+
+func TestCachedClient_Tokens(t *testing.T) {
+	next := newCountingClient(t)
+	client, err := NewCachedClient(next, 16, "")
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		token0, err := client.GetToken0(ctx, usdtWeth)
+		require.NoError(t, err)
+		assert.Equal(t, weth, token0)
+
+		token1, err := client.GetToken1(ctx, usdtWeth)
+		require.NoError(t, err)
+		assert.Equal(t, usdt, token1)
+
+		_, _, err = client.GetReserves(ctx, usdtWeth)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 1, next.calls["token0"])
+	assert.Equal(t, 1, next.calls["token1"])
+	assert.Equal(t, 3, next.calls["getReserves"], "reserves must never be cached")
+	assert.Equal(t, CacheStats{Hits: 5, Misses: 1, Size: 1}, client.Stats())
+}
+
+func TestCachedClient_Bounded(t *testing.T) {
+	client, err := NewCachedClient(newCountingClient(t), 1, "")
+	require.NoError(t, err)
+
+	_, err = client.GetPoolStates(context.Background(), []common.Address{usdtWeth, daiWeth})
+	require.NoError(t, err)
+	assert.Equal(t, 1, client.Stats().Size)
+}
+
+func TestCachedClient_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pools.json")
+	ctx := context.Background()
+
+	wrapped := newCountingClient(t)
+	client, err := NewCachedClient(wrapped, 16, path)
+	require.NoError(t, err)
+	_, err = client.GetPoolStates(ctx, []common.Address{usdtWeth, daiWeth})
+	require.NoError(t, err)
+	client.Flush()
+	assert.Zero(t, wrapped.calls["close"], "flushing leaves the wrapped client open")
+
+	next := newCountingClient(t)
+	restored, err := NewCachedClient(next, 16, path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, restored.Stats().Size)
+
+	token0, err := restored.GetToken0(ctx, daiWeth)
+	require.NoError(t, err)
+	assert.Equal(t, dai, token0)
+	assert.Zero(t, next.calls["token0"])
+}
+
+func TestCachedClient_PersistsInBackground(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pools.json")
+
+	client, err := NewCachedClient(newCountingClient(t), 16, path)
+	require.NoError(t, err)
+	client.persistInterval = 10 * time.Millisecond
+
+	_, err = client.GetPoolStates(context.Background(), []common.Address{usdtWeth})
+	require.NoError(t, err)
+	assert.NoFileExists(t, path, "misses must not write the file")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		client.Run(ctx)
+		close(done)
+	}()
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(path)
+		return err == nil
+	}, time.Second, 5*time.Millisecond)
+
+	// Entries added before shutdown are persisted when Run stops
+	_, err = client.GetPoolStates(context.Background(), []common.Address{daiWeth})
+	require.NoError(t, err)
+	cancel()
+	<-done
+
+	restored, err := NewCachedClient(newCountingClient(t), 16, path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, restored.Stats().Size)
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary files must be renamed or removed")
+}
+
+func TestCachedClient_EntryWithoutFactory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pools.json")
+	ctx := context.Background()
+
+	// Entries persisted before factories were cached only hold the tokens
+	legacy := `{"` + usdtWeth.Hex() + `": {"token0": "` + weth.Hex() + `", "token1": "` + usdt.Hex() + `"}}`
+	require.NoError(t, os.WriteFile(path, []byte(legacy), 0o600))
+
+	next := newCountingClient(t)
+	client, err := NewCachedClient(next, 16, path)
+	require.NoError(t, err)
+
+	token0, err := client.GetToken0(ctx, usdtWeth)
+	require.NoError(t, err)
+	assert.Equal(t, weth, token0)
+	assert.Zero(t, next.calls["token0"])
+
+	states, err := client.GetPoolStates(ctx, []common.Address{usdtWeth})
+	require.NoError(t, err)
+	assert.Equal(t, 1, next.calls["poolStates"], "entry without factory should be fetched in full")
+	assert.Equal(t, factory, states[0].Factory)
+
+	states, err = client.GetPoolStates(ctx, []common.Address{usdtWeth})
+	require.NoError(t, err)
+	assert.Equal(t, 1, next.calls["poolStates"])
+	assert.Equal(t, factory, states[0].Factory)
+}
@@ -2,6 +2,7 @@ package uniswap_v2
 
 import (
 	"context"
+	"fmt"
 	"github.com/ethereum/go-ethereum/accounts/abi"
 	"math/big"
 	"strings"
@@ -9,15 +10,39 @@ import (
 	"github.com/ethereum/go-ethereum/accounts/abi/bind"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
 )
 
 type IUniswapV2 interface {
 	GetReserves(ctx context.Context, poolAddress common.Address) (*big.Int, *big.Int, error)
 	GetToken0(ctx context.Context, poolAddress common.Address) (common.Address, error)
 	GetToken1(ctx context.Context, poolAddress common.Address) (common.Address, error)
+	GetPoolStates(ctx context.Context, poolAddresses []common.Address) ([]PoolState, error)
+	GetReservesBatch(ctx context.Context, poolAddresses []common.Address) ([]Reserves, error)
 	Close()
 }
 
+// Reserves holds the reserves of a Uniswap V2 pair
+type Reserves struct {
+	Reserve0 *big.Int
+	Reserve1 *big.Int
+	// BlockTimestampLast is the timestamp of the block the reserves were last updated in
+	BlockTimestampLast uint32
+}
+
+// PoolState holds the tokens and reserves of a Uniswap V2 pair
+type PoolState struct {
+	Pool   common.Address
+	Token0 common.Address
+	Token1 common.Address
+	// Factory is the factory that deployed the pair, it identifies the fork and therefore the swap fee
+	Factory  common.Address
+	Reserve0 *big.Int
+	Reserve1 *big.Int
+	// BlockTimestampLast is the timestamp of the block the reserves were last updated in
+	BlockTimestampLast uint32
+}
+
 // Client wraps the Ethereum client
 type Client struct {
 	client    *ethclient.Client
@@ -42,6 +67,16 @@ func NewClient(infuraURL string) (*Client, error) {
 	}, nil
 }
 
+// Backend returns the underlying Ethereum client for contract calls
+func (c *Client) Backend() bind.ContractBackend {
+	return c.client
+}
+
+// RPC returns the underlying JSON-RPC client for calls not covered by ethclient
+func (c *Client) RPC() *rpc.Client {
+	return c.client.Client()
+}
+
 // Close closes the Ethereum client connection
 func (c *Client) Close() {
 	c.client.Close()
@@ -49,14 +84,28 @@ func (c *Client) Close() {
 
 // GetReserves gets the reserves from a Uniswap V2 pair
 func (c *Client) GetReserves(ctx context.Context, poolAddress common.Address) (*big.Int, *big.Int, error) {
+	reserves, err := c.getReserves(ctx, poolAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return reserves.Reserve0, reserves.Reserve1, nil
+}
+
+// getReserves gets the reserves together with the last update timestamp from a Uniswap V2 pair
+func (c *Client) getReserves(ctx context.Context, poolAddress common.Address) (Reserves, error) {
 	contract := bind.NewBoundContract(poolAddress, c.parsedABI, c.client, c.client, c.client)
 
 	var out []interface{}
-	if err := contract.Call(&bind.CallOpts{Context: ctx}, &out, "getReserves"); err != nil {
-		return nil, nil, err
+	if err := contract.Call(callOpts(ctx), &out, "getReserves"); err != nil {
+		return Reserves{}, err
 	}
 
-	return out[0].(*big.Int), out[1].(*big.Int), nil
+	return Reserves{
+		Reserve0:           out[0].(*big.Int),
+		Reserve1:           out[1].(*big.Int),
+		BlockTimestampLast: out[2].(uint32),
+	}, nil
 }
 
 // GetToken0 gets token0 address from the pair
@@ -64,7 +113,7 @@ func (c *Client) GetToken0(ctx context.Context, poolAddress common.Address) (com
 	contract := bind.NewBoundContract(poolAddress, c.parsedABI, c.client, c.client, c.client)
 
 	var out []interface{}
-	if err := contract.Call(&bind.CallOpts{Context: ctx}, &out, "token0"); err != nil {
+	if err := contract.Call(callOpts(ctx), &out, "token0"); err != nil {
 		return common.Address{}, err
 	}
 
@@ -76,9 +125,73 @@ func (c *Client) GetToken1(ctx context.Context, poolAddress common.Address) (com
 	contract := bind.NewBoundContract(poolAddress, c.parsedABI, c.client, c.client, c.client)
 
 	var out []interface{}
-	if err := contract.Call(&bind.CallOpts{Context: ctx}, &out, "token1"); err != nil {
+	if err := contract.Call(callOpts(ctx), &out, "token1"); err != nil {
 		return common.Address{}, err
 	}
 
 	return out[0].(common.Address), nil
 }
+
+// getFactory gets the factory address from the pair
+func (c *Client) getFactory(ctx context.Context, poolAddress common.Address) (common.Address, error) {
+	contract := bind.NewBoundContract(poolAddress, c.parsedABI, c.client, c.client, c.client)
+
+	var out []interface{}
+	if err := contract.Call(callOpts(ctx), &out, "factory"); err != nil {
+		return common.Address{}, err
+	}
+
+	return out[0].(common.Address), nil
+}
+
+// GetPoolStates gets tokens, factory and reserves of the pairs, querying every pair one call at a time
+func (c *Client) GetPoolStates(ctx context.Context, poolAddresses []common.Address) ([]PoolState, error) {
+	states := make([]PoolState, len(poolAddresses))
+	for i, poolAddress := range poolAddresses {
+		token0, err := c.GetToken0(ctx, poolAddress)
+		if err != nil {
+			return nil, fmt.Errorf("token0 of %s: %w", poolAddress.Hex(), err)
+		}
+
+		token1, err := c.GetToken1(ctx, poolAddress)
+		if err != nil {
+			return nil, fmt.Errorf("token1 of %s: %w", poolAddress.Hex(), err)
+		}
+
+		factory, err := c.getFactory(ctx, poolAddress)
+		if err != nil {
+			return nil, fmt.Errorf("factory of %s: %w", poolAddress.Hex(), err)
+		}
+
+		reserves, err := c.getReserves(ctx, poolAddress)
+		if err != nil {
+			return nil, fmt.Errorf("reserves of %s: %w", poolAddress.Hex(), err)
+		}
+
+		states[i] = PoolState{
+			Pool:               poolAddress,
+			Token0:             token0,
+			Token1:             token1,
+			Factory:            factory,
+			Reserve0:           reserves.Reserve0,
+			Reserve1:           reserves.Reserve1,
+			BlockTimestampLast: reserves.BlockTimestampLast,
+		}
+	}
+
+	return states, nil
+}
+
+// GetReservesBatch gets reserves of the pairs, querying every pair one call at a time
+func (c *Client) GetReservesBatch(ctx context.Context, poolAddresses []common.Address) ([]Reserves, error) {
+	reserves := make([]Reserves, len(poolAddresses))
+	for i, poolAddress := range poolAddresses {
+		poolReserves, err := c.getReserves(ctx, poolAddress)
+		if err != nil {
+			return nil, fmt.Errorf("reserves of %s: %w", poolAddress.Hex(), err)
+		}
+		reserves[i] = poolReserves
+	}
+
+	return reserves, nil
+}
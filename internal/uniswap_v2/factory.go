@@ -0,0 +1,181 @@
+package uniswap_v2
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// ErrPairNotFound is returned when a factory has no pair for the tokens
+var ErrPairNotFound = errors.New("pair not found")
+
+// UniswapV2Factory is the Uniswap V2 factory on mainnet and the init code hash of its pairs
+var UniswapV2Factory = Factory{
+	Address:      common.HexToAddress("0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f"),
+	InitCodeHash: common.HexToHash("0x96e8ac4277198ff8b6f785478aa9a39f403cb768dd02cbee326c3e7da348845f"),
+}
+
+// Factory is the factory of a Uniswap V2 fork
+type Factory struct {
+	Address common.Address
+	// InitCodeHash is the keccak256 of the pair creation code the factory deploys pairs with using CREATE2,
+	// zero when unknown
+	InitCodeHash common.Hash
+}
+
+// PairFor derives the address of the pair of tokenA and tokenB offline, like UniswapV2Library.pairFor.
+// The pair is not guaranteed to be deployed.
+func (f Factory) PairFor(tokenA, tokenB common.Address) common.Address {
+	token0, token1 := SortTokens(tokenA, tokenB)
+	salt := crypto.Keccak256Hash(token0.Bytes(), token1.Bytes())
+	return crypto.CreateAddress2(f.Address, salt, f.InitCodeHash.Bytes())
+}
+
+// ParseFactories parses factory entries of the form address or address:initCodeHash
+func ParseFactories(entries []string) ([]Factory, error) {
+	factories := make([]Factory, 0, len(entries))
+	for _, entry := range entries {
+		address, hash, hasHash := strings.Cut(entry, ":")
+		if !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("invalid factory entry %q, expected address or address:initCodeHash", entry)
+		}
+
+		factory := Factory{Address: common.HexToAddress(address)}
+		if hasHash {
+			if !strings.HasPrefix(hash, "0x") || len(hash) != 66 || common.HexToHash(hash).Hex() != strings.ToLower(hash) {
+				return nil, fmt.Errorf("invalid factory entry %q: init code hash must be 32 bytes of hex", entry)
+			}
+			factory.InitCodeHash = common.HexToHash(hash)
+		}
+		factories = append(factories, factory)
+	}
+	return factories, nil
+}
+
+// SortTokens returns the tokens ordered like the token0 and token1 of their pair
+func SortTokens(tokenA, tokenB common.Address) (common.Address, common.Address) {
+	if bytes.Compare(tokenA.Bytes(), tokenB.Bytes()) > 0 {
+		return tokenB, tokenA
+	}
+	return tokenA, tokenB
+}
+
+// IPairFinder finds the pair of two tokens deployed by a factory
+type IPairFinder interface {
+	// GetPair returns the pair of tokenA and tokenB, ErrPairNotFound when it is not deployed.
+	// The block is taken from ctx, see WithBlockNumber.
+	GetPair(ctx context.Context, tokenA, tokenB common.Address) (common.Address, error)
+}
+
+// NewPairFinder creates a pair finder for factory: pairs are derived with CREATE2 when the init code hash is known
+// and looked up with the factory's getPair otherwise
+func NewPairFinder(backend bind.ContractCaller, factory Factory) (IPairFinder, error) {
+	if factory.InitCodeHash != (common.Hash{}) {
+		return NewCreate2PairFinder(backend, factory), nil
+	}
+	return NewFactoryPairFinder(backend, factory.Address)
+}
+
+// pairCache remembers found pairs, a deployed pair never changes
+type pairCache struct {
+	mu    sync.RWMutex
+	pairs map[[2]common.Address]common.Address
+}
+
+func (c *pairCache) get(token0, token1 common.Address) (common.Address, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	pair, ok := c.pairs[[2]common.Address{token0, token1}]
+	return pair, ok
+}
+
+func (c *pairCache) add(token0, token1, pair common.Address) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.pairs == nil {
+		c.pairs = make(map[[2]common.Address]common.Address)
+	}
+	c.pairs[[2]common.Address{token0, token1}] = pair
+}
+
+// FactoryPairFinder finds pairs by calling getPair on the factory contract
+type FactoryPairFinder struct {
+	factory  common.Address
+	contract *bind.BoundContract
+	cache    pairCache
+}
+
+// NewFactoryPairFinder creates a pair finder calling the factory at factoryAddress
+func NewFactoryPairFinder(backend bind.ContractCaller, factoryAddress common.Address) (*FactoryPairFinder, error) {
+	factoryABI, err := abi.JSON(strings.NewReader(UniswapV2FactoryABI))
+	if err != nil {
+		return nil, err
+	}
+
+	return &FactoryPairFinder{
+		factory:  factoryAddress,
+		contract: bind.NewBoundContract(factoryAddress, factoryABI, backend, nil, nil),
+	}, nil
+}
+
+// GetPair returns the pair of tokenA and tokenB registered in the factory
+func (f *FactoryPairFinder) GetPair(ctx context.Context, tokenA, tokenB common.Address) (common.Address, error) {
+	token0, token1 := SortTokens(tokenA, tokenB)
+	if pair, ok := f.cache.get(token0, token1); ok {
+		return pair, nil
+	}
+
+	var out []interface{}
+	if err := f.contract.Call(callOpts(ctx), &out, "getPair", token0, token1); err != nil {
+		return common.Address{}, fmt.Errorf("getPair of factory %s: %w", f.factory.Hex(), err)
+	}
+
+	pair := out[0].(common.Address)
+	if pair == (common.Address{}) {
+		return common.Address{}, fmt.Errorf("%w: %s/%s in factory %s", ErrPairNotFound, token0.Hex(), token1.Hex(), f.factory.Hex())
+	}
+
+	f.cache.add(token0, token1, pair)
+	return pair, nil
+}
+
+// Create2PairFinder derives pair addresses offline from the factory address and init code hash,
+// only checking on chain that the derived pair has been deployed
+type Create2PairFinder struct {
+	factory Factory
+	backend bind.ContractCaller
+	cache   pairCache
+}
+
+// NewCreate2PairFinder creates a pair finder deriving the pairs of factory
+func NewCreate2PairFinder(backend bind.ContractCaller, factory Factory) *Create2PairFinder {
+	return &Create2PairFinder{factory: factory, backend: backend}
+}
+
+// GetPair returns the CREATE2 address of the pair of tokenA and tokenB once it has code
+func (f *Create2PairFinder) GetPair(ctx context.Context, tokenA, tokenB common.Address) (common.Address, error) {
+	token0, token1 := SortTokens(tokenA, tokenB)
+	if pair, ok := f.cache.get(token0, token1); ok {
+		return pair, nil
+	}
+
+	pair := f.factory.PairFor(token0, token1)
+	code, err := f.backend.CodeAt(ctx, pair, BlockNumberFromContext(ctx))
+	if err != nil {
+		return common.Address{}, fmt.Errorf("code of pair %s: %w", pair.Hex(), err)
+	}
+	if len(code) == 0 {
+		return common.Address{}, fmt.Errorf("%w: %s/%s in factory %s", ErrPairNotFound, token0.Hex(), token1.Hex(), f.factory.Address.Hex())
+	}
+
+	f.cache.add(token0, token1, pair)
+	return pair, nil
+}
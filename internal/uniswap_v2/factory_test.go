@@ -0,0 +1,160 @@
+package uniswap_v2
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	usdc = common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	// sushiSwapFactory is the SushiSwap factory on mainnet and the init code hash of its pairs
+	sushiSwapFactory = Factory{
+		Address:      common.HexToAddress("0xC0AEe478e3658e2610c5F7A4A2E1777cE9e4f2Ac"),
+		InitCodeHash: common.HexToHash("0xe18a34eb0e04b04f7a0ac29a6e80748dca96319b42c54d679cb821dca90c6303"),
+	}
+)
+
+func TestFactory_PairFor(t *testing.T) {
+	tests := []struct {
+		name           string
+		factory        Factory
+		tokenA, tokenB common.Address
+		want           common.Address
+	}{
+		{name: "uniswap USDC/WETH", factory: UniswapV2Factory, tokenA: usdc, tokenB: weth, want: common.HexToAddress("0xB4e16d0168e52d35CaCD2c6185b44281Ec28C9Dc")},
+		{name: "uniswap WETH/USDT", factory: UniswapV2Factory, tokenA: weth, tokenB: usdt, want: common.HexToAddress("0x0d4a11d5EEaaC28EC3F61d100daF4d40471f1852")},
+		{name: "uniswap DAI/WETH", factory: UniswapV2Factory, tokenA: dai, tokenB: weth, want: common.HexToAddress("0xA478c2975Ab1Ea89e8196811F51A7B7Ade33eB11")},
+		{name: "sushiswap USDC/WETH", factory: sushiSwapFactory, tokenA: usdc, tokenB: weth, want: common.HexToAddress("0x397FF1542f962076d0BFE58eA045FfA2d347ACa0")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.factory.PairFor(tt.tokenA, tt.tokenB))
+			assert.Equal(t, tt.want, tt.factory.PairFor(tt.tokenB, tt.tokenA), "token order should not matter")
+		})
+	}
+}
+
+func TestParseFactories(t *testing.T) {
+	factories, err := ParseFactories([]string{
+		"0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f:0x96e8ac4277198ff8b6f785478aa9a39f403cb768dd02cbee326c3e7da348845f",
+		"0xC0AEe478e3658e2610c5F7A4A2E1777cE9e4f2Ac",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []Factory{UniswapV2Factory, {Address: sushiSwapFactory.Address}}, factories)
+
+	for _, entry := range []string{
+		"0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f:0x96e8ac42",
+		"0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f:96e8ac4277198ff8b6f785478aa9a39f403cb768dd02cbee326c3e7da348845f",
+		"0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f:0xzze8ac4277198ff8b6f785478aa9a39f403cb768dd02cbee326c3e7da348845f",
+		"uniswap",
+	} {
+		_, err := ParseFactories([]string{entry})
+		assert.Error(t, err, entry)
+	}
+}
+
+// fakeFactory is a bind.ContractCaller serving getPair of a factory and the code of deployed pairs
+type fakeFactory struct {
+	t          *testing.T
+	factoryABI abi.ABI
+	// pairs are the deployed pairs by sorted tokens
+	pairs map[[2]common.Address]common.Address
+	err   error
+	calls int
+}
+
+func newFakeFactory(t *testing.T, pairs ...common.Address) *fakeFactory {
+	factoryABI, err := abi.JSON(strings.NewReader(UniswapV2FactoryABI))
+	require.NoError(t, err)
+
+	f := &fakeFactory{t: t, factoryABI: factoryABI, pairs: make(map[[2]common.Address]common.Address)}
+	for i := 0; i < len(pairs); i += 3 {
+		f.pairs[[2]common.Address{pairs[i], pairs[i+1]}] = pairs[i+2]
+	}
+	return f
+}
+
+func (f *fakeFactory) CodeAt(_ context.Context, contract common.Address, _ *big.Int) ([]byte, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	for _, pair := range f.pairs {
+		if pair == contract {
+			return []byte{0x1}, nil
+		}
+	}
+	return nil, nil
+}
+
+func (f *fakeFactory) CallContract(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, f.err
+	}
+	method, err := f.factoryABI.MethodById(msg.Data[:4])
+	require.NoError(f.t, err)
+	args, err := method.Inputs.Unpack(msg.Data[4:])
+	require.NoError(f.t, err)
+
+	// The factory registers pairs under both token orders
+	token0, token1 := SortTokens(args[0].(common.Address), args[1].(common.Address))
+	return method.Outputs.Pack(f.pairs[[2]common.Address{token0, token1}])
+}
+
+func TestPairFinders(t *testing.T) {
+	ctx := context.Background()
+	usdcWeth := UniswapV2Factory.PairFor(usdc, weth)
+
+	finders := map[string]func(backend *fakeFactory) IPairFinder{
+		"factory": func(backend *fakeFactory) IPairFinder {
+			finder, err := NewPairFinder(backend, Factory{Address: UniswapV2Factory.Address})
+			require.NoError(t, err)
+			return finder
+		},
+		"create2": func(backend *fakeFactory) IPairFinder {
+			finder, err := NewPairFinder(backend, UniswapV2Factory)
+			require.NoError(t, err)
+			return finder
+		},
+	}
+
+	for name, newFinder := range finders {
+		t.Run(name, func(t *testing.T) {
+			backend := newFakeFactory(t, usdc, weth, usdcWeth)
+			finder := newFinder(backend)
+
+			pair, err := finder.GetPair(ctx, weth, usdc)
+			require.NoError(t, err)
+			assert.Equal(t, usdcWeth, pair)
+
+			// Found pairs are remembered
+			pair, err = finder.GetPair(ctx, usdc, weth)
+			require.NoError(t, err)
+			assert.Equal(t, usdcWeth, pair)
+			assert.Equal(t, 1, backend.calls)
+
+			// Missing pairs are not
+			_, err = finder.GetPair(ctx, usdc, dai)
+			assert.ErrorIs(t, err, ErrPairNotFound)
+			_, err = finder.GetPair(ctx, dai, usdc)
+			assert.ErrorIs(t, err, ErrPairNotFound)
+			assert.Equal(t, 3, backend.calls)
+
+			backend.err = errors.New("429 Too Many Requests")
+			_, err = finder.GetPair(ctx, dai, usdc)
+			assert.ErrorContains(t, err, "429 Too Many Requests")
+			assert.NotErrorIs(t, err, ErrPairNotFound)
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package uniswap_v2
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Multicall3Address is the address Multicall3 is deployed at on mainnet and most other chains
+var Multicall3Address = common.HexToAddress("0xcA11bde05977b3631167028862bE2a173976CA11")
+
+// Multicall3Call is a single call of Multicall3.aggregate3
+type Multicall3Call struct {
+	Target       common.Address
+	AllowFailure bool
+	CallData     []byte
+}
+
+// Multicall3Result is a single result of Multicall3.aggregate3
+type Multicall3Result struct {
+	Success    bool
+	ReturnData []byte
+}
+
+// Multicall3 batches calls through the aggregate3 method of a Multicall3 contract
+type Multicall3 struct {
+	contract *bind.BoundContract
+}
+
+// NewMulticall3 binds the Multicall3 contract at address
+func NewMulticall3(backend bind.ContractCaller, address common.Address) (*Multicall3, error) {
+	callABI, err := abi.JSON(strings.NewReader(Multicall3ABI))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Multicall3{contract: bind.NewBoundContract(address, callABI, backend, nil, nil)}, nil
+}
+
+// Aggregate3 executes the calls in a single eth_call at the block pinned in ctx, see WithBlockNumber,
+// and returns one result per call
+func (m *Multicall3) Aggregate3(ctx context.Context, calls []Multicall3Call) ([]Multicall3Result, error) {
+	var raw []interface{}
+	if err := m.contract.Call(callOpts(ctx), &raw, "aggregate3", calls); err != nil {
+		return nil, fmt.Errorf("multicall: %w", err)
+	}
+
+	results := *abi.ConvertType(raw[0], new([]Multicall3Result)).(*[]Multicall3Result)
+	if len(results) != len(calls) {
+		return nil, fmt.Errorf("multicall: expected %d results, got %d", len(calls), len(results))
+	}
+	return results, nil
+}
+
+// MulticallClient implements IUniswapV2 by batching pair calls through Multicall3,
+// so tokens and reserves of any number of pairs are fetched with a single eth_call
+type MulticallClient struct {
+	multicall *Multicall3
+	pairABI   abi.ABI
+}
+
+// NewMulticallClient creates a client that batches calls through the Multicall3 contract at multicallAddress.
+// The backend is owned by the caller and is not closed by Close.
+func NewMulticallClient(backend bind.ContractCaller, multicallAddress common.Address) (*MulticallClient, error) {
+	pairABI, err := abi.JSON(strings.NewReader(UniswapV2PairABI))
+	if err != nil {
+		return nil, err
+	}
+
+	multicall, err := NewMulticall3(backend, multicallAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MulticallClient{
+		multicall: multicall,
+		pairABI:   pairABI,
+	}, nil
+}
+
+// Close is a no-op, the backend is closed by its owner
+func (c *MulticallClient) Close() {}
+
+// GetReserves gets the reserves from a Uniswap V2 pair
+func (c *MulticallClient) GetReserves(ctx context.Context, poolAddress common.Address) (*big.Int, *big.Int, error) {
+	out, err := c.aggregate(ctx, []common.Address{poolAddress}, []string{"getReserves"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return out[0][0].(*big.Int), out[0][1].(*big.Int), nil
+}
+
+// GetToken0 gets token0 address from the pair
+func (c *MulticallClient) GetToken0(ctx context.Context, poolAddress common.Address) (common.Address, error) {
+	out, err := c.aggregate(ctx, []common.Address{poolAddress}, []string{"token0"})
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	return out[0][0].(common.Address), nil
+}
+
+// GetToken1 gets token1 address from the pair
+func (c *MulticallClient) GetToken1(ctx context.Context, poolAddress common.Address) (common.Address, error) {
+	out, err := c.aggregate(ctx, []common.Address{poolAddress}, []string{"token1"})
+	if err != nil {
+		return common.Address{}, err
+	}
+
+	return out[0][0].(common.Address), nil
+}
+
+// GetPoolStates gets tokens, factory and reserves of all the pairs with a single eth_call
+func (c *MulticallClient) GetPoolStates(ctx context.Context, poolAddresses []common.Address) ([]PoolState, error) {
+	if len(poolAddresses) == 0 {
+		return nil, nil
+	}
+
+	targets := make([]common.Address, 0, len(poolAddresses)*4)
+	methods := make([]string, 0, len(poolAddresses)*4)
+	for _, poolAddress := range poolAddresses {
+		targets = append(targets, poolAddress, poolAddress, poolAddress, poolAddress)
+		methods = append(methods, "token0", "token1", "factory", "getReserves")
+	}
+
+	out, err := c.aggregate(ctx, targets, methods)
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]PoolState, len(poolAddresses))
+	for i, poolAddress := range poolAddresses {
+		states[i] = PoolState{
+			Pool:               poolAddress,
+			Token0:             out[4*i][0].(common.Address),
+			Token1:             out[4*i+1][0].(common.Address),
+			Factory:            out[4*i+2][0].(common.Address),
+			Reserve0:           out[4*i+3][0].(*big.Int),
+			Reserve1:           out[4*i+3][1].(*big.Int),
+			BlockTimestampLast: out[4*i+3][2].(uint32),
+		}
+	}
+
+	return states, nil
+}
+
+// GetReservesBatch gets reserves of all the pairs with a single eth_call
+func (c *MulticallClient) GetReservesBatch(ctx context.Context, poolAddresses []common.Address) ([]Reserves, error) {
+	if len(poolAddresses) == 0 {
+		return nil, nil
+	}
+
+	methods := make([]string, len(poolAddresses))
+	for i := range poolAddresses {
+		methods[i] = "getReserves"
+	}
+
+	out, err := c.aggregate(ctx, poolAddresses, methods)
+	if err != nil {
+		return nil, err
+	}
+
+	reserves := make([]Reserves, len(poolAddresses))
+	for i := range poolAddresses {
+		reserves[i] = Reserves{
+			Reserve0:           out[i][0].(*big.Int),
+			Reserve1:           out[i][1].(*big.Int),
+			BlockTimestampLast: out[i][2].(uint32),
+		}
+	}
+
+	return reserves, nil
+}
+
+// aggregate calls methods[i] on targets[i] in a single Multicall3.aggregate3 call and returns the unpacked outputs
+func (c *MulticallClient) aggregate(ctx context.Context, targets []common.Address, methods []string) ([][]interface{}, error) {
+	calls := make([]Multicall3Call, len(targets))
+	for i, target := range targets {
+		callData, err := c.pairABI.Pack(methods[i])
+		if err != nil {
+			return nil, err
+		}
+		calls[i] = Multicall3Call{Target: target, AllowFailure: true, CallData: callData}
+	}
+
+	results, err := c.multicall.Aggregate3(ctx, calls)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([][]interface{}, len(results))
+	for i, result := range results {
+		if !result.Success {
+			return nil, fmt.Errorf("%s of %s: execution reverted", methods[i], targets[i].Hex())
+		}
+
+		values, err := c.pairABI.Unpack(methods[i], result.ReturnData)
+		if err != nil {
+			return nil, fmt.Errorf("%s of %s: %w", methods[i], targets[i].Hex(), err)
+		}
+		out[i] = values
+	}
+
+	return out, nil
+}
@@ -0,0 +1,175 @@
+package uniswap_v2
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePair is the state of a pair served by fakeMulticall
+type fakePair struct {
+	token0, token1     common.Address
+	factory            common.Address
+	reserve0, reserve1 *big.Int
+	blockTimestampLast uint32
+}
+
+// fakeMulticall is a bind.ContractCaller that executes Multicall3.aggregate3 against in-memory pairs
+type fakeMulticall struct {
+	t       *testing.T
+	pairs   map[common.Address]fakePair
+	pairABI abi.ABI
+	callABI abi.ABI
+	calls   int
+	// blocks records the block number of every call, nil for latest
+	blocks []*big.Int
+}
+
+func newFakeMulticall(t *testing.T, pairs map[common.Address]fakePair) *fakeMulticall {
+	pairABI, err := abi.JSON(strings.NewReader(UniswapV2PairABI))
+	require.NoError(t, err)
+	callABI, err := abi.JSON(strings.NewReader(Multicall3ABI))
+	require.NoError(t, err)
+
+	return &fakeMulticall{t: t, pairs: pairs, pairABI: pairABI, callABI: callABI}
+}
+
+func (f *fakeMulticall) CodeAt(context.Context, common.Address, *big.Int) ([]byte, error) {
+	return []byte{0x1}, nil
+}
+
+func (f *fakeMulticall) CallContract(_ context.Context, msg ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	f.calls++
+	f.blocks = append(f.blocks, blockNumber)
+	require.Equal(f.t, Multicall3Address, *msg.To)
+
+	method, err := f.callABI.MethodById(msg.Data[:4])
+	require.NoError(f.t, err)
+	require.Equal(f.t, "aggregate3", method.Name)
+
+	args, err := method.Inputs.Unpack(msg.Data[4:])
+	require.NoError(f.t, err)
+	calls := *abi.ConvertType(args[0], new([]Multicall3Call)).(*[]Multicall3Call)
+
+	results := make([]Multicall3Result, len(calls))
+	for i, call := range calls {
+		pair, ok := f.pairs[call.Target]
+		if !ok {
+			if !call.AllowFailure {
+				return nil, errors.New("execution reverted")
+			}
+			continue
+		}
+
+		pairMethod, err := f.pairABI.MethodById(call.CallData[:4])
+		require.NoError(f.t, err)
+
+		var returnData []byte
+		switch pairMethod.Name {
+		case "token0":
+			returnData, err = pairMethod.Outputs.Pack(pair.token0)
+		case "token1":
+			returnData, err = pairMethod.Outputs.Pack(pair.token1)
+		case "factory":
+			returnData, err = pairMethod.Outputs.Pack(pair.factory)
+		case "getReserves":
+			returnData, err = pairMethod.Outputs.Pack(pair.reserve0, pair.reserve1, pair.blockTimestampLast)
+		}
+		require.NoError(f.t, err)
+		results[i] = Multicall3Result{Success: true, ReturnData: returnData}
+	}
+
+	return method.Outputs.Pack(results)
+}
+
+var (
+	usdt     = common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+	weth     = common.HexToAddress("0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2")
+	dai      = common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+	usdtWeth = common.HexToAddress("0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852")
+	daiWeth  = common.HexToAddress("0xa478c2975ab1ea89e8196811f51a7b7ade33eb11")
+	factory  = common.HexToAddress("0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f")
+)
+
+func newTestMulticallClient(t *testing.T) (*MulticallClient, *fakeMulticall) {
+	backend := newFakeMulticall(t, map[common.Address]fakePair{
+		usdtWeth: {token0: weth, token1: usdt, factory: factory, reserve0: big.NewInt(500), reserve1: big.NewInt(1000000), blockTimestampLast: 1700000000},
+		daiWeth:  {token0: dai, token1: weth, factory: factory, reserve0: big.NewInt(2000000), reserve1: big.NewInt(1000), blockTimestampLast: 1700000012},
+	})
+	client, err := NewMulticallClient(backend, Multicall3Address)
+	require.NoError(t, err)
+	return client, backend
+}
+
+func TestMulticallClient_GetPoolStates(t *testing.T) {
+	client, backend := newTestMulticallClient(t)
+
+	states, err := client.GetPoolStates(context.Background(), []common.Address{usdtWeth, daiWeth})
+	require.NoError(t, err)
+	assert.Equal(t, 1, backend.calls, "all pools should be fetched with a single eth_call")
+
+	assert.Equal(t, []PoolState{
+		{Pool: usdtWeth, Token0: weth, Token1: usdt, Factory: factory, Reserve0: big.NewInt(500), Reserve1: big.NewInt(1000000), BlockTimestampLast: 1700000000},
+		{Pool: daiWeth, Token0: dai, Token1: weth, Factory: factory, Reserve0: big.NewInt(2000000), Reserve1: big.NewInt(1000), BlockTimestampLast: 1700000012},
+	}, states)
+}
+
+func TestMulticallClient_SingleCalls(t *testing.T) {
+	client, _ := newTestMulticallClient(t)
+	ctx := context.Background()
+
+	token0, err := client.GetToken0(ctx, daiWeth)
+	require.NoError(t, err)
+	assert.Equal(t, dai, token0)
+
+	token1, err := client.GetToken1(ctx, daiWeth)
+	require.NoError(t, err)
+	assert.Equal(t, weth, token1)
+
+	reserve0, reserve1, err := client.GetReserves(ctx, usdtWeth)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(500), reserve0)
+	assert.Equal(t, big.NewInt(1000000), reserve1)
+}
+
+func TestMulticallClient_FailedCallNamesPool(t *testing.T) {
+	client, _ := newTestMulticallClient(t)
+	unknown := common.HexToAddress("0x0000000000000000000000000000000000000001")
+
+	_, err := client.GetPoolStates(context.Background(), []common.Address{usdtWeth, unknown})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), unknown.Hex())
+}
+
+func TestMulticallClient_GetReservesBatch(t *testing.T) {
+	client, backend := newTestMulticallClient(t)
+
+	reserves, err := client.GetReservesBatch(context.Background(), []common.Address{usdtWeth, daiWeth})
+	require.NoError(t, err)
+	assert.Equal(t, 1, backend.calls)
+	assert.Equal(t, []Reserves{
+		{Reserve0: big.NewInt(500), Reserve1: big.NewInt(1000000), BlockTimestampLast: 1700000000},
+		{Reserve0: big.NewInt(2000000), Reserve1: big.NewInt(1000), BlockTimestampLast: 1700000012},
+	}, reserves)
+}
+
+func TestMulticallClient_PinnedBlock(t *testing.T) {
+	client, backend := newTestMulticallClient(t)
+	ctx := context.Background()
+
+	_, err := client.GetPoolStates(ctx, []common.Address{usdtWeth})
+	require.NoError(t, err)
+
+	_, err = client.GetPoolStates(WithBlockNumber(ctx, big.NewInt(18500000)), []common.Address{usdtWeth})
+	require.NoError(t, err)
+
+	assert.Equal(t, []*big.Int{nil, big.NewInt(18500000)}, backend.blocks)
+}
@@ -0,0 +1,126 @@
+package uniswap_v2
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// Router02Address is the address UniswapV2Router02 is deployed at on mainnet
+	Router02Address = common.HexToAddress("0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D")
+	// WETHAddress is the address of WETH on mainnet
+	WETHAddress = common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2")
+	// NativeETH is the placeholder address that stands for native ETH in swap requests
+	NativeETH = common.HexToAddress("0xEeeeeEeeeEeEeeEeEeEeeEEEeeeeEeeeeeeeEEeE")
+)
+
+// ErrNativeToNative is returned when both sides of a swap are native ETH
+var ErrNativeToNative = errors.New("cannot swap ETH for ETH")
+
+// SwapParams describes an exact input swap through the router
+type SwapParams struct {
+	AmountIn     *big.Int
+	AmountOutMin *big.Int
+	// Path is the list of tokens the swap goes through, with WETH standing for ETH
+	Path     []common.Address
+	To       common.Address
+	Deadline *big.Int
+	// NativeIn and NativeOut swap from and to native ETH instead of WETH
+	NativeIn  bool
+	NativeOut bool
+	// FeeOnTransfer uses the router methods that support tokens taking a fee on transfer
+	FeeOnTransfer bool
+}
+
+// SwapTx is a router transaction ready to be signed
+type SwapTx struct {
+	To     common.Address
+	Method string
+	Data   []byte
+	// Value is the ETH sent with the transaction
+	Value *big.Int
+}
+
+// Router builds calldata for the UniswapV2Router02 contract
+type Router struct {
+	address common.Address
+	weth    common.Address
+	// factory is the factory the router derives the pairs of a path from
+	factory   Factory
+	routerABI abi.ABI
+}
+
+// NewRouter creates a calldata builder for the router at address, which wraps ETH as weth and swaps through
+// the pairs of factory
+func NewRouter(address, weth common.Address, factory Factory) (*Router, error) {
+	routerABI, err := abi.JSON(strings.NewReader(UniswapV2Router02ABI))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Router{
+		address:   address,
+		weth:      weth,
+		factory:   factory,
+		routerABI: routerABI,
+	}, nil
+}
+
+// WETH returns the wrapped ETH token used by the router
+func (r *Router) WETH() common.Address {
+	return r.weth
+}
+
+// Factory returns the factory whose pairs the router swaps through
+func (r *Router) Factory() Factory {
+	return r.factory
+}
+
+// BuildSwap encodes an exact input swap, choosing the ETH variant of the method for native ETH
+func (r *Router) BuildSwap(params SwapParams) (*SwapTx, error) {
+	if len(params.Path) < 2 {
+		return nil, fmt.Errorf("swap path must contain at least 2 tokens, got %d", len(params.Path))
+	}
+
+	tx := &SwapTx{To: r.address, Value: new(big.Int)}
+	var args []interface{}
+	switch {
+	case params.NativeIn && params.NativeOut:
+		return nil, ErrNativeToNative
+	case params.NativeIn:
+		tx.Method = "swapExactETHForTokens"
+		tx.Value = params.AmountIn
+		args = []interface{}{params.AmountOutMin, params.Path, params.To, params.Deadline}
+	case params.NativeOut:
+		tx.Method = "swapExactTokensForETH"
+		args = []interface{}{params.AmountIn, params.AmountOutMin, params.Path, params.To, params.Deadline}
+	default:
+		tx.Method = "swapExactTokensForTokens"
+		args = []interface{}{params.AmountIn, params.AmountOutMin, params.Path, params.To, params.Deadline}
+	}
+
+	if params.NativeIn && params.Path[0] != r.weth {
+		return nil, fmt.Errorf("ETH swap path must start with WETH, got %s", params.Path[0].Hex())
+	}
+	if params.NativeOut && params.Path[len(params.Path)-1] != r.weth {
+		return nil, fmt.Errorf("ETH swap path must end with WETH, got %s", params.Path[len(params.Path)-1].Hex())
+	}
+
+	// The fee-on-transfer variants take the same arguments and check the received balance instead of the expected amounts
+	if params.FeeOnTransfer {
+		tx.Method += "SupportingFeeOnTransferTokens"
+	}
+
+	data, err := r.routerABI.Pack(tx.Method, args...)
+	if err != nil {
+		return nil, fmt.Errorf("pack %s: %w", tx.Method, err)
+	}
+	tx.Data = data
+
+	return tx, nil
+}
@@ -0,0 +1,115 @@
+package uniswap_v2
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouter_BuildSwap(t *testing.T) {
+	router, err := NewRouter(Router02Address, weth, UniswapV2Factory)
+	require.NoError(t, err)
+
+	recipient := common.HexToAddress("0x1111111111111111111111111111111111111111")
+	amountIn := big.NewInt(10000000)
+	amountOutMin := big.NewInt(3958971695138132)
+	deadline := big.NewInt(1700000000)
+
+	tests := []struct {
+		name         string
+		params       SwapParams
+		wantMethod   string
+		wantSelector string
+		wantValue    *big.Int
+		wantErr      string
+	}{
+		{
+			name:         "tokens for tokens",
+			params:       SwapParams{Path: []common.Address{usdt, weth, dai}},
+			wantMethod:   "swapExactTokensForTokens",
+			wantSelector: "0x38ed1739",
+			wantValue:    big.NewInt(0),
+		},
+		{
+			name:         "ETH for tokens",
+			params:       SwapParams{Path: []common.Address{weth, usdt}, NativeIn: true},
+			wantMethod:   "swapExactETHForTokens",
+			wantSelector: "0x7ff36ab5",
+			wantValue:    amountIn,
+		},
+		{
+			name:         "tokens for ETH",
+			params:       SwapParams{Path: []common.Address{usdt, weth}, NativeOut: true},
+			wantMethod:   "swapExactTokensForETH",
+			wantSelector: "0x18cbafe5",
+			wantValue:    big.NewInt(0),
+		},
+		{
+			name:         "fee-on-transfer tokens for ETH",
+			params:       SwapParams{Path: []common.Address{usdt, weth}, NativeOut: true, FeeOnTransfer: true},
+			wantMethod:   "swapExactTokensForETHSupportingFeeOnTransferTokens",
+			wantSelector: "0x791ac947",
+			wantValue:    big.NewInt(0),
+		},
+		{
+			name:         "ETH for fee-on-transfer tokens",
+			params:       SwapParams{Path: []common.Address{weth, usdt}, NativeIn: true, FeeOnTransfer: true},
+			wantMethod:   "swapExactETHForTokensSupportingFeeOnTransferTokens",
+			wantSelector: "0xb6f9de95",
+			wantValue:    amountIn,
+		},
+		{
+			name:    "ETH for ETH",
+			params:  SwapParams{Path: []common.Address{weth, usdt, weth}, NativeIn: true, NativeOut: true},
+			wantErr: "cannot swap ETH for ETH",
+		},
+		{
+			name:    "ETH path not starting with WETH",
+			params:  SwapParams{Path: []common.Address{usdt, dai}, NativeIn: true},
+			wantErr: "must start with WETH",
+		},
+		{
+			name:    "single token path",
+			params:  SwapParams{Path: []common.Address{usdt}},
+			wantErr: "at least 2 tokens",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := tt.params
+			params.AmountIn = amountIn
+			params.AmountOutMin = amountOutMin
+			params.To = recipient
+			params.Deadline = deadline
+
+			tx, err := router.BuildSwap(params)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, Router02Address, tx.To)
+			assert.Equal(t, tt.wantMethod, tx.Method)
+			assert.Equal(t, tt.wantValue, tx.Value)
+			assert.Equal(t, tt.wantSelector, hexutil.Encode(tx.Data[:4]))
+
+			method, err := router.routerABI.MethodById(tx.Data[:4])
+			require.NoError(t, err)
+			args, err := method.Inputs.Unpack(tx.Data[4:])
+			require.NoError(t, err)
+
+			wantArgs := []interface{}{amountOutMin, params.Path, recipient, deadline}
+			if !params.NativeIn {
+				wantArgs = append([]interface{}{amountIn}, wantArgs...)
+			}
+			assert.Equal(t, wantArgs, args)
+		})
+	}
+}
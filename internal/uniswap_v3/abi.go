@@ -0,0 +1,78 @@
+package uniswap_v3
+
+// UniswapV3PoolABI is the ABI for the state getters of the Uniswap V3 Pool contract
+const UniswapV3PoolABI = `[
+	{
+		"inputs": [],
+		"name": "slot0",
+		"outputs": [
+			{"name": "sqrtPriceX96", "type": "uint160"},
+			{"name": "tick", "type": "int24"},
+			{"name": "observationIndex", "type": "uint16"},
+			{"name": "observationCardinality", "type": "uint16"},
+			{"name": "observationCardinalityNext", "type": "uint16"},
+			{"name": "feeProtocol", "type": "uint8"},
+			{"name": "unlocked", "type": "bool"}
+		],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [],
+		"name": "liquidity",
+		"outputs": [{"name": "", "type": "uint128"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [],
+		"name": "fee",
+		"outputs": [{"name": "", "type": "uint24"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [],
+		"name": "tickSpacing",
+		"outputs": [{"name": "", "type": "int24"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [],
+		"name": "token0",
+		"outputs": [{"name": "", "type": "address"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [],
+		"name": "token1",
+		"outputs": [{"name": "", "type": "address"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [{"name": "wordPosition", "type": "int16"}],
+		"name": "tickBitmap",
+		"outputs": [{"name": "", "type": "uint256"}],
+		"stateMutability": "view",
+		"type": "function"
+	},
+	{
+		"inputs": [{"name": "tick", "type": "int24"}],
+		"name": "ticks",
+		"outputs": [
+			{"name": "liquidityGross", "type": "uint128"},
+			{"name": "liquidityNet", "type": "int128"},
+			{"name": "feeGrowthOutside0X128", "type": "uint256"},
+			{"name": "feeGrowthOutside1X128", "type": "uint256"},
+			{"name": "tickCumulativeOutside", "type": "int56"},
+			{"name": "secondsPerLiquidityOutsideX128", "type": "uint160"},
+			{"name": "secondsOutside", "type": "uint32"},
+			{"name": "initialized", "type": "bool"}
+		],
+		"stateMutability": "view",
+		"type": "function"
+	}
+]`
@@ -0,0 +1,163 @@
+package uniswap_v3
+
+import (
+	"1inch_testtask/internal/uniswap_v2"
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// IUniswapV3 defines the interface for reading Uniswap V3 pools
+type IUniswapV3 interface {
+	// GetPoolState loads the pool state with the initialized ticks around the current price.
+	// The block is taken from ctx, see uniswap_v2.WithBlockNumber.
+	GetPoolState(ctx context.Context, poolAddress common.Address) (*PoolState, error)
+}
+
+// poolCall is a pool method call batched through Multicall3
+type poolCall struct {
+	method string
+	args   []interface{}
+}
+
+// Client implements IUniswapV3 by batching pool calls through Multicall3.
+// A pool state is loaded with three eth_calls: the pool globals, the tick bitmap words and the initialized ticks.
+type Client struct {
+	multicall *uniswap_v2.Multicall3
+	poolABI   abi.ABI
+	// tickWords is how many bitmap words are loaded on each side of the current tick's word
+	tickWords int
+}
+
+// NewClient creates a client that batches calls through the Multicall3 contract at multicallAddress and loads
+// tickWords bitmap words (256 tick spacings each) on each side of the current price
+func NewClient(backend bind.ContractCaller, multicallAddress common.Address, tickWords int) (*Client, error) {
+	poolABI, err := abi.JSON(strings.NewReader(UniswapV3PoolABI))
+	if err != nil {
+		return nil, err
+	}
+
+	multicall, err := uniswap_v2.NewMulticall3(backend, multicallAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		multicall: multicall,
+		poolABI:   poolABI,
+		tickWords: tickWords,
+	}, nil
+}
+
+// GetPoolState loads the pool state with the initialized ticks around the current price
+func (c *Client) GetPoolState(ctx context.Context, poolAddress common.Address) (*PoolState, error) {
+	out, err := c.aggregate(ctx, poolAddress, []poolCall{
+		{method: "slot0"},
+		{method: "liquidity"},
+		{method: "fee"},
+		{method: "tickSpacing"},
+		{method: "token0"},
+		{method: "token1"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	state := &PoolState{
+		Pool:         poolAddress,
+		SqrtPriceX96: out[0][0].(*big.Int),
+		Tick:         int(out[0][1].(*big.Int).Int64()),
+		Liquidity:    out[1][0].(*big.Int),
+		Fee:          uint32(out[2][0].(*big.Int).Uint64()),
+		TickSpacing:  int(out[3][0].(*big.Int).Int64()),
+		Token0:       out[4][0].(common.Address),
+		Token1:       out[5][0].(common.Address),
+		TickBitmap:   make(map[int16]*big.Int),
+		LiquidityNet: make(map[int]*big.Int),
+	}
+	if state.SqrtPriceX96.Sign() == 0 || state.TickSpacing <= 0 {
+		return nil, fmt.Errorf("pool %s is not initialized", poolAddress.Hex())
+	}
+
+	compressed := state.Tick / state.TickSpacing
+	if state.Tick < 0 && state.Tick%state.TickSpacing != 0 {
+		compressed--
+	}
+	current, _ := position(compressed)
+
+	var words []int16
+	for wordPos := int(current) - c.tickWords; wordPos <= int(current)+c.tickWords; wordPos++ {
+		if wordPos >= -1<<15 && wordPos < 1<<15 {
+			words = append(words, int16(wordPos))
+		}
+	}
+
+	calls := make([]poolCall, len(words))
+	for i, wordPos := range words {
+		calls[i] = poolCall{method: "tickBitmap", args: []interface{}{wordPos}}
+	}
+	if out, err = c.aggregate(ctx, poolAddress, calls); err != nil {
+		return nil, err
+	}
+
+	var ticks []int
+	for i, wordPos := range words {
+		word := out[i][0].(*big.Int)
+		state.TickBitmap[wordPos] = word
+		ticks = append(ticks, initializedTicks(wordPos, word, state.TickSpacing)...)
+	}
+	if len(ticks) == 0 {
+		return state, nil
+	}
+
+	calls = make([]poolCall, len(ticks))
+	for i, tick := range ticks {
+		calls[i] = poolCall{method: "ticks", args: []interface{}{big.NewInt(int64(tick))}}
+	}
+	if out, err = c.aggregate(ctx, poolAddress, calls); err != nil {
+		return nil, err
+	}
+	for i, tick := range ticks {
+		state.LiquidityNet[tick] = out[i][1].(*big.Int)
+	}
+
+	return state, nil
+}
+
+// aggregate calls the pool methods in a single Multicall3.aggregate3 call and returns the unpacked outputs
+func (c *Client) aggregate(ctx context.Context, poolAddress common.Address, poolCalls []poolCall) ([][]interface{}, error) {
+	calls := make([]uniswap_v2.Multicall3Call, len(poolCalls))
+	for i, call := range poolCalls {
+		callData, err := c.poolABI.Pack(call.method, call.args...)
+		if err != nil {
+			return nil, err
+		}
+		calls[i] = uniswap_v2.Multicall3Call{Target: poolAddress, AllowFailure: true, CallData: callData}
+	}
+
+	results, err := c.multicall.Aggregate3(ctx, calls)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([][]interface{}, len(results))
+	for i, result := range results {
+		method := poolCalls[i].method
+		if !result.Success {
+			return nil, fmt.Errorf("%s of %s: execution reverted", method, poolAddress.Hex())
+		}
+
+		values, err := c.poolABI.Unpack(method, result.ReturnData)
+		if err != nil {
+			return nil, fmt.Errorf("%s of %s: %w", method, poolAddress.Hex(), err)
+		}
+		out[i] = values
+	}
+
+	return out, nil
+}
@@ -0,0 +1,132 @@
+package uniswap_v3
+
+import (
+	"1inch_testtask/internal/uniswap_v2"
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePool is a bind.ContractCaller that executes Multicall3.aggregate3 against an in-memory V3 pool
+type fakePool struct {
+	t       *testing.T
+	state   *PoolState
+	poolABI abi.ABI
+	callABI abi.ABI
+	calls   int
+	// words records every requested bitmap word
+	words []int16
+}
+
+func newFakePool(t *testing.T, state *PoolState) *fakePool {
+	poolABI, err := abi.JSON(strings.NewReader(UniswapV3PoolABI))
+	require.NoError(t, err)
+	callABI, err := abi.JSON(strings.NewReader(uniswap_v2.Multicall3ABI))
+	require.NoError(t, err)
+
+	return &fakePool{t: t, state: state, poolABI: poolABI, callABI: callABI}
+}
+
+func (f *fakePool) CodeAt(context.Context, common.Address, *big.Int) ([]byte, error) {
+	return []byte{0x1}, nil
+}
+
+func (f *fakePool) CallContract(_ context.Context, msg ethereum.CallMsg, _ *big.Int) ([]byte, error) {
+	f.calls++
+	method, err := f.callABI.MethodById(msg.Data[:4])
+	require.NoError(f.t, err)
+	args, err := method.Inputs.Unpack(msg.Data[4:])
+	require.NoError(f.t, err)
+	calls := *abi.ConvertType(args[0], new([]uniswap_v2.Multicall3Call)).(*[]uniswap_v2.Multicall3Call)
+
+	results := make([]uniswap_v2.Multicall3Result, len(calls))
+	for i, call := range calls {
+		require.Equal(f.t, f.state.Pool, call.Target)
+		poolMethod, err := f.poolABI.MethodById(call.CallData[:4])
+		require.NoError(f.t, err)
+		inputs, err := poolMethod.Inputs.Unpack(call.CallData[4:])
+		require.NoError(f.t, err)
+
+		var returnData []byte
+		switch poolMethod.Name {
+		case "slot0":
+			returnData, err = poolMethod.Outputs.Pack(f.state.SqrtPriceX96, big.NewInt(int64(f.state.Tick)), uint16(0), uint16(1), uint16(1), uint8(0), true)
+		case "liquidity":
+			returnData, err = poolMethod.Outputs.Pack(f.state.Liquidity)
+		case "fee":
+			returnData, err = poolMethod.Outputs.Pack(big.NewInt(int64(f.state.Fee)))
+		case "tickSpacing":
+			returnData, err = poolMethod.Outputs.Pack(big.NewInt(int64(f.state.TickSpacing)))
+		case "token0":
+			returnData, err = poolMethod.Outputs.Pack(f.state.Token0)
+		case "token1":
+			returnData, err = poolMethod.Outputs.Pack(f.state.Token1)
+		case "tickBitmap":
+			wordPos := inputs[0].(int16)
+			f.words = append(f.words, wordPos)
+			word, ok := f.state.TickBitmap[wordPos]
+			if !ok {
+				word = new(big.Int)
+			}
+			returnData, err = poolMethod.Outputs.Pack(word)
+		case "ticks":
+			tick := int(inputs[0].(*big.Int).Int64())
+			liquidityNet := f.state.LiquidityNet[tick]
+			gross := new(big.Int).Abs(liquidityNet)
+			returnData, err = poolMethod.Outputs.Pack(gross, liquidityNet, new(big.Int), new(big.Int), new(big.Int), new(big.Int), uint32(0), true)
+		}
+		require.NoError(f.t, err)
+		results[i] = uniswap_v2.Multicall3Result{Success: true, ReturnData: returnData}
+	}
+
+	return method.Outputs.Pack(results)
+}
+
+func TestClient_GetPoolState(t *testing.T) {
+	// The USDC/WETH 0.05% pool layout: tick spacing 10, current tick in a negative word
+	pool := &PoolState{
+		Pool:         common.HexToAddress("0x88e6A0c2dDD26FEEb64F039a2c41296FcB3f5640"),
+		Token0:       common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"),
+		Token1:       common.HexToAddress("0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2"),
+		Fee:          500,
+		TickSpacing:  10,
+		SqrtPriceX96: mustBigInt("1829744519839510475089003785136130"),
+		Tick:         200696,
+		Liquidity:    mustBigInt("20354208966429711426"),
+		TickBitmap: map[int16]*big.Int{
+			77: new(big.Int).SetBit(new(big.Int), 100, 1),
+			78: new(big.Int).SetBit(new(big.Int), 120, 1),
+		},
+		LiquidityNet: map[int]*big.Int{
+			198120: mustBigInt("1000000000000"),
+			200880: mustBigInt("-1000000000000"),
+		},
+	}
+	backend := newFakePool(t, pool)
+
+	client, err := NewClient(backend, uniswap_v2.Multicall3Address, 2)
+	require.NoError(t, err)
+
+	state, err := client.GetPoolState(context.Background(), pool.Pool)
+	require.NoError(t, err)
+	assert.Equal(t, 3, backend.calls, "globals, bitmap words and ticks should take one eth_call each")
+
+	// Tick 200696 is compressed to 20069, which is in word 78
+	assert.Equal(t, []int16{76, 77, 78, 79, 80}, backend.words)
+	assert.Equal(t, pool.Token0, state.Token0)
+	assert.Equal(t, pool.Token1, state.Token1)
+	assert.Equal(t, uint32(500), state.Fee)
+	assert.Equal(t, 10, state.TickSpacing)
+	assert.Equal(t, pool.Tick, state.Tick)
+	assert.Equal(t, pool.SqrtPriceX96, state.SqrtPriceX96)
+	assert.Equal(t, pool.Liquidity, state.Liquidity)
+	assert.Len(t, state.TickBitmap, 5)
+	assert.Equal(t, pool.LiquidityNet, state.LiquidityNet)
+}
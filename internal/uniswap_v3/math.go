@@ -0,0 +1,396 @@
+package uniswap_v3
+
+import (
+	"errors"
+	"math/big"
+)
+
+// Math of the Uniswap V3 core contracts (TickMath, SqrtPriceMath, SwapMath, FullMath), ported with the same
+// rounding and overflow behaviour so that quotes match the on-chain Quoter exactly.
+
+const (
+	// MinTick is the minimum tick that may be passed to GetSqrtRatioAtTick, log base 1.0001 of 2**-128
+	MinTick = -887272
+	// MaxTick is the maximum tick that may be passed to GetSqrtRatioAtTick, log base 1.0001 of 2**128
+	MaxTick = -MinTick
+
+	// FeeDenominator is the denominator of pool fees, which are expressed in hundredths of a basis point
+	FeeDenominator = 1000000
+)
+
+var (
+	// MinSqrtRatio is the value returned by GetSqrtRatioAtTick(MinTick)
+	MinSqrtRatio = big.NewInt(4295128739)
+	// MaxSqrtRatio is the value returned by GetSqrtRatioAtTick(MaxTick)
+	MaxSqrtRatio, _ = new(big.Int).SetString("1461446703485210103287273052203988822378723970342", 10)
+
+	// Q96 is 2**96, the scale of sqrt prices
+	Q96 = new(big.Int).Lsh(big.NewInt(1), 96)
+
+	maxUint160 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 160), big.NewInt(1))
+	maxUint256 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 256), big.NewInt(1))
+	q128       = new(big.Int).Lsh(big.NewInt(1), 128)
+	q32        = new(big.Int).Lsh(big.NewInt(1), 32)
+)
+
+var (
+	// ErrTickOutOfRange is returned for ticks outside [MinTick, MaxTick]
+	ErrTickOutOfRange = errors.New("tick out of range")
+	// ErrSqrtRatioOutOfRange is returned for sqrt prices outside [MinSqrtRatio, MaxSqrtRatio)
+	ErrSqrtRatioOutOfRange = errors.New("sqrt ratio out of range")
+	// ErrMathOverflow is returned where the contracts would revert on an overflowing or invalid operation
+	ErrMathOverflow = errors.New("math overflow")
+)
+
+// tickRatios are the Q128 ratios 1/sqrt(1.0001)**(2**i) applied for every set bit i of the absolute tick
+var tickRatios = []*big.Int{
+	hexInt("fffcb933bd6fad37aa2d162d1a594001"),
+	hexInt("fff97272373d413259a46990580e213a"),
+	hexInt("fff2e50f5f656932ef12357cf3c7fdcc"),
+	hexInt("ffe5caca7e10e4e61c3624eaa0941cd0"),
+	hexInt("ffcb9843d60f6159c9db58835c926644"),
+	hexInt("ff973b41fa98c081472e6896dfb254c0"),
+	hexInt("ff2ea16466c96a3843ec78b326b52861"),
+	hexInt("fe5dee046a99a2a811c461f1969c3053"),
+	hexInt("fcbe86c7900a88aedcffc83b479aa3a4"),
+	hexInt("f987a7253ac413176f2b074cf7815e54"),
+	hexInt("f3392b0822b70005940c7a398e4b70f3"),
+	hexInt("e7159475a2c29b7443b29c7fa6e889d9"),
+	hexInt("d097f3bdfd2022b8845ad8f792aa5825"),
+	hexInt("a9f746462d870fdf8a65dc1f90e061e5"),
+	hexInt("70d869a156d2a1b890bb3df62baf32f7"),
+	hexInt("31be135f97d08fd981231505542fcfa6"),
+	hexInt("9aa508b5b7a84e1c677de54f3e99bc9"),
+	hexInt("5d6af8dedb81196699c329225ee604"),
+	hexInt("2216e584f5fa1ea926041bedfe98"),
+	hexInt("48a170391f7dc42444e8fa2"),
+}
+
+func hexInt(s string) *big.Int {
+	value, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		panic("invalid hex constant: " + s)
+	}
+	return value
+}
+
+// GetSqrtRatioAtTick calculates sqrt(1.0001^tick) * 2^96 (TickMath.getSqrtRatioAtTick)
+func GetSqrtRatioAtTick(tick int) (*big.Int, error) {
+	absTick := tick
+	if absTick < 0 {
+		absTick = -absTick
+	}
+	if absTick > MaxTick {
+		return nil, ErrTickOutOfRange
+	}
+
+	ratio := new(big.Int).Set(q128)
+	for i, tickRatio := range tickRatios {
+		if absTick&(1<<i) != 0 {
+			ratio.Mul(ratio, tickRatio)
+			ratio.Rsh(ratio, 128)
+		}
+	}
+	if tick > 0 {
+		ratio.Div(maxUint256, ratio)
+	}
+
+	// Divide by 1<<32 rounding up to go from a Q128.128 to a Q128.96
+	remainder := new(big.Int).Mod(ratio, q32)
+	ratio.Rsh(ratio, 32)
+	if remainder.Sign() != 0 {
+		ratio.Add(ratio, big.NewInt(1))
+	}
+	return ratio, nil
+}
+
+// GetTickAtSqrtRatio calculates the greatest tick whose sqrt ratio is at most sqrtPriceX96 (TickMath.getTickAtSqrtRatio).
+// The contract computes it with a fixed-point log2, here it is found by binary search over GetSqrtRatioAtTick,
+// which has the same result by definition.
+func GetTickAtSqrtRatio(sqrtPriceX96 *big.Int) (int, error) {
+	if sqrtPriceX96.Cmp(MinSqrtRatio) < 0 || sqrtPriceX96.Cmp(MaxSqrtRatio) >= 0 {
+		return 0, ErrSqrtRatioOutOfRange
+	}
+
+	low, high := MinTick, MaxTick
+	for low < high {
+		mid := low + (high-low+1)/2
+		ratio, err := GetSqrtRatioAtTick(mid)
+		if err != nil {
+			return 0, err
+		}
+		if ratio.Cmp(sqrtPriceX96) <= 0 {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+	return low, nil
+}
+
+// mulDiv calculates floor(a*b/denominator), failing when the result overflows uint256 (FullMath.mulDiv)
+func mulDiv(a, b, denominator *big.Int) (*big.Int, error) {
+	if denominator.Sign() == 0 {
+		return nil, ErrMathOverflow
+	}
+	result := new(big.Int).Mul(a, b)
+	result.Div(result, denominator)
+	if result.Cmp(maxUint256) > 0 {
+		return nil, ErrMathOverflow
+	}
+	return result, nil
+}
+
+// mulDivRoundingUp calculates ceil(a*b/denominator), failing when the result overflows uint256 (FullMath.mulDivRoundingUp)
+func mulDivRoundingUp(a, b, denominator *big.Int) (*big.Int, error) {
+	if denominator.Sign() == 0 {
+		return nil, ErrMathOverflow
+	}
+	product := new(big.Int).Mul(a, b)
+	result, remainder := new(big.Int).QuoRem(product, denominator, new(big.Int))
+	if remainder.Sign() != 0 {
+		result.Add(result, big.NewInt(1))
+	}
+	if result.Cmp(maxUint256) > 0 {
+		return nil, ErrMathOverflow
+	}
+	return result, nil
+}
+
+// divRoundingUp calculates ceil(x/y) (UnsafeMath.divRoundingUp)
+func divRoundingUp(x, y *big.Int) *big.Int {
+	result, remainder := new(big.Int).QuoRem(x, y, new(big.Int))
+	if remainder.Sign() != 0 {
+		result.Add(result, big.NewInt(1))
+	}
+	return result
+}
+
+// toUint160 fails when value does not fit into a uint160 (SafeCast.toUint160)
+func toUint160(value *big.Int) (*big.Int, error) {
+	if value.Cmp(maxUint160) > 0 {
+		return nil, ErrMathOverflow
+	}
+	return value, nil
+}
+
+// getNextSqrtPriceFromAmount0RoundingUp gets the next sqrt price given a delta of token0, rounding up
+// (SqrtPriceMath.getNextSqrtPriceFromAmount0RoundingUp)
+func getNextSqrtPriceFromAmount0RoundingUp(sqrtPX96, liquidity, amount *big.Int, add bool) (*big.Int, error) {
+	if amount.Sign() == 0 {
+		return new(big.Int).Set(sqrtPX96), nil
+	}
+	numerator1 := new(big.Int).Lsh(liquidity, 96)
+	product := new(big.Int).Mul(amount, sqrtPX96)
+
+	if add {
+		// The contract falls back to a less precise formula when amount * sqrtPX96 or the denominator overflows uint256
+		if product.Cmp(maxUint256) <= 0 {
+			denominator := new(big.Int).Add(numerator1, product)
+			if denominator.Cmp(maxUint256) <= 0 {
+				return mulDivRoundingUp(numerator1, sqrtPX96, denominator)
+			}
+		}
+		denominator := new(big.Int).Div(numerator1, sqrtPX96)
+		denominator.Add(denominator, amount)
+		return divRoundingUp(numerator1, denominator), nil
+	}
+
+	if product.Cmp(maxUint256) > 0 || numerator1.Cmp(product) <= 0 {
+		return nil, ErrMathOverflow
+	}
+	denominator := new(big.Int).Sub(numerator1, product)
+	next, err := mulDivRoundingUp(numerator1, sqrtPX96, denominator)
+	if err != nil {
+		return nil, err
+	}
+	return toUint160(next)
+}
+
+// getNextSqrtPriceFromAmount1RoundingDown gets the next sqrt price given a delta of token1, rounding down
+// (SqrtPriceMath.getNextSqrtPriceFromAmount1RoundingDown)
+func getNextSqrtPriceFromAmount1RoundingDown(sqrtPX96, liquidity, amount *big.Int, add bool) (*big.Int, error) {
+	if add {
+		quotient, err := mulDiv(amount, Q96, liquidity)
+		if err != nil {
+			return nil, err
+		}
+		return toUint160(quotient.Add(quotient, sqrtPX96))
+	}
+
+	quotient, err := mulDivRoundingUp(amount, Q96, liquidity)
+	if err != nil {
+		return nil, err
+	}
+	if sqrtPX96.Cmp(quotient) <= 0 {
+		return nil, ErrMathOverflow
+	}
+	return quotient.Sub(sqrtPX96, quotient), nil
+}
+
+// GetNextSqrtPriceFromInput gets the next sqrt price after adding amountIn of token0 (zeroForOne) or token1
+// (SqrtPriceMath.getNextSqrtPriceFromInput)
+func GetNextSqrtPriceFromInput(sqrtPX96, liquidity, amountIn *big.Int, zeroForOne bool) (*big.Int, error) {
+	if sqrtPX96.Sign() <= 0 || liquidity.Sign() <= 0 {
+		return nil, ErrMathOverflow
+	}
+	if zeroForOne {
+		return getNextSqrtPriceFromAmount0RoundingUp(sqrtPX96, liquidity, amountIn, true)
+	}
+	return getNextSqrtPriceFromAmount1RoundingDown(sqrtPX96, liquidity, amountIn, true)
+}
+
+// GetNextSqrtPriceFromOutput gets the next sqrt price after removing amountOut of token1 (zeroForOne) or token0
+// (SqrtPriceMath.getNextSqrtPriceFromOutput)
+func GetNextSqrtPriceFromOutput(sqrtPX96, liquidity, amountOut *big.Int, zeroForOne bool) (*big.Int, error) {
+	if sqrtPX96.Sign() <= 0 || liquidity.Sign() <= 0 {
+		return nil, ErrMathOverflow
+	}
+	if zeroForOne {
+		return getNextSqrtPriceFromAmount1RoundingDown(sqrtPX96, liquidity, amountOut, false)
+	}
+	return getNextSqrtPriceFromAmount0RoundingUp(sqrtPX96, liquidity, amountOut, false)
+}
+
+// GetAmount0Delta gets the amount of token0 between two sqrt prices for the given liquidity (SqrtPriceMath.getAmount0Delta)
+func GetAmount0Delta(sqrtRatioAX96, sqrtRatioBX96, liquidity *big.Int, roundUp bool) (*big.Int, error) {
+	if sqrtRatioAX96.Cmp(sqrtRatioBX96) > 0 {
+		sqrtRatioAX96, sqrtRatioBX96 = sqrtRatioBX96, sqrtRatioAX96
+	}
+	if sqrtRatioAX96.Sign() <= 0 {
+		return nil, ErrMathOverflow
+	}
+
+	numerator1 := new(big.Int).Lsh(liquidity, 96)
+	numerator2 := new(big.Int).Sub(sqrtRatioBX96, sqrtRatioAX96)
+
+	if roundUp {
+		amount, err := mulDivRoundingUp(numerator1, numerator2, sqrtRatioBX96)
+		if err != nil {
+			return nil, err
+		}
+		return divRoundingUp(amount, sqrtRatioAX96), nil
+	}
+
+	amount, err := mulDiv(numerator1, numerator2, sqrtRatioBX96)
+	if err != nil {
+		return nil, err
+	}
+	return amount.Div(amount, sqrtRatioAX96), nil
+}
+
+// GetAmount1Delta gets the amount of token1 between two sqrt prices for the given liquidity (SqrtPriceMath.getAmount1Delta)
+func GetAmount1Delta(sqrtRatioAX96, sqrtRatioBX96, liquidity *big.Int, roundUp bool) (*big.Int, error) {
+	if sqrtRatioAX96.Cmp(sqrtRatioBX96) > 0 {
+		sqrtRatioAX96, sqrtRatioBX96 = sqrtRatioBX96, sqrtRatioAX96
+	}
+
+	diff := new(big.Int).Sub(sqrtRatioBX96, sqrtRatioAX96)
+	if roundUp {
+		return mulDivRoundingUp(liquidity, diff, Q96)
+	}
+	return mulDiv(liquidity, diff, Q96)
+}
+
+// SwapStep is the result of swapping within a single tick range
+type SwapStep struct {
+	SqrtRatioNextX96 *big.Int
+	AmountIn         *big.Int
+	AmountOut        *big.Int
+	FeeAmount        *big.Int
+}
+
+// ComputeSwapStep computes the result of swapping amountRemaining towards sqrtRatioTargetX96 (SwapMath.computeSwapStep).
+// A positive amountRemaining is an exact input, a negative one an exact output. feePips is in hundredths of a basis point.
+func ComputeSwapStep(sqrtRatioCurrentX96, sqrtRatioTargetX96, liquidity, amountRemaining *big.Int, feePips uint32) (*SwapStep, error) {
+	zeroForOne := sqrtRatioCurrentX96.Cmp(sqrtRatioTargetX96) >= 0
+	exactIn := amountRemaining.Sign() >= 0
+	feeComplement := big.NewInt(int64(FeeDenominator - feePips))
+	fee := big.NewInt(int64(feePips))
+	remaining := new(big.Int).Abs(amountRemaining)
+
+	var (
+		amountIn, amountOut, next *big.Int
+		err                       error
+	)
+	if exactIn {
+		remainingLessFee, err := mulDiv(remaining, feeComplement, big.NewInt(FeeDenominator))
+		if err != nil {
+			return nil, err
+		}
+		if zeroForOne {
+			amountIn, err = GetAmount0Delta(sqrtRatioTargetX96, sqrtRatioCurrentX96, liquidity, true)
+		} else {
+			amountIn, err = GetAmount1Delta(sqrtRatioCurrentX96, sqrtRatioTargetX96, liquidity, true)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if remainingLessFee.Cmp(amountIn) >= 0 {
+			next = sqrtRatioTargetX96
+		} else if next, err = GetNextSqrtPriceFromInput(sqrtRatioCurrentX96, liquidity, remainingLessFee, zeroForOne); err != nil {
+			return nil, err
+		}
+	} else {
+		if zeroForOne {
+			amountOut, err = GetAmount1Delta(sqrtRatioTargetX96, sqrtRatioCurrentX96, liquidity, false)
+		} else {
+			amountOut, err = GetAmount0Delta(sqrtRatioCurrentX96, sqrtRatioTargetX96, liquidity, false)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if remaining.Cmp(amountOut) >= 0 {
+			next = sqrtRatioTargetX96
+		} else if next, err = GetNextSqrtPriceFromOutput(sqrtRatioCurrentX96, liquidity, remaining, zeroForOne); err != nil {
+			return nil, err
+		}
+	}
+
+	reachedTarget := sqrtRatioTargetX96.Cmp(next) == 0
+
+	// Get the input/output amounts
+	if zeroForOne {
+		if !(reachedTarget && exactIn) {
+			if amountIn, err = GetAmount0Delta(next, sqrtRatioCurrentX96, liquidity, true); err != nil {
+				return nil, err
+			}
+		}
+		if !(reachedTarget && !exactIn) {
+			if amountOut, err = GetAmount1Delta(next, sqrtRatioCurrentX96, liquidity, false); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		if !(reachedTarget && exactIn) {
+			if amountIn, err = GetAmount1Delta(sqrtRatioCurrentX96, next, liquidity, true); err != nil {
+				return nil, err
+			}
+		}
+		if !(reachedTarget && !exactIn) {
+			if amountOut, err = GetAmount0Delta(sqrtRatioCurrentX96, next, liquidity, false); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Cap the output amount to not exceed the remaining output amount
+	if !exactIn && amountOut.Cmp(remaining) > 0 {
+		amountOut = remaining
+	}
+
+	var feeAmount *big.Int
+	if exactIn && !reachedTarget {
+		// The target was not reached, so the remainder of the maximum input is taken as fee
+		feeAmount = new(big.Int).Sub(remaining, amountIn)
+	} else if feeAmount, err = mulDivRoundingUp(amountIn, fee, feeComplement); err != nil {
+		return nil, err
+	}
+
+	return &SwapStep{
+		SqrtRatioNextX96: next,
+		AmountIn:         amountIn,
+		AmountOut:        amountOut,
+		FeeAmount:        feeAmount,
+	}, nil
+}
@@ -0,0 +1,259 @@
+package uniswap_v3
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Expected values are taken from the TickMath, SqrtPriceMath and SwapMath tests of the Uniswap V3 core repository.
+// encodePriceSqrt(reserve1, reserve0) there is floor(sqrt(reserve1 / reserve0) * 2**96).
+
+var (
+	// encodePriceSqrt(1, 1)
+	priceOne = mustBigInt("79228162514264337593543950336")
+	// encodePriceSqrt(121, 100)
+	price121to100 = mustBigInt("87150978765690771352898345369")
+	// encodePriceSqrt(101, 100)
+	price101to100 = mustBigInt("79623317895830914510639640423")
+	// encodePriceSqrt(1000, 100)
+	price1000to100 = mustBigInt("250541448375047931186413801569")
+
+	ether = mustBigInt("1000000000000000000")
+)
+
+func mustBigInt(s string) *big.Int {
+	value, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic("invalid big int string: " + s)
+	}
+	return value
+}
+
+func TestGetSqrtRatioAtTick(t *testing.T) {
+	tests := []struct {
+		tick int
+		want string
+	}{
+		{MinTick, "4295128739"},
+		{MinTick + 1, "4295343490"},
+		{0, "79228162514264337593543950336"},
+		{MaxTick - 1, "1461373636630004318706518188784493106690254656249"},
+		{MaxTick, "1461446703485210103287273052203988822378723970342"},
+	}
+
+	for _, tt := range tests {
+		got, err := GetSqrtRatioAtTick(tt.tick)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got.String(), "tick %d", tt.tick)
+	}
+
+	_, err := GetSqrtRatioAtTick(MinTick - 1)
+	assert.ErrorIs(t, err, ErrTickOutOfRange)
+	_, err = GetSqrtRatioAtTick(MaxTick + 1)
+	assert.ErrorIs(t, err, ErrTickOutOfRange)
+}
+
+func TestGetSqrtRatioAtTick_MatchesExactPrice(t *testing.T) {
+	// sqrt(1.0001^tick) * 2^96 computed with 256-bit floats must be within 1e-12 of the fixed-point result
+	for _, tick := range []int{-500000, -50000, -1000, -60, -1, 1, 10, 60, 1000, 50000, 150000, 500000} {
+		got, err := GetSqrtRatioAtTick(tick)
+		require.NoError(t, err)
+
+		exact := new(big.Float).SetPrec(256).SetInt64(1)
+		base, _ := new(big.Float).SetPrec(256).SetString("1.0001")
+		power := tick
+		if power < 0 {
+			power = -power
+		}
+		for i := 0; i < power; i++ {
+			exact.Mul(exact, base)
+		}
+		if tick < 0 {
+			exact.Quo(big.NewFloat(1).SetPrec(256), exact)
+		}
+		exact.Sqrt(exact)
+		exact.Mul(exact, new(big.Float).SetInt(Q96))
+
+		diff := new(big.Float).Sub(new(big.Float).SetInt(got), exact)
+		diff.Quo(diff, exact)
+		relative, _ := diff.Abs(diff).Float64()
+		assert.Less(t, relative, 1e-12, "tick %d", tick)
+	}
+}
+
+func TestGetTickAtSqrtRatio(t *testing.T) {
+	tests := []struct {
+		sqrtPriceX96 *big.Int
+		want         int
+	}{
+		{MinSqrtRatio, MinTick},
+		{mustBigInt("4295343490"), MinTick + 1},
+		{priceOne, 0},
+		{new(big.Int).Sub(priceOne, big.NewInt(1)), -1},
+		{mustBigInt("1461373636630004318706518188784493106690254656249"), MaxTick - 1},
+		{new(big.Int).Sub(MaxSqrtRatio, big.NewInt(1)), MaxTick - 1},
+	}
+
+	for _, tt := range tests {
+		got, err := GetTickAtSqrtRatio(tt.sqrtPriceX96)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, got, "sqrt price %s", tt.sqrtPriceX96)
+	}
+
+	_, err := GetTickAtSqrtRatio(MaxSqrtRatio)
+	assert.ErrorIs(t, err, ErrSqrtRatioOutOfRange)
+	_, err = GetTickAtSqrtRatio(new(big.Int).Sub(MinSqrtRatio, big.NewInt(1)))
+	assert.ErrorIs(t, err, ErrSqrtRatioOutOfRange)
+}
+
+func TestGetNextSqrtPrice(t *testing.T) {
+	tenthEther := new(big.Int).Div(ether, big.NewInt(10))
+
+	t.Run("input amount of 0.1 token1", func(t *testing.T) {
+		got, err := GetNextSqrtPriceFromInput(priceOne, ether, tenthEther, false)
+		require.NoError(t, err)
+		assert.Equal(t, "87150978765690771352898345369", got.String())
+	})
+
+	t.Run("input amount of 0.1 token0", func(t *testing.T) {
+		got, err := GetNextSqrtPriceFromInput(priceOne, ether, tenthEther, true)
+		require.NoError(t, err)
+		assert.Equal(t, "72025602285694852357767227579", got.String())
+	})
+
+	t.Run("input amount of 2^100 token0 with overflowing product", func(t *testing.T) {
+		got, err := GetNextSqrtPriceFromInput(priceOne, new(big.Int).Mul(big.NewInt(10), ether), new(big.Int).Lsh(big.NewInt(1), 100), true)
+		require.NoError(t, err)
+		assert.Equal(t, "624999999995069620", got.String())
+	})
+
+	t.Run("zero input returns the input price", func(t *testing.T) {
+		got, err := GetNextSqrtPriceFromInput(priceOne, tenthEther, big.NewInt(0), true)
+		require.NoError(t, err)
+		assert.Equal(t, priceOne, got)
+	})
+
+	t.Run("output amount of 0.1 token1", func(t *testing.T) {
+		got, err := GetNextSqrtPriceFromOutput(priceOne, ether, tenthEther, false)
+		require.NoError(t, err)
+		assert.Equal(t, "88031291682515930659493278152", got.String())
+	})
+
+	t.Run("output amount of 0.1 token0", func(t *testing.T) {
+		got, err := GetNextSqrtPriceFromOutput(priceOne, ether, tenthEther, true)
+		require.NoError(t, err)
+		assert.Equal(t, "71305346262837903834189555302", got.String())
+	})
+
+	t.Run("output exceeding the virtual reserves fails", func(t *testing.T) {
+		// A sqrt price of 256 with liquidity 1024 has 4 token0 and 262144 token1 in virtual reserves
+		price := new(big.Int).Mul(big.NewInt(256), Q96)
+		_, err := GetNextSqrtPriceFromOutput(price, big.NewInt(1024), big.NewInt(4), false)
+		assert.ErrorIs(t, err, ErrMathOverflow)
+
+		got, err := GetNextSqrtPriceFromOutput(price, big.NewInt(1024), big.NewInt(262143), true)
+		require.NoError(t, err)
+		assert.Equal(t, "77371252455336267181195264", got.String())
+	})
+}
+
+func TestGetAmountDeltas(t *testing.T) {
+	amount0Up, err := GetAmount0Delta(priceOne, price121to100, ether, true)
+	require.NoError(t, err)
+	assert.Equal(t, "90909090909090910", amount0Up.String())
+
+	amount0Down, err := GetAmount0Delta(priceOne, price121to100, ether, false)
+	require.NoError(t, err)
+	assert.Equal(t, "90909090909090909", amount0Down.String())
+
+	amount1Up, err := GetAmount1Delta(priceOne, price121to100, ether, true)
+	require.NoError(t, err)
+	assert.Equal(t, "100000000000000000", amount1Up.String())
+
+	amount1Down, err := GetAmount1Delta(priceOne, price121to100, ether, false)
+	require.NoError(t, err)
+	assert.Equal(t, "99999999999999999", amount1Down.String())
+
+	// Reversed bounds give the same amounts
+	reversed, err := GetAmount0Delta(price121to100, priceOne, ether, true)
+	require.NoError(t, err)
+	assert.Equal(t, amount0Up, reversed)
+
+	zero, err := GetAmount0Delta(priceOne, priceOne, ether, true)
+	require.NoError(t, err)
+	assert.Zero(t, zero.Sign())
+}
+
+func TestComputeSwapStep(t *testing.T) {
+	t.Run("exact amount in capped at price target in one for zero", func(t *testing.T) {
+		step, err := ComputeSwapStep(priceOne, price101to100, new(big.Int).Mul(big.NewInt(2), ether), ether, 600)
+		require.NoError(t, err)
+		assert.Equal(t, "9975124224178055", step.AmountIn.String())
+		assert.Equal(t, "5988667735148", step.FeeAmount.String())
+		assert.Equal(t, "9925619580021728", step.AmountOut.String())
+		assert.Equal(t, price101to100, step.SqrtRatioNextX96)
+	})
+
+	t.Run("exact amount out capped at price target in one for zero", func(t *testing.T) {
+		step, err := ComputeSwapStep(priceOne, price101to100, new(big.Int).Mul(big.NewInt(2), ether), new(big.Int).Neg(ether), 600)
+		require.NoError(t, err)
+		assert.Equal(t, "9975124224178055", step.AmountIn.String())
+		assert.Equal(t, "5988667735148", step.FeeAmount.String())
+		assert.Equal(t, "9925619580021728", step.AmountOut.String())
+		assert.Equal(t, price101to100, step.SqrtRatioNextX96)
+	})
+
+	t.Run("exact amount in fully spent in one for zero", func(t *testing.T) {
+		step, err := ComputeSwapStep(priceOne, price1000to100, new(big.Int).Mul(big.NewInt(2), ether), ether, 600)
+		require.NoError(t, err)
+		assert.Equal(t, "999400000000000000", step.AmountIn.String())
+		assert.Equal(t, "600000000000000", step.FeeAmount.String())
+		assert.Equal(t, "666399946655997866", step.AmountOut.String())
+
+		// The price moves exactly as far as the input less fee allows
+		expected, err := GetNextSqrtPriceFromInput(priceOne, new(big.Int).Mul(big.NewInt(2), ether), step.AmountIn, false)
+		require.NoError(t, err)
+		assert.Equal(t, expected, step.SqrtRatioNextX96)
+	})
+
+	t.Run("exact amount out fully received in one for zero", func(t *testing.T) {
+		step, err := ComputeSwapStep(priceOne, price1000to100, new(big.Int).Mul(big.NewInt(2), ether), new(big.Int).Neg(ether), 600)
+		require.NoError(t, err)
+		assert.Equal(t, "2000000000000000000", step.AmountIn.String())
+		assert.Equal(t, "1200720432259356", step.FeeAmount.String())
+		assert.Equal(t, ether, step.AmountOut)
+	})
+
+	t.Run("amount out is capped at the desired amount out", func(t *testing.T) {
+		step, err := ComputeSwapStep(
+			mustBigInt("417332158212080721273783715441582"),
+			mustBigInt("1452870262520218020823638996"),
+			mustBigInt("159344665391607089467575320103"),
+			big.NewInt(-1),
+			1,
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "1", step.AmountIn.String())
+		assert.Equal(t, "1", step.FeeAmount.String())
+		assert.Equal(t, "1", step.AmountOut.String())
+		assert.Equal(t, "417332158212080721273783715441581", step.SqrtRatioNextX96.String())
+	})
+
+	t.Run("entire input amount taken as fee", func(t *testing.T) {
+		step, err := ComputeSwapStep(
+			mustBigInt("2413"),
+			mustBigInt("79887613182836312"),
+			mustBigInt("1985041575832132834610021537970"),
+			big.NewInt(10),
+			1872,
+		)
+		require.NoError(t, err)
+		assert.Equal(t, "0", step.AmountIn.String())
+		assert.Equal(t, "10", step.FeeAmount.String())
+		assert.Equal(t, "0", step.AmountOut.String())
+		assert.Equal(t, "2413", step.SqrtRatioNextX96.String())
+	})
+}
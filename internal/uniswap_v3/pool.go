@@ -0,0 +1,228 @@
+package uniswap_v3
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"math/bits"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// ErrInsufficientLiquidity is returned when the pool runs out of liquidity before the whole amount is swapped
+	ErrInsufficientLiquidity = errors.New("insufficient liquidity")
+	// ErrTicksNotLoaded is returned when a swap crosses past the tick bitmap words loaded with the pool state
+	ErrTicksNotLoaded = errors.New("swap crosses ticks that were not loaded")
+)
+
+// PoolState is the state of a Uniswap V3 pool needed to quote swaps against it
+type PoolState struct {
+	Pool   common.Address
+	Token0 common.Address
+	Token1 common.Address
+	// Fee is the swap fee in hundredths of a basis point, e.g. 3000 for 0.3%
+	Fee          uint32
+	TickSpacing  int
+	SqrtPriceX96 *big.Int
+	Tick         int
+	// Liquidity is the in-range liquidity
+	Liquidity *big.Int
+	// TickBitmap holds the loaded words of the pool's tick bitmap, missing words are unknown rather than empty
+	TickBitmap map[int16]*big.Int
+	// LiquidityNet holds the net liquidity of every initialized tick in the loaded words
+	LiquidityNet map[int]*big.Int
+}
+
+// FeeBps returns the swap fee in basis points, rounded down
+func (p *PoolState) FeeBps() uint32 {
+	return p.Fee / 100
+}
+
+// QuoteExactIn calculates the output of swapping amountIn of token0 (zeroForOne) or token1, crossing initialized
+// ticks like UniswapV3Pool.swap does without a price limit
+func (p *PoolState) QuoteExactIn(amountIn *big.Int, zeroForOne bool) (*big.Int, error) {
+	if amountIn.Sign() <= 0 {
+		return big.NewInt(0), nil
+	}
+
+	_, amountOut, err := p.swap(amountIn, zeroForOne)
+	if err != nil {
+		return nil, err
+	}
+	return amountOut, nil
+}
+
+// QuoteExactOut calculates the input of token0 (zeroForOne) or token1 needed to receive amountOut of the other token
+func (p *PoolState) QuoteExactOut(amountOut *big.Int, zeroForOne bool) (*big.Int, error) {
+	if amountOut.Sign() <= 0 {
+		return big.NewInt(0), nil
+	}
+
+	amountIn, _, err := p.swap(new(big.Int).Neg(amountOut), zeroForOne)
+	if err != nil {
+		return nil, err
+	}
+	return amountIn, nil
+}
+
+// swap simulates UniswapV3Pool.swap. A positive amountSpecified is an exact input, a negative one an exact output.
+// It returns the amounts paid into and out of the pool.
+func (p *PoolState) swap(amountSpecified *big.Int, zeroForOne bool) (*big.Int, *big.Int, error) {
+	exactInput := amountSpecified.Sign() > 0
+
+	// Without a price limit the Quoter swaps up to the extreme prices
+	sqrtPriceLimitX96 := new(big.Int).Add(MinSqrtRatio, big.NewInt(1))
+	if !zeroForOne {
+		sqrtPriceLimitX96 = new(big.Int).Sub(MaxSqrtRatio, big.NewInt(1))
+	}
+
+	remaining := new(big.Int).Set(amountSpecified)
+	amountIn := big.NewInt(0)
+	amountOut := big.NewInt(0)
+	sqrtPriceX96 := new(big.Int).Set(p.SqrtPriceX96)
+	tick := p.Tick
+	liquidity := new(big.Int).Set(p.Liquidity)
+
+	for remaining.Sign() != 0 && sqrtPriceX96.Cmp(sqrtPriceLimitX96) != 0 {
+		sqrtPriceStartX96 := sqrtPriceX96
+
+		tickNext, initialized, err := p.nextInitializedTickWithinOneWord(tick, zeroForOne)
+		if err != nil {
+			return nil, nil, err
+		}
+		// The bitmap is not aware of the tick bounds
+		if tickNext < MinTick {
+			tickNext = MinTick
+		} else if tickNext > MaxTick {
+			tickNext = MaxTick
+		}
+
+		sqrtPriceNextX96, err := GetSqrtRatioAtTick(tickNext)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		target := sqrtPriceNextX96
+		if (zeroForOne && sqrtPriceNextX96.Cmp(sqrtPriceLimitX96) < 0) || (!zeroForOne && sqrtPriceNextX96.Cmp(sqrtPriceLimitX96) > 0) {
+			target = sqrtPriceLimitX96
+		}
+
+		step, err := ComputeSwapStep(sqrtPriceX96, target, liquidity, remaining, p.Fee)
+		if err != nil {
+			return nil, nil, err
+		}
+		sqrtPriceX96 = step.SqrtRatioNextX96
+
+		paid := new(big.Int).Add(step.AmountIn, step.FeeAmount)
+		if exactInput {
+			remaining.Sub(remaining, paid)
+		} else {
+			remaining.Add(remaining, step.AmountOut)
+		}
+		amountIn.Add(amountIn, paid)
+		amountOut.Add(amountOut, step.AmountOut)
+
+		if sqrtPriceX96.Cmp(sqrtPriceNextX96) == 0 {
+			// The next tick was reached, cross it if it is initialized
+			if initialized {
+				liquidityNet, ok := p.LiquidityNet[tickNext]
+				if !ok {
+					return nil, nil, fmt.Errorf("%w: tick %d", ErrTicksNotLoaded, tickNext)
+				}
+				if zeroForOne {
+					liquidity.Sub(liquidity, liquidityNet)
+				} else {
+					liquidity.Add(liquidity, liquidityNet)
+				}
+				if liquidity.Sign() < 0 {
+					return nil, nil, ErrMathOverflow
+				}
+			}
+			if zeroForOne {
+				tick = tickNext - 1
+			} else {
+				tick = tickNext
+			}
+		} else if sqrtPriceX96.Cmp(sqrtPriceStartX96) != 0 {
+			if tick, err = GetTickAtSqrtRatio(sqrtPriceX96); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	if remaining.Sign() != 0 {
+		return nil, nil, ErrInsufficientLiquidity
+	}
+	return amountIn, amountOut, nil
+}
+
+// nextInitializedTickWithinOneWord returns the next initialized tick in the same bitmap word as tick, or the word
+// boundary when there is none (TickBitmap.nextInitializedTickWithinOneWord)
+func (p *PoolState) nextInitializedTickWithinOneWord(tick int, lte bool) (int, bool, error) {
+	spacing := p.TickSpacing
+	compressed := tick / spacing
+	if tick < 0 && tick%spacing != 0 {
+		// Round towards negative infinity
+		compressed--
+	}
+
+	if lte {
+		wordPos, bitPos := position(compressed)
+		word, err := p.word(wordPos)
+		if err != nil {
+			return 0, false, err
+		}
+
+		// All the 1s at or to the right of the current bitPos
+		mask := new(big.Int).Lsh(big.NewInt(1), bitPos+1)
+		mask.Sub(mask, big.NewInt(1))
+		masked := mask.And(mask, word)
+
+		if masked.Sign() != 0 {
+			return (compressed - int(bitPos) + masked.BitLen() - 1) * spacing, true, nil
+		}
+		return (compressed - int(bitPos)) * spacing, false, nil
+	}
+
+	// Start from the word of the next tick, since the current tick state doesn't matter
+	wordPos, bitPos := position(compressed + 1)
+	word, err := p.word(wordPos)
+	if err != nil {
+		return 0, false, err
+	}
+
+	// All the 1s at or to the left of bitPos
+	masked := new(big.Int).Rsh(word, bitPos)
+	if masked.Sign() != 0 {
+		return (compressed + 1 + int(masked.TrailingZeroBits())) * spacing, true, nil
+	}
+	return (compressed + 1 + 255 - int(bitPos)) * spacing, false, nil
+}
+
+// word returns a loaded word of the tick bitmap
+func (p *PoolState) word(wordPos int16) (*big.Int, error) {
+	word, ok := p.TickBitmap[wordPos]
+	if !ok {
+		return nil, fmt.Errorf("%w: bitmap word %d", ErrTicksNotLoaded, wordPos)
+	}
+	return word, nil
+}
+
+// position returns the bitmap word and bit of a compressed tick (TickBitmap.position)
+func position(compressed int) (int16, uint) {
+	return int16(compressed >> 8), uint(uint8(compressed))
+}
+
+// initializedTicks returns the ticks initialized in a bitmap word, in ascending order
+func initializedTicks(wordPos int16, word *big.Int, tickSpacing int) []int {
+	var ticks []int
+	for i, w := range word.Bits() {
+		for w != 0 {
+			bit := bits.TrailingZeros(uint(w))
+			w &^= 1 << bit
+			ticks = append(ticks, (int(wordPos)*256+i*bits.UintSize+bit)*tickSpacing)
+		}
+	}
+	return ticks
+}
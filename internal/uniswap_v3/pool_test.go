@@ -0,0 +1,221 @@
+package uniswap_v3
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestPool creates a pool at tick 0 with tick spacing 60 and the given net liquidity per initialized tick,
+// loading the bitmap words -2..1
+func newTestPool(t *testing.T, liquidity *big.Int, liquidityNet map[int]*big.Int) *PoolState {
+	pool := &PoolState{
+		Fee:          3000,
+		TickSpacing:  60,
+		SqrtPriceX96: priceOne,
+		Tick:         0,
+		Liquidity:    liquidity,
+		TickBitmap:   map[int16]*big.Int{-2: new(big.Int), -1: new(big.Int), 0: new(big.Int), 1: new(big.Int)},
+		LiquidityNet: liquidityNet,
+	}
+	for tick := range liquidityNet {
+		wordPos, bitPos := position(tick / pool.TickSpacing)
+		require.Contains(t, pool.TickBitmap, wordPos)
+		pool.TickBitmap[wordPos].SetBit(pool.TickBitmap[wordPos], int(bitPos), 1)
+	}
+	return pool
+}
+
+func sqrtRatio(t *testing.T, tick int) *big.Int {
+	ratio, err := GetSqrtRatioAtTick(tick)
+	require.NoError(t, err)
+	return ratio
+}
+
+func TestNextInitializedTickWithinOneWord(t *testing.T) {
+	// Initialized ticks of the TickBitmap tests of the Uniswap V3 core repository, with a tick spacing of 1
+	pool := &PoolState{TickSpacing: 1, TickBitmap: make(map[int16]*big.Int)}
+	for wordPos := int16(-3); wordPos <= 4; wordPos++ {
+		pool.TickBitmap[wordPos] = new(big.Int)
+	}
+	for _, tick := range []int{-200, -55, -4, 70, 78, 84, 139, 240, 535} {
+		wordPos, bitPos := position(tick)
+		pool.TickBitmap[wordPos].SetBit(pool.TickBitmap[wordPos], int(bitPos), 1)
+	}
+
+	tests := []struct {
+		name            string
+		tick            int
+		lte             bool
+		wantTick        int
+		wantInitialized bool
+	}{
+		{"right of initialized tick", 78, false, 84, true},
+		{"right of negative initialized tick", -55, false, -4, true},
+		{"directly to the right", 77, false, 78, true},
+		{"directly to the right of negative tick", -56, false, -55, true},
+		{"right word boundary", 255, false, 511, false},
+		{"right from the previous word", -257, false, -200, true},
+		{"does not exceed the word", 508, false, 511, false},
+		{"skips half a word", 383, false, 511, false},
+		{"at initialized tick", 78, true, 78, true},
+		{"left of initialized tick", 79, true, 78, true},
+		{"left word boundary", 258, true, 256, false},
+		{"at word boundary", 256, true, 256, false},
+		{"directly to the left", 72, true, 70, true},
+		{"negative word boundary", -257, true, -512, false},
+		{"entire empty word", 1023, true, 768, false},
+		{"half an empty word", 900, true, 768, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tick, initialized, err := pool.nextInitializedTickWithinOneWord(tt.tick, tt.lte)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantTick, tick)
+			assert.Equal(t, tt.wantInitialized, initialized)
+		})
+	}
+
+	_, _, err := pool.nextInitializedTickWithinOneWord(5*256, true)
+	assert.ErrorIs(t, err, ErrTicksNotLoaded)
+}
+
+func TestInitializedTicks(t *testing.T) {
+	word := new(big.Int)
+	for _, bit := range []int{0, 63, 64, 200, 255} {
+		word.SetBit(word, bit, 1)
+	}
+
+	assert.Equal(t, []int{-2560, -1930, -1920, -560, -10}, initializedTicks(-1, word, 10))
+	assert.Empty(t, initializedTicks(3, new(big.Int), 10))
+}
+
+func TestPoolState_QuoteExactIn(t *testing.T) {
+	// One position over [-600, 600] with liquidity of 1 ether
+	single := map[int]*big.Int{-600: ether, 600: new(big.Int).Neg(ether)}
+
+	t.Run("zero for one within a tick range", func(t *testing.T) {
+		pool := newTestPool(t, ether, single)
+		amountIn := big.NewInt(1000000000000000)
+
+		out, err := pool.QuoteExactIn(amountIn, true)
+		require.NoError(t, err)
+
+		step, err := ComputeSwapStep(priceOne, sqrtRatio(t, -600), ether, amountIn, 3000)
+		require.NoError(t, err)
+		assert.Equal(t, step.AmountOut, out)
+		assert.Equal(t, "996006981039903", out.String())
+	})
+
+	t.Run("one for zero within a tick range", func(t *testing.T) {
+		pool := newTestPool(t, ether, single)
+		amountIn := big.NewInt(1000000000000000)
+
+		out, err := pool.QuoteExactIn(amountIn, false)
+		require.NoError(t, err)
+
+		step, err := ComputeSwapStep(priceOne, sqrtRatio(t, 600), ether, amountIn, 3000)
+		require.NoError(t, err)
+		assert.Equal(t, step.AmountOut, out)
+	})
+
+	t.Run("crossing an initialized tick", func(t *testing.T) {
+		// A second position over [-1200, -600] with liquidity of 2 ether takes over below -600
+		pool := newTestPool(t, ether, map[int]*big.Int{
+			-1200: new(big.Int).Mul(big.NewInt(2), ether),
+			-600:  new(big.Int).Sub(ether, new(big.Int).Mul(big.NewInt(2), ether)),
+			600:   new(big.Int).Neg(ether),
+		})
+		amountIn := new(big.Int).Div(ether, big.NewInt(20))
+
+		out, err := pool.QuoteExactIn(amountIn, true)
+		require.NoError(t, err)
+
+		first, err := ComputeSwapStep(priceOne, sqrtRatio(t, -600), ether, amountIn, 3000)
+		require.NoError(t, err)
+		require.Equal(t, sqrtRatio(t, -600), first.SqrtRatioNextX96, "the first range must be exhausted")
+
+		remaining := new(big.Int).Sub(amountIn, first.AmountIn)
+		remaining.Sub(remaining, first.FeeAmount)
+		second, err := ComputeSwapStep(sqrtRatio(t, -600), sqrtRatio(t, -1200), new(big.Int).Mul(big.NewInt(2), ether), remaining, 3000)
+		require.NoError(t, err)
+
+		assert.Equal(t, new(big.Int).Add(first.AmountOut, second.AmountOut), out)
+	})
+
+	t.Run("swap past the loaded ticks", func(t *testing.T) {
+		pool := newTestPool(t, ether, single)
+
+		_, err := pool.QuoteExactIn(ether, true)
+		assert.ErrorIs(t, err, ErrTicksNotLoaded)
+	})
+
+	t.Run("zero amount", func(t *testing.T) {
+		pool := newTestPool(t, ether, single)
+
+		out, err := pool.QuoteExactIn(big.NewInt(0), true)
+		require.NoError(t, err)
+		assert.Zero(t, out.Sign())
+	})
+}
+
+func TestPoolState_QuoteExactOut(t *testing.T) {
+	// Positions over [-1200, -600] with liquidity of 2 ether and [-600, 600] with liquidity of 1 ether
+	crossing := map[int]*big.Int{
+		-1200: new(big.Int).Mul(big.NewInt(2), ether),
+		-600:  new(big.Int).Sub(ether, new(big.Int).Mul(big.NewInt(2), ether)),
+		600:   new(big.Int).Neg(ether),
+	}
+
+	tests := []struct {
+		name       string
+		zeroForOne bool
+		amountIn   *big.Int
+	}{
+		{name: "zero for one within a tick range", zeroForOne: true, amountIn: big.NewInt(1000000000000000)},
+		{name: "one for zero within a tick range", zeroForOne: false, amountIn: big.NewInt(1000000000000000)},
+		{name: "crossing an initialized tick", zeroForOne: true, amountIn: new(big.Int).Div(ether, big.NewInt(20))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := newTestPool(t, ether, crossing)
+
+			amountOut, err := pool.QuoteExactIn(tt.amountIn, tt.zeroForOne)
+			require.NoError(t, err)
+
+			// Receiving the output of amountIn needs at most amountIn, and swapping that gives at least the output
+			needed, err := pool.QuoteExactOut(amountOut, tt.zeroForOne)
+			require.NoError(t, err)
+			assert.LessOrEqual(t, needed.Cmp(tt.amountIn), 0, "needed %s", needed)
+
+			received, err := pool.QuoteExactIn(needed, tt.zeroForOne)
+			require.NoError(t, err)
+			assert.GreaterOrEqual(t, received.Cmp(amountOut), 0, "received %s", received)
+		})
+	}
+
+	t.Run("matches a single swap step", func(t *testing.T) {
+		pool := newTestPool(t, ether, crossing)
+		amountOut := big.NewInt(1000000000000000)
+
+		needed, err := pool.QuoteExactOut(amountOut, true)
+		require.NoError(t, err)
+
+		step, err := ComputeSwapStep(priceOne, sqrtRatio(t, -600), ether, new(big.Int).Neg(amountOut), 3000)
+		require.NoError(t, err)
+		assert.Equal(t, amountOut, step.AmountOut)
+		assert.Equal(t, new(big.Int).Add(step.AmountIn, step.FeeAmount), needed)
+	})
+
+	t.Run("zero amount", func(t *testing.T) {
+		pool := newTestPool(t, ether, crossing)
+
+		needed, err := pool.QuoteExactOut(big.NewInt(0), true)
+		require.NoError(t, err)
+		assert.Zero(t, needed.Sign())
+	})
+}
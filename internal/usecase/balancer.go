@@ -0,0 +1,47 @@
+package usecase
+
+import (
+	"1inch_testtask/internal/balancer"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ErrBalancerDisabled is returned when a Balancer estimate is requested but no Balancer client is configured
+var ErrBalancerDisabled = errors.New("balancer is not configured")
+
+// WithBalancer enables estimates against Balancer V2 weighted pools
+func WithBalancer(client balancer.IBalancer) Option {
+	return func(s *Usecase) {
+		s.balancerClient = client
+	}
+}
+
+// loadBalancerPool loads the state of a Balancer V2 weighted pool at the block pinned in ctx
+func (s *Usecase) loadBalancerPool(ctx context.Context, poolAddress common.Address) (Pool, error) {
+	if s.balancerClient == nil {
+		return nil, ErrBalancerDisabled
+	}
+
+	state, err := s.balancerClient.GetPoolState(ctx, poolAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool state: %w", err)
+	}
+
+	return newBalancerPool(state), nil
+}
+
+// newBalancerPool returns a Pool quoting a Balancer V2 weighted pool with the weighted math of the pool contracts
+func newBalancerPool(state *balancer.PoolState) Pool {
+	return &indexedPool{
+		address:      state.Pool,
+		tokens:       state.Tokens,
+		feeBps:       state.FeeBps(),
+		exactIn:      state.CalcOutGivenIn,
+		exactOut:     state.CalcInGivenOut,
+		spotPrice:    state.SpotPrice,
+		errLiquidity: balancer.ErrInsufficientLiquidity,
+	}
+}
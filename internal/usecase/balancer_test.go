@@ -0,0 +1,105 @@
+package usecase
+
+import (
+	"1inch_testtask/internal/balancer"
+	"1inch_testtask/internal/pooldetect"
+	"1inch_testtask/internal/uniswap_v2"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockBalancer is an in-memory balancer.IBalancer implementation
+type mockBalancer = mockPoolClient[balancer.PoolState]
+
+var (
+	bal = common.HexToAddress("0xba100000625a3754423978a60c9317c58a424e3D")
+	// balWeth is the Balancer 80/20 BAL/WETH pool
+	balWeth = common.HexToAddress("0x5c6Ee304399DBdB9C8Ef030aB642B10820DB8F56")
+)
+
+// newMockBalancer returns a mock with an 80/20 BAL/WETH pool holding 4M BAL and 1000 WETH, BAL at 0.001 WETH
+func newMockBalancer() *mockBalancer {
+	return &mockBalancer{pools: map[common.Address]*balancer.PoolState{
+		balWeth: {
+			Pool:           balWeth,
+			Tokens:         []common.Address{bal, weth},
+			Balances:       []*big.Int{mustBigInt("4000000000000000000000000"), mustBigInt("1000000000000000000000")},
+			Weights:        []*big.Int{big.NewInt(8e17), big.NewInt(2e17)},
+			ScalingFactors: []*big.Int{big.NewInt(1), big.NewInt(1)},
+			SwapFee:        big.NewInt(1e16),
+		},
+	}}
+}
+
+func TestService_EstimateSwapBalancer(t *testing.T) {
+	ctx := context.Background()
+	detector := &mockPoolDetector{types: map[common.Address]pooldetect.Type{balWeth: pooldetect.Balancer}}
+
+	t.Run("detected balancer pool", func(t *testing.T) {
+		client := newMockBalancer()
+		service := NewUsecase(newMockUniswapV2(), WithBalancer(client), WithPoolDetector(detector))
+
+		estimate, err := service.EstimateSwap(ctx, balWeth.Hex(), bal.Hex(), weth.Hex(), "1000000000000000000000", "")
+		require.NoError(t, err)
+
+		expected, err := client.pools[balWeth].CalcOutGivenIn(0, 1, mustBigInt("1000000000000000000000"))
+		require.NoError(t, err)
+		assert.Equal(t, expected, estimate.DstAmount)
+		assert.Equal(t, uint32(100), estimate.FeeBps)
+
+		// (1000 WETH / 0.2) / (4M BAL / 0.8)
+		spot, _ := estimate.SpotPrice.Float64()
+		assert.InEpsilon(t, 0.001, spot, 1e-12)
+		impact, _ := estimate.PriceImpactBps.Float64()
+		assert.Greater(t, impact, 100.0, "impact includes the 1% fee")
+	})
+
+	t.Run("explicit protocol", func(t *testing.T) {
+		client := newMockBalancer()
+		block := &uniswap_v2.BlockRef{Number: big.NewInt(18500000)}
+		service := NewUsecase(newMockUniswapV2(), WithBalancer(client), WithBlockResolver(&mockBlockResolver{block: block}))
+
+		estimate, err := service.EstimatePoolSwap(ctx, pooldetect.Balancer, balWeth.Hex(), weth.Hex(), bal.Hex(), "1000000000000000000", "18500000")
+		require.NoError(t, err)
+		assert.Equal(t, block, estimate.Block)
+		assert.Equal(t, []*big.Int{big.NewInt(18500000)}, client.blocks)
+	})
+
+	t.Run("reverse estimate", func(t *testing.T) {
+		client := newMockBalancer()
+		service := NewUsecase(newMockUniswapV2(), WithBalancer(client), WithPoolDetector(detector))
+
+		estimate, err := service.EstimateSwapIn(ctx, balWeth.Hex(), bal.Hex(), weth.Hex(), "1000000000000000000", "")
+		require.NoError(t, err)
+
+		expected, err := client.pools[balWeth].CalcInGivenOut(0, 1, mustBigInt("1000000000000000000"))
+		require.NoError(t, err)
+		assert.Equal(t, expected, estimate.SrcAmount)
+	})
+
+	t.Run("over the max out ratio", func(t *testing.T) {
+		service := NewUsecase(newMockUniswapV2(), WithBalancer(newMockBalancer()), WithPoolDetector(detector))
+
+		_, err := service.EstimateSwapIn(ctx, balWeth.Hex(), bal.Hex(), weth.Hex(), "500000000000000000000", "")
+		assert.ErrorIs(t, err, ErrInsufficientLiquidity)
+	})
+
+	t.Run("token not in the pool", func(t *testing.T) {
+		service := NewUsecase(newMockUniswapV2(), WithBalancer(newMockBalancer()), WithPoolDetector(detector))
+
+		_, err := service.EstimateSwap(ctx, balWeth.Hex(), dai.Hex(), weth.Hex(), "1000000000000000000", "")
+		assert.ErrorContains(t, err, "token pair mismatch")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		service := NewUsecase(newMockUniswapV2(), WithPoolDetector(detector))
+
+		_, err := service.EstimateSwap(ctx, balWeth.Hex(), bal.Hex(), weth.Hex(), "1000000000000000000", "")
+		assert.ErrorIs(t, err, ErrBalancerDisabled)
+	})
+}
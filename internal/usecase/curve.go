@@ -0,0 +1,47 @@
+package usecase
+
+import (
+	"1inch_testtask/internal/curve"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ErrCurveDisabled is returned when a Curve estimate is requested but no Curve client is configured
+var ErrCurveDisabled = errors.New("curve is not configured")
+
+// WithCurve enables estimates against Curve StableSwap pools
+func WithCurve(client curve.ICurve) Option {
+	return func(s *Usecase) {
+		s.curveClient = client
+	}
+}
+
+// loadCurvePool loads the state of a Curve StableSwap pool at the block pinned in ctx
+func (s *Usecase) loadCurvePool(ctx context.Context, poolAddress common.Address) (Pool, error) {
+	if s.curveClient == nil {
+		return nil, ErrCurveDisabled
+	}
+
+	state, err := s.curveClient.GetPoolState(ctx, poolAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool state: %w", err)
+	}
+
+	return newCurvePool(state), nil
+}
+
+// newCurvePool returns a Pool quoting a Curve StableSwap pool with the get_dy math of the pool contracts
+func newCurvePool(state *curve.PoolState) Pool {
+	return &indexedPool{
+		address:      state.Pool,
+		tokens:       state.Coins,
+		feeBps:       state.FeeBps(),
+		exactIn:      state.GetDy,
+		exactOut:     state.GetDx,
+		spotPrice:    state.SpotPrice,
+		errLiquidity: curve.ErrInsufficientLiquidity,
+	}
+}
@@ -0,0 +1,107 @@
+package usecase
+
+import (
+	"1inch_testtask/internal/curve"
+	"1inch_testtask/internal/pooldetect"
+	"1inch_testtask/internal/uniswap_v2"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockCurve is an in-memory curve.ICurve implementation
+type mockCurve = mockPoolClient[curve.PoolState]
+
+var (
+	usdc = common.HexToAddress("0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48")
+	// threePool is the Curve DAI/USDC/USDT pool
+	threePool = common.HexToAddress("0xbEbc44782C7dB0a1A60Cb6fe97d0b483032FF1C7")
+)
+
+// newMockCurve returns a mock with a balanced 3pool holding 100M of each coin
+func newMockCurve() *mockCurve {
+	return &mockCurve{pools: map[common.Address]*curve.PoolState{
+		threePool: {
+			Pool:       threePool,
+			Coins:      []common.Address{dai, usdc, usdt},
+			Balances:   []*big.Int{mustBigInt("100000000000000000000000000"), big.NewInt(100000000000000), big.NewInt(100000000000000)},
+			Rates:      []*big.Int{mustBigInt("1000000000000000000"), mustBigInt("1000000000000000000000000000000"), mustBigInt("1000000000000000000000000000000")},
+			A:          big.NewInt(2000),
+			APrecision: big.NewInt(1),
+			Fee:        big.NewInt(1000000),
+			Legacy:     true,
+		},
+	}}
+}
+
+func TestService_EstimateSwapCurve(t *testing.T) {
+	ctx := context.Background()
+	detector := &mockPoolDetector{types: map[common.Address]pooldetect.Type{threePool: pooldetect.Curve}}
+
+	t.Run("detected curve pool", func(t *testing.T) {
+		client := newMockCurve()
+		service := NewUsecase(newMockUniswapV2(), WithCurve(client), WithPoolDetector(detector))
+
+		estimate, err := service.EstimateSwap(ctx, threePool.Hex(), usdt.Hex(), dai.Hex(), "1000000000", "")
+		require.NoError(t, err)
+
+		expected, err := client.pools[threePool].GetDy(2, 0, big.NewInt(1000000000))
+		require.NoError(t, err)
+		assert.Equal(t, expected, estimate.DstAmount)
+		assert.Equal(t, uint32(1), estimate.FeeBps)
+
+		// A balanced pool trades 1:1 less the 0.01% fee
+		spot, _ := estimate.SpotPrice.Float64()
+		assert.InEpsilon(t, 1e12, spot, 1e-9)
+		impact, _ := estimate.PriceImpactBps.Float64()
+		assert.InDelta(t, 1, impact, 0.01)
+	})
+
+	t.Run("explicit protocol", func(t *testing.T) {
+		client := newMockCurve()
+		block := &uniswap_v2.BlockRef{Number: big.NewInt(18500000)}
+		service := NewUsecase(newMockUniswapV2(), WithCurve(client), WithBlockResolver(&mockBlockResolver{block: block}))
+
+		estimate, err := service.EstimatePoolSwap(ctx, pooldetect.Curve, threePool.Hex(), dai.Hex(), usdc.Hex(), "1000000000000000000000", "18500000")
+		require.NoError(t, err)
+		assert.Equal(t, block, estimate.Block)
+		assert.Equal(t, []*big.Int{big.NewInt(18500000)}, client.blocks)
+	})
+
+	t.Run("reverse estimate", func(t *testing.T) {
+		client := newMockCurve()
+		service := NewUsecase(newMockUniswapV2(), WithCurve(client), WithPoolDetector(detector))
+
+		estimate, err := service.EstimateSwapIn(ctx, threePool.Hex(), usdc.Hex(), usdt.Hex(), "1000000000", "")
+		require.NoError(t, err)
+
+		out, err := client.pools[threePool].GetDy(1, 2, estimate.SrcAmount)
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, out.Cmp(big.NewInt(1000000000)), 0)
+	})
+
+	t.Run("more than the balance", func(t *testing.T) {
+		service := NewUsecase(newMockUniswapV2(), WithCurve(newMockCurve()), WithPoolDetector(detector))
+
+		_, err := service.EstimateSwapIn(ctx, threePool.Hex(), usdc.Hex(), usdt.Hex(), "100000000000000", "")
+		assert.ErrorIs(t, err, ErrInsufficientLiquidity)
+	})
+
+	t.Run("coin not in the pool", func(t *testing.T) {
+		service := NewUsecase(newMockUniswapV2(), WithCurve(newMockCurve()), WithPoolDetector(detector))
+
+		_, err := service.EstimateSwap(ctx, threePool.Hex(), weth.Hex(), dai.Hex(), "1000000000", "")
+		assert.ErrorContains(t, err, "token pair mismatch")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		service := NewUsecase(newMockUniswapV2(), WithPoolDetector(detector))
+
+		_, err := service.EstimateSwap(ctx, threePool.Hex(), usdt.Hex(), dai.Hex(), "1000000000", "")
+		assert.ErrorIs(t, err, ErrCurveDisabled)
+	})
+}
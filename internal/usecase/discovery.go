@@ -0,0 +1,46 @@
+package usecase
+
+import (
+	"1inch_testtask/internal/routing"
+	"1inch_testtask/internal/uniswap_v2"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// WithPairFinders makes best-route searches also consider the pairs of src and dst deployed by the factories
+// of the finders, so estimates without a pool work for pairs missing from the routing graph or without one
+func WithPairFinders(finders []uniswap_v2.IPairFinder) Option {
+	return func(s *Usecase) {
+		s.pairFinders = finders
+	}
+}
+
+// findPairRoutes returns a single hop route through every deployed pair of src and dst
+func (s *Usecase) findPairRoutes(ctx context.Context, src, dst common.Address) ([]routing.Route, error) {
+	if src == dst {
+		return nil, nil
+	}
+
+	var routes []routing.Route
+	seen := make(map[common.Address]bool)
+	for _, finder := range s.pairFinders {
+		pair, err := finder.GetPair(ctx, src, dst)
+		if errors.Is(err, uniswap_v2.ErrPairNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to find pair: %w", err)
+		}
+		if seen[pair] {
+			continue
+		}
+		seen[pair] = true
+
+		token0, _ := uniswap_v2.SortTokens(src, dst)
+		routes = append(routes, routing.Route{{Pool: pair, TokenIn: src, TokenOut: dst, ZeroForOne: src == token0}})
+	}
+	return routes, nil
+}
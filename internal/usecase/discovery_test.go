@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"1inch_testtask/internal/routing"
+	"1inch_testtask/internal/uniswap_v2"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockPairFinder is an in-memory uniswap_v2.IPairFinder of a single factory
+type mockPairFinder struct {
+	pairs map[[2]common.Address]common.Address
+	err   error
+	// blocks records the block each lookup was pinned to
+	blocks []*big.Int
+}
+
+func newMockPairFinder(pools ...common.Address) *mockPairFinder {
+	client := newMockUniswapV2()
+	finder := &mockPairFinder{pairs: make(map[[2]common.Address]common.Address)}
+	for _, pool := range pools {
+		finder.pairs[[2]common.Address{client.pools[pool].token0, client.pools[pool].token1}] = pool
+	}
+	return finder
+}
+
+func (m *mockPairFinder) GetPair(ctx context.Context, tokenA, tokenB common.Address) (common.Address, error) {
+	m.blocks = append(m.blocks, uniswap_v2.BlockNumberFromContext(ctx))
+	if m.err != nil {
+		return common.Address{}, m.err
+	}
+	token0, token1 := uniswap_v2.SortTokens(tokenA, tokenB)
+	pair, ok := m.pairs[[2]common.Address{token0, token1}]
+	if !ok {
+		return common.Address{}, uniswap_v2.ErrPairNotFound
+	}
+	return pair, nil
+}
+
+func TestService_FindBestRoutePairDiscovery(t *testing.T) {
+	ctx := context.Background()
+	finders := []uniswap_v2.IPairFinder{newMockPairFinder(usdtWeth, daiWeth, usdtDai), newMockPairFinder(sushiUsdtWeth)}
+
+	t.Run("pairs of every factory are compared", func(t *testing.T) {
+		service := NewUsecase(newMockUniswapV2(), WithPairFinders(finders))
+
+		route, err := service.FindBestRoute(ctx, weth.Hex(), usdt.Hex(), "1000000000000000000", "")
+		require.NoError(t, err)
+		assert.Equal(t, []common.Address{usdtWeth}, route.Pools, "the deeper Uniswap pair gives more")
+
+		expected, err := service.EstimateSwap(ctx, usdtWeth.Hex(), weth.Hex(), usdt.Hex(), "1000000000000000000", "")
+		require.NoError(t, err)
+		assert.Equal(t, expected.DstAmount, route.DstAmount())
+		assert.Equal(t, expected.PriceInfo, route.PriceInfo)
+	})
+
+	t.Run("pair fee follows its factory", func(t *testing.T) {
+		fees := FeeConfig{DefaultBps: DefaultFeeBps, Factories: map[common.Address]uint32{sushiFactory: 0}}
+		service := NewUsecase(newMockUniswapV2(), WithPairFinders(finders), WithFees(fees))
+
+		// Without a fee the smaller SushiSwap pair wins small swaps
+		route, err := service.FindBestRoute(ctx, usdt.Hex(), weth.Hex(), "1000000", "")
+		require.NoError(t, err)
+		assert.Equal(t, []common.Address{sushiUsdtWeth}, route.Pools)
+		assert.Equal(t, []uint32{0}, route.FeeBps)
+	})
+
+	t.Run("pairs are discovered at the pinned block", func(t *testing.T) {
+		block := &uniswap_v2.BlockRef{Number: big.NewInt(18500000)}
+		finder := newMockPairFinder(usdtWeth)
+		service := NewUsecase(newMockUniswapV2(), WithPairFinders([]uniswap_v2.IPairFinder{finder}), WithBlockResolver(&mockBlockResolver{block: block}))
+
+		route, err := service.FindBestRoute(ctx, usdt.Hex(), weth.Hex(), "1000000", "finalized")
+		require.NoError(t, err)
+		assert.Equal(t, block, route.Block)
+		require.NotEmpty(t, finder.blocks)
+		for _, number := range finder.blocks {
+			assert.Equal(t, block.Number, number)
+		}
+	})
+
+	t.Run("pairs missing from the routing graph", func(t *testing.T) {
+		client := newMockUniswapV2()
+		graph := routing.NewGraph([]routing.Pair{{Pool: usdtDai, Token0: dai, Token1: usdt}})
+		service := NewUsecase(client, WithRouter(graph, RoutingConfig{MaxHops: 3, MaxCandidates: 10}), WithPairFinders(finders))
+
+		route, err := service.FindBestRoute(ctx, dai.Hex(), weth.Hex(), "1000000000000000000", "")
+		require.NoError(t, err)
+		assert.Equal(t, []common.Address{daiWeth}, route.Pools)
+		assert.Equal(t, []common.Address{dai, weth}, route.Path)
+	})
+
+	t.Run("no pair", func(t *testing.T) {
+		service := NewUsecase(newMockUniswapV2(), WithPairFinders(finders))
+
+		_, err := service.FindBestRoute(ctx, usdt.Hex(), common.HexToAddress("0x01").Hex(), "1000000", "")
+		assert.ErrorIs(t, err, ErrNoRoute)
+	})
+
+	t.Run("factory error", func(t *testing.T) {
+		service := NewUsecase(newMockUniswapV2(), WithPairFinders([]uniswap_v2.IPairFinder{&mockPairFinder{err: errors.New("429 Too Many Requests")}}))
+
+		_, err := service.FindBestRoute(ctx, usdt.Hex(), weth.Hex(), "1000000", "")
+		assert.ErrorContains(t, err, "429 Too Many Requests")
+		assert.NotErrorIs(t, err, ErrNoRoute)
+	})
+}
@@ -0,0 +1,57 @@
+package usecase
+
+import (
+	"1inch_testtask/internal/uniswap_v2"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	// FeeDenominator is the denominator of swap fees, fees are expressed in basis points
+	FeeDenominator = 10000
+	// DefaultFeeBps is the 0.3% swap fee of Uniswap V2
+	DefaultFeeBps = 30
+)
+
+// FeeConfig resolves the swap fee of a pool. A pool override wins over its factory's fee,
+// which wins over the default, so forks such as PancakeSwap (25 bps) can be quoted alongside Uniswap V2.
+type FeeConfig struct {
+	DefaultBps uint32
+	// Pools overrides the fee of individual pools
+	Pools map[common.Address]uint32
+	// Factories sets the fee of every pair deployed by a factory
+	Factories map[common.Address]uint32
+}
+
+// FeeBps returns the swap fee of the pool in basis points
+func (f FeeConfig) FeeBps(state uniswap_v2.PoolState) uint32 {
+	if bps, ok := f.Pools[state.Pool]; ok {
+		return bps
+	}
+	if bps, ok := f.Factories[state.Factory]; ok {
+		return bps
+	}
+	return f.DefaultBps
+}
+
+// ParseFees parses fee entries of the form address:bps
+func ParseFees(entries []string) (map[common.Address]uint32, error) {
+	fees := make(map[common.Address]uint32, len(entries))
+	for _, entry := range entries {
+		address, value, found := strings.Cut(entry, ":")
+		if !found || !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("invalid fee entry %q, expected address:bps", entry)
+		}
+
+		bps, err := strconv.ParseUint(value, 10, 32)
+		if err != nil || bps >= FeeDenominator {
+			return nil, fmt.Errorf("invalid fee entry %q: fee must be between 0 and %d bps", entry, FeeDenominator-1)
+		}
+
+		fees[common.HexToAddress(address)] = uint32(bps)
+	}
+	return fees, nil
+}
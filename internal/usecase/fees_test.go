@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"1inch_testtask/internal/uniswap_v2"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFees(t *testing.T) {
+	tests := []struct {
+		name    string
+		entries []string
+		want    map[common.Address]uint32
+		wantErr bool
+	}{
+		{
+			name:    "empty",
+			entries: nil,
+			want:    map[common.Address]uint32{},
+		},
+		{
+			name:    "factory and pool",
+			entries: []string{sushiFactory.Hex() + ":25", usdtWeth.Hex() + ":0"},
+			want:    map[common.Address]uint32{sushiFactory: 25, usdtWeth: 0},
+		},
+		{
+			name:    "missing fee",
+			entries: []string{sushiFactory.Hex()},
+			wantErr: true,
+		},
+		{
+			name:    "invalid address",
+			entries: []string{"0x1234:25"},
+			wantErr: true,
+		},
+		{
+			name:    "fee of 100%",
+			entries: []string{sushiFactory.Hex() + ":10000"},
+			wantErr: true,
+		},
+		{
+			name:    "negative fee",
+			entries: []string{sushiFactory.Hex() + ":-1"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFees(tt.entries)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFeeConfig_FeeBps(t *testing.T) {
+	fees := FeeConfig{
+		DefaultBps: DefaultFeeBps,
+		Pools:      map[common.Address]uint32{sushiUsdtWeth: 20},
+		Factories:  map[common.Address]uint32{sushiFactory: 25},
+	}
+
+	tests := []struct {
+		name  string
+		state uniswap_v2.PoolState
+		want  uint32
+	}{
+		{"default", uniswap_v2.PoolState{Pool: usdtWeth, Factory: uniswapFactory}, 30},
+		{"factory", uniswap_v2.PoolState{Pool: daiWeth, Factory: sushiFactory}, 25},
+		{"pool overrides factory", uniswap_v2.PoolState{Pool: sushiUsdtWeth, Factory: sushiFactory}, 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, fees.FeeBps(tt.state))
+		})
+	}
+}
+
+func TestService_Fees(t *testing.T) {
+	ctx := context.Background()
+	service := NewUsecase(newMockUniswapV2(), WithFees(FeeConfig{
+		DefaultBps: DefaultFeeBps,
+		Factories:  map[common.Address]uint32{sushiFactory: 25},
+	}))
+
+	t.Run("default fee", func(t *testing.T) {
+		estimate, err := service.EstimateSwap(ctx, usdtWeth.Hex(), usdt.Hex(), weth.Hex(), "1000000000", "")
+		require.NoError(t, err)
+		assert.Equal(t, uint32(30), estimate.FeeBps)
+
+		defaults, err := NewUsecase(newMockUniswapV2()).EstimateSwap(ctx, usdtWeth.Hex(), usdt.Hex(), weth.Hex(), "1000000000", "")
+		require.NoError(t, err)
+		assert.Equal(t, defaults.DstAmount, estimate.DstAmount)
+	})
+
+	t.Run("factory fee", func(t *testing.T) {
+		estimate, err := service.EstimateSwap(ctx, sushiUsdtWeth.Hex(), usdt.Hex(), weth.Hex(), "1000000000", "")
+		require.NoError(t, err)
+		assert.Equal(t, uint32(25), estimate.FeeBps)
+
+		// 1000 USDT into 500k USDT / 250 ETH at 0.25%: 1000e6 * 9975 * 250e18 / (500000e6 * 10000 + 1000e6 * 9975)
+		assert.Equal(t, mustBigInt("497756974835203768"), estimate.DstAmount)
+	})
+
+	t.Run("reverse estimate uses the same fee", func(t *testing.T) {
+		estimate, err := service.EstimateSwapIn(ctx, sushiUsdtWeth.Hex(), usdt.Hex(), weth.Hex(), "497756974835203768", "")
+		require.NoError(t, err)
+		assert.Equal(t, uint32(25), estimate.FeeBps)
+
+		out := calculateOutputAmount(estimate.SrcAmount, big.NewInt(500000000000), mustBigInt("250000000000000000000"), 25)
+		assert.GreaterOrEqual(t, out.Cmp(mustBigInt("497756974835203768")), 0)
+		assert.LessOrEqual(t, estimate.SrcAmount.Cmp(big.NewInt(1000000000)), 0)
+	})
+
+	t.Run("split reports fee per pool", func(t *testing.T) {
+		split, err := service.EstimateSplit(ctx, []string{usdtWeth.Hex(), sushiUsdtWeth.Hex()}, usdt.Hex(), weth.Hex(), "1000000000", 10, "")
+		require.NoError(t, err)
+		assert.Equal(t, uint32(30), split.Allocations[0].FeeBps)
+		assert.Equal(t, uint32(25), split.Allocations[1].FeeBps)
+	})
+}
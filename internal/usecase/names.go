@@ -0,0 +1,84 @@
+package usecase
+
+import (
+	"1inch_testtask/internal/ens"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// ErrNameResolutionDisabled is returned when an ENS name is given but no ENS resolver is configured
+	ErrNameResolutionDisabled = errors.New("ENS name resolution is not configured")
+	// ErrUnknownAlias is returned when a token is given by a symbol that is not a configured alias
+	ErrUnknownAlias = errors.New("unknown token alias")
+)
+
+// WithNameResolver lets pool and token addresses be given as ENS names
+func WithNameResolver(resolver ens.IResolver) Option {
+	return func(s *Usecase) {
+		s.nameResolver = resolver
+	}
+}
+
+// WithTokenAliases lets tokens be given by the symbols in aliases, which are keyed by uppercase symbol
+func WithTokenAliases(aliases map[string]common.Address) Option {
+	return func(s *Usecase) {
+		s.tokenAliases = aliases
+	}
+}
+
+// ParseTokenAliases parses token alias entries of the form symbol:address, symbols are case-insensitive
+func ParseTokenAliases(entries []string) (map[string]common.Address, error) {
+	aliases := make(map[string]common.Address, len(entries))
+	for _, entry := range entries {
+		symbol, address, found := strings.Cut(entry, ":")
+		if !found || symbol == "" || !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("invalid token alias entry %q, expected symbol:address", entry)
+		}
+		if strings.Contains(symbol, ".") || strings.HasPrefix(symbol, "0x") {
+			return nil, fmt.Errorf("invalid token alias entry %q: a symbol cannot look like an ENS name or an address", entry)
+		}
+
+		aliases[strings.ToUpper(symbol)] = common.HexToAddress(address)
+	}
+	return aliases, nil
+}
+
+// ResolveAddress resolves a pool or token given by name. Names containing a dot are ENS names, resolved at block when
+// it is given, and, when token is set, other values are looked up in the token aliases. ok is false when value is not
+// a name and should be validated as an address.
+func (s *Usecase) ResolveAddress(ctx context.Context, value string, token bool, block string) (address common.Address, ok bool, err error) {
+	if token {
+		if address, found := s.tokenAliases[strings.ToUpper(value)]; found {
+			return address, true, nil
+		}
+	}
+
+	if strings.Contains(value, ".") {
+		if s.nameResolver == nil {
+			return common.Address{}, false, fmt.Errorf("%w: %s", ErrNameResolutionDisabled, value)
+		}
+		// Without a requested block the name is resolved at the latest block, even in snapshot mode
+		if block != "" {
+			if ctx, _, err = s.pinBlock(ctx, block); err != nil {
+				return common.Address{}, false, err
+			}
+		}
+		address, err := s.nameResolver.Resolve(ctx, value)
+		if err != nil {
+			return common.Address{}, false, err
+		}
+		return address, true, nil
+	}
+
+	// Anything that cannot be a hex address is reported as a symbol once aliases are configured
+	if token && len(s.tokenAliases) > 0 && !strings.HasPrefix(value, "0x") && strings.Trim(value, "0123456789abcdefABCDEF") != "" {
+		return common.Address{}, false, fmt.Errorf("%w: %s", ErrUnknownAlias, value)
+	}
+
+	return common.Address{}, false, nil
+}
@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"1inch_testtask/internal/ens"
+	"1inch_testtask/internal/uniswap_v2"
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pinnedResolver resolves every name to the block it is resolved at, zero for the latest
+type pinnedResolver struct{}
+
+func (pinnedResolver) Resolve(ctx context.Context, _ string) (common.Address, error) {
+	if block := uniswap_v2.BlockNumberFromContext(ctx); block != nil {
+		return common.BigToAddress(block), nil
+	}
+	return common.Address{}, nil
+}
+
+// mockResolver is an in-memory ens.IResolver implementation
+type mockResolver map[string]common.Address
+
+func (m mockResolver) Resolve(_ context.Context, name string) (common.Address, error) {
+	if address, ok := m[name]; ok {
+		return address, nil
+	}
+	return common.Address{}, fmt.Errorf("%w: %s", ens.ErrNotFound, name)
+}
+
+func TestParseTokenAliases(t *testing.T) {
+	aliases, err := ParseTokenAliases([]string{"usdt:" + usdt.Hex(), "WETH:" + weth.Hex()})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]common.Address{"USDT": usdt, "WETH": weth}, aliases)
+
+	for _, entries := range [][]string{{"USDT"}, {":" + usdt.Hex()}, {"USDT:0x1234"}, {"usdt.eth:" + usdt.Hex()}, {"0xdead:" + usdt.Hex()}} {
+		_, err := ParseTokenAliases(entries)
+		assert.Error(t, err, entries)
+	}
+}
+
+func TestService_ResolveAddress(t *testing.T) {
+	ctx := context.Background()
+	aliases := map[string]common.Address{"USDT": usdt, "WETH": weth}
+	service := NewUsecase(newMockUniswapV2(),
+		WithTokenAliases(aliases),
+		WithNameResolver(mockResolver{"usdt-weth.eth": usdtWeth}),
+	)
+
+	tests := []struct {
+		name    string
+		value   string
+		token   bool
+		want    common.Address
+		wantOk  bool
+		wantErr error
+	}{
+		{name: "token alias", value: "USDT", token: true, want: usdt, wantOk: true},
+		{name: "lowercase token alias", value: "weth", token: true, want: weth, wantOk: true},
+		{name: "ENS name", value: "usdt-weth.eth", want: usdtWeth, wantOk: true},
+		{name: "token address", value: usdt.Hex(), token: true},
+		{name: "malformed address is left to validation", value: "0x1234", token: true},
+		{name: "aliases are not pools", value: "USDT"},
+		{name: "unknown alias", value: "USDTT", token: true, wantErr: ErrUnknownAlias},
+		{name: "unknown ENS name", value: "nobody.eth", token: true, wantErr: ens.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			address, ok, err := service.ResolveAddress(ctx, tt.value, tt.token, "")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.want, address)
+		})
+	}
+
+	t.Run("ENS disabled", func(t *testing.T) {
+		service := NewUsecase(newMockUniswapV2())
+		_, _, err := service.ResolveAddress(ctx, "usdt-weth.eth", false, "")
+		assert.ErrorIs(t, err, ErrNameResolutionDisabled)
+
+		// Without aliases symbols are left to address validation
+		_, ok, err := service.ResolveAddress(ctx, "USDT", true, "")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+	t.Run("ENS names are resolved at the requested block", func(t *testing.T) {
+		resolver := &mockBlockResolver{block: &uniswap_v2.BlockRef{Number: big.NewInt(18500000)}}
+		service := NewUsecase(newMockUniswapV2(), WithNameResolver(pinnedResolver{}), WithBlockResolver(resolver), WithSnapshots())
+
+		address, _, err := service.ResolveAddress(ctx, "usdt-weth.eth", false, "finalized")
+		require.NoError(t, err)
+		assert.Equal(t, common.BigToAddress(big.NewInt(18500000)), address)
+
+		// The latest block is left unresolved, even in snapshot mode
+		address, _, err = service.ResolveAddress(ctx, "usdt-weth.eth", false, "")
+		require.NoError(t, err)
+		assert.Equal(t, common.Address{}, address)
+		assert.Equal(t, 1, resolver.calls)
+
+		_, _, err = service.ResolveAddress(ctx, "usdt-weth.eth", false, "0x404")
+		assert.ErrorIs(t, err, uniswap_v2.ErrBlockNotFound)
+	})
+}
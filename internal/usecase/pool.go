@@ -0,0 +1,220 @@
+package usecase
+
+import (
+	"1inch_testtask/internal/pooldetect"
+	"1inch_testtask/internal/uniswap_v2"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Pool is a liquidity pool of any protocol, quoting swaps between its tokens
+type Pool interface {
+	Address() common.Address
+	Tokens() []common.Address
+	// FeeBps returns the swap fee of the pool in basis points
+	FeeBps() uint32
+	// QuoteExactIn returns the amount of tokenOut received for amountIn of tokenIn
+	QuoteExactIn(tokenIn, tokenOut common.Address, amountIn *big.Int) (*big.Int, error)
+	// QuoteExactOut returns the amount of tokenIn needed to receive amountOut of tokenOut
+	QuoteExactOut(tokenIn, tokenOut common.Address, amountOut *big.Int) (*big.Int, error)
+	// SpotPrice returns the mid price in tokenOut per tokenIn, nil when the pool has no liquidity
+	SpotPrice(tokenIn, tokenOut common.Address) *big.Rat
+}
+
+// PoolDetector detects the protocol of a pool
+type PoolDetector interface {
+	Detect(ctx context.Context, poolAddress common.Address) (pooldetect.Type, error)
+}
+
+// WithPoolDetector detects the type of pools passed to EstimateSwap and EstimateSwapIn instead of assuming Uniswap V2
+func WithPoolDetector(detector PoolDetector) Option {
+	return func(s *Usecase) {
+		s.poolDetector = detector
+	}
+}
+
+// loadPool loads the state of a pool at block. An empty poolType is detected, or Uniswap V2 without a pool detector.
+// Pairs in the live pool state are served from it without any RPC call. Otherwise the block is pinned before detection
+// so that the pool is probed and read at the same block.
+func (s *Usecase) loadPool(ctx context.Context, poolType pooldetect.Type, poolAddr, block string) (Pool, *uniswap_v2.BlockRef, error) {
+	poolAddress := common.HexToAddress(poolAddr)
+
+	// Only Uniswap V2 pairs are tracked
+	if poolType == "" || poolType == pooldetect.UniswapV2 {
+		if states, blockRef, ok := s.trackedPoolStates([]common.Address{poolAddress}, block); ok {
+			return &uniswapV2Pool{state: states[0], feeBps: s.fees.FeeBps(states[0])}, blockRef, nil
+		}
+	}
+
+	ctx, blockRef, err := s.pinBlock(ctx, block)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if poolType == "" {
+		poolType = pooldetect.UniswapV2
+		if s.poolDetector != nil {
+			detected, err := s.poolDetector.Detect(ctx, poolAddress)
+			if err != nil {
+				return nil, nil, fmt.Errorf("failed to detect pool type: %w", err)
+			}
+			poolType = detected
+		}
+	}
+
+	var pool Pool
+	switch poolType {
+	case pooldetect.UniswapV2:
+		states, err := s.readPoolStates(ctx, []common.Address{poolAddress})
+		if err != nil {
+			return nil, nil, err
+		}
+		pool = &uniswapV2Pool{state: states[0], feeBps: s.fees.FeeBps(states[0])}
+	case pooldetect.UniswapV3:
+		pool, err = s.loadUniswapV3Pool(ctx, poolAddress)
+	case pooldetect.Curve:
+		pool, err = s.loadCurvePool(ctx, poolAddress)
+	case pooldetect.Balancer:
+		pool, err = s.loadBalancerPool(ctx, poolAddress)
+	default:
+		err = fmt.Errorf("%w: %s", pooldetect.ErrUnknownPool, poolType)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pool, blockRef, nil
+}
+
+// checkPair returns an error unless the pool trades src for dst
+func checkPair(pool Pool, src, dst common.Address) error {
+	tokens := pool.Tokens()
+	if src != dst && slices.Contains(tokens, src) && slices.Contains(tokens, dst) {
+		return nil
+	}
+	return fmt.Errorf("token pair mismatch: src=%s, dst=%s, pool tokens=%v", src.Hex(), dst.Hex(), tokens)
+}
+
+// indexedPool adapts the math of a pool addressing its tokens by index to Pool, the math is the only part that
+// differs between protocols
+type indexedPool struct {
+	address common.Address
+	tokens  []common.Address
+	feeBps  uint32
+	// exactIn and exactOut quote a swap of token i for token j
+	exactIn  func(i, j int, amount *big.Int) (*big.Int, error)
+	exactOut func(i, j int, amount *big.Int) (*big.Int, error)
+	// spotPrice returns the mid price of token j per token i
+	spotPrice func(i, j int) *big.Rat
+	// errLiquidity is the error the math returns when the pool runs out of liquidity
+	errLiquidity error
+}
+
+func (p *indexedPool) Address() common.Address {
+	return p.address
+}
+
+func (p *indexedPool) Tokens() []common.Address {
+	return p.tokens
+}
+
+func (p *indexedPool) FeeBps() uint32 {
+	return p.feeBps
+}
+
+func (p *indexedPool) QuoteExactIn(tokenIn, tokenOut common.Address, amountIn *big.Int) (*big.Int, error) {
+	i, j, err := p.indexes(tokenIn, tokenOut)
+	if err != nil {
+		return nil, err
+	}
+	return p.liquidityError(p.exactIn(i, j, amountIn))
+}
+
+func (p *indexedPool) QuoteExactOut(tokenIn, tokenOut common.Address, amountOut *big.Int) (*big.Int, error) {
+	i, j, err := p.indexes(tokenIn, tokenOut)
+	if err != nil {
+		return nil, err
+	}
+	return p.liquidityError(p.exactOut(i, j, amountOut))
+}
+
+func (p *indexedPool) SpotPrice(tokenIn, tokenOut common.Address) *big.Rat {
+	i, j, err := p.indexes(tokenIn, tokenOut)
+	if err != nil {
+		return nil
+	}
+	return p.spotPrice(i, j)
+}
+
+// indexes returns the token indexes of tokenIn and tokenOut
+func (p *indexedPool) indexes(tokenIn, tokenOut common.Address) (int, int, error) {
+	if err := checkPair(p, tokenIn, tokenOut); err != nil {
+		return 0, 0, err
+	}
+	return slices.Index(p.tokens, tokenIn), slices.Index(p.tokens, tokenOut), nil
+}
+
+// liquidityError maps running out of liquidity to ErrInsufficientLiquidity
+func (p *indexedPool) liquidityError(amount *big.Int, err error) (*big.Int, error) {
+	if errors.Is(err, p.errLiquidity) {
+		return nil, ErrInsufficientLiquidity
+	}
+	return amount, err
+}
+
+// uniswapV2Pool quotes a Uniswap V2 pair with the constant product formula
+type uniswapV2Pool struct {
+	state  uniswap_v2.PoolState
+	feeBps uint32
+}
+
+func (p *uniswapV2Pool) Address() common.Address {
+	return p.state.Pool
+}
+
+func (p *uniswapV2Pool) Tokens() []common.Address {
+	return []common.Address{p.state.Token0, p.state.Token1}
+}
+
+func (p *uniswapV2Pool) FeeBps() uint32 {
+	return p.feeBps
+}
+
+func (p *uniswapV2Pool) QuoteExactIn(tokenIn, tokenOut common.Address, amountIn *big.Int) (*big.Int, error) {
+	reserveIn, reserveOut, err := orientReserves(p.state, tokenIn.Hex(), tokenOut.Hex())
+	if err != nil {
+		return nil, err
+	}
+	if err := checkReserveBounds(amountIn, reserveIn); err != nil {
+		return nil, err
+	}
+	return calculateOutputAmount(amountIn, reserveIn, reserveOut, p.feeBps), nil
+}
+
+func (p *uniswapV2Pool) QuoteExactOut(tokenIn, tokenOut common.Address, amountOut *big.Int) (*big.Int, error) {
+	reserveIn, reserveOut, err := orientReserves(p.state, tokenIn.Hex(), tokenOut.Hex())
+	if err != nil {
+		return nil, err
+	}
+	amountIn, err := calculateInputAmount(amountOut, reserveIn, reserveOut, p.feeBps)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkReserveBounds(amountIn, reserveIn); err != nil {
+		return nil, err
+	}
+	return amountIn, nil
+}
+
+func (p *uniswapV2Pool) SpotPrice(tokenIn, tokenOut common.Address) *big.Rat {
+	reserveIn, reserveOut, err := orientReserves(p.state, tokenIn.Hex(), tokenOut.Hex())
+	if err != nil {
+		return nil
+	}
+	return spotPrice(reserveIn, reserveOut)
+}
@@ -0,0 +1,155 @@
+package usecase
+
+import (
+	"1inch_testtask/internal/pooldetect"
+	"1inch_testtask/internal/uniswap_v2"
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockPoolClient is an in-memory pool state client of any protocol, such as uniswap_v3.IUniswapV3 or curve.ICurve
+type mockPoolClient[S any] struct {
+	pools map[common.Address]*S
+	// blocks records the block number each GetPoolState call was pinned to
+	blocks []*big.Int
+}
+
+func (m *mockPoolClient[S]) GetPoolState(ctx context.Context, poolAddress common.Address) (*S, error) {
+	m.blocks = append(m.blocks, uniswap_v2.BlockNumberFromContext(ctx))
+	pool, ok := m.pools[poolAddress]
+	if !ok {
+		return nil, fmt.Errorf("no contract code at %s", poolAddress.Hex())
+	}
+	return pool, nil
+}
+
+// mockPoolDetector is a PoolDetector with fixed pool types
+type mockPoolDetector struct {
+	types map[common.Address]pooldetect.Type
+	// detected records every detected pool and blocks the block each detection was pinned to
+	detected []common.Address
+	blocks   []*big.Int
+}
+
+func (m *mockPoolDetector) Detect(ctx context.Context, poolAddress common.Address) (pooldetect.Type, error) {
+	m.detected = append(m.detected, poolAddress)
+	m.blocks = append(m.blocks, uniswap_v2.BlockNumberFromContext(ctx))
+	poolType, ok := m.types[poolAddress]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", pooldetect.ErrUnknownPool, poolAddress.Hex())
+	}
+	return poolType, nil
+}
+
+func newMockPoolDetector() *mockPoolDetector {
+	return &mockPoolDetector{types: map[common.Address]pooldetect.Type{
+		usdtWeth:   pooldetect.UniswapV2,
+		usdtWethV3: pooldetect.UniswapV3,
+	}}
+}
+
+func TestService_PoolDetection(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("uniswap v2 pair", func(t *testing.T) {
+		detector := newMockPoolDetector()
+		service := NewUsecase(newMockUniswapV2(), WithUniswapV3(newMockUniswapV3(t)), WithPoolDetector(detector))
+
+		estimate, err := service.EstimateSwap(ctx, usdtWeth.Hex(), usdt.Hex(), weth.Hex(), "1000000", "")
+		require.NoError(t, err)
+		assert.Equal(t, mustBigInt("498499502995995"), estimate.DstAmount)
+		assert.Equal(t, []common.Address{usdtWeth}, detector.detected)
+	})
+
+	t.Run("uniswap v3 pool", func(t *testing.T) {
+		client := newMockUniswapV3(t)
+		service := NewUsecase(newMockUniswapV2(), WithUniswapV3(client), WithPoolDetector(newMockPoolDetector()))
+
+		estimate, err := service.EstimateSwap(ctx, usdtWethV3.Hex(), usdt.Hex(), weth.Hex(), "2000000000", "")
+		require.NoError(t, err)
+
+		expected, err := client.pools[usdtWethV3].QuoteExactIn(big.NewInt(2000000000), false)
+		require.NoError(t, err)
+		assert.Equal(t, expected, estimate.DstAmount)
+		assert.Equal(t, uint32(30), estimate.FeeBps)
+	})
+
+	t.Run("without a detector pools are uniswap v2 pairs", func(t *testing.T) {
+		service := NewUsecase(newMockUniswapV2(), WithUniswapV3(newMockUniswapV3(t)))
+
+		_, err := service.EstimateSwap(ctx, usdtWethV3.Hex(), usdt.Hex(), weth.Hex(), "2000000000", "")
+		assert.ErrorContains(t, err, "failed to get pool state")
+	})
+
+	t.Run("explicit type skips detection", func(t *testing.T) {
+		detector := newMockPoolDetector()
+		service := NewUsecase(newMockUniswapV2(), WithUniswapV3(newMockUniswapV3(t)), WithPoolDetector(detector))
+
+		_, err := service.EstimatePoolSwap(ctx, pooldetect.UniswapV3, usdtWethV3.Hex(), usdt.Hex(), weth.Hex(), "2000000000", "")
+		require.NoError(t, err)
+		assert.Empty(t, detector.detected)
+	})
+
+	t.Run("unknown pool", func(t *testing.T) {
+		service := NewUsecase(newMockUniswapV2(), WithPoolDetector(newMockPoolDetector()))
+
+		_, err := service.EstimateSwap(ctx, usdt.Hex(), usdt.Hex(), weth.Hex(), "1000000", "")
+		assert.ErrorIs(t, err, pooldetect.ErrUnknownPool)
+	})
+
+	t.Run("detection is pinned to the quoted block", func(t *testing.T) {
+		block := &uniswap_v2.BlockRef{Number: big.NewInt(18500000)}
+		detector := newMockPoolDetector()
+		client := newMockUniswapV2()
+		service := NewUsecase(client, WithPoolDetector(detector), WithBlockResolver(&mockBlockResolver{block: block}))
+
+		estimate, err := service.EstimateSwap(ctx, usdtWeth.Hex(), usdt.Hex(), weth.Hex(), "1000000", "finalized")
+		require.NoError(t, err)
+		assert.Equal(t, block, estimate.Block)
+		assert.Equal(t, []*big.Int{block.Number}, detector.blocks)
+		assert.Equal(t, []*big.Int{block.Number}, client.blocks)
+	})
+
+	t.Run("detected v3 pool without a v3 client", func(t *testing.T) {
+		service := NewUsecase(newMockUniswapV2(), WithPoolDetector(newMockPoolDetector()))
+
+		_, err := service.EstimateSwap(ctx, usdtWethV3.Hex(), usdt.Hex(), weth.Hex(), "2000000000", "")
+		assert.ErrorIs(t, err, ErrUniswapV3Disabled)
+	})
+}
+
+func TestService_EstimateSwapInV3(t *testing.T) {
+	ctx := context.Background()
+	client := newMockUniswapV3(t)
+	service := NewUsecase(newMockUniswapV2(), WithUniswapV3(client), WithPoolDetector(newMockPoolDetector()))
+
+	dstAmount := mustBigInt("500000000000000000")
+	estimate, err := service.EstimateSwapIn(ctx, usdtWethV3.Hex(), usdt.Hex(), weth.Hex(), dstAmount.String(), "")
+	require.NoError(t, err)
+	assert.Equal(t, uint32(30), estimate.FeeBps)
+
+	// Swapping the estimated input yields at least the requested output
+	out, err := client.pools[usdtWethV3].QuoteExactIn(estimate.SrcAmount, false)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, out.Cmp(dstAmount), 0)
+
+	// 0.5 ETH costs a bit more than 1000 USDT after the 0.3% fee and price impact
+	assert.Equal(t, 1, estimate.SrcAmount.Cmp(big.NewInt(1003000000)))
+	assert.Equal(t, -1, estimate.SrcAmount.Cmp(big.NewInt(1010000000)))
+}
+
+func TestCheckPair(t *testing.T) {
+	pool := &uniswapV2Pool{}
+	pool.state.Token0, pool.state.Token1 = weth, usdt
+
+	assert.NoError(t, checkPair(pool, usdt, weth))
+	assert.NoError(t, checkPair(pool, weth, usdt))
+	assert.ErrorContains(t, checkPair(pool, dai, weth), "token pair mismatch")
+	assert.ErrorContains(t, checkPair(pool, weth, weth), "token pair mismatch")
+}
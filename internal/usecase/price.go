@@ -0,0 +1,43 @@
+package usecase
+
+import (
+	"math/big"
+)
+
+// PriceInfo describes the prices of a swap in destination base units per source base unit
+type PriceInfo struct {
+	// SpotPrice is the mid price given by the reserves before the swap, nil when a pool has no liquidity
+	SpotPrice *big.Rat
+	// ExecutionPrice is the effective price of the swap, dstAmount / srcAmount
+	ExecutionPrice *big.Rat
+	// PriceImpactBps is how much worse the execution price is than the spot price in basis points, including the pool fee
+	PriceImpactBps *big.Rat
+}
+
+// spotPrice returns the mid price of a pool in the reserveIn -> reserveOut direction, nil when a reserve is empty
+func spotPrice(reserveIn, reserveOut *big.Int) *big.Rat {
+	if reserveIn.Sign() <= 0 || reserveOut.Sign() <= 0 {
+		return nil
+	}
+	return new(big.Rat).SetFrac(reserveOut, reserveIn)
+}
+
+// newPriceInfo calculates the execution price and price impact of swapping srcAmount into dstAmount at spot
+func newPriceInfo(spot *big.Rat, srcAmount, dstAmount *big.Int) PriceInfo {
+	if spot == nil || srcAmount.Sign() <= 0 {
+		return PriceInfo{}
+	}
+
+	execution := new(big.Rat).SetFrac(dstAmount, srcAmount)
+
+	// priceImpact = (spot - execution) / spot * 10000
+	impact := new(big.Rat).Sub(spot, execution)
+	impact.Quo(impact, spot)
+	impact.Mul(impact, big.NewRat(10000, 1))
+
+	return PriceInfo{
+		SpotPrice:      spot,
+		ExecutionPrice: execution,
+		PriceImpactBps: impact,
+	}
+}
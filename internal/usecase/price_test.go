@@ -0,0 +1,80 @@
+package usecase
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPriceInfo(t *testing.T) {
+	tests := []struct {
+		name          string
+		reserveIn     *big.Int
+		reserveOut    *big.Int
+		srcAmount     *big.Int
+		dstAmount     *big.Int
+		wantSpot      *big.Rat
+		wantExecution *big.Rat
+		wantImpact    *big.Rat
+	}{
+		{
+			name:          "impact of the fee only",
+			reserveIn:     big.NewInt(1000),
+			reserveOut:    big.NewInt(2000),
+			srcAmount:     big.NewInt(1000),
+			dstAmount:     big.NewInt(1994),
+			wantSpot:      big.NewRat(2, 1),
+			wantExecution: big.NewRat(1994, 1000),
+			wantImpact:    big.NewRat(30, 1),
+		},
+		{
+			name:          "fractional impact",
+			reserveIn:     big.NewInt(1000000000000),
+			reserveOut:    mustBigInt("500000000000000000000"),
+			srcAmount:     big.NewInt(1000000),
+			dstAmount:     big.NewInt(498499502995995),
+			wantSpot:      big.NewRat(500000000, 1),
+			wantExecution: big.NewRat(99699900599199, 200000),
+			wantImpact:    big.NewRat(300099400801, 10000000000),
+		},
+		{
+			name:          "better than spot gives negative impact",
+			reserveIn:     big.NewInt(1000),
+			reserveOut:    big.NewInt(1000),
+			srcAmount:     big.NewInt(100),
+			dstAmount:     big.NewInt(101),
+			wantSpot:      big.NewRat(1, 1),
+			wantExecution: big.NewRat(101, 100),
+			wantImpact:    big.NewRat(-100, 1),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price := newPriceInfo(spotPrice(tt.reserveIn, tt.reserveOut), tt.srcAmount, tt.dstAmount)
+			assert.Equal(t, tt.wantSpot.String(), price.SpotPrice.String())
+			assert.Equal(t, tt.wantExecution.String(), price.ExecutionPrice.String())
+			assert.Equal(t, tt.wantImpact.String(), price.PriceImpactBps.String())
+		})
+	}
+
+	t.Run("empty reserves have no price", func(t *testing.T) {
+		price := newPriceInfo(spotPrice(big.NewInt(0), big.NewInt(1000)), big.NewInt(100), big.NewInt(0))
+		assert.Equal(t, PriceInfo{}, price)
+	})
+}
+
+func TestService_EstimateSwapPrice(t *testing.T) {
+	service := NewUsecase(newMockUniswapV2())
+
+	// usdtWeth holds 1M USDT and 500 ETH
+	estimate, err := service.EstimateSwap(context.Background(), usdtWeth.Hex(), usdt.Hex(), weth.Hex(), "1000000", "")
+	require.NoError(t, err)
+	assert.Equal(t, "498499502995995", estimate.DstAmount.String())
+	assert.Equal(t, "500000000/1", estimate.SpotPrice.String())
+	assert.Equal(t, "99699900599199/200000", estimate.ExecutionPrice.String())
+	assert.Equal(t, "30.01", estimate.PriceImpactBps.FloatString(2))
+}
@@ -0,0 +1,147 @@
+package usecase
+
+import (
+	"1inch_testtask/internal/pooldetect"
+	"1inch_testtask/internal/routing"
+	"1inch_testtask/internal/uniswap_v2"
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// FullSlippageBps is the slippage tolerance at which the minimum output becomes zero, the bound of MinAmountOut.
+// The tolerance accepted from clients is limited further by models.MaxSlippageBps.
+const FullSlippageBps = 10000
+
+// MinAmountOut returns the minimum output accepted for amountOut with a slippage tolerance of slippageBps, rounded down
+func MinAmountOut(amountOut *big.Int, slippageBps int) *big.Int {
+	// amountOutMin = amountOut * (10000 - slippageBps) / 10000
+	amountOutMin := new(big.Int).Mul(amountOut, big.NewInt(int64(FullSlippageBps-slippageBps)))
+	return amountOutMin.Div(amountOutMin, big.NewInt(FullSlippageBps))
+}
+
+// SwapBuild is a router swap transaction built from an estimate
+type SwapBuild struct {
+	Tx *uniswap_v2.SwapTx
+	// Pools and Path are the pools and tokens the swap goes through, with WETH standing for ETH in Path
+	Pools []common.Address
+	Path  []common.Address
+	// FeeBps has the swap fee of every pool
+	FeeBps       []uint32
+	SrcAmount    *big.Int
+	DstAmount    *big.Int
+	AmountOutMin *big.Int
+	Deadline     uint64
+	// Block is the block the estimate was calculated at, nil for the latest state
+	Block *uniswap_v2.BlockRef
+	PriceInfo
+	TransferTaxes
+}
+
+// BuildSwap estimates a swap of srcAmount and encodes it as a router transaction paying at least the estimate
+// minus slippageBps to recipient. src and dst may be uniswap_v2.NativeETH to swap from or to native ETH.
+// When poolAddr is empty the best route through the router's pairs is used. The router derives the pairs of the path from
+// its factory and assumes the 0.3% Uniswap V2 fee, so any other pool is rejected with ErrNotRouterPool rather than
+// quoted differently than it is swapped.
+func (s *Usecase) BuildSwap(ctx context.Context, poolAddr, srcAddr, dstAddr, srcAmountStr string, slippageBps int, recipient string, deadline uint64) (*SwapBuild, error) {
+	if s.swapRouter == nil {
+		return nil, ErrSwapBuildingDisabled
+	}
+	if slippageBps < 0 || slippageBps > FullSlippageBps {
+		return nil, fmt.Errorf("invalid slippage_bps: %d", slippageBps)
+	}
+
+	// Native ETH is quoted and routed as WETH
+	params := uniswap_v2.SwapParams{
+		To:       common.HexToAddress(recipient),
+		Deadline: new(big.Int).SetUint64(deadline),
+	}
+	srcToken, dstToken := common.HexToAddress(srcAddr), common.HexToAddress(dstAddr)
+	if srcToken == uniswap_v2.NativeETH {
+		params.NativeIn = true
+		srcToken = s.swapRouter.WETH()
+	}
+	if dstToken == uniswap_v2.NativeETH {
+		params.NativeOut = true
+		dstToken = s.swapRouter.WETH()
+	}
+	if params.NativeIn && params.NativeOut {
+		return nil, uniswap_v2.ErrNativeToNative
+	}
+
+	build := &SwapBuild{}
+	if poolAddr != "" {
+		pool := common.HexToAddress(poolAddr)
+		if !isRouterPair(s.swapRouter, pool, srcToken, dstToken) {
+			return nil, fmt.Errorf("%w: %s is not the %s/%s pair of factory %s", ErrNotRouterPool, pool.Hex(), srcToken.Hex(), dstToken.Hex(), s.swapRouter.Factory().Address.Hex())
+		}
+		estimate, err := s.EstimatePoolSwap(ctx, pooldetect.UniswapV2, poolAddr, srcToken.Hex(), dstToken.Hex(), srcAmountStr, "")
+		if err != nil {
+			return nil, err
+		}
+		if estimate.FeeBps != DefaultFeeBps {
+			return nil, fmt.Errorf("%w: %s is quoted with a %d bps fee, the router assumes %d bps", ErrNotRouterPool, pool.Hex(), estimate.FeeBps, DefaultFeeBps)
+		}
+		build.Pools = []common.Address{common.HexToAddress(poolAddr)}
+		build.Path = []common.Address{srcToken, dstToken}
+		build.FeeBps = []uint32{estimate.FeeBps}
+		build.DstAmount = estimate.DstAmount
+		build.Block = estimate.Block
+		build.PriceInfo = estimate.PriceInfo
+		build.TransferTaxes = estimate.TransferTaxes
+	} else {
+		route, err := s.findBestRoute(ctx, srcToken.Hex(), dstToken.Hex(), srcAmountStr, "", s.swapRouter)
+		if err != nil {
+			return nil, err
+		}
+		build.Pools = route.Pools
+		build.Path = route.Path
+		build.FeeBps = route.FeeBps
+		build.DstAmount = route.DstAmount()
+		build.Block = route.Block
+		build.PriceInfo = route.PriceInfo
+		build.TransferTaxes = route.TransferTaxes
+	}
+
+	srcAmount, ok := new(big.Int).SetString(srcAmountStr, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid src_amount: %s", srcAmountStr)
+	}
+	build.SrcAmount = srcAmount
+	build.AmountOutMin = MinAmountOut(build.DstAmount, slippageBps)
+	build.Deadline = deadline
+
+	params.AmountIn = build.SrcAmount
+	params.AmountOutMin = build.AmountOutMin
+	params.Path = build.Path
+	params.FeeOnTransfer = build.Taxed()
+	tx, err := s.swapRouter.BuildSwap(params)
+	if err != nil {
+		return nil, err
+	}
+	build.Tx = tx
+
+	return build, nil
+}
+
+// isRouterPair reports whether pool is the pair the router swaps tokenA and tokenB through
+func isRouterPair(router *uniswap_v2.Router, pool, tokenA, tokenB common.Address) bool {
+	return router.Factory().PairFor(tokenA, tokenB) == pool
+}
+
+// routerRoutes returns the routes whose every hop goes through a pair of the router
+func routerRoutes(routes []routing.Route, router *uniswap_v2.Router) []routing.Route {
+	var filtered []routing.Route
+	for _, route := range routes {
+		ok := true
+		for _, edge := range route {
+			ok = ok && isRouterPair(router, edge.Pool, edge.TokenIn, edge.TokenOut)
+		}
+		if ok {
+			filtered = append(filtered, route)
+		}
+	}
+	return filtered
+}
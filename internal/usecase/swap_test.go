@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"1inch_testtask/internal/routing"
+	"1inch_testtask/internal/uniswap_v2"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMinAmountOut(t *testing.T) {
+	tests := []struct {
+		name        string
+		amountOut   *big.Int
+		slippageBps int
+		want        *big.Int
+	}{
+		{name: "no slippage", amountOut: big.NewInt(1000000), slippageBps: 0, want: big.NewInt(1000000)},
+		{name: "half percent", amountOut: big.NewInt(1000000), slippageBps: 50, want: big.NewInt(995000)},
+		{name: "rounded down", amountOut: big.NewInt(999), slippageBps: 50, want: big.NewInt(994)},
+		{name: "full slippage", amountOut: big.NewInt(1000000), slippageBps: FullSlippageBps, want: big.NewInt(0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, MinAmountOut(tt.amountOut, tt.slippageBps))
+		})
+	}
+}
+
+func TestService_BuildSwap(t *testing.T) {
+	ctx := context.Background()
+	client := newMockUniswapV2()
+	router, err := uniswap_v2.NewRouter(uniswap_v2.Router02Address, weth, uniswap_v2.UniswapV2Factory)
+	require.NoError(t, err)
+
+	var pairs []routing.Pair
+	for _, pool := range []common.Address{usdtWeth, daiWeth} {
+		pairs = append(pairs, routing.Pair{Pool: pool, Token0: client.pools[pool].token0, Token1: client.pools[pool].token1})
+	}
+	service := NewUsecase(client,
+		WithSwapRouter(router),
+		WithRouter(routing.NewGraph(pairs), RoutingConfig{MaxHops: 3, MaxCandidates: 10}),
+	)
+	recipient := common.HexToAddress("0x1111111111111111111111111111111111111111").Hex()
+
+	t.Run("single pool", func(t *testing.T) {
+		build, err := service.BuildSwap(ctx, usdtWeth.Hex(), usdt.Hex(), weth.Hex(), "1000000", 50, recipient, 1700000000)
+		require.NoError(t, err)
+
+		estimate, err := service.EstimateSwap(ctx, usdtWeth.Hex(), usdt.Hex(), weth.Hex(), "1000000", "")
+		require.NoError(t, err)
+		assert.Equal(t, estimate.DstAmount, build.DstAmount)
+		assert.Equal(t, MinAmountOut(estimate.DstAmount, 50), build.AmountOutMin)
+		assert.Equal(t, []common.Address{usdt, weth}, build.Path)
+		assert.Equal(t, "swapExactTokensForTokens", build.Tx.Method)
+		assert.Equal(t, big.NewInt(0), build.Tx.Value)
+	})
+
+	t.Run("native ETH in", func(t *testing.T) {
+		build, err := service.BuildSwap(ctx, usdtWeth.Hex(), uniswap_v2.NativeETH.Hex(), usdt.Hex(), "1000000000000000000", 100, recipient, 1700000000)
+		require.NoError(t, err)
+		assert.Equal(t, []common.Address{weth, usdt}, build.Path)
+		assert.Equal(t, "swapExactETHForTokens", build.Tx.Method)
+		assert.Equal(t, mustBigInt("1000000000000000000"), build.Tx.Value)
+	})
+
+	t.Run("best route to native ETH", func(t *testing.T) {
+		build, err := service.BuildSwap(ctx, "", dai.Hex(), uniswap_v2.NativeETH.Hex(), "1000000000000000000000", 50, recipient, 1700000000)
+		require.NoError(t, err)
+		assert.Equal(t, []common.Address{daiWeth}, build.Pools)
+		assert.Equal(t, []common.Address{dai, weth}, build.Path)
+		assert.Equal(t, "swapExactTokensForETH", build.Tx.Method)
+	})
+
+	t.Run("ETH for ETH", func(t *testing.T) {
+		_, err := service.BuildSwap(ctx, usdtWeth.Hex(), uniswap_v2.NativeETH.Hex(), uniswap_v2.NativeETH.Hex(), "1000000", 50, recipient, 1700000000)
+		assert.ErrorIs(t, err, uniswap_v2.ErrNativeToNative)
+	})
+
+	t.Run("pair of another factory", func(t *testing.T) {
+		_, err := service.BuildSwap(ctx, sushiUsdtWeth.Hex(), usdt.Hex(), weth.Hex(), "1000000", 50, recipient, 1700000000)
+		assert.ErrorIs(t, err, ErrNotRouterPool)
+	})
+
+	t.Run("pool of other tokens", func(t *testing.T) {
+		_, err := service.BuildSwap(ctx, daiWeth.Hex(), usdt.Hex(), weth.Hex(), "1000000", 50, recipient, 1700000000)
+		assert.ErrorIs(t, err, ErrNotRouterPool)
+	})
+
+	t.Run("pool with a fee override", func(t *testing.T) {
+		service := NewUsecase(client,
+			WithSwapRouter(router),
+			WithFees(FeeConfig{DefaultBps: DefaultFeeBps, Pools: map[common.Address]uint32{usdtWeth: 25}}),
+		)
+		_, err := service.BuildSwap(ctx, usdtWeth.Hex(), usdt.Hex(), weth.Hex(), "1000000", 50, recipient, 1700000000)
+		assert.ErrorIs(t, err, ErrNotRouterPool)
+	})
+
+	t.Run("best route only uses router pairs", func(t *testing.T) {
+		// The SushiSwap pair is deeper here, but the router would swap through its own pair instead
+		sushi := newMockUniswapV2()
+		sushiPool := sushi.pools[sushiUsdtWeth]
+		sushiPool.reserve0, sushiPool.reserve1 = mustBigInt("5000000000000000000000"), big.NewInt(10000000000000)
+		sushi.pools[sushiUsdtWeth] = sushiPool
+		var pairs []routing.Pair
+		for _, pool := range []common.Address{usdtWeth, sushiUsdtWeth} {
+			pairs = append(pairs, routing.Pair{Pool: pool, Token0: sushi.pools[pool].token0, Token1: sushi.pools[pool].token1})
+		}
+		graph := routing.NewGraph(pairs)
+		service := NewUsecase(sushi, WithSwapRouter(router), WithRouter(graph, RoutingConfig{MaxHops: 1, MaxCandidates: 10}))
+
+		route, err := service.FindBestRoute(ctx, usdt.Hex(), weth.Hex(), "1000000000", "")
+		require.NoError(t, err)
+		assert.Equal(t, []common.Address{sushiUsdtWeth}, route.Pools)
+
+		build, err := service.BuildSwap(ctx, "", usdt.Hex(), weth.Hex(), "1000000000", 50, recipient, 1700000000)
+		require.NoError(t, err)
+		assert.Equal(t, []common.Address{usdtWeth}, build.Pools)
+
+		service = NewUsecase(sushi, WithSwapRouter(router), WithRouter(routing.NewGraph(pairs[1:]), RoutingConfig{MaxHops: 1, MaxCandidates: 10}))
+		_, err = service.BuildSwap(ctx, "", usdt.Hex(), weth.Hex(), "1000000000", 50, recipient, 1700000000)
+		assert.ErrorIs(t, err, ErrNoRoute)
+	})
+
+	t.Run("swap building disabled", func(t *testing.T) {
+		_, err := NewUsecase(client).BuildSwap(ctx, usdtWeth.Hex(), usdt.Hex(), weth.Hex(), "1000000", 50, recipient, 1700000000)
+		assert.ErrorIs(t, err, ErrSwapBuildingDisabled)
+	})
+}
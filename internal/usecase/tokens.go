@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"1inch_testtask/internal/tokens"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	// ErrTokenMetadataDisabled is returned when a decimal amount is given but token metadata is not configured
+	ErrTokenMetadataDisabled = errors.New("token metadata is not configured")
+	// ErrUnknownDecimals is returned when a decimal amount is given for an address without decimals
+	ErrUnknownDecimals = errors.New("token decimals are unknown")
+)
+
+// TokenMetadata is the ERC-20 metadata of the tokens of an estimate, nil when metadata is not configured
+// or the token has no decimals
+type TokenMetadata struct {
+	SrcToken *tokens.Token
+	DstToken *tokens.Token
+}
+
+// WithTokens adds the metadata of src and dst to single pool and best-route estimates
+func WithTokens(client tokens.ITokens) Option {
+	return func(s *Usecase) {
+		s.tokenClient = client
+	}
+}
+
+// getTokenMetadata returns the metadata of src and dst, empty when token metadata is not configured.
+// Metadata only decorates estimates, so a failure to read it is logged and the metadata omitted.
+func (s *Usecase) getTokenMetadata(ctx context.Context, src, dst common.Address) TokenMetadata {
+	if s.tokenClient == nil {
+		return TokenMetadata{}
+	}
+
+	metadata, err := s.tokenClient.GetTokens(ctx, []common.Address{src, dst})
+	if err != nil {
+		log.Printf("Omitting metadata of %s and %s: %v", src.Hex(), dst.Hex(), err)
+		return TokenMetadata{}
+	}
+	return TokenMetadata{SrcToken: metadata[0], DstToken: metadata[1]}
+}
+
+// ParseTokenAmount converts a decimal amount of token, such as 1.5, to base units using the token's on-chain decimals.
+// Amounts are never rounded, see tokens.ParseUnits.
+func (s *Usecase) ParseTokenAmount(ctx context.Context, tokenAddr, amount string) (*big.Int, error) {
+	if s.tokenClient == nil {
+		return nil, ErrTokenMetadataDisabled
+	}
+
+	token := common.HexToAddress(tokenAddr)
+	metadata, err := s.tokenClient.GetTokens(ctx, []common.Address{token})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get token metadata: %w", err)
+	}
+	if metadata[0] == nil {
+		return nil, fmt.Errorf("%w: %s has no decimals", ErrUnknownDecimals, token.Hex())
+	}
+
+	units, err := tokens.ParseUnits(amount, metadata[0].Decimals)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", metadata[0].Symbol, err)
+	}
+	return units, nil
+}
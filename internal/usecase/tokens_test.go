@@ -0,0 +1,108 @@
+package usecase
+
+import (
+	"1inch_testtask/internal/tokens"
+	"1inch_testtask/internal/uniswap_v2"
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockTokens is an in-memory tokens.ITokens implementation
+type mockTokens struct {
+	tokens map[common.Address]*tokens.Token
+	err    error
+}
+
+func (m *mockTokens) GetTokens(_ context.Context, addresses []common.Address) ([]*tokens.Token, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	result := make([]*tokens.Token, len(addresses))
+	for i, address := range addresses {
+		result[i] = m.tokens[address]
+	}
+	return result, nil
+}
+
+func newMockTokens() *mockTokens {
+	return &mockTokens{tokens: map[common.Address]*tokens.Token{
+		usdt: {Address: usdt, Symbol: "USDT", Name: "Tether USD", Decimals: 6},
+		weth: {Address: weth, Symbol: "WETH", Name: "Wrapped Ether", Decimals: 18},
+	}}
+}
+
+func TestService_TokenMetadata(t *testing.T) {
+	ctx := context.Background()
+	client := newMockTokens()
+
+	t.Run("single pool", func(t *testing.T) {
+		service := NewUsecase(newMockUniswapV2(), WithTokens(client))
+
+		estimate, err := service.EstimateSwap(ctx, usdtWeth.Hex(), usdt.Hex(), weth.Hex(), "1000000", "")
+		require.NoError(t, err)
+		assert.Equal(t, TokenMetadata{SrcToken: client.tokens[usdt], DstToken: client.tokens[weth]}, estimate.TokenMetadata)
+	})
+
+	t.Run("best route", func(t *testing.T) {
+		service := NewUsecase(newMockUniswapV2(), WithTokens(client), WithPairFinders([]uniswap_v2.IPairFinder{newMockPairFinder(usdtWeth)}))
+
+		route, err := service.FindBestRoute(ctx, weth.Hex(), usdt.Hex(), "1000000000000000000", "")
+		require.NoError(t, err)
+		assert.Equal(t, TokenMetadata{SrcToken: client.tokens[weth], DstToken: client.tokens[usdt]}, route.TokenMetadata)
+	})
+
+	t.Run("unknown token", func(t *testing.T) {
+		service := NewUsecase(newMockUniswapV2(), WithTokens(client))
+
+		estimate, err := service.EstimateSwap(ctx, daiWeth.Hex(), dai.Hex(), weth.Hex(), "1000000000000000000", "")
+		require.NoError(t, err)
+		assert.Nil(t, estimate.SrcToken)
+		assert.Equal(t, client.tokens[weth], estimate.DstToken)
+	})
+
+	t.Run("rpc error omits metadata", func(t *testing.T) {
+		service := NewUsecase(newMockUniswapV2(), WithTokens(&mockTokens{err: errors.New("429 Too Many Requests")}))
+
+		estimate, err := service.EstimateSwap(ctx, usdtWeth.Hex(), usdt.Hex(), weth.Hex(), "1000000", "")
+		require.NoError(t, err)
+		assert.Positive(t, estimate.DstAmount.Sign())
+		assert.Equal(t, TokenMetadata{}, estimate.TokenMetadata)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		service := NewUsecase(newMockUniswapV2())
+
+		estimate, err := service.EstimateSwap(ctx, usdtWeth.Hex(), usdt.Hex(), weth.Hex(), "1000000", "")
+		require.NoError(t, err)
+		assert.Equal(t, TokenMetadata{}, estimate.TokenMetadata)
+	})
+}
+
+func TestService_ParseTokenAmount(t *testing.T) {
+	ctx := context.Background()
+	service := NewUsecase(newMockUniswapV2(), WithTokens(newMockTokens()))
+
+	amount, err := service.ParseTokenAmount(ctx, usdt.Hex(), "1.5")
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(1500000), amount)
+
+	amount, err = service.ParseTokenAmount(ctx, weth.Hex(), "1.5")
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(1500000000000000000), amount)
+
+	_, err = service.ParseTokenAmount(ctx, usdt.Hex(), "1.0000001")
+	assert.ErrorIs(t, err, tokens.ErrTooPrecise)
+	assert.ErrorContains(t, err, "USDT")
+
+	_, err = service.ParseTokenAmount(ctx, dai.Hex(), "1.5")
+	assert.ErrorIs(t, err, ErrUnknownDecimals)
+
+	_, err = NewUsecase(newMockUniswapV2()).ParseTokenAmount(ctx, usdt.Hex(), "1.5")
+	assert.ErrorIs(t, err, ErrTokenMetadataDisabled)
+}
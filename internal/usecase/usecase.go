@@ -1,78 +1,840 @@
 package usecase
 
 import (
+	"1inch_testtask/internal/balancer"
+	"1inch_testtask/internal/curve"
+	"1inch_testtask/internal/ens"
+	"1inch_testtask/internal/pooldetect"
+	"1inch_testtask/internal/routing"
+	"1inch_testtask/internal/tokens"
+	"1inch_testtask/internal/transfertax"
 	"1inch_testtask/internal/uniswap_v2"
+	"1inch_testtask/internal/uniswap_v3"
 	"context"
+	"errors"
 	"fmt"
 	"github.com/ethereum/go-ethereum/common"
+	"golang.org/x/sync/errgroup"
 	"math/big"
+	"slices"
 )
 
-// Usecase handles Uniswap V2 calculations
+// Usecase handles swap calculations
 type Usecase struct {
 	uniswapV2Client uniswap_v2.IUniswapV2
+	router          *routing.Graph
+	routingConfig   RoutingConfig
+	// pairFinders discover the direct pairs of a best-route search in the configured factories when set
+	pairFinders   []uniswap_v2.IPairFinder
+	blockResolver uniswap_v2.IBlockResolver
+	// snapshots pins every quote to the latest block resolved up front when no block is requested
+	snapshots bool
+	// poolStates serves unpinned quotes from in-memory pool state when set
+	poolStates PoolStateSource
+	swapRouter *uniswap_v2.Router
+	// transferTaxes detects fee-on-transfer tokens when set
+	transferTaxes TransferTaxDetector
+	fees          FeeConfig
+	// uniswapV3Client quotes Uniswap V3 pools when set
+	uniswapV3Client uniswap_v3.IUniswapV3
+	// curveClient quotes Curve StableSwap pools when set
+	curveClient curve.ICurve
+	// balancerClient quotes Balancer V2 weighted pools when set
+	balancerClient balancer.IBalancer
+	// poolDetector detects the type of single pool estimates, which are Uniswap V2 pairs without it
+	poolDetector PoolDetector
+	// tokenClient adds token metadata to estimates when set
+	tokenClient tokens.ITokens
+	// nameResolver resolves ENS names given instead of addresses when set
+	nameResolver ens.IResolver
+	// tokenAliases maps uppercase token symbols to their addresses
+	tokenAliases map[string]common.Address
+}
+
+// TransferTaxDetector reports the transfer tax of a token traded through a pool
+type TransferTaxDetector interface {
+	TransferTax(ctx context.Context, token, pool common.Address) (transfertax.Tax, error)
+}
+
+// PoolStateSource provides pool states kept up to date outside of the request path
+type PoolStateSource interface {
+	// PoolStates returns the state of the pools and the block it is valid at, ok is false when any pool is unavailable
+	PoolStates(poolAddresses []common.Address) (states []uniswap_v2.PoolState, block *uniswap_v2.BlockRef, ok bool)
+}
+
+// RoutingConfig bounds the best-route search
+type RoutingConfig struct {
+	MaxHops       int
+	MaxCandidates int
+}
+
+// Option configures optional Usecase dependencies
+type Option func(*Usecase)
+
+// WithRouter enables best-route search over the given pool graph
+func WithRouter(router *routing.Graph, cfg RoutingConfig) Option {
+	return func(s *Usecase) {
+		s.router = router
+		s.routingConfig = cfg
+	}
+}
+
+// WithBlockResolver enables estimates pinned to a given block
+func WithBlockResolver(blockResolver uniswap_v2.IBlockResolver) Option {
+	return func(s *Usecase) {
+		s.blockResolver = blockResolver
+	}
+}
+
+// WithSnapshots makes every quote resolve the latest block first and evaluate all its calls against it,
+// so multi-call and multi-pool quotes never mix state from different blocks. Requires WithBlockResolver.
+func WithSnapshots() Option {
+	return func(s *Usecase) {
+		s.snapshots = true
+	}
+}
+
+// WithPoolStateSource serves quotes without a requested block from source, falling back to RPC
+// for pools it does not track or when its state is stale
+func WithPoolStateSource(source PoolStateSource) Option {
+	return func(s *Usecase) {
+		s.poolStates = source
+	}
+}
+
+// WithSwapRouter enables building swap transactions for the given router
+func WithSwapRouter(router *uniswap_v2.Router) Option {
+	return func(s *Usecase) {
+		s.swapRouter = router
+	}
+}
+
+// WithTransferTaxes applies the transfer taxes of fee-on-transfer tokens to every hop of the estimates
+func WithTransferTaxes(detector TransferTaxDetector) Option {
+	return func(s *Usecase) {
+		s.transferTaxes = detector
+	}
+}
+
+// WithFees sets the swap fee of pools, by default every pool charges DefaultFeeBps
+func WithFees(fees FeeConfig) Option {
+	return func(s *Usecase) {
+		s.fees = fees
+	}
 }
 
 // NewUsecase creates a new Uniswap service
-func NewUsecase(uniswapV2Client uniswap_v2.IUniswapV2) *Usecase {
-	return &Usecase{
+func NewUsecase(uniswapV2Client uniswap_v2.IUniswapV2, opts ...Option) *Usecase {
+	s := &Usecase{
 		uniswapV2Client: uniswapV2Client,
+		fees:            FeeConfig{DefaultBps: DefaultFeeBps},
 	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
 }
 
-// EstimateSwap calculates the output amount for a Uniswap V2 swap
-func (s *Usecase) EstimateSwap(ctx context.Context, poolAddr, srcAddr, dstAddr, srcAmountStr string) (*big.Int, error) {
+var (
+	// ErrInsufficientLiquidity is returned when the pool cannot provide the requested output amount
+	ErrInsufficientLiquidity = errors.New("insufficient liquidity")
+	// ErrRoutingDisabled is returned when a route is requested but neither a pool graph nor pair discovery is configured
+	ErrRoutingDisabled = errors.New("routing is not configured")
+	// ErrNoRoute is returned when no route connects the requested tokens
+	ErrNoRoute = errors.New("no route found")
+	// ErrBlockPinningDisabled is returned when a block is requested but no block resolver is configured
+	ErrBlockPinningDisabled = errors.New("block pinning is not configured")
+	// ErrSwapBuildingDisabled is returned when a swap transaction is requested but no router is configured
+	ErrSwapBuildingDisabled = errors.New("swap building is not configured")
+	// ErrNotRouterPool is returned when a swap goes through a pool the router would not swap through as quoted
+	ErrNotRouterPool = errors.New("pool cannot be swapped through the router")
+	// ErrReserveOverflow is returned when an input amount would push a Uniswap V2 reserve above uint112,
+	// which makes the pair revert the swap
+	ErrReserveOverflow = errors.New("amount overflows the uint112 pool reserve")
+)
+
+// SwapEstimate is the result of a single pool swap estimation
+type SwapEstimate struct {
+	DstAmount *big.Int
+	// BlockTimestampLast is the timestamp of the block the pool reserves were last updated in
+	BlockTimestampLast uint32
+	// FeeBps is the swap fee of the pool
+	FeeBps uint32
+	// Block is the block the estimate was calculated at, nil for the latest state
+	Block *uniswap_v2.BlockRef
+	PriceInfo
+	TransferTaxes
+	TokenMetadata
+}
+
+// TransferTaxes are the taxes of fee-on-transfer tokens applied to an estimate
+type TransferTaxes struct {
+	// SrcTax is taken from the source amount sent into the first pool
+	SrcTax transfertax.Tax
+	// DstTax is taken from the output sent by the last pool
+	DstTax transfertax.Tax
+	// PathTaxed is set when a token passed between two pools of a multi-hop swap is taxed
+	PathTaxed bool
+}
+
+// Taxed reports whether a tax was applied to the estimate
+func (t TransferTaxes) Taxed() bool {
+	return t.SrcTax.SellBps != 0 || t.DstTax.BuyBps != 0 || t.PathTaxed
+}
+
+// RouteEstimate is the result of a best-route search
+type RouteEstimate struct {
+	Pools   []common.Address
+	Path    []common.Address
+	Amounts []*big.Int
+	// FeeBps has the swap fee of every pool
+	FeeBps []uint32
+	// Block is the block the estimate was calculated at, nil for the latest state
+	Block *uniswap_v2.BlockRef
+	// PriceInfo is the price of the whole route, its spot price is the product of the spot prices of its hops
+	PriceInfo
+	TransferTaxes
+	TokenMetadata
+}
+
+// DstAmount returns the output amount of the route
+func (r *RouteEstimate) DstAmount() *big.Int {
+	return r.Amounts[len(r.Amounts)-1]
+}
+
+// EstimateSwap calculates the output amount for a swap on a single pool of any supported type.
+// block optionally pins the estimate to a block number, hash or tag; empty means the latest state.
+func (s *Usecase) EstimateSwap(ctx context.Context, poolAddr, srcAddr, dstAddr, srcAmountStr, block string) (*SwapEstimate, error) {
+	return s.EstimatePoolSwap(ctx, "", poolAddr, srcAddr, dstAddr, srcAmountStr, block)
+}
+
+// EstimatePoolSwap calculates the output amount for a swap on a pool of the given type, which is detected when empty.
+// block optionally pins the estimate to a block number, hash or tag; empty means the latest state.
+func (s *Usecase) EstimatePoolSwap(ctx context.Context, poolType pooldetect.Type, poolAddr, srcAddr, dstAddr, srcAmountStr, block string) (*SwapEstimate, error) {
 	// Parse source amount
 	srcAmount, ok := new(big.Int).SetString(srcAmountStr, 10)
 	if !ok {
 		return nil, fmt.Errorf("invalid src_amount: %s", srcAmountStr)
 	}
 
-	// Convert addresses
-	poolAddress := common.HexToAddress(poolAddr)
-	srcAddress := common.HexToAddress(srcAddr)
-	dstAddress := common.HexToAddress(dstAddr)
+	pool, blockRef, err := s.loadPool(ctx, poolType, poolAddr, block)
+	if err != nil {
+		return nil, err
+	}
+
+	src, dst := common.HexToAddress(srcAddr), common.HexToAddress(dstAddr)
+	if err := checkPair(pool, src, dst); err != nil {
+		return nil, err
+	}
 
-	// Get token addresses from the pool
-	token0, err := s.uniswapV2Client.GetToken0(ctx, poolAddress)
+	taxes, err := s.getTransferTaxes(taxContext(ctx, block, blockRef), src, pool.Address(), dst, pool.Address())
 	if err != nil {
-		return nil, fmt.Errorf("failed to get token0: %w", err)
+		return nil, err
 	}
 
-	token1, err := s.uniswapV2Client.GetToken1(ctx, poolAddress)
+	// The pool only receives what is left after the transfer tax
+	outputAmount, err := pool.QuoteExactIn(src, dst, taxes.SrcTax.AfterSell(srcAmount))
 	if err != nil {
-		return nil, fmt.Errorf("failed to get token1: %w", err)
+		return nil, err
+	}
+	outputAmount = taxes.DstTax.AfterBuy(outputAmount)
+
+	estimate := &SwapEstimate{
+		DstAmount:     outputAmount,
+		FeeBps:        pool.FeeBps(),
+		Block:         blockRef,
+		PriceInfo:     newPriceInfo(pool.SpotPrice(src, dst), srcAmount, outputAmount),
+		TransferTaxes: taxes,
+		TokenMetadata: s.getTokenMetadata(ctx, src, dst),
+	}
+	if v2, ok := pool.(*uniswapV2Pool); ok {
+		estimate.BlockTimestampLast = v2.state.BlockTimestampLast
 	}
 
-	// Get reserves
-	reserve0, reserve1, err := s.uniswapV2Client.GetReserves(ctx, poolAddress)
+	return estimate, nil
+}
+
+// pinBlock resolves block and returns a context that pins pool calls to it.
+// An empty block means the latest block in snapshot mode, otherwise it leaves ctx unpinned and returns a nil block.
+func (s *Usecase) pinBlock(ctx context.Context, block string) (context.Context, *uniswap_v2.BlockRef, error) {
+	if block == "" {
+		if !s.snapshots {
+			return ctx, nil, nil
+		}
+		block = "latest"
+	}
+	if s.blockResolver == nil {
+		return nil, nil, ErrBlockPinningDisabled
+	}
+
+	blockRef, err := s.blockResolver.ResolveBlock(ctx, block)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get reserves: %w", err)
+		return nil, nil, fmt.Errorf("failed to resolve block: %w", err)
 	}
 
-	// Determine which token is which and get the appropriate reserves
-	var reserveIn, reserveOut *big.Int
-	if srcAddress == token0 && dstAddress == token1 {
-		reserveIn = reserve0
-		reserveOut = reserve1
-	} else if srcAddress == token1 && dstAddress == token0 {
-		reserveIn = reserve1
-		reserveOut = reserve0
+	return uniswap_v2.WithBlockNumber(ctx, blockRef.Number), blockRef, nil
+}
+
+// SwapInEstimate is the result of a reverse single pool swap estimation
+type SwapInEstimate struct {
+	SrcAmount *big.Int
+	// FeeBps is the swap fee of the pool
+	FeeBps uint32
+	// Block is the block the estimate was calculated at, nil for the latest state
+	Block *uniswap_v2.BlockRef
+	TransferTaxes
+}
+
+// EstimateSwapIn calculates the input amount required to receive dstAmount from a swap on a single pool of any supported type.
+// block optionally pins the estimate to a block number, hash or tag; empty means the latest state.
+func (s *Usecase) EstimateSwapIn(ctx context.Context, poolAddr, srcAddr, dstAddr, dstAmountStr, block string) (*SwapInEstimate, error) {
+	// Parse destination amount
+	dstAmount, ok := new(big.Int).SetString(dstAmountStr, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid dst_amount: %s", dstAmountStr)
+	}
+
+	pool, blockRef, err := s.loadPool(ctx, "", poolAddr, block)
+	if err != nil {
+		return nil, err
+	}
+
+	src, dst := common.HexToAddress(srcAddr), common.HexToAddress(dstAddr)
+	if err := checkPair(pool, src, dst); err != nil {
+		return nil, err
+	}
+
+	taxes, err := s.getTransferTaxes(taxContext(ctx, block, blockRef), src, pool.Address(), dst, pool.Address())
+	if err != nil {
+		return nil, err
+	}
+
+	// The pool must send enough for dstAmount to be left after the buy tax,
+	// and enough must be sent for the pool to receive its input after the sell tax
+	poolOut, err := taxes.DstTax.BeforeBuy(dstAmount)
+	if err != nil {
+		return nil, fmt.Errorf("dst %s: %w", dst.Hex(), err)
+	}
+	poolIn, err := pool.QuoteExactOut(src, dst, poolOut)
+	if err != nil {
+		return nil, err
+	}
+	srcAmount, err := taxes.SrcTax.BeforeSell(poolIn)
+	if err != nil {
+		return nil, fmt.Errorf("src %s: %w", src.Hex(), err)
+	}
+
+	return &SwapInEstimate{SrcAmount: srcAmount, FeeBps: pool.FeeBps(), Block: blockRef, TransferTaxes: taxes}, nil
+}
+
+// PathEstimate is the result of a multi-hop swap estimation
+type PathEstimate struct {
+	// Amounts has one amount per path token, starting with the source amount
+	Amounts []*big.Int
+	// FeeBps has the swap fee of every pool
+	FeeBps []uint32
+	// Block is the block the estimate was calculated at, nil for the latest state
+	Block *uniswap_v2.BlockRef
+	TransferTaxes
+}
+
+// EstimatePath calculates every intermediate amount for a multi-hop Uniswap V2 swap (like the router's getAmountsOut).
+// path is the ordered list of tokens and pools[i] is the pool used to swap path[i] into path[i+1].
+// block optionally pins the estimate to a block number, hash or tag; empty means the latest state.
+func (s *Usecase) EstimatePath(ctx context.Context, pools, path []string, srcAmountStr, block string) (*PathEstimate, error) {
+	// Parse source amount
+	srcAmount, ok := new(big.Int).SetString(srcAmountStr, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid src_amount: %s", srcAmountStr)
+	}
+
+	if len(pools) == 0 || len(path) != len(pools)+1 {
+		return nil, fmt.Errorf("invalid path: %d tokens for %d pools", len(path), len(pools))
+	}
+
+	states, blockRef, err := s.getPoolStates(ctx, pools, block)
+	if err != nil {
+		return nil, err
+	}
+
+	poolAddresses := make([]common.Address, len(pools))
+	for i, pool := range pools {
+		poolAddresses[i] = common.HexToAddress(pool)
+	}
+	tokens := make([]common.Address, len(path))
+	for i, token := range path {
+		tokens[i] = common.HexToAddress(token)
+	}
+	taxesIn, taxesOut, taxes, err := s.getPathTaxes(taxContext(ctx, block, blockRef), poolAddresses, tokens)
+	if err != nil {
+		return nil, err
+	}
+
+	amounts := make([]*big.Int, len(path))
+	amounts[0] = srcAmount
+	fees := make([]uint32, len(pools))
+	for i, pool := range pools {
+		reserveIn, reserveOut, err := orientReserves(states[i], path[i], path[i+1])
+		if err != nil {
+			return nil, fmt.Errorf("hop %d (%s -> %s via %s): %w", i, path[i], path[i+1], pool, err)
+		}
+
+		// The pool only receives what is left after the transfer tax
+		amountIn := taxesIn[i].AfterSell(amounts[i])
+		if err := checkReserveBounds(amountIn, reserveIn); err != nil {
+			return nil, fmt.Errorf("hop %d (%s -> %s via %s): %w", i, path[i], path[i+1], pool, err)
+		}
+
+		fees[i] = s.fees.FeeBps(states[i])
+		amounts[i+1] = taxesOut[i].AfterBuy(calculateOutputAmount(amountIn, reserveIn, reserveOut, fees[i]))
+	}
+
+	return &PathEstimate{Amounts: amounts, FeeBps: fees, Block: blockRef, TransferTaxes: taxes}, nil
+}
+
+// FindBestRoute searches the configured pool graph and the pairs of src and dst found in the configured factories
+// for the route with the best output for srcAmount.
+// block optionally pins the estimate to a block number, hash or tag; empty means the latest state.
+func (s *Usecase) FindBestRoute(ctx context.Context, srcAddr, dstAddr, srcAmountStr, block string) (*RouteEstimate, error) {
+	return s.findBestRoute(ctx, srcAddr, dstAddr, srcAmountStr, block, nil)
+}
+
+// findBestRoute is FindBestRoute, restricted to routes the router swaps through as quoted when router is set
+func (s *Usecase) findBestRoute(ctx context.Context, srcAddr, dstAddr, srcAmountStr, block string, router *uniswap_v2.Router) (*RouteEstimate, error) {
+	if s.router == nil && len(s.pairFinders) == 0 {
+		return nil, ErrRoutingDisabled
+	}
+
+	// Parse source amount
+	srcAmount, ok := new(big.Int).SetString(srcAmountStr, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid src_amount: %s", srcAmountStr)
+	}
+
+	// The block is only resolved when pairs are discovered or reserves are read over RPC, so that pairs are
+	// discovered at the block their reserves are read at and routes over tracked pools need no RPC call
+	var pinned context.Context
+	var blockRef *uniswap_v2.BlockRef
+	pin := func() error {
+		if pinned != nil {
+			return nil
+		}
+		var err error
+		pinned, blockRef, err = s.pinBlock(ctx, block)
+		return err
+	}
+
+	src, dst := common.HexToAddress(srcAddr), common.HexToAddress(dstAddr)
+	var routes []routing.Route
+	if s.router != nil {
+		routes = s.router.FindRoutes(src, dst, s.routingConfig.MaxHops, s.routingConfig.MaxCandidates)
+	}
+	if len(s.pairFinders) > 0 {
+		if err := pin(); err != nil {
+			return nil, err
+		}
+		pairRoutes, err := s.findPairRoutes(pinned, src, dst)
+		if err != nil {
+			return nil, err
+		}
+		routes = append(routes, pairRoutes...)
+	}
+	if router != nil {
+		routes = routerRoutes(routes, router)
+	}
+	if len(routes) == 0 {
+		return nil, fmt.Errorf("%w: src=%s, dst=%s", ErrNoRoute, srcAddr, dstAddr)
+	}
+
+	// Reserves of all candidate pools are fetched in one batch since routes share pools
+	var pools []common.Address
+	seen := make(map[common.Address]bool)
+	for _, route := range routes {
+		for _, edge := range route {
+			if !seen[edge.Pool] {
+				seen[edge.Pool] = true
+				pools = append(pools, edge.Pool)
+			}
+		}
+	}
+
+	states, trackedRef, ok := s.trackedPoolStates(pools, block)
+	if ok {
+		blockRef = trackedRef
 	} else {
-		return nil, fmt.Errorf("token pair mismatch: src=%s, dst=%s, token0=%s, token1=%s",
-			srcAddr, dstAddr, token0.Hex(), token1.Hex())
+		if err := pin(); err != nil {
+			return nil, err
+		}
+		var err error
+		if states, err = s.readPoolStates(pinned, pools); err != nil {
+			return nil, err
+		}
+	}
+	reserves := make(map[common.Address]uniswap_v2.PoolState, len(states))
+	for _, state := range states {
+		reserves[state.Pool] = state
+	}
+
+	// Taxes of all candidate routes are resolved together since routes share tokens and pools
+	var taxKeys []taxKey
+	for _, route := range routes {
+		taxKeys = append(taxKeys, pathTaxKeys(route.Pools(), route.Tokens())...)
+	}
+	resolved, err := s.resolveTransferTaxes(taxContext(ctx, block, blockRef), taxKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	var best *RouteEstimate
+	overflow := false
+routes:
+	for _, route := range routes {
+		taxesIn, taxesOut, taxes := pathTaxes(resolved, route.Pools(), route.Tokens())
+
+		amounts := make([]*big.Int, len(route)+1)
+		amounts[0] = srcAmount
+		fees := make([]uint32, len(route))
+		spot := big.NewRat(1, 1)
+
+		for i, edge := range route {
+			state := reserves[edge.Pool]
+			reserveIn, reserveOut := state.Reserve0, state.Reserve1
+			if !edge.ZeroForOne {
+				reserveIn, reserveOut = reserveOut, reserveIn
+			}
+			// The pool only receives what is left after the transfer tax
+			amountIn := taxesIn[i].AfterSell(amounts[i])
+			// The pair would revert the swap, other routes may still take the amount
+			if checkReserveBounds(amountIn, reserveIn) != nil {
+				overflow = true
+				continue routes
+			}
+			fees[i] = s.fees.FeeBps(state)
+			if router != nil && fees[i] != DefaultFeeBps {
+				continue routes
+			}
+			amounts[i+1] = taxesOut[i].AfterBuy(calculateOutputAmount(amountIn, reserveIn, reserveOut, fees[i]))
+
+			if hopSpot := spotPrice(reserveIn, reserveOut); hopSpot != nil && spot != nil {
+				spot.Mul(spot, hopSpot)
+			} else {
+				spot = nil
+			}
+		}
+
+		if best == nil || amounts[len(amounts)-1].Cmp(best.DstAmount()) > 0 {
+			best = &RouteEstimate{
+				Pools:         route.Pools(),
+				Path:          route.Tokens(),
+				Amounts:       amounts,
+				FeeBps:        fees,
+				Block:         blockRef,
+				PriceInfo:     newPriceInfo(spot, srcAmount, amounts[len(amounts)-1]),
+				TransferTaxes: taxes,
+			}
+		}
+	}
+
+	if best == nil && overflow {
+		return nil, fmt.Errorf("%w: src_amount %s is too large for every route", ErrReserveOverflow, srcAmount)
+	}
+	if best == nil {
+		return nil, fmt.Errorf("%w: src=%s, dst=%s", ErrNoRoute, srcAddr, dstAddr)
+	}
+
+	best.TokenMetadata = s.getTokenMetadata(ctx, src, dst)
+
+	return best, nil
+}
+
+// SplitAllocation is the part of a split order routed through a single pool
+type SplitAllocation struct {
+	Pool      common.Address
+	SrcAmount *big.Int
+	DstAmount *big.Int
+	// FeeBps is the swap fee of the pool
+	FeeBps uint32
+	TransferTaxes
+}
+
+// SplitEstimate is the result of splitting an order across several pools of the same pair
+type SplitEstimate struct {
+	DstAmount   *big.Int
+	Allocations []SplitAllocation
+	// Block is the block the estimate was calculated at, nil for the latest state
+	Block *uniswap_v2.BlockRef
+}
+
+// EstimateSplit splits srcAmount across pools trading the same pair to maximize the total output.
+// The amount is divided into parts equal chunks and every chunk is greedily given to the pool with
+// the best marginal output, which is optimal for the concave constant-product output curve.
+// block optionally pins the estimate to a block number, hash or tag; empty means the latest state.
+func (s *Usecase) EstimateSplit(ctx context.Context, pools []string, srcAddr, dstAddr, srcAmountStr string, parts int, block string) (*SplitEstimate, error) {
+	// Parse source amount
+	srcAmount, ok := new(big.Int).SetString(srcAmountStr, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid src_amount: %s", srcAmountStr)
+	}
+	if len(pools) == 0 {
+		return nil, errors.New("no pools to split across")
+	}
+	if parts <= 0 {
+		return nil, fmt.Errorf("invalid parts: %d", parts)
+	}
+
+	states, blockRef, err := s.getPoolStates(ctx, pools, block)
+	if err != nil {
+		return nil, err
+	}
+
+	reservesIn := make([]*big.Int, len(pools))
+	reservesOut := make([]*big.Int, len(pools))
+	fees := make([]uint32, len(pools))
+	taxes := make([]TransferTaxes, len(pools))
+	src, dst := common.HexToAddress(srcAddr), common.HexToAddress(dstAddr)
+	for i, pool := range pools {
+		reserveIn, reserveOut, err := orientReserves(states[i], srcAddr, dstAddr)
+		if err != nil {
+			return nil, fmt.Errorf("pool %s: %w", pool, err)
+		}
+		reservesIn[i], reservesOut[i] = reserveIn, reserveOut
+		fees[i] = s.fees.FeeBps(states[i])
+		if taxes[i], err = s.getTransferTaxes(taxContext(ctx, block, blockRef), src, states[i].Pool, dst, states[i].Pool); err != nil {
+			return nil, err
+		}
+	}
+
+	allocated := make([]*big.Int, len(pools))
+	outputs := make([]*big.Int, len(pools))
+	for i := range pools {
+		allocated[i] = big.NewInt(0)
+		outputs[i] = big.NewInt(0)
+	}
+
+	bigParts := big.NewInt(int64(parts))
+	for part := 0; part < parts; part++ {
+		// chunk = srcAmount*(part+1)/parts - srcAmount*part/parts, so that chunks sum up to srcAmount exactly
+		chunk := new(big.Int).Mul(srcAmount, big.NewInt(int64(part+1)))
+		chunk.Div(chunk, bigParts)
+		chunk.Sub(chunk, new(big.Int).Div(new(big.Int).Mul(srcAmount, big.NewInt(int64(part))), bigParts))
+		if chunk.Sign() == 0 {
+			continue
+		}
+
+		bestPool := -1
+		var bestOutput, bestGain *big.Int
+		for i := range pools {
+			// Every pool receives its allocation in one transfer, taxed as a whole
+			amountIn := taxes[i].SrcTax.AfterSell(new(big.Int).Add(allocated[i], chunk))
+			if checkReserveBounds(amountIn, reservesIn[i]) != nil {
+				continue
+			}
+			output := taxes[i].DstTax.AfterBuy(calculateOutputAmount(amountIn, reservesIn[i], reservesOut[i], fees[i]))
+			gain := new(big.Int).Sub(output, outputs[i])
+			if bestPool == -1 || gain.Cmp(bestGain) > 0 {
+				bestPool, bestOutput, bestGain = i, output, gain
+			}
+		}
+		if bestPool == -1 {
+			return nil, fmt.Errorf("%w: src_amount %s is too large for the pools", ErrReserveOverflow, srcAmount)
+		}
+
+		allocated[bestPool].Add(allocated[bestPool], chunk)
+		outputs[bestPool] = bestOutput
+	}
+
+	result := &SplitEstimate{
+		DstAmount:   big.NewInt(0),
+		Allocations: make([]SplitAllocation, len(pools)),
+		Block:       blockRef,
+	}
+	for i, pool := range pools {
+		result.Allocations[i] = SplitAllocation{
+			Pool:          common.HexToAddress(pool),
+			SrcAmount:     allocated[i],
+			DstAmount:     outputs[i],
+			FeeBps:        fees[i],
+			TransferTaxes: taxes[i],
+		}
+		result.DstAmount.Add(result.DstAmount, outputs[i])
+	}
+
+	return result, nil
+}
+
+// getPoolStates fetches tokens and reserves of the pools at block in a single batch
+func (s *Usecase) getPoolStates(ctx context.Context, poolAddrs []string, block string) ([]uniswap_v2.PoolState, *uniswap_v2.BlockRef, error) {
+	poolAddresses := make([]common.Address, len(poolAddrs))
+	for i, poolAddr := range poolAddrs {
+		poolAddresses[i] = common.HexToAddress(poolAddr)
+	}
+
+	return s.loadPoolStates(ctx, poolAddresses, block)
+}
+
+// loadPoolStates returns the state of the pools and the block it was read at.
+// Unpinned quotes are served from the live pool state when it tracks every pool, otherwise the pools are read over RPC.
+func (s *Usecase) loadPoolStates(ctx context.Context, poolAddresses []common.Address, block string) ([]uniswap_v2.PoolState, *uniswap_v2.BlockRef, error) {
+	if states, blockRef, ok := s.trackedPoolStates(poolAddresses, block); ok {
+		return states, blockRef, nil
+	}
+
+	ctx, blockRef, err := s.pinBlock(ctx, block)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	states, err := s.readPoolStates(ctx, poolAddresses)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return states, blockRef, nil
+}
+
+// trackedPoolStates returns the live state of the pools for unpinned quotes, ok is false when it is unavailable
+func (s *Usecase) trackedPoolStates(poolAddresses []common.Address, block string) ([]uniswap_v2.PoolState, *uniswap_v2.BlockRef, bool) {
+	if block != "" || s.poolStates == nil {
+		return nil, nil, false
+	}
+	return s.poolStates.PoolStates(poolAddresses)
+}
+
+// readPoolStates reads the state of the pools over RPC at the block pinned in ctx
+func (s *Usecase) readPoolStates(ctx context.Context, poolAddresses []common.Address) ([]uniswap_v2.PoolState, error) {
+	states, err := s.uniswapV2Client.GetPoolStates(ctx, poolAddresses)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool state: %w", err)
+	}
+	return states, nil
+}
+
+// taxContext pins transfer tax lookups to the block of an estimate pinned by the caller. Estimates at the latest
+// state, snapshots included, look up the latest taxes so that they stay cached across blocks.
+func taxContext(ctx context.Context, block string, blockRef *uniswap_v2.BlockRef) context.Context {
+	if block == "" || blockRef == nil {
+		return ctx
+	}
+	return uniswap_v2.WithBlockNumber(ctx, blockRef.Number)
+}
+
+// getTransferTaxes returns the tax of src sent into srcPool and of dst sent out of dstPool, zero without a detector
+func (s *Usecase) getTransferTaxes(ctx context.Context, src, srcPool, dst, dstPool common.Address) (TransferTaxes, error) {
+	if s.transferTaxes == nil {
+		return TransferTaxes{}, nil
 	}
 
-	// Calculate output amount using Uniswap V2 formula
-	outputAmount := s.calculateOutputAmount(srcAmount, reserveIn, reserveOut)
+	srcTax, err := s.transferTaxes.TransferTax(ctx, src, srcPool)
+	if err != nil {
+		return TransferTaxes{}, fmt.Errorf("failed to get transfer tax: %w", err)
+	}
+	dstTax, err := s.transferTaxes.TransferTax(ctx, dst, dstPool)
+	if err != nil {
+		return TransferTaxes{}, fmt.Errorf("failed to get transfer tax: %w", err)
+	}
+
+	return TransferTaxes{SrcTax: srcTax, DstTax: dstTax}, nil
+}
+
+// maxTaxLookups bounds the transfer tax lookups run at once, each of which may simulate transfers over RPC
+const maxTaxLookups = 8
+
+// taxKey is a token transferred into or out of a pool
+type taxKey struct {
+	token, pool common.Address
+}
+
+// pathTaxKeys returns the token sent into and the token sent out of every pool of a path
+func pathTaxKeys(pools, path []common.Address) []taxKey {
+	keys := make([]taxKey, 0, 2*len(pools))
+	for i, pool := range pools {
+		keys = append(keys, taxKey{path[i], pool}, taxKey{path[i+1], pool})
+	}
+	return keys
+}
+
+// resolveTransferTaxes looks up the tax of every distinct key concurrently. Without a detector it returns no taxes,
+// which leaves every key untaxed.
+func (s *Usecase) resolveTransferTaxes(ctx context.Context, keys []taxKey) (map[taxKey]transfertax.Tax, error) {
+	taxes := make(map[taxKey]transfertax.Tax, len(keys))
+	if s.transferTaxes == nil {
+		return taxes, nil
+	}
+
+	keys = slices.DeleteFunc(slices.Clone(keys), func(key taxKey) bool {
+		_, seen := taxes[key]
+		taxes[key] = transfertax.Tax{}
+		return seen
+	})
+	results := make([]transfertax.Tax, len(keys))
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(maxTaxLookups)
+	for i, key := range keys {
+		g.Go(func() error {
+			tax, err := s.transferTaxes.TransferTax(gctx, key.token, key.pool)
+			if err != nil {
+				return fmt.Errorf("failed to get transfer tax: %w", err)
+			}
+			results[i] = tax
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	for i, key := range keys {
+		taxes[key] = results[i]
+	}
 
-	return outputAmount, nil
+	return taxes, nil
 }
 
-// calculateOutputAmount implements the Uniswap V2 swap formula
-// amountOut = (amountIn * 997 * reserveOut) / (reserveIn * 1000 + amountIn * 997)
-// This accounts for the 0.3% fee (997/1000 = 0.997)
-func (s *Usecase) calculateOutputAmount(amountIn, reserveIn, reserveOut *big.Int) *big.Int {
+// getPathTaxes resolves the taxes of a path, see pathTaxes
+func (s *Usecase) getPathTaxes(ctx context.Context, pools, path []common.Address) (in, out []transfertax.Tax, taxes TransferTaxes, err error) {
+	resolved, err := s.resolveTransferTaxes(ctx, pathTaxKeys(pools, path))
+	if err != nil {
+		return nil, nil, TransferTaxes{}, err
+	}
+	in, out, taxes = pathTaxes(resolved, pools, path)
+	return in, out, taxes, nil
+}
+
+// pathTaxes returns the tax of the token sent into and of the token sent out of every pool of a path, where
+// pools[i] swaps path[i] into path[i+1]. Tokens passed between two pools are taxed leaving one and entering the next.
+func pathTaxes(resolved map[taxKey]transfertax.Tax, pools, path []common.Address) (in, out []transfertax.Tax, taxes TransferTaxes) {
+	in = make([]transfertax.Tax, len(pools))
+	out = make([]transfertax.Tax, len(pools))
+	for i, pool := range pools {
+		in[i], out[i] = resolved[taxKey{path[i], pool}], resolved[taxKey{path[i+1], pool}]
+		if (i > 0 && in[i].SellBps != 0) || (i < len(pools)-1 && out[i].BuyBps != 0) {
+			taxes.PathTaxed = true
+		}
+	}
+	taxes.SrcTax, taxes.DstTax = in[0], out[len(pools)-1]
+
+	return in, out, taxes
+}
+
+// orientReserves returns the pool reserves oriented in the src -> dst swap direction
+func orientReserves(state uniswap_v2.PoolState, srcAddr, dstAddr string) (*big.Int, *big.Int, error) {
+	srcAddress := common.HexToAddress(srcAddr)
+	dstAddress := common.HexToAddress(dstAddr)
+
+	// Determine which token is which and get the appropriate reserves
+	if srcAddress == state.Token0 && dstAddress == state.Token1 {
+		return state.Reserve0, state.Reserve1, nil
+	} else if srcAddress == state.Token1 && dstAddress == state.Token0 {
+		return state.Reserve1, state.Reserve0, nil
+	}
+
+	return nil, nil, fmt.Errorf("token pair mismatch: src=%s, dst=%s, token0=%s, token1=%s",
+		srcAddr, dstAddr, state.Token0.Hex(), state.Token1.Hex())
+}
+
+// calculateOutputAmount implements the Uniswap V2 swap formula generalized to a fee of feeBps basis points
+// amountOut = (amountIn * (10000 - feeBps) * reserveOut) / (reserveIn * 10000 + amountIn * (10000 - feeBps))
+// With the default 30 bps this is Uniswap's 997/1000 formula
+func calculateOutputAmount(amountIn, reserveIn, reserveOut *big.Int, feeBps uint32) *big.Int {
 	if amountIn.Cmp(big.NewInt(0)) <= 0 {
 		return big.NewInt(0)
 	}
@@ -80,14 +842,14 @@ func (s *Usecase) calculateOutputAmount(amountIn, reserveIn, reserveOut *big.Int
 		return big.NewInt(0)
 	}
 
-	// amountInWithFee = amountIn * 997
-	amountInWithFee := new(big.Int).Mul(amountIn, big.NewInt(997))
+	// amountInWithFee = amountIn * (10000 - feeBps)
+	amountInWithFee := new(big.Int).Mul(amountIn, big.NewInt(int64(FeeDenominator-feeBps)))
 
 	// numerator = amountInWithFee * reserveOut
 	numerator := new(big.Int).Mul(amountInWithFee, reserveOut)
 
-	// denominator = reserveIn * 1000 + amountInWithFee
-	denominator := new(big.Int).Mul(reserveIn, big.NewInt(1000))
+	// denominator = reserveIn * 10000 + amountInWithFee
+	denominator := new(big.Int).Mul(reserveIn, big.NewInt(FeeDenominator))
 	denominator.Add(denominator, amountInWithFee)
 
 	// amountOut = numerator / denominator
@@ -95,3 +857,44 @@ func (s *Usecase) calculateOutputAmount(amountIn, reserveIn, reserveOut *big.Int
 
 	return amountOut
 }
+
+// maxUint112 is the largest reserve a Uniswap V2 pair can hold
+var maxUint112 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 112), big.NewInt(1))
+
+// checkReserveBounds returns ErrReserveOverflow when amountIn on top of reserveIn exceeds uint112,
+// where the pair's _update reverts with UniswapV2: OVERFLOW
+func checkReserveBounds(amountIn, reserveIn *big.Int) error {
+	if new(big.Int).Add(reserveIn, amountIn).Cmp(maxUint112) > 0 {
+		return fmt.Errorf("%w: %s added to reserve %s exceeds %s", ErrReserveOverflow, amountIn, reserveIn, maxUint112)
+	}
+	return nil
+}
+
+// calculateInputAmount implements the inverse Uniswap V2 swap formula (getAmountIn) for a fee of feeBps basis points
+// amountIn = (reserveIn * amountOut * 10000) / ((reserveOut - amountOut) * (10000 - feeBps)) + 1
+// The result is rounded up so that swapping amountIn yields at least amountOut
+func calculateInputAmount(amountOut, reserveIn, reserveOut *big.Int, feeBps uint32) (*big.Int, error) {
+	if amountOut.Cmp(big.NewInt(0)) <= 0 {
+		return big.NewInt(0), nil
+	}
+	if reserveIn.Cmp(big.NewInt(0)) <= 0 || reserveOut.Cmp(big.NewInt(0)) <= 0 {
+		return nil, ErrInsufficientLiquidity
+	}
+	if amountOut.Cmp(reserveOut) >= 0 {
+		return nil, fmt.Errorf("%w: requested %s, reserve %s", ErrInsufficientLiquidity, amountOut, reserveOut)
+	}
+
+	// numerator = reserveIn * amountOut * 10000
+	numerator := new(big.Int).Mul(reserveIn, amountOut)
+	numerator.Mul(numerator, big.NewInt(FeeDenominator))
+
+	// denominator = (reserveOut - amountOut) * (10000 - feeBps)
+	denominator := new(big.Int).Sub(reserveOut, amountOut)
+	denominator.Mul(denominator, big.NewInt(int64(FeeDenominator-feeBps)))
+
+	// amountIn = numerator / denominator + 1
+	amountIn := new(big.Int).Div(numerator, denominator)
+	amountIn.Add(amountIn, big.NewInt(1))
+
+	return amountIn, nil
+}
@@ -1,12 +1,145 @@
 package usecase
 
 import (
+	"1inch_testtask/internal/routing"
+	"1inch_testtask/internal/transfertax"
+	"1inch_testtask/internal/uniswap_v2"
+	"context"
+	"fmt"
 	"math/big"
+	"sync"
 	"testing"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+// mockPool describes the on-chain state of a Uniswap V2 pair for mockUniswapV2
+type mockPool struct {
+	token0, token1     common.Address
+	factory            common.Address
+	reserve0, reserve1 *big.Int
+}
+
+// mockUniswapV2 is an in-memory uniswap_v2.IUniswapV2 implementation
+type mockUniswapV2 struct {
+	pools map[common.Address]mockPool
+	// batches counts GetPoolStates calls
+	batches int
+	// blocks records the block number each GetPoolStates call was pinned to
+	blocks []*big.Int
+}
+
+func (m *mockUniswapV2) GetReserves(_ context.Context, poolAddress common.Address) (*big.Int, *big.Int, error) {
+	pool, ok := m.pools[poolAddress]
+	if !ok {
+		return nil, nil, fmt.Errorf("no contract code at %s", poolAddress.Hex())
+	}
+	return pool.reserve0, pool.reserve1, nil
+}
+
+func (m *mockUniswapV2) GetToken0(_ context.Context, poolAddress common.Address) (common.Address, error) {
+	pool, ok := m.pools[poolAddress]
+	if !ok {
+		return common.Address{}, fmt.Errorf("no contract code at %s", poolAddress.Hex())
+	}
+	return pool.token0, nil
+}
+
+func (m *mockUniswapV2) GetToken1(_ context.Context, poolAddress common.Address) (common.Address, error) {
+	pool, ok := m.pools[poolAddress]
+	if !ok {
+		return common.Address{}, fmt.Errorf("no contract code at %s", poolAddress.Hex())
+	}
+	return pool.token1, nil
+}
+
+func (m *mockUniswapV2) GetPoolStates(ctx context.Context, poolAddresses []common.Address) ([]uniswap_v2.PoolState, error) {
+	m.batches++
+	m.blocks = append(m.blocks, uniswap_v2.BlockNumberFromContext(ctx))
+	states := make([]uniswap_v2.PoolState, len(poolAddresses))
+	for i, poolAddress := range poolAddresses {
+		pool, ok := m.pools[poolAddress]
+		if !ok {
+			return nil, fmt.Errorf("no contract code at %s", poolAddress.Hex())
+		}
+		states[i] = uniswap_v2.PoolState{
+			Pool:     poolAddress,
+			Token0:   pool.token0,
+			Token1:   pool.token1,
+			Factory:  pool.factory,
+			Reserve0: pool.reserve0,
+			Reserve1: pool.reserve1,
+			// Reserves of the mock pools were last updated at a fixed time
+			BlockTimestampLast: 1700000000,
+		}
+	}
+	return states, nil
+}
+
+func (m *mockUniswapV2) GetReservesBatch(_ context.Context, poolAddresses []common.Address) ([]uniswap_v2.Reserves, error) {
+	reserves := make([]uniswap_v2.Reserves, len(poolAddresses))
+	for i, poolAddress := range poolAddresses {
+		pool, ok := m.pools[poolAddress]
+		if !ok {
+			return nil, fmt.Errorf("no contract code at %s", poolAddress.Hex())
+		}
+		reserves[i] = uniswap_v2.Reserves{Reserve0: pool.reserve0, Reserve1: pool.reserve1}
+	}
+	return reserves, nil
+}
+
+func (m *mockUniswapV2) Close() {}
+
+var (
+	usdt     = common.HexToAddress("0xdAC17F958D2ee523a2206206994597C13D831ec7")
+	weth     = common.HexToAddress("0xc02aaa39b223fe8d0a0e5c4f27ead9083c756cc2")
+	dai      = common.HexToAddress("0x6B175474E89094C44Da98b954EedeAC495271d0F")
+	usdtWeth = common.HexToAddress("0x0d4a11d5eeaac28ec3f61d100daf4d40471f1852")
+	daiWeth  = common.HexToAddress("0xa478c2975ab1ea89e8196811f51a7b7ade33eb11")
+	usdtDai  = common.HexToAddress("0xb20bd5d04be54f870d5c0d3ca85d82b34b836405")
+	// sushiUsdtWeth is a SushiSwap fork pool of the same USDT/WETH pair
+	sushiUsdtWeth = common.HexToAddress("0x06da0fd433c1a5d7a4faa01111c044910a184553")
+
+	uniswapFactory = common.HexToAddress("0x5C69bEe701ef814a2B6a3EDD4B1652CB9cc5aA6f")
+	sushiFactory   = common.HexToAddress("0xC0AEe478e3658e2610c5F7A4A2E1777cE9e4f2Ac")
+)
+
+// newMockUniswapV2 returns a mock with USDT/WETH (on two forks), DAI/WETH and a shallow USDT/DAI pool
+func newMockUniswapV2() *mockUniswapV2 {
+	return &mockUniswapV2{pools: map[common.Address]mockPool{
+		usdtWeth: {
+			token0:   weth,
+			token1:   usdt,
+			factory:  uniswapFactory,
+			reserve0: mustBigInt("500000000000000000000"), // 500 ETH
+			reserve1: big.NewInt(1000000000000),           // 1M USDT
+		},
+		daiWeth: {
+			token0:   dai,
+			token1:   weth,
+			factory:  uniswapFactory,
+			reserve0: mustBigInt("2000000000000000000000000"), // 2M DAI
+			reserve1: mustBigInt("1000000000000000000000"),    // 1000 ETH
+		},
+		usdtDai: {
+			token0:   dai,
+			token1:   usdt,
+			factory:  uniswapFactory,
+			reserve0: mustBigInt("1000000000000000000000"), // 1000 DAI
+			reserve1: big.NewInt(1000000000),               // 1000 USDT
+		},
+		sushiUsdtWeth: {
+			token0:   weth,
+			token1:   usdt,
+			factory:  sushiFactory,
+			reserve0: mustBigInt("250000000000000000000"), // 250 ETH
+			reserve1: big.NewInt(500000000000),            // 500k USDT
+		},
+	}}
+}
+
 // Helper function to create big.Int from string
 func mustBigInt(s string) *big.Int {
 	val, ok := new(big.Int).SetString(s, 10)
@@ -17,8 +150,6 @@ func mustBigInt(s string) *big.Int {
 }
 
 func TestService_calculateOutputAmount(t *testing.T) {
-	service := &Usecase{}
-
 	tests := []struct {
 		name        string
 		amountIn    *big.Int
@@ -79,7 +210,7 @@ func TestService_calculateOutputAmount(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := service.calculateOutputAmount(tt.amountIn, tt.reserveIn, tt.reserveOut)
+			result := calculateOutputAmount(tt.amountIn, tt.reserveIn, tt.reserveOut, DefaultFeeBps)
 			assert.Equal(t, tt.expected, result, tt.description)
 		})
 	}
@@ -87,15 +218,13 @@ func TestService_calculateOutputAmount(t *testing.T) {
 
 // TestUniswapV2Formula verifies the Uniswap V2 formula implementation
 func TestUniswapV2Formula(t *testing.T) {
-	service := &Usecase{}
-
 	// Test case based on real Uniswap V2 pair data
 	// This test verifies that our formula matches the expected Uniswap V2 calculation
 	amountIn := big.NewInt(1000000)                     // 1 USDT (6 decimals)
 	reserveIn := big.NewInt(50000000000000)             // 50M USDT reserve
 	reserveOut := mustBigInt("20000000000000000000000") // 20k ETH reserve
 
-	result := service.calculateOutputAmount(amountIn, reserveIn, reserveOut)
+	result := calculateOutputAmount(amountIn, reserveIn, reserveOut, DefaultFeeBps)
 
 	// Manual calculation:
 	// amountInWithFee = 1000000 * 0.97 = 997000
@@ -106,3 +235,682 @@ func TestUniswapV2Formula(t *testing.T) {
 	expected := mustBigInt("398799992047928")
 	assert.Equal(t, expected, result, "Uniswap V2 formula calculation should match expected result")
 }
+
+func TestService_calculateInputAmount(t *testing.T) {
+	tests := []struct {
+		name        string
+		amountOut   *big.Int
+		reserveIn   *big.Int
+		reserveOut  *big.Int
+		expected    *big.Int
+		wantErr     error
+		description string
+	}{
+		{
+			name:        "basic calculation",
+			amountOut:   mustBigInt("498499502995995"),       // ~0.498 ETH
+			reserveIn:   big.NewInt(1000000000000),           // 1M USDT
+			reserveOut:  mustBigInt("500000000000000000000"), // 500 ETH
+			expected:    big.NewInt(1000000),                 // 1 USDT
+			description: "~0.498 ETH out should require 1 USDT in",
+		},
+		{
+			name:        "large amount calculation",
+			amountOut:   mustBigInt("49357901719853064942"),
+			reserveIn:   big.NewInt(10000000000000),
+			reserveOut:  mustBigInt("5000000000000000000000"),
+			expected:    big.NewInt(100000000000),
+			description: "Large amount reverse calculation",
+		},
+		{
+			name:        "small amount calculation",
+			amountOut:   big.NewInt(1),                     // 1 unit of USDT
+			reserveIn:   mustBigInt("1000000000000000000"), // 1 ETH
+			reserveOut:  big.NewInt(2000000000),            // 2000 USDT
+			expected:    big.NewInt(501504514),             // Rounded up
+			description: "Very small amount reverse swap",
+		},
+		{
+			name:        "zero amount out",
+			amountOut:   big.NewInt(0),
+			reserveIn:   big.NewInt(1000000000000),
+			reserveOut:  mustBigInt("500000000000000000000"),
+			expected:    big.NewInt(0),
+			description: "Zero output should require zero input",
+		},
+		{
+			name:        "amount out equals reserve out",
+			amountOut:   mustBigInt("500000000000000000000"),
+			reserveIn:   big.NewInt(1000000000000),
+			reserveOut:  mustBigInt("500000000000000000000"),
+			wantErr:     ErrInsufficientLiquidity,
+			description: "Draining the whole reserve is impossible",
+		},
+		{
+			name:        "amount out exceeds reserve out",
+			amountOut:   mustBigInt("600000000000000000000"),
+			reserveIn:   big.NewInt(1000000000000),
+			reserveOut:  mustBigInt("500000000000000000000"),
+			wantErr:     ErrInsufficientLiquidity,
+			description: "Output above reserve should fail",
+		},
+		{
+			name:        "zero reserve in",
+			amountOut:   big.NewInt(1000000),
+			reserveIn:   big.NewInt(0),
+			reserveOut:  mustBigInt("500000000000000000000"),
+			wantErr:     ErrInsufficientLiquidity,
+			description: "Empty pool should fail",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := calculateInputAmount(tt.amountOut, tt.reserveIn, tt.reserveOut, DefaultFeeBps)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr, tt.description)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result, tt.description)
+		})
+	}
+}
+
+// TestInputOutputRoundTrip verifies that swapping the calculated input yields at least the requested output
+func TestInputOutputRoundTrip(t *testing.T) {
+	reserveIn := big.NewInt(50000000000000)             // 50M USDT reserve
+	reserveOut := mustBigInt("20000000000000000000000") // 20k ETH reserve
+
+	for _, amountOut := range []*big.Int{
+		big.NewInt(1),
+		mustBigInt("398799992047928"),
+		mustBigInt("1000000000000000000"),
+		mustBigInt("19999000000000000000000"),
+	} {
+		amountIn, err := calculateInputAmount(amountOut, reserveIn, reserveOut, DefaultFeeBps)
+		assert.NoError(t, err)
+
+		result := calculateOutputAmount(amountIn, reserveIn, reserveOut, DefaultFeeBps)
+		assert.GreaterOrEqual(t, result.Cmp(amountOut), 0, "output for %s should be at least %s", amountIn, amountOut)
+
+		// One unit less must not be enough
+		result = calculateOutputAmount(new(big.Int).Sub(amountIn, big.NewInt(1)), reserveIn, reserveOut, DefaultFeeBps)
+		assert.Less(t, result.Cmp(amountOut), 0, "output for %s-1 should be less than %s", amountIn, amountOut)
+	}
+}
+
+func TestService_EstimatePath(t *testing.T) {
+	service := NewUsecase(newMockUniswapV2())
+	ctx := context.Background()
+
+	t.Run("two hops", func(t *testing.T) {
+		client := newMockUniswapV2()
+		service := NewUsecase(client)
+
+		estimate, err := service.EstimatePath(ctx,
+			[]string{usdtWeth.Hex(), daiWeth.Hex()},
+			[]string{usdt.Hex(), weth.Hex(), dai.Hex()},
+			"1000000",
+			"",
+		)
+		require.NoError(t, err)
+		amounts := estimate.Amounts
+		require.Len(t, amounts, 3)
+
+		wethOut := calculateOutputAmount(big.NewInt(1000000), big.NewInt(1000000000000), mustBigInt("500000000000000000000"), DefaultFeeBps)
+		daiOut := calculateOutputAmount(wethOut, mustBigInt("1000000000000000000000"), mustBigInt("2000000000000000000000000"), DefaultFeeBps)
+		assert.Equal(t, big.NewInt(1000000), amounts[0])
+		assert.Equal(t, wethOut, amounts[1])
+		assert.Equal(t, daiOut, amounts[2])
+		assert.Equal(t, 1, client.batches, "all hops should be fetched in one batch")
+	})
+
+	t.Run("single hop matches EstimateSwap", func(t *testing.T) {
+		estimate, err := service.EstimatePath(ctx,
+			[]string{usdtWeth.Hex()},
+			[]string{usdt.Hex(), weth.Hex()},
+			"1000000",
+			"",
+		)
+		require.NoError(t, err)
+
+		expected, err := service.EstimateSwap(ctx, usdtWeth.Hex(), usdt.Hex(), weth.Hex(), "1000000", "")
+		require.NoError(t, err)
+		assert.Equal(t, expected.DstAmount, estimate.Amounts[1])
+	})
+
+	t.Run("mismatched hop is reported", func(t *testing.T) {
+		_, err := service.EstimatePath(ctx,
+			[]string{usdtWeth.Hex(), usdtWeth.Hex()},
+			[]string{usdt.Hex(), weth.Hex(), dai.Hex()},
+			"1000000",
+			"",
+		)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "hop 1")
+		assert.Contains(t, err.Error(), "token pair mismatch")
+	})
+
+	t.Run("path length mismatch", func(t *testing.T) {
+		_, err := service.EstimatePath(ctx,
+			[]string{usdtWeth.Hex()},
+			[]string{usdt.Hex(), weth.Hex(), dai.Hex()},
+			"1000000",
+			"",
+		)
+		assert.Error(t, err)
+	})
+}
+
+func TestService_FindBestRoute(t *testing.T) {
+	client := newMockUniswapV2()
+	ctx := context.Background()
+
+	var pairs []routing.Pair
+	for _, pool := range []common.Address{usdtWeth, daiWeth, usdtDai} {
+		pairs = append(pairs, routing.Pair{Pool: pool, Token0: client.pools[pool].token0, Token1: client.pools[pool].token1})
+	}
+	service := NewUsecase(client, WithRouter(routing.NewGraph(pairs), RoutingConfig{MaxHops: 3, MaxCandidates: 10}))
+
+	t.Run("small amount prefers direct pool", func(t *testing.T) {
+		route, err := service.FindBestRoute(ctx, usdt.Hex(), dai.Hex(), "1000000", "")
+		require.NoError(t, err)
+		assert.Equal(t, []common.Address{usdtDai}, route.Pools)
+
+		expected, err := service.EstimateSwap(ctx, usdtDai.Hex(), usdt.Hex(), dai.Hex(), "1000000", "")
+		require.NoError(t, err)
+		assert.Equal(t, expected.DstAmount, route.DstAmount())
+		assert.Equal(t, expected.PriceInfo, route.PriceInfo)
+	})
+
+	t.Run("large amount prefers deep two hop route", func(t *testing.T) {
+		route, err := service.FindBestRoute(ctx, usdt.Hex(), dai.Hex(), "10000000000", "")
+		require.NoError(t, err)
+		assert.Equal(t, []common.Address{usdtWeth, daiWeth}, route.Pools)
+		assert.Equal(t, []common.Address{usdt, weth, dai}, route.Path)
+
+		estimate, err := service.EstimatePath(ctx,
+			[]string{usdtWeth.Hex(), daiWeth.Hex()},
+			[]string{usdt.Hex(), weth.Hex(), dai.Hex()},
+			"10000000000",
+			"",
+		)
+		require.NoError(t, err)
+		assert.Equal(t, estimate.Amounts, route.Amounts)
+		// The route spot price is the product of 500M wei per USDT unit and 2000 DAI per ETH
+		assert.Equal(t, "1000000000000/1", route.SpotPrice.String())
+		assert.Equal(t, 1, route.PriceImpactBps.Cmp(big.NewRat(60, 1)), "impact includes the fee of both hops")
+	})
+
+	t.Run("no route", func(t *testing.T) {
+		_, err := service.FindBestRoute(ctx, usdt.Hex(), common.HexToAddress("0x01").Hex(), "1000000", "")
+		assert.ErrorIs(t, err, ErrNoRoute)
+	})
+
+	t.Run("routing disabled", func(t *testing.T) {
+		_, err := NewUsecase(client).FindBestRoute(ctx, usdt.Hex(), dai.Hex(), "1000000", "")
+		assert.ErrorIs(t, err, ErrRoutingDisabled)
+	})
+}
+
+func TestService_EstimateSplit(t *testing.T) {
+	service := NewUsecase(newMockUniswapV2())
+	ctx := context.Background()
+	pools := []string{usdtWeth.Hex(), sushiUsdtWeth.Hex()}
+
+	t.Run("split beats every single pool", func(t *testing.T) {
+		srcAmount := "100000000000" // 100k USDT
+		split, err := service.EstimateSplit(ctx, pools, usdt.Hex(), weth.Hex(), srcAmount, 20, "")
+		require.NoError(t, err)
+		require.Len(t, split.Allocations, 2)
+
+		total := new(big.Int).Add(split.Allocations[0].SrcAmount, split.Allocations[1].SrcAmount)
+		assert.Equal(t, mustBigInt(srcAmount), total)
+
+		for _, pool := range pools {
+			single, err := service.EstimateSwap(ctx, pool, usdt.Hex(), weth.Hex(), srcAmount, "")
+			require.NoError(t, err)
+			assert.Greater(t, split.DstAmount.Cmp(single.DstAmount), 0, "split should beat pool %s", pool)
+		}
+
+		// Pool with twice the liquidity should get two thirds of the order
+		assert.Equal(t, mustBigInt("65000000000"), split.Allocations[0].SrcAmount)
+		assert.Equal(t, mustBigInt("35000000000"), split.Allocations[1].SrcAmount)
+	})
+
+	t.Run("allocation outputs add up", func(t *testing.T) {
+		split, err := service.EstimateSplit(ctx, pools, usdt.Hex(), weth.Hex(), "123456789", 7, "")
+		require.NoError(t, err)
+
+		totalIn, totalOut := big.NewInt(0), big.NewInt(0)
+		for _, allocation := range split.Allocations {
+			out, err := service.EstimateSwap(ctx, allocation.Pool.Hex(), usdt.Hex(), weth.Hex(), allocation.SrcAmount.String(), "")
+			require.NoError(t, err)
+			assert.Equal(t, out.DstAmount, allocation.DstAmount)
+			totalIn.Add(totalIn, allocation.SrcAmount)
+			totalOut.Add(totalOut, allocation.DstAmount)
+		}
+		assert.Equal(t, big.NewInt(123456789), totalIn)
+		assert.Equal(t, split.DstAmount, totalOut)
+	})
+
+	t.Run("single part uses best pool", func(t *testing.T) {
+		split, err := service.EstimateSplit(ctx, pools, usdt.Hex(), weth.Hex(), "1000000", 1, "")
+		require.NoError(t, err)
+		assert.Equal(t, big.NewInt(1000000), split.Allocations[0].SrcAmount)
+		assert.Equal(t, 0, split.Allocations[1].SrcAmount.Sign())
+	})
+
+	t.Run("pool of another pair", func(t *testing.T) {
+		_, err := service.EstimateSplit(ctx, []string{usdtWeth.Hex(), daiWeth.Hex()}, usdt.Hex(), weth.Hex(), "1000000", 10, "")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "token pair mismatch")
+	})
+}
+
+// mockBlockResolver resolves every block to a fixed block
+type mockBlockResolver struct {
+	block *uniswap_v2.BlockRef
+	// calls counts ResolveBlock calls
+	calls int
+}
+
+func (m *mockBlockResolver) ResolveBlock(_ context.Context, block string) (*uniswap_v2.BlockRef, error) {
+	m.calls++
+	if block == "0x404" {
+		return nil, uniswap_v2.ErrBlockNotFound
+	}
+	return m.block, nil
+}
+
+func TestService_EstimateSwapAtBlock(t *testing.T) {
+	ctx := context.Background()
+	block := &uniswap_v2.BlockRef{
+		Number: big.NewInt(18500000),
+		Hash:   common.HexToHash("0x9e3d4a1b6c0f4b0b1c3e2f5d8a7b6c5d4e3f2a1b0c9d8e7f6a5b4c3d2e1f0a9b"),
+	}
+
+	t.Run("latest", func(t *testing.T) {
+		client := newMockUniswapV2()
+		service := NewUsecase(client, WithBlockResolver(&mockBlockResolver{block: block}))
+
+		estimate, err := service.EstimateSwap(ctx, usdtWeth.Hex(), usdt.Hex(), weth.Hex(), "1000000", "")
+		require.NoError(t, err)
+		assert.Nil(t, estimate.Block)
+		assert.Equal(t, uint32(1700000000), estimate.BlockTimestampLast)
+		assert.Equal(t, []*big.Int{nil}, client.blocks)
+	})
+
+	t.Run("pinned", func(t *testing.T) {
+		client := newMockUniswapV2()
+		service := NewUsecase(client, WithBlockResolver(&mockBlockResolver{block: block}))
+
+		estimate, err := service.EstimateSwap(ctx, usdtWeth.Hex(), usdt.Hex(), weth.Hex(), "1000000", "finalized")
+		require.NoError(t, err)
+		assert.Equal(t, block, estimate.Block)
+		assert.Equal(t, []*big.Int{big.NewInt(18500000)}, client.blocks, "pool calls should be pinned to the resolved block")
+	})
+
+	t.Run("unknown block", func(t *testing.T) {
+		service := NewUsecase(newMockUniswapV2(), WithBlockResolver(&mockBlockResolver{block: block}))
+
+		_, err := service.EstimateSwap(ctx, usdtWeth.Hex(), usdt.Hex(), weth.Hex(), "1000000", "0x404")
+		assert.ErrorIs(t, err, uniswap_v2.ErrBlockNotFound)
+	})
+
+	t.Run("pinning disabled", func(t *testing.T) {
+		service := NewUsecase(newMockUniswapV2())
+
+		_, err := service.EstimateSwap(ctx, usdtWeth.Hex(), usdt.Hex(), weth.Hex(), "1000000", "latest")
+		assert.ErrorIs(t, err, ErrBlockPinningDisabled)
+	})
+}
+
+func TestService_Snapshots(t *testing.T) {
+	ctx := context.Background()
+	block := &uniswap_v2.BlockRef{
+		Number: big.NewInt(18500000),
+		Hash:   common.HexToHash("0x9e3d4a1b6c0f4b0b1c3e2f5d8a7b6c5d4e3f2a1b0c9d8e7f6a5b4c3d2e1f0a9b"),
+	}
+	client := newMockUniswapV2()
+	service := NewUsecase(client, WithBlockResolver(&mockBlockResolver{block: block}), WithSnapshots())
+
+	estimate, err := service.EstimateSwap(ctx, usdtWeth.Hex(), usdt.Hex(), weth.Hex(), "1000000", "")
+	require.NoError(t, err)
+	assert.Equal(t, block, estimate.Block)
+
+	estimateIn, err := service.EstimateSwapIn(ctx, usdtWeth.Hex(), usdt.Hex(), weth.Hex(), "1000000", "")
+	require.NoError(t, err)
+	assert.Equal(t, block, estimateIn.Block)
+
+	path, err := service.EstimatePath(ctx,
+		[]string{usdtWeth.Hex(), daiWeth.Hex()},
+		[]string{usdt.Hex(), weth.Hex(), dai.Hex()},
+		"1000000",
+		"",
+	)
+	require.NoError(t, err)
+	assert.Equal(t, block, path.Block)
+
+	split, err := service.EstimateSplit(ctx, []string{usdtWeth.Hex(), sushiUsdtWeth.Hex()}, usdt.Hex(), weth.Hex(), "1000000", 10, "")
+	require.NoError(t, err)
+	assert.Equal(t, block, split.Block)
+
+	for _, number := range client.blocks {
+		assert.Equal(t, block.Number, number, "every pool call should be pinned to the snapshot block")
+	}
+	assert.Len(t, client.blocks, 4)
+}
+
+// mockPoolStateSource serves the states of the tracked pools of a mock client at a fixed block
+type mockPoolStateSource struct {
+	client  *mockUniswapV2
+	tracked map[common.Address]bool
+	block   *uniswap_v2.BlockRef
+}
+
+func (m *mockPoolStateSource) PoolStates(poolAddresses []common.Address) ([]uniswap_v2.PoolState, *uniswap_v2.BlockRef, bool) {
+	for _, poolAddress := range poolAddresses {
+		if !m.tracked[poolAddress] {
+			return nil, nil, false
+		}
+	}
+	states, err := m.client.GetPoolStates(context.Background(), poolAddresses)
+	if err != nil {
+		return nil, nil, false
+	}
+	return states, m.block, true
+}
+
+func TestService_PoolStateSource(t *testing.T) {
+	ctx := context.Background()
+	head := &uniswap_v2.BlockRef{Number: big.NewInt(18500001)}
+	block := &uniswap_v2.BlockRef{Number: big.NewInt(18500000)}
+
+	newService := func() (*Usecase, *mockUniswapV2) {
+		client := newMockUniswapV2()
+		source := &mockPoolStateSource{
+			client:  newMockUniswapV2(),
+			tracked: map[common.Address]bool{usdtWeth: true, daiWeth: true},
+			block:   head,
+		}
+		return NewUsecase(client, WithBlockResolver(&mockBlockResolver{block: block}), WithPoolStateSource(source)), client
+	}
+
+	t.Run("tracked pools are served without RPC", func(t *testing.T) {
+		service, client := newService()
+
+		estimate, err := service.EstimatePath(ctx,
+			[]string{usdtWeth.Hex(), daiWeth.Hex()},
+			[]string{usdt.Hex(), weth.Hex(), dai.Hex()},
+			"1000000",
+			"",
+		)
+		require.NoError(t, err)
+		assert.Equal(t, head, estimate.Block)
+		assert.Zero(t, client.batches)
+	})
+
+	t.Run("tracked pools are served without RPC in snapshot mode", func(t *testing.T) {
+		client := newMockUniswapV2()
+		resolver := &mockBlockResolver{block: block}
+		detector := newMockPoolDetector()
+		source := &mockPoolStateSource{
+			client:  newMockUniswapV2(),
+			tracked: map[common.Address]bool{usdtWeth: true},
+			block:   head,
+		}
+		service := NewUsecase(client, WithBlockResolver(resolver), WithSnapshots(), WithPoolStateSource(source), WithPoolDetector(detector))
+
+		estimate, err := service.EstimateSwap(ctx, usdtWeth.Hex(), usdt.Hex(), weth.Hex(), "1000000", "")
+		require.NoError(t, err)
+		assert.Equal(t, head, estimate.Block)
+		assert.Zero(t, client.batches)
+		assert.Zero(t, resolver.calls)
+		assert.Empty(t, detector.detected)
+	})
+
+	t.Run("routes over tracked pools are quoted without RPC in snapshot mode", func(t *testing.T) {
+		client := newMockUniswapV2()
+		resolver := &mockBlockResolver{block: block}
+		source := &mockPoolStateSource{
+			client:  newMockUniswapV2(),
+			tracked: map[common.Address]bool{usdtWeth: true},
+			block:   head,
+		}
+		graph := routing.NewGraph([]routing.Pair{{Pool: usdtWeth, Token0: weth, Token1: usdt}})
+		service := NewUsecase(client, WithBlockResolver(resolver), WithSnapshots(), WithPoolStateSource(source),
+			WithRouter(graph, RoutingConfig{MaxHops: 3, MaxCandidates: 10}))
+
+		route, err := service.FindBestRoute(ctx, usdt.Hex(), weth.Hex(), "1000000", "")
+		require.NoError(t, err)
+		assert.Equal(t, head, route.Block)
+		assert.Zero(t, client.batches)
+		assert.Zero(t, resolver.calls)
+	})
+
+	t.Run("untracked pool falls back to RPC", func(t *testing.T) {
+		service, client := newService()
+
+		estimate, err := service.EstimateSwap(ctx, sushiUsdtWeth.Hex(), usdt.Hex(), weth.Hex(), "1000000", "")
+		require.NoError(t, err)
+		assert.Nil(t, estimate.Block)
+		assert.Equal(t, 1, client.batches)
+	})
+
+	t.Run("pinned block is read over RPC", func(t *testing.T) {
+		service, client := newService()
+
+		estimate, err := service.EstimateSwap(ctx, usdtWeth.Hex(), usdt.Hex(), weth.Hex(), "1000000", "finalized")
+		require.NoError(t, err)
+		assert.Equal(t, block, estimate.Block)
+		assert.Equal(t, []*big.Int{big.NewInt(18500000)}, client.blocks)
+	})
+}
+
+// mockTransferTaxes taxes tokens regardless of the pool
+type mockTransferTaxes map[common.Address]transfertax.Tax
+
+func (m mockTransferTaxes) TransferTax(_ context.Context, token, _ common.Address) (transfertax.Tax, error) {
+	return m[token], nil
+}
+
+// countingTransferTaxes records the token and pool pairs looked up
+type countingTransferTaxes struct {
+	mockTransferTaxes
+	mu      sync.Mutex
+	lookups map[taxKey]int
+}
+
+func (m *countingTransferTaxes) TransferTax(ctx context.Context, token, pool common.Address) (transfertax.Tax, error) {
+	m.mu.Lock()
+	m.lookups[taxKey{token, pool}]++
+	m.mu.Unlock()
+	return m.mockTransferTaxes.TransferTax(ctx, token, pool)
+}
+
+func TestService_TransferTaxes(t *testing.T) {
+	ctx := context.Background()
+	client := newMockUniswapV2()
+	taxes := mockTransferTaxes{usdt: {BuyBps: 300, SellBps: 500}}
+
+	plain := NewUsecase(client)
+	taxed := NewUsecase(client, WithTransferTaxes(taxes))
+
+	t.Run("sell tax reduces the pool input", func(t *testing.T) {
+		estimate, err := taxed.EstimateSwap(ctx, usdtWeth.Hex(), usdt.Hex(), weth.Hex(), "1000000", "")
+		require.NoError(t, err)
+
+		// The pool receives 95% of the 1 USDT sent
+		expected, err := plain.EstimateSwap(ctx, usdtWeth.Hex(), usdt.Hex(), weth.Hex(), "950000", "")
+		require.NoError(t, err)
+		assert.Equal(t, expected.DstAmount, estimate.DstAmount)
+		assert.Equal(t, TransferTaxes{SrcTax: taxes[usdt]}, estimate.TransferTaxes)
+		assert.True(t, estimate.Taxed())
+		assert.Equal(t, 1, estimate.PriceImpactBps.Cmp(big.NewRat(528, 1)), "price impact includes the tax")
+	})
+
+	t.Run("buy tax reduces the output", func(t *testing.T) {
+		estimate, err := taxed.EstimateSwap(ctx, usdtWeth.Hex(), weth.Hex(), usdt.Hex(), "1000000000000000000", "")
+		require.NoError(t, err)
+
+		expected, err := plain.EstimateSwap(ctx, usdtWeth.Hex(), weth.Hex(), usdt.Hex(), "1000000000000000000", "")
+		require.NoError(t, err)
+		assert.Equal(t, taxes[usdt].AfterBuy(expected.DstAmount), estimate.DstAmount)
+		assert.True(t, estimate.Taxed())
+	})
+
+	t.Run("untaxed tokens", func(t *testing.T) {
+		estimate, err := taxed.EstimateSwap(ctx, daiWeth.Hex(), dai.Hex(), weth.Hex(), "1000000000000000000", "")
+		require.NoError(t, err)
+		assert.False(t, estimate.Taxed())
+	})
+
+	t.Run("route", func(t *testing.T) {
+		var pairs []routing.Pair
+		for _, pool := range []common.Address{usdtWeth, daiWeth} {
+			pairs = append(pairs, routing.Pair{Pool: pool, Token0: client.pools[pool].token0, Token1: client.pools[pool].token1})
+		}
+		graph := routing.NewGraph(pairs)
+		cfg := RoutingConfig{MaxHops: 3, MaxCandidates: 10}
+
+		route, err := NewUsecase(client, WithRouter(graph, cfg), WithTransferTaxes(taxes)).FindBestRoute(ctx, dai.Hex(), usdt.Hex(), "1000000000000000000000", "")
+		require.NoError(t, err)
+		expected, err := NewUsecase(client, WithRouter(graph, cfg)).FindBestRoute(ctx, dai.Hex(), usdt.Hex(), "1000000000000000000000", "")
+		require.NoError(t, err)
+
+		assert.Equal(t, taxes[usdt].AfterBuy(expected.DstAmount()), route.DstAmount())
+		assert.Equal(t, TransferTaxes{DstTax: taxes[usdt]}, route.TransferTaxes)
+	})
+
+	t.Run("route taxes are looked up once across candidates", func(t *testing.T) {
+		var pairs []routing.Pair
+		for _, pool := range []common.Address{usdtWeth, sushiUsdtWeth, daiWeth, usdtDai} {
+			pairs = append(pairs, routing.Pair{Pool: pool, Token0: client.pools[pool].token0, Token1: client.pools[pool].token1})
+		}
+		cfg := RoutingConfig{MaxHops: 3, MaxCandidates: 10}
+		counting := &countingTransferTaxes{mockTransferTaxes: taxes, lookups: make(map[taxKey]int)}
+
+		_, err := NewUsecase(client, WithRouter(routing.NewGraph(pairs), cfg), WithTransferTaxes(counting)).
+			FindBestRoute(ctx, dai.Hex(), usdt.Hex(), "1000000000000000000000", "")
+		require.NoError(t, err)
+
+		// The routes through daiWeth share its lookups
+		assert.Len(t, counting.lookups, 8)
+		for key, n := range counting.lookups {
+			assert.Equal(t, 1, n, "%s in %s", key.token, key.pool)
+		}
+	})
+
+	t.Run("reverse estimate inverts the taxes", func(t *testing.T) {
+		// Receiving 1 WETH for taxed USDT
+		estimate, err := taxed.EstimateSwapIn(ctx, usdtWeth.Hex(), usdt.Hex(), weth.Hex(), "1000000000000000000", "")
+		require.NoError(t, err)
+		expected, err := plain.EstimateSwapIn(ctx, usdtWeth.Hex(), usdt.Hex(), weth.Hex(), "1000000000000000000", "")
+		require.NoError(t, err)
+		srcAmount, err := taxes[usdt].BeforeSell(expected.SrcAmount)
+		require.NoError(t, err)
+		assert.Equal(t, srcAmount, estimate.SrcAmount)
+		assert.True(t, estimate.Taxed())
+
+		// The taxed source amount is enough for the requested output
+		forward, err := taxed.EstimateSwap(ctx, usdtWeth.Hex(), usdt.Hex(), weth.Hex(), estimate.SrcAmount.String(), "")
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, forward.DstAmount.Cmp(mustBigInt("1000000000000000000")), 0)
+
+		// Receiving 1000 taxed USDT
+		estimate, err = taxed.EstimateSwapIn(ctx, usdtWeth.Hex(), weth.Hex(), usdt.Hex(), "1000000000", "")
+		require.NoError(t, err)
+		poolOut, err := taxes[usdt].BeforeBuy(big.NewInt(1000000000))
+		require.NoError(t, err)
+		expected, err = plain.EstimateSwapIn(ctx, usdtWeth.Hex(), weth.Hex(), usdt.Hex(), poolOut.String(), "")
+		require.NoError(t, err)
+		assert.Equal(t, expected.SrcAmount, estimate.SrcAmount)
+
+		_, err = NewUsecase(client, WithTransferTaxes(mockTransferTaxes{usdt: {BuyBps: transfertax.MaxBps}})).
+			EstimateSwapIn(ctx, usdtWeth.Hex(), weth.Hex(), usdt.Hex(), "1000000000", "")
+		assert.ErrorIs(t, err, transfertax.ErrWholeAmount)
+	})
+
+	t.Run("path taxes every hop", func(t *testing.T) {
+		wethTaxes := mockTransferTaxes{weth: {BuyBps: 100, SellBps: 200}}
+		pools := []string{usdtWeth.Hex(), daiWeth.Hex()}
+		path := []string{usdt.Hex(), weth.Hex(), dai.Hex()}
+
+		estimate, err := NewUsecase(client, WithTransferTaxes(wethTaxes)).EstimatePath(ctx, pools, path, "1000000000", "")
+		require.NoError(t, err)
+
+		// WETH is taxed leaving the first pool and again entering the second
+		first, err := plain.EstimatePath(ctx, pools[:1], path[:2], "1000000000", "")
+		require.NoError(t, err)
+		received := wethTaxes[weth].AfterBuy(first.Amounts[1])
+		second, err := plain.EstimatePath(ctx, pools[1:], path[1:], wethTaxes[weth].AfterSell(received).String(), "")
+		require.NoError(t, err)
+
+		assert.Equal(t, []*big.Int{big.NewInt(1000000000), received, second.Amounts[1]}, estimate.Amounts)
+		assert.Equal(t, TransferTaxes{PathTaxed: true}, estimate.TransferTaxes)
+		assert.True(t, estimate.Taxed())
+	})
+
+	t.Run("split taxes every allocation", func(t *testing.T) {
+		pools := []string{usdtWeth.Hex(), sushiUsdtWeth.Hex()}
+		split, err := taxed.EstimateSplit(ctx, pools, usdt.Hex(), weth.Hex(), "10000000000", 10, "")
+		require.NoError(t, err)
+
+		for _, allocation := range split.Allocations {
+			expected, err := plain.EstimatePath(ctx, []string{allocation.Pool.Hex()}, []string{usdt.Hex(), weth.Hex()},
+				taxes[usdt].AfterSell(allocation.SrcAmount).String(), "")
+			require.NoError(t, err)
+			assert.Equal(t, expected.Amounts[1], allocation.DstAmount)
+			assert.Equal(t, TransferTaxes{SrcTax: taxes[usdt]}, allocation.TransferTaxes)
+		}
+	})
+}
+
+func TestService_ReserveBounds(t *testing.T) {
+	ctx := context.Background()
+	service := NewUsecase(newMockUniswapV2(), WithPairFinders([]uniswap_v2.IPairFinder{newMockPairFinder(usdtWeth)}))
+
+	// The USDT reserve of usdtWeth is 1M USDT, 10^12 base units
+	const (
+		fits     = "5192296858534827628529496329220095"
+		overflow = "5192296858534827628529496329220096"
+	)
+
+	t.Run("amount above uint64", func(t *testing.T) {
+		estimate, err := service.EstimateSwap(ctx, usdtWeth.Hex(), usdt.Hex(), weth.Hex(), "100000000000000000000000", "")
+		require.NoError(t, err)
+		assert.Equal(t, calculateOutputAmount(mustBigInt("100000000000000000000000"), big.NewInt(1000000000000), mustBigInt("500000000000000000000"), DefaultFeeBps), estimate.DstAmount)
+	})
+
+	t.Run("reserve reaching uint112", func(t *testing.T) {
+		_, err := service.EstimateSwap(ctx, usdtWeth.Hex(), usdt.Hex(), weth.Hex(), fits, "")
+		assert.NoError(t, err)
+	})
+
+	t.Run("single pool", func(t *testing.T) {
+		_, err := service.EstimateSwap(ctx, usdtWeth.Hex(), usdt.Hex(), weth.Hex(), overflow, "")
+		assert.ErrorIs(t, err, ErrReserveOverflow)
+	})
+
+	t.Run("path", func(t *testing.T) {
+		_, err := service.EstimatePath(ctx, []string{usdtWeth.Hex()}, []string{usdt.Hex(), weth.Hex()}, overflow, "")
+		assert.ErrorIs(t, err, ErrReserveOverflow)
+	})
+
+	t.Run("best route", func(t *testing.T) {
+		_, err := service.FindBestRoute(ctx, usdt.Hex(), weth.Hex(), overflow, "")
+		assert.ErrorIs(t, err, ErrReserveOverflow)
+	})
+
+	t.Run("split across pools", func(t *testing.T) {
+		// Half of the amount fits into each pool
+		split, err := service.EstimateSplit(ctx, []string{usdtWeth.Hex(), sushiUsdtWeth.Hex()}, usdt.Hex(), weth.Hex(), overflow, 2, "")
+		require.NoError(t, err)
+		assert.Equal(t, mustBigInt(overflow), new(big.Int).Add(split.Allocations[0].SrcAmount, split.Allocations[1].SrcAmount))
+
+		_, err = service.EstimateSplit(ctx, []string{usdtWeth.Hex()}, usdt.Hex(), weth.Hex(), overflow, 2, "")
+		assert.ErrorIs(t, err, ErrReserveOverflow)
+	})
+}
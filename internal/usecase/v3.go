@@ -0,0 +1,69 @@
+package usecase
+
+import (
+	"1inch_testtask/internal/uniswap_v3"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ErrUniswapV3Disabled is returned when a Uniswap V3 estimate is requested but no V3 client is configured
+var ErrUniswapV3Disabled = errors.New("uniswap v3 is not configured")
+
+// WithUniswapV3 enables estimates against Uniswap V3 pools
+func WithUniswapV3(client uniswap_v3.IUniswapV3) Option {
+	return func(s *Usecase) {
+		s.uniswapV3Client = client
+	}
+}
+
+// loadUniswapV3Pool loads the state of a Uniswap V3 pool at the block pinned in ctx with the initialized ticks around its price
+func (s *Usecase) loadUniswapV3Pool(ctx context.Context, poolAddress common.Address) (Pool, error) {
+	if s.uniswapV3Client == nil {
+		return nil, ErrUniswapV3Disabled
+	}
+
+	state, err := s.uniswapV3Client.GetPoolState(ctx, poolAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pool state: %w", err)
+	}
+
+	return newUniswapV3Pool(state), nil
+}
+
+// newUniswapV3Pool returns a Pool quoting a Uniswap V3 pool by simulating its swaps across the loaded ticks,
+// token 0 is token0 so swapping from index 0 is zeroForOne
+func newUniswapV3Pool(state *uniswap_v3.PoolState) Pool {
+	return &indexedPool{
+		address: state.Pool,
+		tokens:  []common.Address{state.Token0, state.Token1},
+		feeBps:  state.FeeBps(),
+		exactIn: func(i, _ int, amount *big.Int) (*big.Int, error) {
+			return state.QuoteExactIn(amount, i == 0)
+		},
+		exactOut: func(i, _ int, amount *big.Int) (*big.Int, error) {
+			return state.QuoteExactOut(amount, i == 0)
+		},
+		spotPrice: func(i, _ int) *big.Rat {
+			return sqrtSpotPrice(state.SqrtPriceX96, i == 0)
+		},
+		errLiquidity: uniswap_v3.ErrInsufficientLiquidity,
+	}
+}
+
+// sqrtSpotPrice returns the mid price of a V3 pool in the swap direction, (sqrtPriceX96 / 2^96)^2 token1 per token0
+func sqrtSpotPrice(sqrtPriceX96 *big.Int, zeroForOne bool) *big.Rat {
+	if sqrtPriceX96.Sign() <= 0 {
+		return nil
+	}
+
+	numerator := new(big.Int).Mul(sqrtPriceX96, sqrtPriceX96)
+	denominator := new(big.Int).Lsh(big.NewInt(1), 192)
+	if zeroForOne {
+		return new(big.Rat).SetFrac(numerator, denominator)
+	}
+	return new(big.Rat).SetFrac(denominator, numerator)
+}
@@ -0,0 +1,121 @@
+package usecase
+
+import (
+	"1inch_testtask/internal/pooldetect"
+	"1inch_testtask/internal/uniswap_v2"
+	"1inch_testtask/internal/uniswap_v3"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockUniswapV3 is an in-memory uniswap_v3.IUniswapV3 implementation
+type mockUniswapV3 = mockPoolClient[uniswap_v3.PoolState]
+
+// usdtWethV3 is a 0.3% USDT/WETH V3 pool
+var usdtWethV3 = common.HexToAddress("0x4e68Ccd3E89f51C3074ca5072bbAC773960dFa36")
+
+// newMockUniswapV3 returns a mock with a WETH/USDT pool at a price of 2000 USDT per ETH,
+// holding a single position over [-207240, -193200]
+func newMockUniswapV3(t *testing.T) *mockUniswapV3 {
+	// sqrt(2000e6 / 1e18) * 2^96
+	sqrtPriceX96 := mustBigInt("3543191142285914205922034")
+	tick, err := uniswap_v3.GetTickAtSqrtRatio(sqrtPriceX96)
+	require.NoError(t, err)
+
+	liquidity := mustBigInt("10000000000000000")
+	pool := &uniswap_v3.PoolState{
+		Pool:         usdtWethV3,
+		Token0:       weth,
+		Token1:       usdt,
+		Fee:          3000,
+		TickSpacing:  60,
+		SqrtPriceX96: sqrtPriceX96,
+		Tick:         tick,
+		Liquidity:    liquidity,
+		TickBitmap:   make(map[int16]*big.Int),
+		LiquidityNet: map[int]*big.Int{-207240: liquidity, -193200: new(big.Int).Neg(liquidity)},
+	}
+	for wordPos := int16(-16); wordPos <= -11; wordPos++ {
+		pool.TickBitmap[wordPos] = new(big.Int)
+	}
+	for tick := range pool.LiquidityNet {
+		compressed := tick / pool.TickSpacing
+		word := pool.TickBitmap[int16(compressed>>8)]
+		word.SetBit(word, int(uint8(compressed)), 1)
+	}
+
+	return &mockUniswapV3{pools: map[common.Address]*uniswap_v3.PoolState{usdtWethV3: pool}}
+}
+
+func TestService_EstimateSwapV3(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("disabled", func(t *testing.T) {
+		service := NewUsecase(newMockUniswapV2())
+
+		_, err := service.EstimatePoolSwap(ctx, pooldetect.UniswapV3, usdtWethV3.Hex(), usdt.Hex(), weth.Hex(), "1000000", "")
+		assert.ErrorIs(t, err, ErrUniswapV3Disabled)
+	})
+
+	t.Run("one for zero", func(t *testing.T) {
+		client := newMockUniswapV3(t)
+		service := NewUsecase(newMockUniswapV2(), WithUniswapV3(client))
+
+		estimate, err := service.EstimatePoolSwap(ctx, pooldetect.UniswapV3, usdtWethV3.Hex(), usdt.Hex(), weth.Hex(), "2000000000", "")
+		require.NoError(t, err)
+
+		expected, err := client.pools[usdtWethV3].QuoteExactIn(big.NewInt(2000000000), false)
+		require.NoError(t, err)
+		assert.Equal(t, expected, estimate.DstAmount)
+		assert.Equal(t, uint32(30), estimate.FeeBps)
+		assert.Nil(t, estimate.Block)
+
+		// 2000 USDT buy a bit less than 1 ETH after the 0.3% fee and price impact
+		assert.Equal(t, -1, estimate.DstAmount.Cmp(mustBigInt("997000000000000000")))
+		assert.Equal(t, 1, estimate.DstAmount.Cmp(mustBigInt("990000000000000000")))
+
+		// The spot price is about 1 / 2000e6 ETH wei per USDT unit
+		spot, _ := estimate.SpotPrice.Float64()
+		assert.InDelta(t, 1e18/2000e6, spot, 1e18/2000e6*1e-6)
+		impact, _ := estimate.PriceImpactBps.Float64()
+		assert.Greater(t, impact, 30.0)
+	})
+
+	t.Run("zero for one", func(t *testing.T) {
+		client := newMockUniswapV3(t)
+		service := NewUsecase(newMockUniswapV2(), WithUniswapV3(client))
+
+		estimate, err := service.EstimatePoolSwap(ctx, pooldetect.UniswapV3, usdtWethV3.Hex(), weth.Hex(), usdt.Hex(), "1000000000000000000", "")
+		require.NoError(t, err)
+
+		expected, err := client.pools[usdtWethV3].QuoteExactIn(mustBigInt("1000000000000000000"), true)
+		require.NoError(t, err)
+		assert.Equal(t, expected, estimate.DstAmount)
+
+		spot, _ := estimate.SpotPrice.Float64()
+		assert.InDelta(t, 2000e6/1e18, spot, 2000e6/1e18*1e-6)
+	})
+
+	t.Run("pinned", func(t *testing.T) {
+		block := &uniswap_v2.BlockRef{Number: big.NewInt(18500000)}
+		client := newMockUniswapV3(t)
+		service := NewUsecase(newMockUniswapV2(), WithUniswapV3(client), WithBlockResolver(&mockBlockResolver{block: block}))
+
+		estimate, err := service.EstimatePoolSwap(ctx, pooldetect.UniswapV3, usdtWethV3.Hex(), usdt.Hex(), weth.Hex(), "2000000000", "finalized")
+		require.NoError(t, err)
+		assert.Equal(t, block, estimate.Block)
+		assert.Equal(t, []*big.Int{big.NewInt(18500000)}, client.blocks)
+	})
+
+	t.Run("token pair mismatch", func(t *testing.T) {
+		service := NewUsecase(newMockUniswapV2(), WithUniswapV3(newMockUniswapV3(t)))
+
+		_, err := service.EstimatePoolSwap(ctx, pooldetect.UniswapV3, usdtWethV3.Hex(), dai.Hex(), weth.Hex(), "1000000", "")
+		assert.ErrorContains(t, err, "token pair mismatch")
+	})
+}